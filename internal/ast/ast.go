@@ -925,6 +925,25 @@ func (objLit ObjectLiteral) HasNamedProp(name string) bool {
 	return false
 }
 
+// WithoutNamedProp returns a shallow copy of the literal without the property named $name,
+// the literal itself is returned if it has no such property.
+func (objLit *ObjectLiteral) WithoutNamedProp(name string) *ObjectLiteral {
+	if !objLit.HasNamedProp(name) {
+		return objLit
+	}
+
+	copy := *objLit
+	copy.Properties = nil
+
+	for _, prop := range objLit.Properties {
+		if prop.Key == nil || prop.Name() != name {
+			copy.Properties = append(copy.Properties, prop)
+		}
+	}
+
+	return &copy
+}
+
 func (ObjectLiteral) Kind() NodeKind {
 	return Expr
 }
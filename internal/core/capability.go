@@ -0,0 +1,280 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/inoxlang/inox/internal/commonfmt"
+	"github.com/inoxlang/inox/internal/core/symbolic"
+)
+
+const (
+	CAPABILITY_CONFIG__DURATION_PROPNAME = "duration"
+	CAPABILITY_CONFIG__MAX_USES_PROPNAME = "max-uses"
+)
+
+var (
+	CAPABILITY_PROPNAMES = []string{"revoke", "is-valid", "remaining-uses", "expiration"}
+
+	ErrCapabilityCanOnlyBeRevokedByIssuer = errors.New("a capability can only be revoked by its issuer")
+	ErrEmptyCapability                    = errors.New("a capability should grant at least one permission")
+	ErrCapabilityNotHeld                  = errors.New("a capability can only be delegated by its issuer or by a context it was delegated to")
+
+	_ = PotentiallySharable((*Capability)(nil))
+)
+
+func init() {
+	RegisterSymbolicGoFunction(NewCapabilityFromListing, func(ctx *symbolic.Context, listing *symbolic.Object, config *symbolic.OptionalParam[*symbolic.Object]) *symbolic.Capability {
+		return symbolic.ANY_CAPABILITY
+	})
+}
+
+// A Capability is a first-class token granting a subset of the permissions of the context that issued it.
+// Capabilities are delegated to lthreads and imported modules (see the 'capabilities' section of the lthread
+// metadata and of import configurations). A capability can be time-bound and/or use-count-bound, and it can be
+// revoked by its issuer at any time.
+// A capability stops granting permissions as soon as it is revoked, expired, exhausted or when its issuer
+// no longer has the permissions.
+type Capability struct {
+	issuer      *Context
+	permissions []Permission
+	expiration  time.Time //zero if the capability does not expire
+	maxUseCount int64     //0 if the number of uses is not limited
+
+	useCount atomic.Int64
+	revoked  atomic.Bool
+	shared   atomic.Bool
+}
+
+type CapabilityConfig struct {
+	Duration    time.Duration //if zero the capability does not expire
+	MaxUseCount int64         //if zero the number of uses is not limited
+}
+
+// NewCapability creates a capability granting a subset of the issuer context's permissions.
+func NewCapability(issuer *Context, perms []Permission, config CapabilityConfig) (*Capability, error) {
+	if len(perms) == 0 {
+		return nil, ErrEmptyCapability
+	}
+
+	if config.Duration < 0 {
+		return nil, errors.New("the duration of a capability should be positive")
+	}
+
+	if config.MaxUseCount < 0 {
+		return nil, errors.New("the maximum use count of a capability should be positive")
+	}
+
+	for _, perm := range perms {
+		if !issuer.HasPermission(perm) {
+			return nil, fmt.Errorf("cannot issue a capability: %w", NewNotAllowedError(perm))
+		}
+	}
+
+	capability := &Capability{
+		issuer:      issuer,
+		permissions: slices.Clone(perms),
+		maxUseCount: config.MaxUseCount,
+	}
+
+	if config.Duration > 0 {
+		capability.expiration = time.Now().Add(config.Duration)
+	}
+
+	return capability, nil
+}
+
+// NewCapabilityFromListing is the Inox constructor for capabilities: the permissions are described by
+// a listing with the same format as the permissions section of manifests. The optional configuration
+// object can have a .duration and a .max-uses properties.
+func NewCapabilityFromListing(ctx *Context, listing *Object, config *OptionalParam[*Object]) (*Capability, error) {
+	perms, err := getPermissionsFromListing(ctx, listing, nil, nil, false)
+	if err != nil {
+		return nil, err
+	}
+
+	capabilityConfig := CapabilityConfig{}
+
+	if config != nil {
+		err := config.Value.ForEachEntry(func(k string, v Serializable) error {
+			switch k {
+			case CAPABILITY_CONFIG__DURATION_PROPNAME:
+				duration, ok := v.(Duration)
+				if !ok {
+					return commonfmt.FmtPropOfArgXShouldBeY(k, "config", "a duration")
+				}
+				capabilityConfig.Duration = time.Duration(duration)
+			case CAPABILITY_CONFIG__MAX_USES_PROPNAME:
+				maxUses, ok := v.(Int)
+				if !ok {
+					return commonfmt.FmtPropOfArgXShouldBeY(k, "config", "an integer")
+				}
+				capabilityConfig.MaxUseCount = int64(maxUses)
+			default:
+				return commonfmt.FmtUnexpectedPropInArgX(k, "config")
+			}
+			return nil
+		})
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return NewCapability(ctx, perms, capabilityConfig)
+}
+
+// Permissions returns the permissions the capability can grant, the result should not be modified.
+func (c *Capability) Permissions() []Permission {
+	return c.permissions
+}
+
+func (c *Capability) Issuer() *Context {
+	return c.issuer
+}
+
+// Revoke revokes the capability, only the issuer is allowed to revoke it.
+func (c *Capability) Revoke(ctx *Context) error {
+	if ctx != c.issuer {
+		return ErrCapabilityCanOnlyBeRevokedByIssuer
+	}
+	c.revoked.Store(true)
+	return nil
+}
+
+func (c *Capability) IsRevoked() bool {
+	return c.revoked.Load()
+}
+
+// IsValid returns true if the capability is not revoked, not expired, not exhausted and if its issuer is not done.
+func (c *Capability) IsValid() bool {
+	if c.revoked.Load() || c.issuer.IsDone() {
+		return false
+	}
+
+	if !c.expiration.IsZero() && !time.Now().Before(c.expiration) {
+		return false
+	}
+
+	return c.maxUseCount == 0 || c.useCount.Load() < c.maxUseCount
+}
+
+// RemainingUses returns the remaining number of uses, (-1) is returned if the number of uses is not limited.
+func (c *Capability) RemainingUses() int64 {
+	if c.maxUseCount == 0 {
+		return -1
+	}
+	return max(0, c.maxUseCount-c.useCount.Load())
+}
+
+// grants returns true if the capability can currently grant $perm, if $use is true a use is counted.
+func (c *Capability) grants(perm Permission, use bool) bool {
+	included := slices.ContainsFunc(c.permissions, func(p Permission) bool {
+		return p.Includes(perm)
+	})
+
+	if !included || !c.IsValid() {
+		return false
+	}
+
+	//The issuer may have dropped the permission since the creation of the capability.
+	if !c.issuer.HasPermission(perm) {
+		return false
+	}
+
+	if !use || c.maxUseCount == 0 {
+		return true
+	}
+
+	for {
+		count := c.useCount.Load()
+		if count >= c.maxUseCount {
+			return false
+		}
+		if c.useCount.CompareAndSwap(count, count+1) {
+			return true
+		}
+	}
+}
+
+// isHeldBy returns true if $ctx issued the capability or if the capability was delegated to $ctx.
+func (c *Capability) isHeldBy(ctx *Context) bool {
+	return c.issuer == ctx || slices.Contains(ctx.GetCapabilities(), c)
+}
+
+func (c *Capability) IsSharable(originState *GlobalState) (bool, string) {
+	return true, ""
+}
+
+func (c *Capability) Share(originState *GlobalState) {
+	c.shared.Store(true)
+}
+
+func (c *Capability) IsShared() bool {
+	return c.shared.Load()
+}
+
+func (c *Capability) SmartLock(state *GlobalState) {
+
+}
+
+func (c *Capability) SmartUnlock(state *GlobalState) {
+
+}
+
+func (c *Capability) GetGoMethod(name string) (*GoFunction, bool) {
+	switch name {
+	case "revoke":
+		return WrapGoMethod(c.Revoke), true
+	}
+	return nil, false
+}
+
+func (c *Capability) Prop(ctx *Context, name string) Value {
+	switch name {
+	case "is-valid":
+		return Bool(c.IsValid())
+	case "remaining-uses":
+		return Int(c.RemainingUses())
+	case "expiration":
+		if c.expiration.IsZero() {
+			return Nil
+		}
+		return DateTime(c.expiration)
+	}
+	method, ok := c.GetGoMethod(name)
+	if !ok {
+		panic(FormatErrPropertyDoesNotExist(name, c))
+	}
+	return method
+}
+
+func (*Capability) SetProp(ctx *Context, name string, value Value) error {
+	return ErrCannotSetProp
+}
+
+func (*Capability) PropertyNames(ctx *Context) []string {
+	return CAPABILITY_PROPNAMES
+}
+
+// getCapabilitiesFromValue returns the capabilities in $v, $v should be a capability or an array of capabilities.
+func getCapabilitiesFromValue(v Value) ([]*Capability, error) {
+	switch val := v.(type) {
+	case *Capability:
+		return []*Capability{val}, nil
+	case *Array:
+		var capabilities []*Capability
+		for _, elem := range *val {
+			capability, ok := elem.(*Capability)
+			if !ok {
+				return nil, errors.New("an array of capabilities should only contain capabilities")
+			}
+			capabilities = append(capabilities, capability)
+		}
+		return capabilities, nil
+	}
+	return nil, errors.New("a capability or an array of capabilities is expected")
+}
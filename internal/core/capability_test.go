@@ -0,0 +1,172 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/inoxlang/inox/internal/core/permbase"
+	"github.com/inoxlang/inox/internal/testconfig"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCapability(t *testing.T) {
+	testconfig.AllowParallelization(t)
+
+	readTmp := FilesystemPermission{Kind_: permbase.Read, Entity: PathPattern("/tmp/...")}
+	readTmpFile := FilesystemPermission{Kind_: permbase.Read, Entity: Path("/tmp/file.txt")}
+	readEtc := FilesystemPermission{Kind_: permbase.Read, Entity: PathPattern("/etc/...")}
+
+	t.Run("the issuer should have the permissions", func(t *testing.T) {
+		issuer := NewContextWithEmptyState(ContextConfig{Permissions: []Permission{readTmp}}, nil)
+		defer issuer.CancelGracefully()
+
+		_, err := NewCapability(issuer, []Permission{readEtc}, CapabilityConfig{})
+		assert.ErrorIs(t, err, NewNotAllowedError(readEtc))
+
+		_, err = NewCapability(issuer, nil, CapabilityConfig{})
+		assert.ErrorIs(t, err, ErrEmptyCapability)
+	})
+
+	t.Run("a context should be granted the permissions of its capabilities", func(t *testing.T) {
+		issuer := NewContextWithEmptyState(ContextConfig{Permissions: []Permission{readTmp}}, nil)
+		defer issuer.CancelGracefully()
+
+		capability, err := NewCapability(issuer, []Permission{readTmp}, CapabilityConfig{})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		ctx := NewContextWithEmptyState(ContextConfig{Capabilities: []*Capability{capability}}, nil)
+		defer ctx.CancelGracefully()
+
+		assert.True(t, ctx.HasPermission(readTmpFile))
+		assert.NoError(t, ctx.CheckHasPermission(readTmpFile))
+		assert.False(t, ctx.HasPermission(readEtc))
+		assert.Equal(t, []*Capability{capability}, ctx.GetCapabilities())
+	})
+
+	t.Run("forbidden permissions should take precedence over capabilities", func(t *testing.T) {
+		issuer := NewContextWithEmptyState(ContextConfig{Permissions: []Permission{readTmp}}, nil)
+		defer issuer.CancelGracefully()
+
+		capability, err := NewCapability(issuer, []Permission{readTmp}, CapabilityConfig{})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		ctx := NewContextWithEmptyState(ContextConfig{
+			Capabilities:         []*Capability{capability},
+			ForbiddenPermissions: []Permission{readTmpFile},
+		}, nil)
+		defer ctx.CancelGracefully()
+
+		assert.False(t, ctx.HasPermission(readTmpFile))
+	})
+
+	t.Run("revocation", func(t *testing.T) {
+		issuer := NewContextWithEmptyState(ContextConfig{Permissions: []Permission{readTmp}}, nil)
+		defer issuer.CancelGracefully()
+
+		capability, err := NewCapability(issuer, []Permission{readTmp}, CapabilityConfig{})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		ctx := NewContextWithEmptyState(ContextConfig{Capabilities: []*Capability{capability}}, nil)
+		defer ctx.CancelGracefully()
+
+		assert.ErrorIs(t, capability.Revoke(ctx), ErrCapabilityCanOnlyBeRevokedByIssuer)
+		assert.True(t, ctx.HasPermission(readTmpFile))
+
+		assert.NoError(t, capability.Revoke(issuer))
+		assert.False(t, capability.IsValid())
+		assert.False(t, ctx.HasPermission(readTmpFile))
+	})
+
+	t.Run("expiration", func(t *testing.T) {
+		issuer := NewContextWithEmptyState(ContextConfig{Permissions: []Permission{readTmp}}, nil)
+		defer issuer.CancelGracefully()
+
+		capability, err := NewCapability(issuer, []Permission{readTmp}, CapabilityConfig{Duration: 20 * time.Millisecond})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		ctx := NewContextWithEmptyState(ContextConfig{Capabilities: []*Capability{capability}}, nil)
+		defer ctx.CancelGracefully()
+
+		assert.True(t, ctx.HasPermission(readTmpFile))
+		time.Sleep(30 * time.Millisecond)
+		assert.False(t, ctx.HasPermission(readTmpFile))
+	})
+
+	t.Run("maximum use count", func(t *testing.T) {
+		issuer := NewContextWithEmptyState(ContextConfig{Permissions: []Permission{readTmp}}, nil)
+		defer issuer.CancelGracefully()
+
+		capability, err := NewCapability(issuer, []Permission{readTmp}, CapabilityConfig{MaxUseCount: 2})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		ctx := NewContextWithEmptyState(ContextConfig{Capabilities: []*Capability{capability}}, nil)
+		defer ctx.CancelGracefully()
+
+		//HasPermission does not count as a use.
+		assert.True(t, ctx.HasPermission(readTmpFile))
+		assert.EqualValues(t, 2, capability.RemainingUses())
+
+		assert.NoError(t, ctx.CheckHasPermission(readTmpFile))
+		assert.NoError(t, ctx.CheckHasPermission(readTmpFile))
+		assert.EqualValues(t, 0, capability.RemainingUses())
+
+		assert.ErrorIs(t, ctx.CheckHasPermission(readTmpFile), NewNotAllowedError(readTmpFile))
+		assert.False(t, capability.IsValid())
+	})
+
+	t.Run("a capability should stop granting a permission dropped by its issuer", func(t *testing.T) {
+		issuer := NewContextWithEmptyState(ContextConfig{Permissions: []Permission{readTmp}}, nil)
+		defer issuer.CancelGracefully()
+
+		capability, err := NewCapability(issuer, []Permission{readTmp}, CapabilityConfig{})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		ctx := NewContextWithEmptyState(ContextConfig{Capabilities: []*Capability{capability}}, nil)
+		defer ctx.CancelGracefully()
+
+		issuer.DropPermissions([]Permission{readTmp})
+		assert.False(t, ctx.HasPermission(readTmpFile))
+	})
+
+	t.Run("a capability should be invalid once its issuer is done", func(t *testing.T) {
+		issuer := NewContextWithEmptyState(ContextConfig{Permissions: []Permission{readTmp}}, nil)
+
+		capability, err := NewCapability(issuer, []Permission{readTmp}, CapabilityConfig{})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		issuer.CancelGracefully()
+		assert.False(t, capability.IsValid())
+	})
+
+	t.Run("child contexts should inherit the capabilities", func(t *testing.T) {
+		issuer := NewContextWithEmptyState(ContextConfig{Permissions: []Permission{readTmp}}, nil)
+		defer issuer.CancelGracefully()
+
+		capability, err := NewCapability(issuer, []Permission{readTmp}, CapabilityConfig{})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		ctx := NewContextWithEmptyState(ContextConfig{Capabilities: []*Capability{capability}}, nil)
+		defer ctx.CancelGracefully()
+
+		child := ctx.BoundChild()
+		defer child.CancelGracefully()
+
+		assert.True(t, child.HasPermission(readTmpFile))
+	})
+}
@@ -0,0 +1,404 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/inoxlang/inox/internal/core/symbolic"
+)
+
+const (
+	MAX_CHANNEL_CAPACITY = 100_000
+)
+
+var (
+	CHANNEL_PROPNAMES = []string{"send", "receive", "close", "capacity"}
+
+	ErrClosedChannel             = errors.New("channel is closed")
+	ErrInvalidChannelCapacity    = fmt.Errorf("the capacity of a channel should be in the range 0..%d", MAX_CHANNEL_CAPACITY)
+	ErrChannelSelectTimeout      = errors.New("channel selection timeout")
+	ErrNoChannelToSelect         = errors.New("at least one channel should be provided")
+	ErrValueDoesNotMatchChanElem = errors.New("value does not match the element pattern of the channel")
+
+	_ = []ReadableStream{(*channelStream)(nil)}
+)
+
+func init() {
+	RegisterSymbolicGoFunctions([]any{
+		NewChannel, func(ctx *symbolic.Context, elementPattern symbolic.Pattern, capacity *symbolic.OptionalParam[*symbolic.Int]) *symbolic.Channel {
+			return symbolic.NewChannel(elementPattern.SymbolicValue())
+		},
+		SelectChannel, func(ctx *symbolic.Context, timeout *symbolic.Duration, channels ...*symbolic.Channel) (*symbolic.Int, symbolic.Value, *symbolic.Error) {
+			if len(channels) == 0 {
+				ctx.AddSymbolicGoFunctionError(ErrNoChannelToSelect.Error())
+				return symbolic.ANY_INT, symbolic.ANY, nil
+			}
+			return symbolic.ANY_INT, symbolic.JoinChannelElements(channels...), nil
+		},
+	})
+}
+
+// A Channel allows lthreads to communicate by sending values to each other, the elements of a channel should
+// match its element pattern. A channel is buffered if its capacity is greater than zero. The mutable values sent
+// through a channel are shared or cloned (see ShareOrClone). A Channel is a StreamSource: its stream ends after the
+// channel is closed and all its buffered elements are received. Channels are always shared.
+type Channel struct {
+	elementPattern Pattern
+	elements       chan Value
+	closed         chan struct{}
+
+	closeOnce sync.Once
+	isClosed  atomic.Bool
+}
+
+// NewChannel creates a channel whose elements should match elementPattern, the channel is unbuffered if
+// capacity is not provided or is zero.
+func NewChannel(ctx *Context, elementPattern Pattern, capacity *OptionalParam[Int]) (*Channel, error) {
+	size := Int(0)
+	if capacity != nil {
+		size = capacity.Value
+	}
+
+	if size < 0 || size > MAX_CHANNEL_CAPACITY {
+		return nil, ErrInvalidChannelCapacity
+	}
+
+	return &Channel{
+		elementPattern: elementPattern,
+		elements:       make(chan Value, size),
+		closed:         make(chan struct{}),
+	}, nil
+}
+
+func (c *Channel) ElementPattern() Pattern {
+	return c.elementPattern
+}
+
+func (c *Channel) Capacity() int {
+	return cap(c.elements)
+}
+
+func (c *Channel) IsClosed() bool {
+	return c.isClosed.Load()
+}
+
+// Send sends a value through the channel, it waits until a receiver is ready (unbuffered channel) or until there
+// is room in the buffer. An error is returned if the channel is closed or if ctx is done.
+func (c *Channel) Send(ctx *Context, v Value) error {
+	if !c.elementPattern.Test(ctx, v) {
+		return ErrValueDoesNotMatchChanElem
+	}
+
+	if c.IsClosed() {
+		return ErrClosedChannel
+	}
+
+	v, err := ShareOrClone(v, ctx.MustGetClosestState())
+	if err != nil {
+		return fmt.Errorf("failed to share or clone the value sent through the channel: %w", err)
+	}
+
+	return ctx.DoIO(func() error {
+		select {
+		case <-c.closed:
+			return ErrClosedChannel
+		case <-ctx.Done():
+			return ctx.Err()
+		case c.elements <- v:
+			return nil
+		}
+	})
+}
+
+// Receive waits for a value to be sent through the channel. ErrClosedChannel is returned if the channel
+// is closed and all its buffered elements have been received.
+func (c *Channel) Receive(ctx *Context) (Value, error) {
+	return DoIO2(ctx, func() (Value, error) {
+		select {
+		case v := <-c.elements:
+			return v, nil
+		case <-c.closed:
+			return c.receiveBufferedElement()
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	})
+}
+
+// receiveBufferedElement should only be called after the channel is closed.
+func (c *Channel) receiveBufferedElement() (Value, error) {
+	select {
+	case v := <-c.elements:
+		return v, nil
+	default:
+		return nil, ErrClosedChannel
+	}
+}
+
+// Close closes the channel: sending is no longer possible, the buffered elements can still be received.
+// ErrClosedChannel is returned if the channel is already closed.
+func (c *Channel) Close(ctx *Context) error {
+	alreadyClosed := true
+	c.closeOnce.Do(func() {
+		alreadyClosed = false
+		c.isClosed.Store(true)
+		close(c.closed)
+	})
+
+	if alreadyClosed {
+		return ErrClosedChannel
+	}
+	return nil
+}
+
+// SelectChannel waits until one of the channels has an element to receive, the index of the channel and the received element
+// are returned. Closed channels with no buffered elements are ignored, ErrClosedChannel is returned if all the channels
+// are closed. ErrChannelSelectTimeout is returned if no element is received before the timeout.
+func SelectChannel(ctx *Context, timeout Duration, channels ...*Channel) (Int, Value, error) {
+	if len(channels) == 0 {
+		return -1, nil, ErrNoChannelToSelect
+	}
+
+	timer := time.NewTimer(time.Duration(timeout))
+	defer timer.Stop()
+
+	//the first len(channels) cases receive the elements, the next len(channels) cases are the closing signals.
+	cases := make([]reflect.SelectCase, 2*len(channels), 2*len(channels)+2)
+	for i, channel := range channels {
+		cases[i] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(channel.elements)}
+		cases[len(channels)+i] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(channel.closed)}
+	}
+
+	ctxDoneCaseIndex := len(cases)
+	timeoutCaseIndex := len(cases) + 1
+	cases = append(cases,
+		reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+		reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(timer.C)},
+	)
+
+	remainingChannelCount := len(channels)
+
+	var (
+		index    Int = -1
+		received Value
+	)
+
+	err := ctx.DoIO(func() error {
+		for {
+			chosen, recv, _ := reflect.Select(cases)
+
+			switch {
+			case chosen == ctxDoneCaseIndex:
+				return ctx.Err()
+			case chosen == timeoutCaseIndex:
+				return ErrChannelSelectTimeout
+			case chosen < len(channels):
+				index, received = Int(chosen), recv.Interface().(Value)
+				return nil
+			}
+
+			//closed channel
+			channelIndex := chosen - len(channels)
+			v, err := channels[channelIndex].receiveBufferedElement()
+			if err == nil {
+				index, received = Int(channelIndex), v
+				return nil
+			}
+
+			//ignore the channel from now on.
+			cases[channelIndex].Chan = reflect.Value{}
+			cases[chosen].Chan = reflect.Value{}
+			remainingChannelCount--
+
+			if remainingChannelCount == 0 {
+				return ErrClosedChannel
+			}
+		}
+	})
+
+	if err != nil {
+		return -1, nil, err
+	}
+	return index, received, nil
+}
+
+func (c *Channel) PropertyNames(ctx *Context) []string {
+	return CHANNEL_PROPNAMES
+}
+
+func (c *Channel) GetGoMethod(name string) (*GoFunction, bool) {
+	switch name {
+	case "send":
+		return WrapGoMethod(c.Send), true
+	case "receive":
+		return WrapGoMethod(c.Receive), true
+	case "close":
+		return WrapGoMethod(c.Close), true
+	}
+	return nil, false
+}
+
+func (c *Channel) Prop(ctx *Context, name string) Value {
+	switch name {
+	case "capacity":
+		return Int(c.Capacity())
+	}
+	method, ok := c.GetGoMethod(name)
+	if !ok {
+		panic(FormatErrPropertyDoesNotExist(name, c))
+	}
+	return method
+}
+
+func (*Channel) SetProp(ctx *Context, name string, value Value) error {
+	return ErrCannotSetProp
+}
+
+func (c *Channel) IsSharable(originState *GlobalState) (bool, string) {
+	return true, ""
+}
+
+func (c *Channel) Share(originState *GlobalState) {
+	//ok
+}
+
+func (c *Channel) IsShared() bool {
+	return true
+}
+
+func (c *Channel) SmartLock(state *GlobalState) {
+	//no-op
+}
+
+func (c *Channel) SmartUnlock(state *GlobalState) {
+	//no-op
+}
+
+func (c *Channel) Stream(ctx *Context, config *ReadableStreamConfiguration) ReadableStream {
+	stream := &channelStream{channel: c}
+	if config != nil {
+		stream.filter = config.Filter
+	}
+	return stream
+}
+
+// A channelStream is a ReadableStream receiving the elements of a Channel, the received elements not matching the filter
+// are dropped.
+type channelStream struct {
+	channel *Channel
+	filter  Pattern //can be nil
+	stopped atomic.Bool
+	stop    chan struct{}
+	once    sync.Once
+}
+
+func (s *channelStream) stopSignal() chan struct{} {
+	s.once.Do(func() {
+		s.stop = make(chan struct{})
+	})
+	return s.stop
+}
+
+func (s *channelStream) Stream(ctx *Context, config *ReadableStreamConfiguration) ReadableStream {
+	return s
+}
+
+func (s *channelStream) WaitNext(ctx *Context, filter Pattern, timeout time.Duration) (Value, error) {
+	if s.IsStopped() {
+		return nil, ErrEndOfStream
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	stopSignal := s.stopSignal()
+
+	for {
+		next, err := DoIO2(ctx, func() (Value, error) {
+			select {
+			case v := <-s.channel.elements:
+				return v, nil
+			case <-s.channel.closed:
+				v, err := s.channel.receiveBufferedElement()
+				if errors.Is(err, ErrClosedChannel) {
+					err = ErrEndOfStream
+				}
+				return v, err
+			case <-stopSignal:
+				return nil, ErrEndOfStream
+			case <-timer.C:
+				return nil, ErrStreamElemWaitTimeout
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		if (s.filter == nil || s.filter.Test(ctx, next)) && (filter == nil || filter.Test(ctx, next)) {
+			return next, nil
+		}
+	}
+}
+
+func (s *channelStream) WaitNextChunk(ctx *Context, filter Pattern, sizeRange IntRange, timeout time.Duration) (*DataChunk, error) {
+	min := int(sizeRange.KnownStart())
+	chunkData := make([]Serializable, 0, min)
+	deadline := time.Now().Add(timeout)
+
+	newChunk := func() *DataChunk {
+		return &DataChunk{
+			data: NewWrappedValueList(chunkData...),
+		}
+	}
+
+	for len(chunkData) < min {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return newChunk(), ErrStreamChunkWaitTimeout
+		}
+
+		next, err := s.WaitNext(ctx, filter, remaining)
+		if errors.Is(err, ErrEndOfStream) {
+			return newChunk(), ErrEndOfStream
+		}
+		if errors.Is(err, ErrStreamElemWaitTimeout) {
+			return newChunk(), ErrStreamChunkWaitTimeout
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		serializable, ok := next.(Serializable)
+		if !ok {
+			return nil, fmt.Errorf("a chunk cannot contain a non-serializable value: %T", next)
+		}
+		chunkData = append(chunkData, serializable)
+	}
+
+	return newChunk(), nil
+}
+
+func (s *channelStream) Stop() {
+	if s.stopped.CompareAndSwap(false, true) {
+		close(s.stopSignal())
+	}
+}
+
+func (s *channelStream) IsStopped() bool {
+	return s.stopped.Load()
+}
+
+func (s *channelStream) IsMainlyChunked() bool {
+	return false
+}
+
+func (s *channelStream) ChunkDataType() Pattern {
+	return ELEMENTS_STREAM_CHUNK_DATA_TYPE
+}
@@ -0,0 +1,232 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChannel(t *testing.T) {
+
+	t.Run("unbuffered channel: send should wait for a receiver", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		channel, err := NewChannel(ctx, INT_PATTERN, nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		sent := make(chan error, 1)
+		go func() {
+			sent <- channel.Send(ctx, Int(1))
+		}()
+
+		select {
+		case <-sent:
+			assert.FailNow(t, "send should wait for a receiver")
+		case <-time.After(20 * time.Millisecond):
+		}
+
+		v, err := channel.Receive(ctx)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, Int(1), v)
+		assert.NoError(t, <-sent)
+	})
+
+	t.Run("buffered channel", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		channel, err := NewChannel(ctx, INT_PATTERN, &OptionalParam[Int]{Value: Int(2)})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.Equal(t, 2, channel.Capacity())
+		assert.NoError(t, channel.Send(ctx, Int(1)))
+		assert.NoError(t, channel.Send(ctx, Int(2)))
+
+		v, _ := channel.Receive(ctx)
+		assert.Equal(t, Int(1), v)
+		v, _ = channel.Receive(ctx)
+		assert.Equal(t, Int(2), v)
+	})
+
+	t.Run("invalid capacity", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		_, err := NewChannel(ctx, INT_PATTERN, &OptionalParam[Int]{Value: -1})
+		assert.ErrorIs(t, err, ErrInvalidChannelCapacity)
+	})
+
+	t.Run("sent values should match the element pattern", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		channel, err := NewChannel(ctx, INT_PATTERN, &OptionalParam[Int]{Value: Int(1)})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.ErrorIs(t, channel.Send(ctx, String("a")), ErrValueDoesNotMatchChanElem)
+	})
+
+	t.Run("sent mutable values should be shared or cloned", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		channel, _ := NewChannel(ctx, ANYVAL_PATTERN, &OptionalParam[Int]{Value: 1})
+
+		obj := NewObjectFromMap(ValMap{"a": Int(1)}, ctx)
+		assert.NoError(t, channel.Send(ctx, obj))
+		assert.True(t, obj.IsShared())
+	})
+
+	t.Run("buffered elements should be received after the channel is closed", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		channel, err := NewChannel(ctx, INT_PATTERN, &OptionalParam[Int]{Value: Int(2)})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		channel.Send(ctx, Int(1))
+		assert.NoError(t, channel.Close(ctx))
+
+		assert.ErrorIs(t, channel.Send(ctx, Int(2)), ErrClosedChannel)
+		assert.ErrorIs(t, channel.Close(ctx), ErrClosedChannel)
+
+		v, err := channel.Receive(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, Int(1), v)
+
+		_, err = channel.Receive(ctx)
+		assert.ErrorIs(t, err, ErrClosedChannel)
+	})
+
+	t.Run("receive should return an error when the context is done", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		channel, err := NewChannel(ctx, INT_PATTERN, nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			ctx.CancelGracefully()
+		}()
+
+		_, err = channel.Receive(ctx)
+		assert.Error(t, err)
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		channel, err := NewChannel(ctx, INT_PATTERN, &OptionalParam[Int]{Value: Int(3)})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		channel.Send(ctx, Int(1))
+		channel.Send(ctx, Int(2))
+		channel.Send(ctx, Int(3))
+		channel.Close(ctx)
+
+		stream := channel.Stream(ctx, &ReadableStreamConfiguration{
+			Filter: NewExactValuePattern(Int(2)),
+		})
+
+		v, err := stream.WaitNext(ctx, nil, time.Second)
+		assert.NoError(t, err)
+		assert.Equal(t, Int(2), v)
+
+		_, err = stream.WaitNext(ctx, nil, time.Second)
+		assert.ErrorIs(t, err, ErrEndOfStream)
+	})
+
+	t.Run("stream: timeout", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		channel, err := NewChannel(ctx, INT_PATTERN, nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		stream := channel.Stream(ctx, nil)
+		_, err = stream.WaitNext(ctx, nil, 10*time.Millisecond)
+		assert.ErrorIs(t, err, ErrStreamElemWaitTimeout)
+
+		stream.Stop()
+		_, err = stream.WaitNext(ctx, nil, time.Second)
+		assert.ErrorIs(t, err, ErrEndOfStream)
+	})
+}
+
+func TestSelectChannel(t *testing.T) {
+	ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+	defer ctx.CancelGracefully()
+
+	newChannel := func() *Channel {
+		channel, _ := NewChannel(ctx, INT_PATTERN, &OptionalParam[Int]{Value: 1})
+		return channel
+	}
+
+	t.Run("element available", func(t *testing.T) {
+		channel1, channel2 := newChannel(), newChannel()
+		channel2.Send(ctx, Int(2))
+
+		index, v, err := SelectChannel(ctx, Duration(time.Second), channel1, channel2)
+		assert.NoError(t, err)
+		assert.Equal(t, Int(1), index)
+		assert.Equal(t, Int(2), v)
+	})
+
+	t.Run("element sent during the selection", func(t *testing.T) {
+		channel1, channel2 := newChannel(), newChannel()
+
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			channel1.Send(ctx, Int(1))
+		}()
+
+		index, v, err := SelectChannel(ctx, Duration(time.Second), channel1, channel2)
+		assert.NoError(t, err)
+		assert.Equal(t, Int(0), index)
+		assert.Equal(t, Int(1), v)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		_, _, err := SelectChannel(ctx, Duration(10*time.Millisecond), newChannel(), newChannel())
+		assert.ErrorIs(t, err, ErrChannelSelectTimeout)
+	})
+
+	t.Run("closed channels should be ignored", func(t *testing.T) {
+		channel1, channel2 := newChannel(), newChannel()
+		channel1.Close(ctx)
+
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			channel2.Send(ctx, Int(2))
+		}()
+
+		index, v, err := SelectChannel(ctx, Duration(time.Second), channel1, channel2)
+		assert.NoError(t, err)
+		assert.Equal(t, Int(1), index)
+		assert.Equal(t, Int(2), v)
+
+		channel2.Close(ctx)
+		_, _, err = SelectChannel(ctx, Duration(time.Second), channel1, channel2)
+		assert.ErrorIs(t, err, ErrClosedChannel)
+	})
+}
@@ -0,0 +1,110 @@
+package core
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+var (
+	_ = []Clock{RealClock{}, (*VirtualClock)(nil)}
+)
+
+// A Clock provides the current time and delayed function calls, it allows components such as the Scheduler to be tested
+// deterministically with a VirtualClock.
+type Clock interface {
+	Now() time.Time
+
+	// AfterFunc calls f after d has elapsed, the returned function cancels the call and returns false if the call
+	// has already been made or cancelled.
+	AfterFunc(d time.Duration, f func()) (stop func() bool)
+}
+
+// RealClock is a Clock relying on the time package, f is called in its own goroutine by AfterFunc.
+type RealClock struct{}
+
+func (RealClock) Now() time.Time {
+	return time.Now()
+}
+
+func (RealClock) AfterFunc(d time.Duration, f func()) (stop func() bool) {
+	return time.AfterFunc(d, f).Stop
+}
+
+// A VirtualClock is a Clock whose time only changes when Advance is called, the functions passed to AfterFunc are called
+// synchronously by Advance. VirtualClock is intended to be used in tests.
+type VirtualClock struct {
+	lock   sync.Mutex
+	now    time.Time
+	timers []*virtualTimer
+}
+
+type virtualTimer struct {
+	deadline time.Time
+	f        func()
+}
+
+func NewVirtualClock(start time.Time) *VirtualClock {
+	return &VirtualClock{now: start}
+}
+
+func (c *VirtualClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+func (c *VirtualClock) AfterFunc(d time.Duration, f func()) (stop func() bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	timer := &virtualTimer{deadline: c.now.Add(d), f: f}
+	c.timers = append(c.timers, timer)
+
+	return func() bool {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+
+		index := slices.Index(c.timers, timer)
+		if index < 0 {
+			return false
+		}
+		c.timers = slices.Delete(c.timers, index, index+1)
+		return true
+	}
+}
+
+// Advance moves the time forward by d, the due functions are called in the order of their deadlines and the time
+// is set to the deadline of each function before calling it. The functions can call AfterFunc: the new calls
+// are made during the same Advance call if they are due.
+func (c *VirtualClock) Advance(d time.Duration) {
+	c.lock.Lock()
+	target := c.now.Add(d)
+	c.lock.Unlock()
+
+	for {
+		c.lock.Lock()
+
+		next := -1
+		for i, timer := range c.timers {
+			if !timer.deadline.After(target) && (next < 0 || timer.deadline.Before(c.timers[next].deadline)) {
+				next = i
+			}
+		}
+
+		if next < 0 {
+			c.now = target
+			c.lock.Unlock()
+			return
+		}
+
+		timer := c.timers[next]
+		c.timers = slices.Delete(c.timers, next, next+1)
+		if timer.deadline.After(c.now) {
+			c.now = timer.deadline
+		}
+		c.lock.Unlock()
+
+		timer.f()
+	}
+}
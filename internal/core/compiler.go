@@ -75,6 +75,10 @@ type loopCompilation struct {
 	continuePositions []int
 	breakPositions    []int
 	iteratorSymbol    *symbol
+
+	//only set for the for expressions having a block body, yield statements push the yielded value
+	//and increment the local variable storing the length of the list.
+	yieldListLengthSymbol *symbol
 }
 
 type CompileError struct {
@@ -724,7 +728,11 @@ func (c *compiler) Compile(node ast.Node) error {
 
 		// enter loop
 		loop := c.enterLoop(itSymbol, ForLoop)
-		_ = loop
+
+		_, isBlockBody := node.Body.(*ast.Block)
+		if isBlockBody {
+			loop.yieldListLengthSymbol = listLengthSymbol
+		}
 
 		// Assign the key variable.
 		if node.KeyIndexIdent != nil && node.KeyIndexIdent.Name != "_" {
@@ -742,11 +750,10 @@ func (c *compiler) Compile(node ast.Node) error {
 			c.emit(node, OpSetLocal, valueSymbol.Index)
 		}
 
-		// Increment the list length.
-		c.emit(node, OpGetLocal, listLengthSymbol.Index)
-		c.emit(node, OpPushConstant, c.addConstant(Int(1)))
-		c.emit(node, OpIntBin, int(ast.Add))
-		c.emit(node, OpSetLocal, listLengthSymbol.Index)
+		// Increment the list length, the length is incremented by yield statements if the body is a block.
+		if !isBlockBody {
+			c.emitIncrementListLength(node, listLengthSymbol)
+		}
 
 		// body
 		if err := c.Compile(node.Body); err != nil {
@@ -758,7 +765,6 @@ func (c *compiler) Compile(node ast.Node) error {
 
 		// post-body position
 		postBodyPos := len(c.currentInstructions())
-		_ = postBodyPos
 
 		// back to condition
 		c.emit(node, OpJump, preCondPos)
@@ -767,13 +773,13 @@ func (c *compiler) Compile(node ast.Node) error {
 		postStmtPos := len(c.currentInstructions())
 		c.changeOperand(postCondPos, postStmtPos)
 
-		// // update all break/continue jump positions
-		// for _, pos := range loop.breakPositions {
-		// 	c.changeOperand(pos, postStmtPos)
-		// }
-		// for _, pos := range loop.continuePositions {
-		// 	c.changeOperand(pos, postBodyPos)
-		// }
+		// update all break/continue/yield jump positions
+		for _, pos := range loop.breakPositions {
+			c.changeOperand(pos, postStmtPos)
+		}
+		for _, pos := range loop.continuePositions {
+			c.changeOperand(pos, postBodyPos)
+		}
 
 		//Create list.
 		c.emit(node, OpGetLocal, listLengthSymbol.Index)
@@ -1817,13 +1823,34 @@ func (c *compiler) Compile(node ast.Node) error {
 			c.emit(node, OpReturn, 1)
 		}
 	case *ast.YieldStatement:
+		//The yielded value is left on the stack, it will be an element of the list created by the for expression.
+		//The iteration is then ended.
+
+		loop := c.currentForExpressionLoop()
+		if loop == nil {
+			return c.NewError(node, "yield not allowed outside the body of a for expression")
+		}
+
+		if node.Expr == nil {
+			c.emit(node, OpPushNil)
+		} else {
+			if err := c.Compile(node.Expr); err != nil {
+				return err
+			}
+		}
+
+		c.emitIncrementListLength(node, loop.yieldListLengthSymbol)
+
+		pos := c.emit(node, OpJump, 0)
+		loop.continuePositions = append(loop.continuePositions, pos)
+	case *ast.CoyieldStatement:
 		if node.Expr == nil {
-			c.emit(node, OpYield, 0)
+			c.emit(node, OpCoyield, 0)
 		} else {
 			if err := c.Compile(node.Expr); err != nil {
 				return err
 			}
-			c.emit(node, OpYield, 1)
+			c.emit(node, OpCoyield, 1)
 		}
 	case *ast.CallExpression:
 		c.emit(node, OpPushNil) //slot for the result
@@ -2048,10 +2075,22 @@ func (c *compiler) Compile(node ast.Node) error {
 			return err
 		}
 
-		if err := c.Compile(node.Configuration); err != nil {
+		//The capabilities section is compiled separately because objects cannot contain capabilities.
+		configLit := node.Configuration.(*ast.ObjectLiteral)
+		capabilitiesNode, hasCapabilities := configLit.PropValue(inoxconsts.IMPORT_CONFIG__CAPABILITIES_PROPNAME)
+
+		if err := c.Compile(configLit.WithoutNamedProp(inoxconsts.IMPORT_CONFIG__CAPABILITIES_PROPNAME)); err != nil {
 			return err
 		}
 
+		if hasCapabilities {
+			if err := c.Compile(capabilitiesNode); err != nil {
+				return err
+			}
+		} else {
+			c.emit(node, OpPushNil)
+		}
+
 		c.emit(node, OpImport, c.addConstant(String(node.Identifier.Name)))
 	case *ast.SpawnExpression:
 		if node.Meta != nil {
@@ -2912,6 +2951,24 @@ func (c *compiler) currentWalkLoop() *loopCompilation {
 	return lastWalkLoop
 }
 
+// currentForExpressionLoop returns the innermost loop of a for expression having a block body, nil is returned
+// if there is no such loop.
+func (c *compiler) currentForExpressionLoop() *loopCompilation {
+	for i := len(c.loops) - 1; i >= 0; i-- {
+		if c.loops[i].yieldListLengthSymbol != nil {
+			return c.loops[i]
+		}
+	}
+	return nil
+}
+
+func (c *compiler) emitIncrementListLength(node ast.Node, listLengthSymbol *symbol) {
+	c.emit(node, OpGetLocal, listLengthSymbol.Index)
+	c.emit(node, OpPushConstant, c.addConstant(Int(1)))
+	c.emit(node, OpIntBin, int(ast.Add))
+	c.emit(node, OpSetLocal, listLengthSymbol.Index)
+}
+
 func (c *compiler) currentInstructions() []byte {
 	return c.scopes[c.scopeIndex].instructions
 }
@@ -47,6 +47,7 @@ type DefaultContextConfig struct {
 
 	Limits              []Limit
 	HostDefinitions     map[Host]Value
+	RetryPolicy         *RetryPolicy    //optional
 	ParentContext       *Context        //optional
 	ParentStdLibContext context.Context //optional, should not be set if ParentContext is set
 
@@ -89,8 +89,12 @@ type Context struct {
 	//permissions & limits
 	grantedPermissions   []Permission
 	forbiddenPermissions []Permission
+	capabilities         []*Capability //delegated capabilities, they are checked if a permission is not granted.
 	limits               []Limit
 	limiters             map[string]*limitbase.Limiter //the map is not changed after context creation
+	cpuTime              *cpuTimeAccount
+	retryPolicy          *RetryPolicy        //can be nil
+	transactionJournal   *TransactionJournal //can be nil
 
 	//values
 	namedPatterns       map[string]Pattern
@@ -136,12 +140,25 @@ type ContextConfig struct {
 	Kind                    ContextKind
 	Permissions             []Permission
 	ForbiddenPermissions    []Permission
+	Capabilities            []*Capability
 	DoNotCheckDatabasePerms bool
 
 	//If (cpu time limit is not present) AND (parent context has it) then the limit is inherited.
 	//The depletion of total limits' tokens for the created context starts when the associated state is set.
 	Limits []Limit
 
+	//If not zero the context is cancelled when the pseudo CPU time consumed by its state reaches the budget.
+	//Unlike the execution/cpu-time limit the budget is not shared with the parent context.
+	CPUTimeBudget time.Duration
+
+	//Policy used by the IO operations that support retrying (see DoIOWithRetry). If nil the policy
+	//of the parent context is used.
+	RetryPolicy *RetryPolicy
+
+	//Journal in which the effects of the transactions are recorded before being applied (see TransactionJournal).
+	//If nil the journal of the parent context is used.
+	TransactionJournal *TransactionJournal
+
 	HostDefinitions     map[Host]Value
 	TypeExtensions      []*TypeExtension
 	ParentContext       *Context
@@ -336,6 +353,9 @@ func NewContext(config ContextConfig) *Context {
 		executionStartTime:      time.Now(),
 		grantedPermissions:      slices.Clone(config.Permissions),
 		forbiddenPermissions:    slices.Clone(config.ForbiddenPermissions),
+		capabilities:            slices.Clone(config.Capabilities),
+		retryPolicy:             config.RetryPolicy,
+		transactionJournal:      config.TransactionJournal,
 		limits:                  limits,
 		limiters:                limiters,
 		namedPatterns:           map[string]Pattern{},
@@ -349,6 +369,12 @@ func NewContext(config ContextConfig) *Context {
 		waitConfirmPrompt: config.WaitConfirmPrompt,
 	}
 
+	ctx.cpuTime = newCPUTimeAccount(config.CPUTimeBudget, func() {
+		logger := ctx.Logger()
+		logger.Print(fmt.Sprintf("%s (%s), the context is cancelled", ErrCPUTimeBudgetExhausted, config.CPUTimeBudget))
+		ctx.CancelGracefully()
+	})
+
 	for _, limiter := range limiters {
 		limiter.SetContextIfNotChild(ctx)
 	}
@@ -393,6 +419,7 @@ func NewContext(config ContextConfig) *Context {
 		for _, limiter := range ctx.limiters {
 			limiter.Destroy()
 		}
+		ctx.cpuTime.stop()
 
 		//release acquired resources
 		//TODO
@@ -403,7 +430,7 @@ func NewContext(config ContextConfig) *Context {
 		ctx.currentTxLock.Unlock()
 
 		if tx != nil {
-			tx.Rollback(ctx)
+			tx.root().Rollback(ctx)
 		}
 
 		//call microtasks
@@ -568,6 +595,7 @@ func (ctx *Context) SetClosestState(state *GlobalState) {
 	for _, limiter := range ctx.limiters {
 		limiter.SetStateOnce(int64(state.id))
 	}
+	ctx.cpuTime.start()
 }
 
 func (ctx *Context) Logger() zerolog.Logger {
@@ -707,21 +735,50 @@ func (ctx *Context) GetWaitConfirmPrompt() WaitConfirmPrompt {
 // HasPermission checks if the passed permission is present in the Context.
 // The passed permission is first checked against forbidden permissions: if it is included in one of them, false is returned.
 func (ctx *Context) HasPermission(perm Permission) bool {
-	ctx.lock.RLock()
-	defer ctx.lock.RUnlock()
-	return ctx.hasPermission(perm)
+	return ctx.hasPermissionOrCapability(perm, false)
 }
 
 func (ctx *Context) hasPermission(perm Permission) bool {
+	if ctx.isPermissionForbidden(perm) {
+		return false
+	}
 
+	for _, grantedPerm := range ctx.grantedPermissions {
+		if grantedPerm.Includes(perm) {
+			return true
+		}
+	}
+	return false
+}
+
+func (ctx *Context) isPermissionForbidden(perm Permission) bool {
 	for _, forbiddenPerm := range ctx.forbiddenPermissions {
 		if forbiddenPerm.Includes(perm) {
-			return false
+			return true
 		}
 	}
+	return false
+}
 
-	for _, grantedPerm := range ctx.grantedPermissions {
-		if grantedPerm.Includes(perm) {
+// hasPermissionOrCapability checks if the permission is granted, if it is not granted the delegated capabilities
+// are checked. If $use is true a use is counted for the capability granting the permission.
+func (ctx *Context) hasPermissionOrCapability(perm Permission, use bool) bool {
+	ctx.lock.RLock()
+	if ctx.hasPermission(perm) {
+		ctx.lock.RUnlock()
+		return true
+	}
+	forbidden := ctx.isPermissionForbidden(perm)
+	capabilities := ctx.capabilities
+	ctx.lock.RUnlock()
+
+	if forbidden {
+		return false
+	}
+
+	//The lock is not held during the check because the issuer of a capability may be the context itself.
+	for _, capability := range capabilities {
+		if capability.grants(perm, use) {
 			return true
 		}
 	}
@@ -756,10 +813,7 @@ func (ctx *Context) CheckHasPermission(perm Permission) error {
 		return ctx.makeDoneContextError()
 	}
 
-	ctx.lock.RLock()
-	defer ctx.lock.RUnlock()
-
-	if !ctx.hasPermission(perm) {
+	if !ctx.hasPermissionOrCapability(perm, true) {
 		return NewNotAllowedError(perm)
 	}
 
@@ -793,6 +847,7 @@ func (ctx *Context) boundChild(opts BoundChildContextOptions) *Context {
 	child := NewContext(ContextConfig{
 		Permissions:             ctx.grantedPermissions,
 		ForbiddenPermissions:    ctx.forbiddenPermissions,
+		Capabilities:            ctx.capabilities,
 		Limits:                  limits,
 		ParentContext:           ctx,
 		AdditionalParentContext: opts.AdditionalParentContext,
@@ -826,7 +881,11 @@ func (ctx *Context) New() *Context {
 	clone := NewContext(ContextConfig{
 		Permissions:          ctx.grantedPermissions,
 		ForbiddenPermissions: ctx.forbiddenPermissions,
+		Capabilities:         ctx.capabilities,
 		Limits:               ctx.limits,
+		CPUTimeBudget:        ctx.cpuTime.budget,
+		RetryPolicy:          ctx.retryPolicy,
+		TransactionJournal:   ctx.transactionJournal,
 		HostDefinitions:      ctx.hostDefinitions,
 		TypeExtensions:       ctx.typeExtensions,
 		ParentContext:        ctx.parentCtx,
@@ -930,16 +989,6 @@ func (ctx *Context) PauseTokenDepletion(limitName string) error {
 	return fmt.Errorf("context: non existing limit '%s'", limitName)
 }
 
-func (ctx *Context) PauseCPUTimeDepletionIfNotPaused() error {
-	limitName := limitbase.EXECUTION_CPU_TIME_LIMIT_NAME
-	limiter, ok := ctx.limiters[limitName]
-	if ok {
-		limiter.PauseDepletionIfNotPaused()
-		return nil
-	}
-	return fmt.Errorf("context: non existing limit '%s'", limitName)
-}
-
 func (ctx *Context) DefinitelyStopTokenDepletion(limitName string) error {
 	limiter, ok := ctx.limiters[limitName]
 	if ok {
@@ -958,15 +1007,20 @@ func (ctx *Context) ResumeDepletion(limitName string) error {
 	return fmt.Errorf("context: non existing limit '%s'", limitName)
 }
 
+// PauseCPUTimeDepletion pauses the depletion of the execution/cpu-time limit's tokens and the measurement
+// of the CPU time consumed by the context's state (see ConsumedCPUTime).
 func (ctx *Context) PauseCPUTimeDepletion() error {
+	ctx.cpuTime.pause()
 	return ctx.PauseTokenDepletion(limitbase.EXECUTION_CPU_TIME_LIMIT_NAME)
 }
 
 func (ctx *Context) ResumeCPUTimeDepletion() error {
+	ctx.cpuTime.resume()
 	return ctx.ResumeDepletion(limitbase.EXECUTION_CPU_TIME_LIMIT_NAME)
 }
 
 func (ctx *Context) DefinitelyStopCPUTimeDepletion() error {
+	ctx.cpuTime.stop()
 	return ctx.DefinitelyStopTokenDepletion(limitbase.EXECUTION_CPU_TIME_LIMIT_NAME)
 }
 
@@ -1052,6 +1106,14 @@ func (ctx *Context) GetGrantedPermissions() []Permission {
 	return slices.Clone(ctx.grantedPermissions)
 }
 
+// GetCapabilities returns the capabilities delegated to the context, some of them may no longer be valid.
+func (ctx *Context) GetCapabilities() []*Capability {
+	ctx.lock.RLock()
+	defer ctx.lock.RUnlock()
+
+	return slices.Clone(ctx.capabilities)
+}
+
 func (ctx *Context) GetForbiddenPermissions() []Permission {
 	ctx.lock.RLock()
 	defer ctx.lock.RUnlock()
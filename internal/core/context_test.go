@@ -412,6 +412,63 @@ func TestContextDropPermissions(t *testing.T) {
 	assert.False(t, ctx.HasPermission(readFile))
 }
 
+func TestContextCPUTime(t *testing.T) {
+
+	t.Run("the time spent doing IO should not be counted", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		ctx.DoIO(func() error {
+			time.Sleep(50 * time.Millisecond)
+
+			//nested pauses are allowed.
+			return ctx.DoIO(func() error {
+				time.Sleep(50 * time.Millisecond)
+				return nil
+			})
+		})
+
+		assert.Less(t, ctx.ConsumedCPUTime(), 25*time.Millisecond)
+
+		time.Sleep(50 * time.Millisecond)
+		assert.GreaterOrEqual(t, ctx.ConsumedCPUTime(), 50*time.Millisecond)
+	})
+
+	t.Run("the measurement should stop once the depletion is definitively stopped", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		ctx.DefinitelyStopCPUTimeDepletion()
+		consumed := ctx.ConsumedCPUTime()
+
+		time.Sleep(20 * time.Millisecond)
+		assert.Equal(t, consumed, ctx.ConsumedCPUTime())
+	})
+
+	t.Run("the context should be cancelled when the budget is exhausted", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{CPUTimeBudget: 20 * time.Millisecond}, nil)
+		defer ctx.CancelGracefully()
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Second):
+			assert.FailNow(t, "context not cancelled")
+		}
+
+		assert.True(t, ctx.IsCPUTimeBudgetExhausted())
+	})
+
+	t.Run("the budget should not be depleted during IO", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{CPUTimeBudget: 50 * time.Millisecond}, nil)
+		defer ctx.CancelGracefully()
+
+		ctx.Sleep(100 * time.Millisecond)
+
+		assert.False(t, ctx.IsDone())
+		assert.False(t, ctx.IsCPUTimeBudgetExhausted())
+	})
+}
+
 func TestContextLimiters(t *testing.T) {
 	{
 		runtime.GC()
@@ -0,0 +1,157 @@
+package core
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	ErrCPUTimeBudgetExhausted = errors.New("CPU time budget exhausted")
+)
+
+// A cpuTimeAccount measures the pseudo CPU time consumed by the state associated with a context (e.g. a lthread):
+// the time during which the state is executing, excluding the time spent doing IO (see Context.DoIO), sleeping
+// or waiting for a lock. Unlike the execution/cpu-time limit, whose bucket is shared with the descendant contexts,
+// an account is never shared.
+//
+// The measurement starts when the state is set (Context.SetClosestState) and it is definitively stopped
+// by Context.DefinitelyStopCPUTimeDepletion or when the context is done.
+type cpuTimeAccount struct {
+	lock         sync.Mutex
+	started      bool
+	stopped      bool
+	pauseDepth   int       //number of pending pauses, pauses can be nested (e.g. Context.Take called inside Context.DoIO).
+	runningSince time.Time //zero if the state is not running
+	consumed     time.Duration
+
+	//If not zero onBudgetExhausted is called as soon as the consumed time reaches the budget.
+	budget            time.Duration
+	budgetTimer       *time.Timer
+	budgetExhausted   atomic.Bool
+	onBudgetExhausted func()
+}
+
+func newCPUTimeAccount(budget time.Duration, onBudgetExhausted func()) *cpuTimeAccount {
+	return &cpuTimeAccount{
+		budget:            budget,
+		onBudgetExhausted: onBudgetExhausted,
+	}
+}
+
+func (a *cpuTimeAccount) start() {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if a.started || a.stopped {
+		return
+	}
+	a.started = true
+
+	if a.pauseDepth == 0 {
+		a.startRunningNoLock()
+	}
+}
+
+func (a *cpuTimeAccount) pause() {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	a.pauseDepth++
+	if a.pauseDepth == 1 {
+		a.stopRunningNoLock()
+	}
+}
+
+func (a *cpuTimeAccount) resume() {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if a.pauseDepth == 0 {
+		return
+	}
+	a.pauseDepth--
+
+	if a.pauseDepth == 0 && a.started && !a.stopped {
+		a.startRunningNoLock()
+	}
+}
+
+// stop definitively stops the measurement.
+func (a *cpuTimeAccount) stop() {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if a.stopped {
+		return
+	}
+	a.stopRunningNoLock()
+	a.stopped = true
+}
+
+// consumedTime returns the CPU time consumed so far.
+func (a *cpuTimeAccount) consumedTime() time.Duration {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	consumed := a.consumed
+	if !a.runningSince.IsZero() {
+		consumed += time.Since(a.runningSince)
+	}
+	return consumed
+}
+
+func (a *cpuTimeAccount) startRunningNoLock() {
+	if !a.runningSince.IsZero() || a.stopped {
+		return
+	}
+	a.runningSince = time.Now()
+
+	if a.budget > 0 && !a.budgetExhausted.Load() {
+		a.budgetTimer = time.AfterFunc(a.budget-a.consumed, a.checkBudget)
+	}
+}
+
+func (a *cpuTimeAccount) stopRunningNoLock() {
+	if a.runningSince.IsZero() {
+		return
+	}
+	a.consumed += time.Since(a.runningSince)
+	a.runningSince = time.Time{}
+
+	if a.budgetTimer != nil {
+		a.budgetTimer.Stop()
+		a.budgetTimer = nil
+	}
+}
+
+func (a *cpuTimeAccount) checkBudget() {
+	a.lock.Lock()
+	consumed := a.consumed
+	if !a.runningSince.IsZero() {
+		consumed += time.Since(a.runningSince)
+	}
+	exhausted := consumed >= a.budget
+	a.lock.Unlock()
+
+	if exhausted && a.budgetExhausted.CompareAndSwap(false, true) && a.onBudgetExhausted != nil {
+		a.onBudgetExhausted()
+	}
+}
+
+// ConsumedCPUTime returns the pseudo CPU time consumed by the state associated with the context, the time spent
+// in IO (see DoIO), sleeping or waiting for locks is not counted. The CPU time consumed by child contexts is not included.
+func (ctx *Context) ConsumedCPUTime() time.Duration {
+	return ctx.cpuTime.consumedTime()
+}
+
+// CPUTimeBudget returns the CPU time budget of the context, zero is returned if the context has no budget.
+func (ctx *Context) CPUTimeBudget() time.Duration {
+	return ctx.cpuTime.budget
+}
+
+// IsCPUTimeBudgetExhausted returns true if the context has been cancelled because its CPU time budget was exhausted.
+func (ctx *Context) IsCPUTimeBudgetExhausted() bool {
+	return ctx.cpuTime.budgetExhausted.Load()
+}
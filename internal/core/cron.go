@@ -0,0 +1,195 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// maximum duration searched by CronExpression.NextTime.
+	MAX_CRON_SEARCH_DURATION = 5 * 366 * 24 * time.Hour
+)
+
+var (
+	ErrInvalidCronExpression = errors.New("invalid cron expression")
+
+	CRON_MACROS = map[string]string{
+		"@yearly":   "0 0 1 1 *",
+		"@annually": "0 0 1 1 *",
+		"@monthly":  "0 0 1 * *",
+		"@weekly":   "0 0 * * 0",
+		"@daily":    "0 0 * * *",
+		"@midnight": "0 0 * * *",
+		"@hourly":   "0 * * * *",
+	}
+)
+
+// A CronExpression is a parsed cron expression with 5 fields: minute (0-59), hour (0-23), day of month (1-31),
+// month (1-12) and day of week (0-7, 0 and 7 are Sunday). Each field is either '*' or a comma-separated list of
+// values, ranges (a-b) and steps (*/n, a-b/n). The macros in CRON_MACROS (e.g. @daily) are also supported.
+// As with most cron implementations a day matches if the day of month or the day of week matches when both
+// fields are restricted.
+type CronExpression struct {
+	source string
+
+	minutes     uint64 //bit i is set if minute i matches
+	hours       uint64
+	daysOfMonth uint64
+	months      uint64
+	daysOfWeek  uint64
+
+	daysOfMonthRestricted bool
+	daysOfWeekRestricted  bool
+}
+
+type cronFieldBounds struct {
+	name     string
+	min, max int
+}
+
+var cronFields = [5]cronFieldBounds{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+func ParseCronExpression(s string) (*CronExpression, error) {
+	source := s
+	s = strings.TrimSpace(s)
+
+	if strings.HasPrefix(s, "@") {
+		expanded, ok := CRON_MACROS[s]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown macro %s", ErrInvalidCronExpression, s)
+		}
+		s = expanded
+	}
+
+	fields := strings.Fields(s)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("%w: %d fields were expected but there are %d", ErrInvalidCronExpression, len(cronFields), len(fields))
+	}
+
+	expr := &CronExpression{source: source}
+	sets := [5]*uint64{&expr.minutes, &expr.hours, &expr.daysOfMonth, &expr.months, &expr.daysOfWeek}
+
+	for i, field := range fields {
+		set, err := parseCronField(field, cronFields[i])
+		if err != nil {
+			return nil, err
+		}
+		*sets[i] = set
+	}
+
+	//7 is Sunday.
+	if expr.daysOfWeek&(1<<7) != 0 {
+		expr.daysOfWeek |= 1
+	}
+
+	expr.daysOfMonthRestricted = fields[2] != "*"
+	expr.daysOfWeekRestricted = fields[4] != "*"
+
+	return expr, nil
+}
+
+func parseCronField(field string, bounds cronFieldBounds) (uint64, error) {
+	var set uint64
+
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("%w: invalid step in %s field: %q", ErrInvalidCronExpression, bounds.name, part)
+			}
+			step = n
+		}
+
+		start, end := bounds.min, bounds.max
+
+		if rangePart != "*" {
+			startPart, endPart, isRange := strings.Cut(rangePart, "-")
+
+			n, err := strconv.Atoi(startPart)
+			if err != nil {
+				return 0, fmt.Errorf("%w: invalid %s: %q", ErrInvalidCronExpression, bounds.name, part)
+			}
+			start = n
+
+			if isRange {
+				n, err := strconv.Atoi(endPart)
+				if err != nil {
+					return 0, fmt.Errorf("%w: invalid %s: %q", ErrInvalidCronExpression, bounds.name, part)
+				}
+				end = n
+			} else if !hasStep {
+				end = start
+			}
+		}
+
+		if start < bounds.min || end > bounds.max || start > end {
+			return 0, fmt.Errorf("%w: %s out of range (%d-%d): %q", ErrInvalidCronExpression, bounds.name, bounds.min, bounds.max, part)
+		}
+
+		for i := start; i <= end; i += step {
+			set |= 1 << i
+		}
+	}
+
+	return set, nil
+}
+
+func (expr *CronExpression) String() string {
+	return expr.source
+}
+
+// NextTime returns the first time strictly after $after that matches the expression, the location of $after is used.
+// ok is false if there is no matching time in the next MAX_CRON_SEARCH_DURATION (e.g. 0 0 31 2 *).
+func (expr *CronExpression) NextTime(after time.Time) (_ time.Time, ok bool) {
+	loc := after.Location()
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := after.Add(MAX_CRON_SEARCH_DURATION)
+
+	for t.Before(limit) {
+		if expr.months&(1<<int(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+
+		if !expr.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+
+		if expr.hours&(1<<t.Hour()) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+
+		if expr.minutes&(1<<t.Minute()) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+
+		return t, true
+	}
+
+	return time.Time{}, false
+}
+
+func (expr *CronExpression) matchesDay(t time.Time) bool {
+	dayOfMonthMatch := expr.daysOfMonth&(1<<t.Day()) != 0
+	dayOfWeekMatch := expr.daysOfWeek&(1<<int(t.Weekday())) != 0
+
+	if expr.daysOfMonthRestricted && expr.daysOfWeekRestricted {
+		return dayOfMonthMatch || dayOfWeekMatch
+	}
+	return dayOfMonthMatch && dayOfWeekMatch
+}
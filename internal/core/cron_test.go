@@ -0,0 +1,77 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCronExpression(t *testing.T) {
+
+	//Monday.
+	start := time.Date(2024, 1, 1, 10, 30, 0, 0, time.UTC)
+
+	testCases := []struct {
+		expr     string
+		after    time.Time
+		expected time.Time
+	}{
+		{"* * * * *", start, start.Add(time.Minute)},
+		{"*/15 * * * *", start, time.Date(2024, 1, 1, 10, 45, 0, 0, time.UTC)},
+		{"0 * * * *", start, time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC)},
+		{"5,10 8-9 * * *", start, time.Date(2024, 1, 2, 8, 5, 0, 0, time.UTC)},
+		{"0 0 1 * *", start, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", start, time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 12 * * 5", start, time.Date(2024, 1, 5, 12, 0, 0, 0, time.UTC)},
+		{"0 12 * * 7", start, time.Date(2024, 1, 7, 12, 0, 0, 0, time.UTC)},
+		//the day of month OR the day of week should match.
+		{"0 0 15 * 3", start, time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)},
+		{"@daily", start, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", start.Add(30 * time.Second), time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC)},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.expr, func(t *testing.T) {
+			expr, err := ParseCronExpression(testCase.expr)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			next, ok := expr.NextTime(testCase.after)
+			if assert.True(t, ok) {
+				assert.Equal(t, testCase.expected, next)
+			}
+		})
+	}
+
+	t.Run("no matching time", func(t *testing.T) {
+		expr, err := ParseCronExpression("0 0 31 2 *")
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		_, ok := expr.NextTime(start)
+		assert.False(t, ok)
+	})
+
+	t.Run("invalid expressions", func(t *testing.T) {
+		invalidExpressions := []string{
+			"",
+			"* * * *",
+			"60 * * * *",
+			"* 24 * * *",
+			"* * 0 * *",
+			"* * * 13 *",
+			"*/0 * * * *",
+			"5-1 * * * *",
+			"a * * * *",
+			"@every-second",
+		}
+
+		for _, s := range invalidExpressions {
+			_, err := ParseCronExpression(s)
+			assert.ErrorIs(t, err, ErrInvalidCronExpression, s)
+		}
+	})
+}
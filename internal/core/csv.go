@@ -0,0 +1,686 @@
+package core
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/inoxlang/inox/internal/inoxconsts"
+	"github.com/inoxlang/inox/internal/mimeconsts"
+)
+
+const (
+	DEFAULT_CSV_DELIMITER = ','
+	TSV_DELIMITER         = '\t'
+
+	CSV_DATE_LAYOUT = time.DateOnly
+	CSV_YEAR_LAYOUT = "2006"
+)
+
+var (
+	ErrInvalidCSVRowPattern    = errors.New("the pattern of CSV rows should be a record pattern or an object pattern")
+	ErrInvalidCSVDelimiter     = errors.New("invalid CSV delimiter")
+	ErrEmptyCSVColumnName      = errors.New("empty CSV column name")
+	ErrDuplicateCSVColumnName  = errors.New("duplicate CSV column name")
+	ErrCSVRowNotMatchingSchema = errors.New("CSV row does not match the pattern")
+	ErrCSVRowNotIProps         = errors.New("CSV rows should be records or objects")
+	ErrNoCSVRepresentation     = errors.New("value has no CSV cell representation")
+
+	CSV_STREAM_CHUNK_DATA_TYPE = &ListPattern{generalElementPattern: SERIALIZABLE_PATTERN}
+
+	_ = []ReadableStream{(*CSVRowStream)(nil)}
+)
+
+func init() {
+	RegisterParser(mimeconsts.CSV_CTYPE, &csvParser{delimiter: DEFAULT_CSV_DELIMITER})
+	RegisterParser(mimeconsts.TSV_CTYPE, &csvParser{delimiter: TSV_DELIMITER})
+}
+
+type CSVConfig struct {
+	Delimiter rune //defaults to DEFAULT_CSV_DELIMITER
+
+	//If true the first row is not a header: the names of the columns are Columns, or the column indexes if Columns
+	//is empty. If false the names of the columns are read from the first row and Columns is ignored when reading.
+	NoHeader bool
+	Columns  []string
+
+	//Pattern of the rows:
+	// - nil: rows are parsed as records whose values are strings.
+	// - *RecordPattern: rows are parsed as records.
+	// - *ObjectPattern: rows are parsed as objects.
+	//
+	//Cells are coerced to the type expected by the pattern of their column (int, float, bool, year, date, datetime,
+	//quantities); an empty cell in an optional column is omitted.
+	Pattern Pattern
+}
+
+func (c CSVConfig) delimiter() rune {
+	if c.Delimiter == 0 {
+		return DEFAULT_CSV_DELIMITER
+	}
+	return c.Delimiter
+}
+
+// An InvalidCSVRowError is returned when a row is malformed or does not match the pattern, the reading can be continued.
+type InvalidCSVRowError struct {
+	Index int //index of the row, the header is not counted
+	Line  int //line of the row in the input (1-based)
+	Err   error
+}
+
+func (e *InvalidCSVRowError) Error() string {
+	return fmt.Sprintf("invalid CSV row at index %d (line %d): %s", e.Index, e.Line, e.Err)
+}
+
+func (e *InvalidCSVRowError) Unwrap() error {
+	return e.Err
+}
+
+// A CSVReader reads the rows of a CSV/TSV input one at a time and converts them to records or objects.
+type CSVReader struct {
+	ctx     *Context
+	reader  *csv.Reader
+	config  CSVConfig
+	pattern IPropsPattern //nil if config.Pattern is nil
+	columns []string
+
+	headerRead bool
+	rowIndex   int
+	fatalErr   error
+}
+
+func NewCSVReader(ctx *Context, r io.Reader, config CSVConfig) (*CSVReader, error) {
+	delimiter := config.delimiter()
+	if delimiter == '"' || delimiter == '\r' || delimiter == '\n' {
+		return nil, ErrInvalidCSVDelimiter
+	}
+
+	reader := &CSVReader{
+		ctx:    ctx,
+		reader: csv.NewReader(r),
+		config: config,
+	}
+	reader.reader.Comma = delimiter
+	reader.reader.ReuseRecord = true
+
+	switch p := config.Pattern.(type) {
+	case nil:
+	case *RecordPattern:
+		reader.pattern = p
+	case *ObjectPattern:
+		reader.pattern = p
+	default:
+		return nil, ErrInvalidCSVRowPattern
+	}
+
+	if config.NoHeader && len(config.Columns) > 0 {
+		if err := checkCSVColumnNames(config.Columns); err != nil {
+			return nil, err
+		}
+		reader.columns = slices.Clone(config.Columns)
+	}
+
+	return reader, nil
+}
+
+// NewCSVReaderFromByteStream creates a CSVReader reading from a byte stream, readTimeout is the maximum duration to wait
+// for the next bytes (DEFAULT_JSON_STREAM_READ_TIMEOUT if zero).
+func NewCSVReaderFromByteStream(ctx *Context, stream *ReadableByteStream, readTimeout time.Duration, config CSVConfig) (*CSVReader, error) {
+	if readTimeout <= 0 {
+		readTimeout = DEFAULT_JSON_STREAM_READ_TIMEOUT
+	}
+	return NewCSVReader(ctx, &byteStreamReader{ctx: ctx, stream: stream, timeout: readTimeout}, config)
+}
+
+// Columns returns the names of the columns, it returns nil if the header has not been read yet.
+func (r *CSVReader) Columns() []string {
+	return slices.Clone(r.columns)
+}
+
+// Next returns the next row, ErrEndOfStream is returned after the last row. If a row is malformed or does not match
+// the pattern an *InvalidCSVRowError is returned and the reading can be continued.
+func (r *CSVReader) Next() (Serializable, error) {
+	if r.fatalErr != nil {
+		return nil, r.fatalErr
+	}
+
+	if r.ctx.IsDoneSlowCheck() {
+		return nil, r.ctx.Err()
+	}
+
+	if !r.headerRead {
+		r.headerRead = true
+
+		if !r.config.NoHeader {
+			header, err := r.reader.Read()
+			if err == io.EOF {
+				r.fatalErr = ErrEndOfStream
+				return nil, ErrEndOfStream
+			}
+			if err == nil {
+				err = checkCSVColumnNames(header)
+			}
+			if err != nil {
+				r.fatalErr = fmt.Errorf("invalid CSV header: %w", err)
+				return nil, r.fatalErr
+			}
+			r.columns = slices.Clone(header)
+		}
+	}
+
+	row, err := r.reader.Read()
+	if err == io.EOF {
+		r.fatalErr = ErrEndOfStream
+		return nil, ErrEndOfStream
+	}
+
+	index := r.rowIndex
+	r.rowIndex++
+
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return nil, &InvalidCSVRowError{Index: index, Line: parseErr.StartLine, Err: parseErr.Err}
+	}
+	if err != nil {
+		r.fatalErr = err
+		return nil, err
+	}
+
+	line, _ := r.reader.FieldPos(0)
+
+	value, err := r.convertRow(row)
+	if err != nil {
+		return nil, &InvalidCSVRowError{Index: index, Line: line, Err: err}
+	}
+	return value, nil
+}
+
+func (r *CSVReader) convertRow(row []string) (Serializable, error) {
+	if r.columns == nil { //no header and no column names
+		r.columns = make([]string, len(row))
+		for i := range row {
+			r.columns[i] = strconv.Itoa(i)
+		}
+	}
+
+	if len(row) != len(r.columns) {
+		return nil, fmt.Errorf("%d cells were found but there are %d columns", len(row), len(r.columns))
+	}
+
+	keys := make([]string, 0, len(row))
+	values := make([]Serializable, 0, len(row))
+
+	for i, cell := range row {
+		column := r.columns[i]
+		var value Serializable = String(cell)
+
+		if r.pattern != nil {
+			if entryPattern, isOptional, ok := r.pattern.ValuePropPattern(column); ok {
+				if isOptional && cell == "" {
+					continue
+				}
+
+				var err error
+				value, err = coerceTextToPatternType(r.ctx, cell, entryPattern)
+				if err != nil {
+					return nil, fmt.Errorf("column %q: %w", column, err)
+				}
+			}
+		}
+
+		keys = append(keys, column)
+		values = append(values, value)
+	}
+
+	var result Serializable
+
+	if _, ok := r.pattern.(*ObjectPattern); ok {
+		valMap := make(ValMap, len(keys))
+		for i, key := range keys {
+			valMap[key] = values[i]
+		}
+		result = NewObjectFromMap(valMap, r.ctx)
+	} else {
+		result = NewRecordFromKeyValLists(keys, values)
+	}
+
+	if r.config.Pattern != nil && !r.config.Pattern.Test(r.ctx, result) {
+		return nil, ErrCSVRowNotMatchingSchema
+	}
+	return result, nil
+}
+
+func checkCSVColumnNames(names []string) error {
+	for i, name := range names {
+		if name == "" {
+			return fmt.Errorf("%w (column %d)", ErrEmptyCSVColumnName, i)
+		}
+		if slices.Contains(names[:i], name) {
+			return fmt.Errorf("%w: %q", ErrDuplicateCSVColumnName, name)
+		}
+	}
+	return nil
+}
+
+// ParseCSV reads all the rows of a CSV/TSV input, the first invalid row causes an error.
+func ParseCSV(ctx *Context, r io.Reader, config CSVConfig) (*List, error) {
+	reader, err := NewCSVReader(ctx, r, config)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []Serializable
+	for {
+		row, err := reader.Next()
+		if err == ErrEndOfStream {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+
+	return NewWrappedValueListFrom(rows), nil
+}
+
+// coerceTextToPatternType converts a textual value (e.g. a CSV cell) to the type of the values matched by pattern, the
+// text is returned as a String if the pattern does not correspond to a supported type.
+func coerceTextToPatternType(ctx *Context, cell string, pattern Pattern) (Serializable, error) {
+	var (
+		value Serializable
+		err   error
+	)
+
+	switch p := pattern.(type) {
+	case *TypePattern:
+		switch p {
+		case INT_PATTERN:
+			value, err = parseCSVInt(cell)
+		case FLOAT_PATTERN:
+			value, err = parseCSVFloat(cell)
+		case BOOL_PATTERN:
+			var b bool
+			b, err = strconv.ParseBool(strings.TrimSpace(cell))
+			value = Bool(b)
+		case YEAR_PATTERN:
+			var t time.Time
+			t, err = time.ParseInLocation(CSV_YEAR_LAYOUT, strings.TrimSpace(cell), time.UTC)
+			value = Year(t)
+		case DATE_PATTERN:
+			var t time.Time
+			t, err = time.ParseInLocation(CSV_DATE_LAYOUT, strings.TrimSpace(cell), time.UTC)
+			value = Date(t)
+		case DATETIME_PATTERN:
+			var t time.Time
+			t, err = time.Parse(time.RFC3339Nano, strings.TrimSpace(cell))
+			value = DateTime(t)
+		case BYTECOUNT_PATTERN, LINECOUNT_PATTERN, RUNECOUNT_PATTERN, BYTERATE_PATTERN, FREQUENCY_PATTERN, DURATION_PATTERN:
+			value, err = parseQuantityString(strings.TrimSpace(cell))
+		default:
+			return String(cell), nil
+		}
+	case *IntRangePattern:
+		value, err = parseCSVInt(cell)
+	case *FloatRangePattern:
+		value, err = parseCSVFloat(cell)
+	case *ExactValuePattern:
+		switch p.value.(type) {
+		case Int:
+			value, err = parseCSVInt(cell)
+		case Float:
+			value, err = parseCSVFloat(cell)
+		case Bool:
+			return coerceTextToPatternType(ctx, cell, BOOL_PATTERN)
+		default:
+			value = String(cell)
+		}
+	case *OptionalPattern:
+		if cell == "" {
+			return Nil, nil
+		}
+		return coerceTextToPatternType(ctx, cell, p.pattern)
+	case *UnionPattern:
+		//The first case matching the coerced value wins.
+		for _, case_ := range p.cases {
+			value, err := coerceTextToPatternType(ctx, cell, case_)
+			if err == nil && case_.Test(ctx, value) {
+				return value, nil
+			}
+		}
+		return nil, fmt.Errorf("%q does not match any case of the union", cell)
+	default:
+		return String(cell), nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid cell %q: %w", cell, err)
+	}
+
+	if !pattern.Test(ctx, value) {
+		return nil, fmt.Errorf("%q does not match the pattern of the column", cell)
+	}
+	return value, nil
+}
+
+func parseCSVInt(cell string) (Int, error) {
+	i, err := strconv.ParseInt(strings.TrimSpace(cell), 10, 64)
+	return Int(i), err
+}
+
+func parseCSVFloat(cell string) (Float, error) {
+	f, err := strconv.ParseFloat(strings.TrimSpace(cell), 64)
+	return Float(f), err
+}
+
+// parseQuantityString parses a quantity with the same syntax as quantity literals (e.g. 10kB, 1h30mn, 5x/s).
+func parseQuantityString(s string) (Serializable, error) {
+	isRate := false
+	if strings.HasSuffix(s, "/s") {
+		isRate = true
+		s = strings.TrimSuffix(s, "/s")
+	}
+
+	isDigitOrPoint := func(r rune) bool {
+		return (r >= '0' && r <= '9') || r == '.'
+	}
+
+	var (
+		values []float64
+		units  []string
+	)
+
+	for s != "" {
+		numberEnd := strings.IndexFunc(s, func(r rune) bool { return !isDigitOrPoint(r) })
+		if numberEnd <= 0 {
+			return nil, ErrInvalidQuantity
+		}
+
+		value, err := strconv.ParseFloat(s[:numberEnd], 64)
+		if err != nil {
+			return nil, ErrInvalidQuantity
+		}
+		s = s[numberEnd:]
+
+		unitEnd := strings.IndexFunc(s, isDigitOrPoint)
+		if unitEnd < 0 {
+			unitEnd = len(s)
+		}
+		if unitEnd == 0 {
+			return nil, ErrInvalidQuantity
+		}
+
+		values = append(values, value)
+		units = append(units, s[:unitEnd])
+		s = s[unitEnd:]
+	}
+
+	if len(values) == 0 {
+		return nil, ErrInvalidQuantity
+	}
+
+	quantity, err := evalQuantity(values, units)
+	if err != nil {
+		return nil, err
+	}
+	if isRate {
+		return evalRate(quantity, "s")
+	}
+	return quantity, nil
+}
+
+// WriteCSV writes the rows (records, objects or other values having properties) of an iterable in the CSV/TSV format.
+// The columns are config.Columns, the properties of the pattern or the (sorted) properties of the first row. A header
+// is written unless config.NoHeader is true, missing properties are written as empty cells.
+func WriteCSV(ctx *Context, w io.Writer, rows Iterable, config CSVConfig) error {
+	delimiter := config.delimiter()
+
+	writer := csv.NewWriter(w)
+	writer.Comma = delimiter
+
+	columns := config.Columns
+	if len(columns) == 0 && config.Pattern != nil {
+		propsPattern, ok := config.Pattern.(IPropsPattern)
+		if !ok {
+			return ErrInvalidCSVRowPattern
+		}
+		columns = propsPattern.ValuePropertyNames()
+	}
+
+	headerWritten := false
+	cells := make([]string, len(columns))
+	it := rows.Iterator(ctx, IteratorConfiguration{KeysNeverRead: true})
+
+	for index := 0; it.Next(ctx); index++ {
+		rowValue := it.Value(ctx)
+		row, ok := rowValue.(IProps)
+		if !ok {
+			return fmt.Errorf("row at index %d: %w", index, ErrCSVRowNotIProps)
+		}
+
+		if config.Pattern != nil && !config.Pattern.Test(ctx, rowValue) {
+			return fmt.Errorf("row at index %d: %w", index, ErrCSVRowNotMatchingSchema)
+		}
+
+		propertyNames := row.PropertyNames(ctx)
+
+		if !headerWritten {
+			headerWritten = true
+
+			if len(columns) == 0 {
+				columns = slices.Clone(propertyNames)
+				slices.Sort(columns)
+				cells = make([]string, len(columns))
+			}
+
+			if err := checkCSVColumnNames(columns); err != nil {
+				return err
+			}
+
+			if !config.NoHeader {
+				if err := writer.Write(columns); err != nil {
+					return err
+				}
+			}
+		}
+
+		for i, column := range columns {
+			cells[i] = ""
+			if !slices.Contains(propertyNames, column) {
+				continue
+			}
+			cell, err := formatValueAsText(row.Prop(ctx, column))
+			if err != nil {
+				return fmt.Errorf("row at index %d, column %q: %w", index, column, err)
+			}
+			cells[i] = cell
+		}
+
+		if err := writer.Write(cells); err != nil {
+			return err
+		}
+	}
+
+	//Write the header even if there are no rows.
+	if !headerWritten && !config.NoHeader && len(columns) > 0 {
+		if err := checkCSVColumnNames(columns); err != nil {
+			return err
+		}
+		if err := writer.Write(columns); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// formatValueAsText returns the textual representation of a scalar value (e.g. the content of a CSV cell), the
+// representation of quantities, dates and datetimes can be read back by coerceTextToPatternType.
+func formatValueAsText(v Value) (string, error) {
+	switch val := v.(type) {
+	case NilT:
+		return "", nil
+	case StringLike:
+		return val.GetOrBuildString(), nil
+	case Int:
+		return strconv.FormatInt(int64(val), 10), nil
+	case Float:
+		return strconv.FormatFloat(float64(val), 'g', -1, 64), nil
+	case Bool:
+		return strconv.FormatBool(bool(val)), nil
+	case Year:
+		return time.Time(val).UTC().Format(CSV_YEAR_LAYOUT), nil
+	case Date:
+		return time.Time(val).UTC().Format(CSV_DATE_LAYOUT), nil
+	case DateTime:
+		return time.Time(val).Format(time.RFC3339Nano), nil
+	case LineCount:
+		return strconv.FormatInt(int64(val), 10) + inoxconsts.LINE_COUNT_UNIT, nil
+	case RuneCount:
+		return strconv.FormatInt(int64(val), 10) + inoxconsts.RUNE_COUNT_UNIT, nil
+	}
+
+	var buf bytes.Buffer
+	var err error
+
+	switch val := v.(type) {
+	case ByteCount:
+		_, err = val.Write(&buf, -1)
+	case ByteRate:
+		_, err = val.write(&buf)
+	case Frequency:
+		_, err = val.write(&buf)
+	case Duration:
+		_, err = val.write(&buf)
+	default:
+		return "", fmt.Errorf("%w: %T", ErrNoCSVRepresentation, v)
+	}
+
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// A CSVRowStream is a ReadableStream of the rows of a CSV/TSV input, CSVRowStream implements Value.
+// The reading of the underlying input is not interrupted when the timeout passed to WaitNext is reached; use
+// NewCSVReaderFromByteStream to bound the wait for the next bytes.
+type CSVRowStream struct {
+	reader  *CSVReader
+	filter  Pattern
+	stopped atomic.Bool
+}
+
+func NewCSVRowStream(reader *CSVReader, filter Pattern) *CSVRowStream {
+	return &CSVRowStream{reader: reader, filter: filter}
+}
+
+func (s *CSVRowStream) Stream(ctx *Context, config *ReadableStreamConfiguration) ReadableStream {
+	return s
+}
+
+// WaitNext returns the next row matching the filters, invalid rows are returned as *InvalidCSVRowError errors.
+func (s *CSVRowStream) WaitNext(ctx *Context, filter Pattern, timeout time.Duration) (Value, error) {
+	for {
+		if s.IsStopped() {
+			return nil, ErrEndOfStream
+		}
+
+		row, err := s.reader.Next()
+		if err != nil {
+			if _, ok := err.(*InvalidCSVRowError); !ok {
+				s.Stop()
+			}
+			return nil, err
+		}
+
+		if (s.filter == nil || s.filter.Test(ctx, row)) && (filter == nil || filter.Test(ctx, row)) {
+			return row, nil
+		}
+	}
+}
+
+// WaitNextChunk returns a chunk of at most sizeRange.InclusiveEnd() rows, fewer rows are returned if the end of the input
+// is reached or if an error occurs. The rows read before an invalid row are returned along with the *InvalidCSVRowError
+// error, so the reading can continue after the invalid row.
+func (s *CSVRowStream) WaitNextChunk(ctx *Context, filter Pattern, sizeRange IntRange, timeout time.Duration) (*DataChunk, error) {
+	if s.IsStopped() {
+		return nil, ErrEndOfStream
+	}
+
+	max := int(sizeRange.InclusiveEnd())
+	chunkData := make([]Serializable, 0, sizeRange.KnownStart())
+
+	newChunk := func() *DataChunk {
+		return &DataChunk{
+			data: NewWrappedValueListFrom(chunkData),
+		}
+	}
+
+	for len(chunkData) < max {
+		row, err := s.WaitNext(ctx, filter, timeout)
+		if err != nil {
+			return newChunk(), err
+		}
+		chunkData = append(chunkData, row.(Serializable))
+	}
+
+	return newChunk(), nil
+}
+
+func (s *CSVRowStream) Stop() {
+	s.stopped.Store(true)
+}
+
+func (s *CSVRowStream) IsStopped() bool {
+	return s.stopped.Load()
+}
+
+func (s *CSVRowStream) IsMainlyChunked() bool {
+	return false
+}
+
+func (s *CSVRowStream) ChunkDataType() Pattern {
+	return CSV_STREAM_CHUNK_DATA_TYPE
+}
+
+type csvParser struct {
+	delimiter rune
+}
+
+func (p *csvParser) Validate(ctx *Context, s string) bool {
+	if len(s) > DEFAULT_MAX_TESTED_STRING_BYTE_LENGTH {
+		panic(ErrTestedStringTooLarge)
+	}
+
+	reader := csv.NewReader(strings.NewReader(s))
+	reader.Comma = p.delimiter
+	reader.ReuseRecord = true
+
+	for {
+		_, err := reader.Read()
+		if err == io.EOF {
+			return true
+		}
+		if err != nil {
+			return false
+		}
+	}
+}
+
+// Parse returns a list of records (one per row), the first row is the header.
+func (p *csvParser) Parse(ctx *Context, s string) (Serializable, error) {
+	if len(s) > DEFAULT_MAX_TESTED_STRING_BYTE_LENGTH {
+		return nil, ErrTestedStringTooLarge
+	}
+
+	return ParseCSV(ctx, strings.NewReader(s), CSVConfig{Delimiter: p.delimiter})
+}
@@ -0,0 +1,455 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/inoxlang/inox/internal/mimeconsts"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSVParsers(t *testing.T) {
+	ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+	defer ctx.CancelGracefully()
+
+	t.Run("CSV", func(t *testing.T) {
+		parser, ok := GetParser(mimeconsts.CSV_CTYPE)
+		if !assert.True(t, ok) {
+			return
+		}
+
+		assert.True(t, parser.Validate(ctx, "a,b\n1,2\n"))
+		assert.False(t, parser.Validate(ctx, "a,b\n\"1,2\n"))
+
+		result, err := parser.Parse(ctx, "a,b\n1,\"x,y\"\n")
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.Equal(t, NewWrappedValueList(
+			NewRecordFromKeyValLists([]string{"a", "b"}, []Serializable{String("1"), String("x,y")}),
+		), result)
+	})
+
+	t.Run("TSV", func(t *testing.T) {
+		parser, ok := GetParser(mimeconsts.TSV_CTYPE)
+		if !assert.True(t, ok) {
+			return
+		}
+
+		result, err := parser.Parse(ctx, "a\tb\n1\t2,3\n")
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.Equal(t, NewWrappedValueList(
+			NewRecordFromKeyValLists([]string{"a", "b"}, []Serializable{String("1"), String("2,3")}),
+		), result)
+	})
+}
+
+func TestParseCSV(t *testing.T) {
+
+	t.Run("type coercion", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		pattern := NewExactRecordPattern([]RecordPatternEntry{
+			{Name: "name", Pattern: STR_PATTERN},
+			{Name: "count", Pattern: INT_PATTERN},
+			{Name: "ratio", Pattern: FLOAT_PATTERN},
+			{Name: "enabled", Pattern: BOOL_PATTERN},
+			{Name: "day", Pattern: DATE_PATTERN},
+			{Name: "time", Pattern: DATETIME_PATTERN},
+			{Name: "size", Pattern: BYTECOUNT_PATTERN},
+			{Name: "timeout", Pattern: DURATION_PATTERN},
+			{Name: "rate", Pattern: BYTERATE_PATTERN},
+		})
+
+		input := "name,count,ratio,enabled,day,time,size,timeout,rate\n" +
+			"a,1,0.5,true,2024-01-02,2024-01-02T10:00:00Z,10kB,1h30mn,1MB/s\n"
+
+		list, err := ParseCSV(ctx, strings.NewReader(input), CSVConfig{Pattern: pattern})
+		if !assert.NoError(t, err) || !assert.Equal(t, 1, list.Len()) {
+			return
+		}
+
+		record := list.At(ctx, 0).(*Record)
+		assert.Equal(t, String("a"), record.Prop(ctx, "name"))
+		assert.Equal(t, Int(1), record.Prop(ctx, "count"))
+		assert.Equal(t, Float(0.5), record.Prop(ctx, "ratio"))
+		assert.Equal(t, True, record.Prop(ctx, "enabled"))
+		assert.Equal(t, Date(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)), record.Prop(ctx, "day"))
+		assert.Equal(t, DateTime(time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)), record.Prop(ctx, "time"))
+		assert.Equal(t, ByteCount(10_000), record.Prop(ctx, "size"))
+		assert.Equal(t, Duration(90*time.Minute), record.Prop(ctx, "timeout"))
+		assert.Equal(t, ByteRate(1_000_000), record.Prop(ctx, "rate"))
+	})
+
+	t.Run("object pattern with optional column", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		pattern := NewExactObjectPattern([]ObjectPatternEntry{
+			{Name: "id", Pattern: NewIncludedEndIntRangePattern(1, 100, -1)},
+			{Name: "comment", Pattern: STR_PATTERN, IsOptional: true},
+		})
+
+		list, err := ParseCSV(ctx, strings.NewReader("id;comment\n1;\n2;ok\n"), CSVConfig{
+			Delimiter: ';',
+			Pattern:   pattern,
+		})
+		if !assert.NoError(t, err) || !assert.Equal(t, 2, list.Len()) {
+			return
+		}
+
+		first := list.At(ctx, 0).(*Object)
+		assert.Equal(t, Int(1), first.Prop(ctx, "id"))
+		assert.False(t, first.HasProp(ctx, "comment"))
+
+		second := list.At(ctx, 1).(*Object)
+		assert.Equal(t, String("ok"), second.Prop(ctx, "comment"))
+	})
+
+	t.Run("no header", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		list, err := ParseCSV(ctx, strings.NewReader("1,2\n"), CSVConfig{NoHeader: true, Columns: []string{"x", "y"}})
+		if assert.NoError(t, err) {
+			assert.Equal(t, NewWrappedValueList(
+				NewRecordFromKeyValLists([]string{"x", "y"}, []Serializable{String("1"), String("2")}),
+			), list)
+		}
+
+		list, err = ParseCSV(ctx, strings.NewReader("1,2\n"), CSVConfig{NoHeader: true})
+		if assert.NoError(t, err) {
+			assert.Equal(t, NewWrappedValueList(
+				NewRecordFromKeyValLists([]string{"0", "1"}, []Serializable{String("1"), String("2")}),
+			), list)
+		}
+	})
+
+	t.Run("invalid header", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		_, err := ParseCSV(ctx, strings.NewReader("a,a\n1,2\n"), CSVConfig{})
+		assert.ErrorIs(t, err, ErrDuplicateCSVColumnName)
+
+		_, err = ParseCSV(ctx, strings.NewReader("a,\n1,2\n"), CSVConfig{})
+		assert.ErrorIs(t, err, ErrEmptyCSVColumnName)
+	})
+
+	t.Run("unsupported pattern", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		_, err := ParseCSV(ctx, strings.NewReader("a\n1\n"), CSVConfig{Pattern: INT_PATTERN})
+		assert.ErrorIs(t, err, ErrInvalidCSVRowPattern)
+	})
+
+	t.Run("the reading should be continuable after an invalid row", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		pattern := NewInexactRecordPattern([]RecordPatternEntry{{Name: "a", Pattern: INT_PATTERN}})
+		reader, err := NewCSVReader(ctx, strings.NewReader("a,b\n1,x\nnotint,y\n3\n4,z\n"), CSVConfig{Pattern: pattern})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		v, err := reader.Next()
+		if assert.NoError(t, err) {
+			assert.Equal(t, Int(1), v.(*Record).Prop(ctx, "a"))
+		}
+
+		_, err = reader.Next()
+		if assert.IsType(t, &InvalidCSVRowError{}, err) {
+			assert.Equal(t, 1, err.(*InvalidCSVRowError).Index)
+			assert.Equal(t, 3, err.(*InvalidCSVRowError).Line)
+		}
+
+		//wrong number of cells
+		_, err = reader.Next()
+		if assert.IsType(t, &InvalidCSVRowError{}, err) {
+			assert.Equal(t, 2, err.(*InvalidCSVRowError).Index)
+		}
+
+		v, err = reader.Next()
+		if assert.NoError(t, err) {
+			assert.Equal(t, Int(4), v.(*Record).Prop(ctx, "a"))
+		}
+
+		_, err = reader.Next()
+		assert.ErrorIs(t, err, ErrEndOfStream)
+	})
+
+	t.Run("union pattern", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		pattern := NewInexactRecordPattern([]RecordPatternEntry{
+			{Name: "a", Pattern: NewUnionPattern([]Pattern{INT_PATTERN, NewExactStringPattern("none")}, nil)},
+		})
+
+		list, err := ParseCSV(ctx, strings.NewReader("a\n1\nnone\n"), CSVConfig{Pattern: pattern})
+		if assert.NoError(t, err) {
+			assert.Equal(t, Int(1), list.At(ctx, 0).(*Record).Prop(ctx, "a"))
+			assert.Equal(t, String("none"), list.At(ctx, 1).(*Record).Prop(ctx, "a"))
+		}
+
+		_, err = ParseCSV(ctx, strings.NewReader("a\nother\n"), CSVConfig{Pattern: pattern})
+		assert.Error(t, err)
+	})
+}
+
+func TestParseQuantityString(t *testing.T) {
+	testCases := []struct {
+		input  string
+		result Serializable
+	}{
+		{"10B", ByteCount(10)},
+		{"1.5kB", ByteCount(1500)},
+		{"1h30mn", Duration(90 * time.Minute)},
+		{"10ln", LineCount(10)},
+		{"5x/s", Frequency(5)},
+		{"2kB/s", ByteRate(2000)},
+	}
+
+	for _, testCase := range testCases {
+		result, err := parseQuantityString(testCase.input)
+		if assert.NoError(t, err, testCase.input) {
+			assert.Equal(t, testCase.result, result, testCase.input)
+		}
+	}
+
+	for _, input := range []string{"", "B", "10", "1..0B", "10unknown"} {
+		_, err := parseQuantityString(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+
+	t.Run("columns of the first row", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		rows := NewWrappedValueList(
+			NewRecordFromMap(ValMap{"b": String("x,y"), "a": Int(1)}),
+			NewRecordFromMap(ValMap{"a": Int(2)}),
+		)
+
+		var buf bytes.Buffer
+		if assert.NoError(t, WriteCSV(ctx, &buf, rows, CSVConfig{})) {
+			assert.Equal(t, "a,b\n1,\"x,y\"\n2,\n", buf.String())
+		}
+	})
+
+	t.Run("TSV without header", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		rows := NewWrappedValueList(NewRecordFromMap(ValMap{"a": Int(1), "b": Bool(false)}))
+
+		var buf bytes.Buffer
+		if assert.NoError(t, WriteCSV(ctx, &buf, rows, CSVConfig{Delimiter: TSV_DELIMITER, NoHeader: true})) {
+			assert.Equal(t, "1\tfalse\n", buf.String())
+		}
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		pattern := NewExactRecordPattern([]RecordPatternEntry{
+			{Name: "day", Pattern: DATE_PATTERN},
+			{Name: "size", Pattern: BYTECOUNT_PATTERN},
+			{Name: "timeout", Pattern: DURATION_PATTERN},
+			{Name: "time", Pattern: DATETIME_PATTERN},
+			{Name: "ratio", Pattern: FLOAT_PATTERN},
+			{Name: "frequency", Pattern: FREQUENCY_PATTERN},
+		})
+
+		rows := NewWrappedValueList(NewRecordFromMap(ValMap{
+			"day":       Date(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)),
+			"size":      ByteCount(1_500),
+			"timeout":   Duration(90 * time.Second),
+			"time":      DateTime(time.Date(2024, 1, 2, 10, 0, 0, 5, time.UTC)),
+			"ratio":     Float(0.25),
+			"frequency": Frequency(10),
+		}))
+
+		var buf bytes.Buffer
+		if !assert.NoError(t, WriteCSV(ctx, &buf, rows, CSVConfig{Pattern: pattern})) {
+			return
+		}
+
+		result, err := ParseCSV(ctx, &buf, CSVConfig{Pattern: pattern})
+		if assert.NoError(t, err) {
+			assert.True(t, rows.Equal(ctx, result, map[uintptr]uintptr{}, 0))
+		}
+	})
+
+	t.Run("rows not matching the pattern", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		pattern := NewExactRecordPattern([]RecordPatternEntry{{Name: "a", Pattern: INT_PATTERN}})
+		rows := NewWrappedValueList(NewRecordFromMap(ValMap{"a": String("1")}))
+
+		err := WriteCSV(ctx, &bytes.Buffer{}, rows, CSVConfig{Pattern: pattern})
+		assert.ErrorIs(t, err, ErrCSVRowNotMatchingSchema)
+	})
+
+	t.Run("rows having properties", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		rows := NewWrappedValueList(NewError(errors.New("error"), Int(1)))
+
+		buf := bytes.NewBuffer(nil)
+		if assert.NoError(t, WriteCSV(ctx, buf, rows, CSVConfig{Columns: []string{"text", "data"}})) {
+			assert.Equal(t, "text,data\nerror,1\n", buf.String())
+		}
+	})
+
+	t.Run("rows without properties", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		rows := NewWrappedValueList(Int(1))
+		err := WriteCSV(ctx, &bytes.Buffer{}, rows, CSVConfig{})
+		assert.ErrorIs(t, err, ErrCSVRowNotIProps)
+	})
+
+	t.Run("values without CSV representation", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		rows := NewWrappedValueList(NewRecordFromMap(ValMap{"a": NewTupleVariadic(Int(1))}))
+		err := WriteCSV(ctx, &bytes.Buffer{}, rows, CSVConfig{})
+		assert.ErrorIs(t, err, ErrNoCSVRepresentation)
+	})
+}
+
+func TestCSVRowStream(t *testing.T) {
+
+	t.Run("WaitNext", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		byteStream := ToReadableStream(ctx, String("a\n1\n2\n3\n"), ANYVAL_PATTERN).(*ReadableByteStream)
+		pattern := NewExactRecordPattern([]RecordPatternEntry{{Name: "a", Pattern: INT_PATTERN}})
+
+		reader, err := NewCSVReaderFromByteStream(ctx, byteStream, 0, CSVConfig{Pattern: pattern})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		filter := NewExactRecordPattern([]RecordPatternEntry{{Name: "a", Pattern: NewIncludedEndIntRangePattern(2, 3, -1)}})
+		stream := NewCSVRowStream(reader, filter)
+
+		v, err := stream.WaitNext(ctx, nil, time.Second)
+		if assert.NoError(t, err) {
+			assert.Equal(t, Int(2), v.(*Record).Prop(ctx, "a"))
+		}
+
+		v, err = stream.WaitNext(ctx, nil, time.Second)
+		if assert.NoError(t, err) {
+			assert.Equal(t, Int(3), v.(*Record).Prop(ctx, "a"))
+		}
+
+		_, err = stream.WaitNext(ctx, nil, time.Second)
+		assert.ErrorIs(t, err, ErrEndOfStream)
+		assert.True(t, stream.IsStopped())
+	})
+
+	t.Run("stopped byte stream", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		byteStream := ToReadableStream(ctx, String("a\n1\n"), ANYVAL_PATTERN).(*ReadableByteStream)
+		byteStream.Stop()
+
+		reader, err := NewCSVReaderFromByteStream(ctx, byteStream, 0, CSVConfig{})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		_, err = reader.Next()
+		assert.ErrorIs(t, err, ErrEndOfStream)
+	})
+
+	t.Run("WaitNextChunk", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		reader, err := NewCSVReader(ctx, strings.NewReader("a\n1\n2\n3\n"), CSVConfig{})
+		if !assert.NoError(t, err) {
+			return
+		}
+		stream := NewCSVRowStream(reader, nil)
+
+		chunk, err := stream.WaitNextChunk(ctx, nil, NewIntRange(2, 2), time.Second)
+		if assert.NoError(t, err) {
+			data, _ := chunk.Data(ctx)
+			assert.Equal(t, 2, data.(*List).Len())
+		}
+
+		chunk, err = stream.WaitNextChunk(ctx, nil, NewIntRange(2, 2), time.Second)
+		assert.ErrorIs(t, err, ErrEndOfStream)
+		if assert.NotNil(t, chunk) {
+			data, _ := chunk.Data(ctx)
+			assert.Equal(t, 1, data.(*List).Len())
+		}
+	})
+
+	t.Run("WaitNextChunk should read at most the maximum number of rows", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		reader, err := NewCSVReader(ctx, strings.NewReader("a\n1\n2\n3\n"), CSVConfig{})
+		if !assert.NoError(t, err) {
+			return
+		}
+		stream := NewCSVRowStream(reader, nil)
+
+		chunk, err := stream.WaitNextChunk(ctx, nil, NewIntRange(0, 2), time.Second)
+		if assert.NoError(t, err) {
+			data, _ := chunk.Data(ctx)
+			assert.Equal(t, 2, data.(*List).Len())
+		}
+	})
+
+	t.Run("WaitNextChunk should return the rows read before an invalid row", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		pattern := NewExactRecordPattern([]RecordPatternEntry{{Name: "a", Pattern: INT_PATTERN}})
+
+		reader, err := NewCSVReader(ctx, strings.NewReader("a\n1\nx\n3\n"), CSVConfig{Pattern: pattern})
+		if !assert.NoError(t, err) {
+			return
+		}
+		stream := NewCSVRowStream(reader, nil)
+
+		chunk, err := stream.WaitNextChunk(ctx, nil, NewIntRange(3, 3), time.Second)
+		assert.IsType(t, &InvalidCSVRowError{}, err)
+		if assert.NotNil(t, chunk) {
+			data, _ := chunk.Data(ctx)
+			assert.Equal(t, 1, data.(*List).Len())
+		}
+
+		//the reading should continue after the invalid row.
+		chunk, err = stream.WaitNextChunk(ctx, nil, NewIntRange(1, 1), time.Second)
+		if assert.NoError(t, err) {
+			data, _ := chunk.Data(ctx)
+			assert.Equal(t, Int(3), data.(*List).At(ctx, 0).(*Record).Prop(ctx, "a"))
+		}
+	})
+}
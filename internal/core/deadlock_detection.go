@@ -0,0 +1,192 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/inoxlang/inox/internal/memds"
+)
+
+const (
+	UNKNOWN_LOCK_ACQUISITION_LOCATION = "<unknown location>"
+)
+
+var (
+	ErrDeadlock = errors.New("deadlock")
+
+	deadlockDetectionEnabled atomic.Bool
+	lockWaitForGraph         = newWaitForGraph()
+)
+
+// EnableDeadlockDetection enables the detection of deadlocks between modules waiting for smart locks (SmartLock).
+// The detection has a cost, it is intended to be enabled in tests and during development.
+func EnableDeadlockDetection() {
+	deadlockDetectionEnabled.Store(true)
+}
+
+func DisableDeadlockDetection() {
+	deadlockDetectionEnabled.Store(false)
+}
+
+func IsDeadlockDetectionEnabled() bool {
+	return deadlockDetectionEnabled.Load()
+}
+
+// A DeadlockError is returned (panic) by SmartLock.Lock when the module waiting for the lock would close a cycle in
+// the wait-for graph.
+type DeadlockError struct {
+	Waits []LockWait //waits in the cycle, the first one is the wait of the failed module.
+}
+
+// A LockWait is a module (Waiter) waiting for a lock held by another module (Holder).
+type LockWait struct {
+	Waiter, Holder *GlobalState
+	Lock           *SmartLock
+
+	//location where the holder acquired the lock, UNKNOWN_LOCK_ACQUISITION_LOCATION if it is not known.
+	HolderLocation string
+}
+
+func (err DeadlockError) Error() string {
+	buf := &strings.Builder{}
+	buf.WriteString("deadlock detected:")
+
+	for i, wait := range err.Waits {
+		if i != 0 {
+			buf.WriteByte(';')
+		}
+		fmt.Fprintf(buf, " %s waits for a lock acquired by %s at %s", describeLockingState(wait.Waiter), describeLockingState(wait.Holder), wait.HolderLocation)
+	}
+	return buf.String()
+}
+
+func (err DeadlockError) Unwrap() error {
+	return ErrDeadlock
+}
+
+func describeLockingState(state *GlobalState) string {
+	if state.Module != nil && state.Module.Name() != "" {
+		return fmt.Sprintf("module %s (state %d)", state.Module.Name(), state.id)
+	}
+	return fmt.Sprintf("state %d", state.id)
+}
+
+// waitForGraph is a graph whose nodes are modules (states) and whose edges are lock waits: an edge goes from the waiting
+// module to the module holding the lock. A module waits for at most one lock at a time so each node has at most
+// one outgoing edge.
+type waitForGraph struct {
+	lock  sync.Mutex
+	graph *memds.DirectedGraph[*GlobalState, LockWait, struct{}]
+	nodes map[*GlobalState]memds.NodeId
+	waits map[*GlobalState]LockWait
+}
+
+func newWaitForGraph() *waitForGraph {
+	return &waitForGraph{
+		graph: memds.NewDirectedGraph[*GlobalState, LockWait](memds.ThreadUnsafe),
+		nodes: map[*GlobalState]memds.NodeId{},
+		waits: map[*GlobalState]LockWait{},
+	}
+}
+
+// addWait records that wait.Waiter waits for a lock held by wait.Holder, the previous wait of wait.Waiter is removed.
+// If the new wait closes a cycle it is not recorded and a DeadlockError is returned.
+func (g *waitForGraph) addWait(wait LockWait) error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	g.removeWaitNoLock(wait.Waiter)
+
+	waiterId := g.getOrAddNodeNoLock(wait.Waiter)
+	holderId := g.getOrAddNodeNoLock(wait.Holder)
+
+	g.graph.SetEdge(waiterId, holderId, wait)
+	g.waits[wait.Waiter] = wait
+
+	if !g.graph.HasCycle() {
+		return nil
+	}
+
+	//Since cycles are detected as soon as they are formed the cycle goes through the new edge.
+	//We follow the waits from the holder in order to list them.
+
+	cycle := []LockWait{wait}
+	for current := wait.Holder; current != wait.Waiter; {
+		next, ok := g.waits[current]
+		if !ok {
+			break
+		}
+		cycle = append(cycle, next)
+		current = next.Holder
+	}
+
+	g.removeWaitNoLock(wait.Waiter)
+	return DeadlockError{Waits: cycle}
+}
+
+func (g *waitForGraph) hasWait(waiter *GlobalState, lock *SmartLock, holder *GlobalState) bool {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	wait, ok := g.waits[waiter]
+	return ok && wait.Lock == lock && wait.Holder == holder
+}
+
+// removeWait removes the wait of waiter, it is a no-op if waiter is not waiting.
+func (g *waitForGraph) removeWait(waiter *GlobalState) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	g.removeWaitNoLock(waiter)
+}
+
+// removeWaitsFor removes the waits for lock held by holder, it should be called when holder releases lock.
+func (g *waitForGraph) removeWaitsFor(lock *SmartLock, holder *GlobalState) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	for waiter, wait := range g.waits {
+		if wait.Lock == lock && wait.Holder == holder {
+			g.removeWaitNoLock(waiter)
+		}
+	}
+}
+
+func (g *waitForGraph) removeWaitNoLock(waiter *GlobalState) {
+	wait, ok := g.waits[waiter]
+	if !ok {
+		return
+	}
+	delete(g.waits, waiter)
+
+	waiterId := g.nodes[waiter]
+	holderId := g.nodes[wait.Holder]
+	g.graph.RemoveEdge(waiterId, holderId)
+
+	g.removeNodeIfIsolatedNoLock(waiter)
+	g.removeNodeIfIsolatedNoLock(wait.Holder)
+}
+
+func (g *waitForGraph) getOrAddNodeNoLock(state *GlobalState) memds.NodeId {
+	id, ok := g.nodes[state]
+	if !ok {
+		id = g.graph.AddNode(state)
+		g.nodes[state] = id
+	}
+	return id
+}
+
+func (g *waitForGraph) removeNodeIfIsolatedNoLock(state *GlobalState) {
+	id, ok := g.nodes[state]
+	if !ok {
+		return
+	}
+
+	if g.graph.CountSourceNodes(id) == 0 && len(g.graph.DestinationIds(id)) == 0 {
+		g.graph.RemoveNode(id)
+		delete(g.nodes, state)
+	}
+}
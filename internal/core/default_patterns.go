@@ -975,6 +975,7 @@ var (
 		BYTE_PATTERN.Name:           BYTE_PATTERN,
 		STRING_PATTERN.Name:         STRING_PATTERN,
 		STR_PATTERN.Name:            STR_PATTERN,
+		STR_PATTERN_PATTERN.Name:    STR_PATTERN_PATTERN,
 		PATH_PATTERN.Name:           PATH_PATTERN,
 		URL_PATTERN.Name:            URL_PATTERN,
 		SCHEME_PATTERN.Name:         SCHEME_PATTERN,
@@ -1066,6 +1067,7 @@ var (
 				"rfc822":    NewDateFormat(time.RFC822, "rfc822"),
 				"date-only": NewDateFormat(time.DateOnly, "date-only"),
 				"time-only": NewDateFormat(time.TimeOnly, "time-only"),
+				"rfc3339":   NewDateFormat(time.RFC3339, "rfc3339"),
 			},
 		},
 	}
@@ -0,0 +1,133 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var (
+	ErrCannotCommitDryRunTransaction = errors.New("a dry-run transaction cannot be committed")
+)
+
+// StartNewDryRunTransaction creates a dry-run transaction and starts it immediately. A dry-run transaction records
+// the effects performed through PerformEffect (including irreversible ones) but never applies them: it cannot be
+// committed and it has no timeout. The effects are listed by calling DryRunPlan. Operations that do not go through
+// PerformEffect are not recorded and still have their side effects, at the moment only the requests with an unsafe
+// method made by OpenAPI clients (see HttpRequestEffect) are performed as effects.
+func StartNewDryRunTransaction(ctx *Context) *Transaction {
+	tx := newTransaction(ctx, false)
+	tx.isDryRun = true
+	tx.journal = nil
+	tx.Start(ctx)
+	return tx
+}
+
+func (tx *Transaction) IsDryRun() bool {
+	return tx.isDryRun
+}
+
+// IsDryRun returns true if the current transaction of the context is a dry-run transaction.
+func (ctx *Context) IsDryRun() bool {
+	tx := ctx.GetTx()
+	return tx != nil && tx.isDryRun
+}
+
+// PerformEffect should be called by effect-producing operations after the permission checks: the effect is added to the
+// current transaction if there is one, otherwise it is applied immediately. Irreversible effects cannot be added to
+// regular transactions, so they are applied immediately unless the transaction is a dry-run transaction.
+func PerformEffect(ctx *Context, effect Effect) error {
+	tx := ctx.GetTx()
+	if tx != nil && (tx.isDryRun || effect.Reversability(ctx) != Irreversible) {
+		return tx.AddEffect(ctx, effect)
+	}
+	return effect.Apply(ctx)
+}
+
+type DryRunPlanFormat int
+
+const (
+	TextDryRunPlanFormat DryRunPlanFormat = iota
+	JSONDryRunPlanFormat
+)
+
+// A DryRunPlan lists the effects recorded by a dry-run transaction (see PerformEffect), the plan can be
+// marshaled to JSON.
+type DryRunPlan struct {
+	Effects []DryRunPlanEntry `json:"effects"`
+}
+
+type DryRunPlanEntry struct {
+	Type           string   `json:"type"`
+	Resources      []string `json:"resources"`
+	PermissionKind string   `json:"permissionKind"`
+	Reversibility  string   `json:"reversibility"`
+}
+
+// DryRunPlan returns the plan of the effects recorded by the transaction, the effects are listed in the order they were added.
+func (tx *Transaction) DryRunPlan(ctx *Context) *DryRunPlan {
+	tx.lock.RLock()
+	defer tx.lock.RUnlock()
+
+	plan := &DryRunPlan{Effects: []DryRunPlanEntry{}}
+
+	for _, effect := range tx.effects {
+		entry := DryRunPlanEntry{
+			Type:           fmt.Sprintf("%T", effect),
+			Resources:      []string{},
+			PermissionKind: effect.PermissionKind().String(),
+			Reversibility:  effect.Reversability(ctx).String(),
+		}
+		for _, resource := range effect.Resources() {
+			entry.Resources = append(entry.Resources, resource.ResourceName())
+		}
+		plan.Effects = append(plan.Effects, entry)
+	}
+
+	return plan
+}
+
+func (p *DryRunPlan) IrreversibleEffectCount() int {
+	count := 0
+	for _, entry := range p.Effects {
+		if entry.Reversibility == Irreversible.String() {
+			count++
+		}
+	}
+	return count
+}
+
+// String returns a human readable representation of the plan.
+func (p *DryRunPlan) String() string {
+	if len(p.Effects) == 0 {
+		return "no effects\n"
+	}
+
+	buf := &strings.Builder{}
+
+	for _, entry := range p.Effects {
+		resources := "<no resource>"
+		if len(entry.Resources) > 0 {
+			resources = strings.Join(entry.Resources, ", ")
+		}
+		fmt.Fprintf(buf, "  %s %s (%s)\n", entry.PermissionKind, resources, entry.Reversibility)
+	}
+
+	fmt.Fprintf(buf, "Plan: %d effect(s), %d irreversible\n", len(p.Effects), p.IrreversibleEffectCount())
+	return buf.String()
+}
+
+// Write writes the plan in the given format, the JSON format is written on a single line.
+func (p *DryRunPlan) Write(w io.Writer, format DryRunPlanFormat) error {
+	switch format {
+	case TextDryRunPlanFormat:
+		_, err := io.WriteString(w, p.String())
+		return err
+	case JSONDryRunPlanFormat:
+		return json.NewEncoder(w).Encode(p)
+	default:
+		return fmt.Errorf("unknown dry-run plan format %d", format)
+	}
+}
@@ -0,0 +1,120 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDryRun(t *testing.T) {
+
+	t.Run("effects should be recorded but not applied", func(t *testing.T) {
+		ctx := NewContext(ContextConfig{})
+		defer ctx.CancelGracefully()
+
+		tx := StartNewDryRunTransaction(ctx)
+		assert.True(t, ctx.IsDryRun())
+
+		reversible := &testSetEntryEffect{Key: "dry-run-1", Value: "1", Reversability_: Reversible}
+		irreversible := &testSetEntryEffect{Key: "dry-run-2", Value: "2", Reversability_: Irreversible}
+
+		assert.NoError(t, PerformEffect(ctx, reversible))
+		assert.NoError(t, PerformEffect(ctx, irreversible))
+
+		assert.ErrorIs(t, tx.Commit(ctx), ErrCannotCommitDryRunTransaction)
+		assert.False(t, reversible.IsApplied())
+		assert.False(t, irreversible.IsApplied())
+
+		plan := tx.DryRunPlan(ctx)
+		assert.Equal(t, []DryRunPlanEntry{
+			{
+				Type:           "*core.testSetEntryEffect",
+				Resources:      []string{"/dry-run-1"},
+				PermissionKind: "update",
+				Reversibility:  "reversible",
+			},
+			{
+				Type:           "*core.testSetEntryEffect",
+				Resources:      []string{"/dry-run-2"},
+				PermissionKind: "update",
+				Reversibility:  "irreversible",
+			},
+		}, plan.Effects)
+
+		assert.Equal(t,
+			"  update /dry-run-1 (reversible)\n  update /dry-run-2 (irreversible)\nPlan: 2 effect(s), 1 irreversible\n",
+			plan.String(),
+		)
+
+		jsonPlan, err := json.Marshal(plan)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Contains(t, string(jsonPlan), `{"type":"*core.testSetEntryEffect","resources":["/dry-run-2"],"permissionKind":"update","reversibility":"irreversible"}`)
+
+		assert.NoError(t, tx.Rollback(ctx))
+		assert.False(t, ctx.IsDryRun())
+	})
+
+	t.Run("effects recorded by a nested transaction should be part of the plan", func(t *testing.T) {
+		ctx := NewContext(ContextConfig{})
+		defer ctx.CancelGracefully()
+
+		tx := StartNewDryRunTransaction(ctx)
+		defer tx.Rollback(ctx)
+
+		child, _ := tx.StartNested(ctx)
+		assert.True(t, child.IsDryRun())
+
+		effect := &testSetEntryEffect{Key: "dry-run-nested", Reversability_: Irreversible}
+		assert.NoError(t, PerformEffect(ctx, effect))
+		assert.NoError(t, child.Commit(ctx))
+
+		assert.Len(t, tx.DryRunPlan(ctx).Effects, 1)
+		assert.False(t, effect.IsApplied())
+	})
+
+	t.Run("irreversible effects should be applied immediately in regular transactions", func(t *testing.T) {
+		ctx := NewContext(ContextConfig{})
+		defer ctx.CancelGracefully()
+
+		tx := StartNewTransaction(ctx)
+		defer tx.Rollback(ctx)
+
+		effect := &testSetEntryEffect{Key: "dry-run-regular-tx", Reversability_: Irreversible}
+		assert.NoError(t, PerformEffect(ctx, effect))
+		assert.True(t, effect.IsApplied())
+
+		effects, _ := tx.CurrentEffects()
+		assert.Empty(t, effects)
+	})
+
+	t.Run("plan formats", func(t *testing.T) {
+		plan := &DryRunPlan{Effects: []DryRunPlanEntry{
+			{Type: "*core.HttpRequestEffect", Resources: []string{"https://example.com/users"}, PermissionKind: "create", Reversibility: "irreversible"},
+		}}
+
+		buf := &bytes.Buffer{}
+		if assert.NoError(t, plan.Write(buf, TextDryRunPlanFormat)) {
+			assert.Equal(t, "  create https://example.com/users (irreversible)\nPlan: 1 effect(s), 1 irreversible\n", buf.String())
+		}
+
+		buf.Reset()
+		if assert.NoError(t, plan.Write(buf, JSONDryRunPlanFormat)) {
+			assert.Equal(t, `{"effects":[{"type":"*core.HttpRequestEffect","resources":["https://example.com/users"],`+
+				`"permissionKind":"create","reversibility":"irreversible"}]}`+"\n", buf.String())
+		}
+	})
+
+	t.Run("without transaction effects should be applied immediately", func(t *testing.T) {
+		ctx := NewContext(ContextConfig{})
+		defer ctx.CancelGracefully()
+
+		effect := &testSetEntryEffect{Key: "dry-run-no-tx", Reversability_: Irreversible}
+		assert.NoError(t, PerformEffect(ctx, effect))
+		assert.True(t, effect.IsApplied())
+		assert.Equal(t, "no effects\n", (&DryRunPlan{}).String())
+	})
+}
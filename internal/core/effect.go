@@ -24,3 +24,15 @@ const (
 	SomewhatReversible
 	Reversible
 )
+
+func (r Reversability) String() string {
+	switch r {
+	case Irreversible:
+		return "irreversible"
+	case SomewhatReversible:
+		return "somewhat-reversible"
+	case Reversible:
+		return "reversible"
+	}
+	return "unknown"
+}
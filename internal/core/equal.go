@@ -909,7 +909,7 @@ func (pattern *ExactValuePattern) Equal(ctx *Context, other Value, alreadyCompar
 }
 
 func (pattern *ExactStringPattern) Equal(ctx *Context, other Value, alreadyCompared map[uintptr]uintptr, depth int) bool {
-	otherPattern, ok := other.(*ExactValuePattern)
+	otherPattern, ok := other.(*ExactStringPattern)
 	if !ok {
 		return false
 	}
@@ -1480,6 +1480,24 @@ func (g *LThreadGroup) Equal(ctx *Context, other Value, alreadyCompared map[uint
 	return g == otherGroup
 }
 
+func (c *Capability) Equal(ctx *Context, other Value, alreadyCompared map[uintptr]uintptr, depth int) bool {
+	otherCapability, ok := other.(*Capability)
+	if !ok {
+		return false
+	}
+
+	return c == otherCapability
+}
+
+func (p *RetryPolicy) Equal(ctx *Context, other Value, alreadyCompared map[uintptr]uintptr, depth int) bool {
+	otherPolicy, ok := other.(*RetryPolicy)
+	if !ok {
+		return false
+	}
+
+	return *p == *otherPolicy
+}
+
 func (fn *InoxFunction) Equal(ctx *Context, other Value, alreadyCompared map[uintptr]uintptr, depth int) bool {
 	otherFn, ok := other.(*InoxFunction)
 	if !ok {
@@ -1977,11 +1995,46 @@ func (s *ConfluenceStream) Equal(ctx *Context, other Value, alreadyCompared map[
 	return ok && s == otherStream
 }
 
+func (s *CSVRowStream) Equal(ctx *Context, other Value, alreadyCompared map[uintptr]uintptr, depth int) bool {
+	otherStream, ok := other.(*CSVRowStream)
+	return ok && s == otherStream
+}
+
 func (r *RingBuffer) Equal(ctx *Context, other Value, alreadyCompared map[uintptr]uintptr, depth int) bool {
 	otherBuf, ok := other.(*RingBuffer)
 	return ok && r == otherBuf
 }
 
+func (c *Channel) Equal(ctx *Context, other Value, alreadyCompared map[uintptr]uintptr, depth int) bool {
+	otherChannel, ok := other.(*Channel)
+	return ok && c == otherChannel
+}
+
+func (s *channelStream) Equal(ctx *Context, other Value, alreadyCompared map[uintptr]uintptr, depth int) bool {
+	otherStream, ok := other.(*channelStream)
+	return ok && s == otherStream
+}
+
+func (s *Supervisor) Equal(ctx *Context, other Value, alreadyCompared map[uintptr]uintptr, depth int) bool {
+	otherSupervisor, ok := other.(*Supervisor)
+	return ok && s == otherSupervisor
+}
+
+func (r *CrashReport) Equal(ctx *Context, other Value, alreadyCompared map[uintptr]uintptr, depth int) bool {
+	otherReport, ok := other.(*CrashReport)
+	return ok && r == otherReport
+}
+
+func (s *Scheduler) Equal(ctx *Context, other Value, alreadyCompared map[uintptr]uintptr, depth int) bool {
+	otherScheduler, ok := other.(*Scheduler)
+	return ok && s == otherScheduler
+}
+
+func (j *ScheduledJob) Equal(ctx *Context, other Value, alreadyCompared map[uintptr]uintptr, depth int) bool {
+	otherJob, ok := other.(*ScheduledJob)
+	return ok && j == otherJob
+}
+
 func (c *DataChunk) Equal(ctx *Context, other Value, alreadyCompared map[uintptr]uintptr, depth int) bool {
 	otherChunk, ok := other.(*DataChunk)
 	return ok && c == otherChunk
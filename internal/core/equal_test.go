@@ -297,6 +297,19 @@ func TestEqualityCompareUUIDs(t *testing.T) {
 	assertNotEqualInoxValues(t, secondUUID, firstUUID, nil)
 }
 
+func TestEqualityCompareExactStringPatterns(t *testing.T) {
+	ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+	defer ctx.CancelGracefully()
+
+	a := NewExactStringPattern("a")
+
+	assertEqualInoxValues(t, a, a, ctx)
+	assertEqualInoxValues(t, a, NewExactStringPattern("a"), ctx)
+	assertNotEqualInoxValues(t, a, NewExactStringPattern("b"), ctx)
+	assertNotEqualInoxValues(t, a, NewExactValuePattern(String("a")), ctx)
+	assertNotEqualInoxValues(t, NewExactValuePattern(String("a")), a, ctx)
+}
+
 func TestEqualityCompareObjectPatterns(t *testing.T) {
 	ctx := NewContext(ContextConfig{})
 	NewGlobalState(ctx)
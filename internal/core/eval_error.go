@@ -66,7 +66,8 @@ var (
 	ErrInvalidNonDirPath = errors.New("invalid non-dir path")
 	ErrURLAlreadySet     = errors.New("url already set")
 
-	ErrLThreadIsDone = errors.New("lthread is done")
+	ErrLThreadIsDone         = errors.New("lthread is done")
+	ErrLThreadNotRespawnable = errors.New("lthread cannot be respawned: it has not been created by SpawnLThread")
 
 	ErrSelfNotDefined = errors.New("self not defined")
 
@@ -7059,6 +7059,165 @@ func testEval(t *testing.T, bytecodeEval bool, Eval evalFn) {
 			assert.NotContains(t, logBuf.String(), `"src":"`+importedModPath+`","msg":"debug"`)
 		})
 
+		t.Run("capabilities delegated by the capabilities section should be usable by the imported module", func(t *testing.T) {
+			testconfig.AllowParallelization(t)
+
+			dir := t.TempDir()
+			mainModPath := filepath.Join(dir, "mod.ix")
+			importedModPath := filepath.Join(dir, "imported_mod.ix")
+
+			err := os.WriteFile(mainModPath, []byte(`
+				manifest {}
+				import res `+importedModPath+` {
+					capabilities: cap
+				}
+				return res
+			`), 0600)
+
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			err = os.WriteFile(importedModPath, []byte(`
+				manifest {}
+				return can_read_secrets()
+			`), 0600)
+
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			parsingCtx := core.NewContextWithEmptyState(core.ContextConfig{
+				Permissions: []core.Permission{
+					core.CreateFsReadPerm(core.PathPattern("/...")),
+				},
+			}, nil)
+			defer parsingCtx.CancelGracefully()
+
+			mod, err := core.ParseLocalModule(mainModPath, core.ModuleParsingConfig{
+				Context: parsingCtx,
+			})
+
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			readSecretsPerm := core.FilesystemPermission{permbase.Read, core.PathPattern("/secrets/...")}
+
+			ctx := core.NewContext(core.ContextConfig{
+				Permissions: append(
+					core.GetDefaultGlobalVarPermissions(),
+					core.FilesystemPermission{permbase.Read, core.PathPattern("/...")},
+					core.LThreadPermission{permbase.Create},
+				),
+				Limits: []core.Limit{permissiveLthreadLimit},
+			})
+			defer ctx.CancelGracefully()
+
+			capability, err := core.NewCapability(ctx, []core.Permission{readSecretsPerm}, core.CapabilityConfig{})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			state := core.NewGlobalState(ctx)
+			state.Globals.Set("cap", capability)
+			state.Globals.Set("can_read_secrets", core.WrapGoFunction(func(ctx *core.Context) core.Bool {
+				return core.Bool(ctx.HasPermission(readSecretsPerm))
+			}))
+
+			state.Module = mod
+			state.GetBaseGlobalsForImportedModule = func(ctx *core.Context, manifest *core.Manifest) (core.GlobalVariables, error) {
+				return state.Globals, nil
+			}
+			state.GetBasePatternsForImportedModule = func() (map[string]core.Pattern, map[string]*core.PatternNamespace) {
+				return nil, nil
+			}
+
+			res, err := Eval(mod, state, false)
+			assert.NoError(t, err)
+			assert.Equal(t, core.True, res)
+		})
+
+		t.Run("a capability not held by the importing module should not be delegated", func(t *testing.T) {
+			testconfig.AllowParallelization(t)
+
+			dir := t.TempDir()
+			mainModPath := filepath.Join(dir, "mod.ix")
+			importedModPath := filepath.Join(dir, "imported_mod.ix")
+
+			err := os.WriteFile(mainModPath, []byte(`
+				manifest {}
+				import res `+importedModPath+` {
+					capabilities: cap
+				}
+				return res
+			`), 0600)
+
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			err = os.WriteFile(importedModPath, []byte(`
+				manifest {}
+				return 1
+			`), 0600)
+
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			parsingCtx := core.NewContextWithEmptyState(core.ContextConfig{
+				Permissions: []core.Permission{
+					core.CreateFsReadPerm(core.PathPattern("/...")),
+				},
+			}, nil)
+			defer parsingCtx.CancelGracefully()
+
+			mod, err := core.ParseLocalModule(mainModPath, core.ModuleParsingConfig{
+				Context: parsingCtx,
+			})
+
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			perms := append(
+				core.GetDefaultGlobalVarPermissions(),
+				core.FilesystemPermission{permbase.Read, core.PathPattern("/...")},
+				core.LThreadPermission{permbase.Create},
+			)
+
+			otherCtx := core.NewContext(core.ContextConfig{Permissions: perms})
+			defer otherCtx.CancelGracefully()
+
+			//The capability is issued by an unrelated context.
+			capability, err := core.NewCapability(otherCtx, []core.Permission{
+				core.FilesystemPermission{permbase.Read, core.PathPattern("/secrets/...")},
+			}, core.CapabilityConfig{})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			ctx := core.NewContext(core.ContextConfig{
+				Permissions: perms,
+				Limits:      []core.Limit{permissiveLthreadLimit},
+			})
+			defer ctx.CancelGracefully()
+
+			state := core.NewGlobalState(ctx)
+			state.Globals.Set("cap", capability)
+
+			state.Module = mod
+			state.GetBaseGlobalsForImportedModule = func(ctx *core.Context, manifest *core.Manifest) (core.GlobalVariables, error) {
+				return core.GlobalVariablesFromMap(map[string]core.Value{}, nil), nil
+			}
+			state.GetBasePatternsForImportedModule = func() (map[string]core.Pattern, map[string]*core.PatternNamespace) {
+				return nil, nil
+			}
+
+			_, err = Eval(mod, state, false)
+			assert.ErrorContains(t, err, core.ErrCapabilityNotHeld.Error())
+		})
 	})
 
 	t.Run("spawn expression", func(t *testing.T) {
@@ -0,0 +1,175 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync/atomic"
+
+	"github.com/inoxlang/inox/internal/core/permbase"
+	"github.com/inoxlang/inox/internal/utils/fsutils"
+)
+
+const (
+	WRITE_FILE_EFFECT_JOURNAL_KIND = "write-file"
+	WRITE_FILE_EFFECT_FILE_MODE    = 0600
+)
+
+var (
+	ErrWriteFileEffectRequiresAbsolutePath = errors.New("the path of a file written by an effect should be absolute")
+)
+
+func init() {
+	RegisterJournaledEffectKind(WRITE_FILE_EFFECT_JOURNAL_KIND, func(ctx *Context, data []byte, applied bool) (JournaledEffect, error) {
+		var record writeFileEffectRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			return nil, err
+		}
+
+		effect := &WriteFileEffect{
+			path:            record.Path,
+			content:         record.Content,
+			existed:         record.Existed,
+			previousContent: record.PreviousContent,
+		}
+		effect.applied.Store(applied)
+		return effect, nil
+	})
+}
+
+// A WriteFileEffect writes the content of a file in the OS filesystem. The previous content of the file is read when
+// the effect is created so the effect is Reversible: reversing it restores the previous content, or removes the file
+// if it did not exist. The effect is journaled (see TransactionJournal) with the previous content.
+type WriteFileEffect struct {
+	path            Path
+	content         []byte
+	existed         bool
+	previousContent []byte
+
+	applying atomic.Bool
+	applied  atomic.Bool
+}
+
+type writeFileEffectRecord struct {
+	Path            Path   `json:"path"`
+	Content         []byte `json:"content"`
+	Existed         bool   `json:"existed"`
+	PreviousContent []byte `json:"previousContent,omitempty"`
+}
+
+// NewWriteFileEffect creates an effect writing content to the file at path, the current content of the
+// file is read in order to make the effect reversible.
+func NewWriteFileEffect(ctx *Context, path Path, content []byte) (*WriteFileEffect, error) {
+	if !path.IsAbsolute() {
+		return nil, ErrWriteFileEffectRequiresAbsolutePath
+	}
+
+	effect := &WriteFileEffect{path: path, content: content}
+
+	err := ctx.DoIO(func() error {
+		previousContent, err := os.ReadFile(string(path))
+		switch {
+		case err == nil:
+			effect.existed = true
+			effect.previousContent = previousContent
+			return nil
+		case errors.Is(err, fs.ErrNotExist):
+			return nil
+		default:
+			return err
+		}
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the current content of %s: %w", path, err)
+	}
+	return effect, nil
+}
+
+func (e *WriteFileEffect) Resources() []ResourceName {
+	return []ResourceName{e.path}
+}
+
+func (e *WriteFileEffect) PermissionKind() PermissionKind {
+	if e.existed {
+		return permbase.Update
+	}
+	return permbase.Create
+}
+
+func (e *WriteFileEffect) Reversability(*Context) Reversability {
+	return Reversible
+}
+
+func (e *WriteFileEffect) IsApplied() bool {
+	return e.applied.Load()
+}
+
+func (e *WriteFileEffect) IsApplying() bool {
+	return e.applying.Load()
+}
+
+func (e *WriteFileEffect) Apply(ctx *Context) error {
+	if e.applied.Load() {
+		return ErrEffectAlreadyApplied
+	}
+
+	if err := ctx.CheckHasPermission(FilesystemPermission{Kind_: e.PermissionKind(), Entity: e.path}); err != nil {
+		return err
+	}
+
+	e.applying.Store(true)
+	defer e.applying.Store(false)
+
+	err := ctx.DoIO(func() error {
+		return fsutils.WriteFileSync(string(e.path), e.content, WRITE_FILE_EFFECT_FILE_MODE)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	e.applied.Store(true)
+	return nil
+}
+
+// Reverse restores the previous content of the file, or removes the file if it did not exist. Nothing is done if the
+// effect is not applied, so the file written by someone else after the creation of the effect is left untouched.
+func (e *WriteFileEffect) Reverse(ctx *Context) error {
+	if !e.applied.Load() {
+		return nil
+	}
+
+	err := ctx.DoIO(func() error {
+		if e.existed {
+			return fsutils.WriteFileSync(string(e.path), e.previousContent, WRITE_FILE_EFFECT_FILE_MODE)
+		}
+		err := os.Remove(string(e.path))
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	})
+
+	if err != nil {
+		return err
+	}
+
+	e.applied.Store(false)
+	return nil
+}
+
+func (e *WriteFileEffect) JournalKind() string {
+	return WRITE_FILE_EFFECT_JOURNAL_KIND
+}
+
+func (e *WriteFileEffect) MarshalJournalRecord() ([]byte, error) {
+	return json.Marshal(writeFileEffectRecord{
+		Path:            e.path,
+		Content:         e.content,
+		Existed:         e.existed,
+		PreviousContent: e.previousContent,
+	})
+}
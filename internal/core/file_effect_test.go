@@ -0,0 +1,220 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/inoxlang/inox/internal/core/permbase"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteFileEffect(t *testing.T) {
+
+	perms := []Permission{FilesystemPermission{Kind_: permbase.Write, Entity: PathPattern("/...")}}
+
+	readFile := func(path string) string {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "<" + err.Error() + ">"
+		}
+		return string(content)
+	}
+
+	t.Run("file should be written when the transaction is committed", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{Permissions: perms}, nil)
+		defer ctx.CancelGracefully()
+
+		path := filepath.Join(t.TempDir(), "file.txt")
+
+		effect, err := NewWriteFileEffect(ctx, Path(path), []byte("content"))
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		tx := StartNewTransaction(ctx)
+		if !assert.NoError(t, tx.AddEffect(ctx, effect)) {
+			return
+		}
+
+		_, err = os.Stat(path)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+
+		if !assert.NoError(t, tx.Commit(ctx)) {
+			return
+		}
+		assert.Equal(t, "content", readFile(path))
+	})
+
+	t.Run("missing permission", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		path := filepath.Join(t.TempDir(), "file.txt")
+
+		effect, err := NewWriteFileEffect(ctx, Path(path), []byte("content"))
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.IsType(t, &NotAllowedError{}, effect.Apply(ctx))
+	})
+
+	t.Run("relative path", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{Permissions: perms}, nil)
+		defer ctx.CancelGracefully()
+
+		_, err := NewWriteFileEffect(ctx, Path("./file.txt"), nil)
+		assert.ErrorIs(t, err, ErrWriteFileEffectRequiresAbsolutePath)
+	})
+
+	t.Run("reversal should restore the previous content", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{Permissions: perms}, nil)
+		defer ctx.CancelGracefully()
+
+		path := filepath.Join(t.TempDir(), "file.txt")
+		os.WriteFile(path, []byte("previous"), 0600)
+
+		effect, err := NewWriteFileEffect(ctx, Path(path), []byte("content"))
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, permbase.Update, effect.PermissionKind())
+
+		if !assert.NoError(t, effect.Apply(ctx)) {
+			return
+		}
+		assert.Equal(t, "content", readFile(path))
+
+		if !assert.NoError(t, effect.Reverse(ctx)) {
+			return
+		}
+		assert.Equal(t, "previous", readFile(path))
+	})
+
+	t.Run("reversal should remove a created file", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{Permissions: perms}, nil)
+		defer ctx.CancelGracefully()
+
+		path := filepath.Join(t.TempDir(), "file.txt")
+
+		effect, err := NewWriteFileEffect(ctx, Path(path), []byte("content"))
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, permbase.Create, effect.PermissionKind())
+
+		if !assert.NoError(t, effect.Apply(ctx)) {
+			return
+		}
+		if !assert.NoError(t, effect.Reverse(ctx)) {
+			return
+		}
+
+		_, err = os.Stat(path)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("rollback should not touch a file written after the recording of a non-applied effect", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{Permissions: perms}, nil)
+		defer ctx.CancelGracefully()
+
+		path := filepath.Join(t.TempDir(), "file.txt")
+
+		effect, err := NewWriteFileEffect(ctx, Path(path), []byte("content"))
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		tx := StartNewTransaction(ctx)
+		if !assert.NoError(t, tx.AddEffect(ctx, effect)) {
+			return
+		}
+
+		os.WriteFile(path, []byte("other"), 0600)
+
+		if !assert.NoError(t, tx.Rollback(ctx)) {
+			return
+		}
+		assert.Equal(t, "other", readFile(path))
+	})
+
+	t.Run("rollback should reverse successive writes of the same file in the reverse order", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{Permissions: perms}, nil)
+		defer ctx.CancelGracefully()
+
+		path := filepath.Join(t.TempDir(), "file.txt")
+		tx := StartNewTransaction(ctx)
+
+		for _, content := range []string{"1", "2"} {
+			effect, err := NewWriteFileEffect(ctx, Path(path), []byte(content))
+			if !assert.NoError(t, err) {
+				return
+			}
+			if !assert.NoError(t, effect.Apply(ctx)) {
+				return
+			}
+			if !assert.NoError(t, tx.AddEffect(ctx, effect)) {
+				return
+			}
+		}
+
+		if !assert.NoError(t, tx.Rollback(ctx)) {
+			return
+		}
+
+		_, err := os.Stat(path)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("interrupted commit should be reversed during the recovery", func(t *testing.T) {
+		dir := t.TempDir()
+		journalPath := filepath.Join(dir, "journal.db")
+		path1 := filepath.Join(dir, "file1.txt")
+		path2 := filepath.Join(dir, "file2.txt")
+
+		os.WriteFile(path1, []byte("previous"), 0600)
+
+		journal, err := OpenTransactionJournal(journalPath)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		ctx := NewContextWithEmptyState(ContextConfig{Permissions: perms}, nil)
+		defer ctx.CancelGracefully()
+
+		effect1, _ := NewWriteFileEffect(ctx, Path(path1), []byte("1"))
+		effect2, _ := NewWriteFileEffect(ctx, Path(path2), []byte("2"))
+		effects := []Effect{effect1, effect2}
+
+		//simulate a crash after the application of the first effect.
+		txID := NewULID()
+		if !assert.NoError(t, journal.recordTransaction(txID, effects)) {
+			return
+		}
+		if !assert.NoError(t, effect1.Apply(ctx)) {
+			return
+		}
+		if !assert.NoError(t, journal.markEffectApplied(txID, 0)) {
+			return
+		}
+		journal.Close()
+
+		journal, err = OpenTransactionJournal(journalPath)
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer journal.Close()
+
+		recovered, err := journal.Recover(ctx)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.Equal(t, []RecoveredTransaction{{ID: txID, Outcome: TxReversed}}, recovered)
+		assert.Equal(t, "previous", readFile(path1))
+
+		_, err = os.Stat(path2)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
@@ -58,6 +58,9 @@ type GlobalState struct {
 	Heap            *mem.ModuleHeap
 	lockedValues    []PotentiallySharable
 
+	//location of the synchronized block whose values are being locked, only set if deadlock detection is enabled.
+	lockAcquisitionLocation string
+
 	//Re-usable buffers for Go function calls made by reflect.Call.
 
 	goCallArgPrepBuf []any
@@ -91,6 +94,7 @@ type GlobalState struct {
 	//Information on preparation
 
 	EffectivePreparationParameters EffectivePreparationParameters
+	DryRunTransaction              *Transaction //set if the module is prepared in dry-run mode
 }
 
 type StateId int64
@@ -40,18 +40,93 @@ type ValueHistory struct {
 	maxItemCount          int
 	renderCurrentToHTMLFn *InoxFunction // can be nil
 
+	//persistence
+
+	log                  *ValueHistoryLog // can be nil
+	nextSeq              uint64           // sequence number of the next change
+	snapshotInterval     int
+	changesSinceSnapshot int
 }
 
 func NewValueHistory(ctx *Context, v InMemorySnapshotable, config *Object) *ValueHistory {
-	current := v
+	history := newValueHistoryFromConfig(config)
+	history.startValue = utils.Must(TakeSnapshot(ctx, v, false))
+	history.watch(ctx, v)
+
+	return history
+}
+
+// OpenValueHistory returns a history whose changes are recorded in log. If the log is empty the history of initial
+// is started and a first snapshot is written, otherwise the value is rebuilt by replaying the logged changes over
+// the last snapshot and initial is ignored. The history watches the returned value. The changes made before the
+// opening can be retrieved with ValueAt, even if they are not kept in memory. The log is not closed by the history.
+func OpenValueHistory(ctx *Context, log *ValueHistoryLog, initial InMemorySnapshotable, config *Object) (*ValueHistory, InMemorySnapshotable, error) {
+	history := newValueHistoryFromConfig(config)
+	history.log = log
+
+	empty, err := log.isEmpty()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if empty {
+		history.startValue, err = TakeSnapshot(ctx, initial, false)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := log.writeSnapshot(ctx, 0, history.startValue); err != nil {
+			return nil, nil, err
+		}
+		history.watch(ctx, initial)
+		return history, initial, nil
+	}
+
+	seq, snapshot, err := log.snapshotAtOrBefore(ctx, DateTime(time.Now()))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	changes, nextSeq, err := log.changesFrom(seq)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	history.startValue = snapshot
+	history.changes = changes
+	history.nextSeq = nextSeq
+	history.changesSinceSnapshot = len(changes)
+
+	value, err := history.lastValue(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	current, ok := value.(InMemorySnapshotable)
+	if !ok {
+		return nil, nil, fmt.Errorf("the value rebuilt from the value history log is not snapshotable: %T", value)
+	}
+
+	//only the last changes are kept in memory.
+	if len(history.changes) >= history.maxItemCount {
+		history.forgetChangesBeforeIndex(ctx, len(history.changes)-history.maxItemCount+1)
+	}
+
+	history.watch(ctx, current)
+	return history, current, nil
+}
+
+func newValueHistoryFromConfig(config *Object) *ValueHistory {
 	history := &ValueHistory{
-		maxItemCount: DEFAULT_MAX_HISTORY_LEN,
+		maxItemCount:     DEFAULT_MAX_HISTORY_LEN,
+		snapshotInterval: DEFAULT_HISTORY_SNAPSHOT_INTERVAL,
 	}
 
 	config.ForEachEntry(func(k string, v Serializable) error {
 		switch k {
 		case "max-length":
 			history.maxItemCount = int(v.(Int))
+		case "snapshot-interval":
+			history.snapshotInterval = int(v.(Int))
 		case "render":
 			history.renderCurrentToHTMLFn = v.(*InoxFunction)
 		default:
@@ -61,31 +136,23 @@ func NewValueHistory(ctx *Context, v InMemorySnapshotable, config *Object) *Valu
 		return nil
 	})
 
-	var handle CallbackHandle
-
-	_ = handle
+	return history
+}
 
-	registerMutationCallback := func(ctx *Context) {
-		var err error
-		handle, err = current.OnMutation(ctx, func(ctx *Context, mutation Mutation) (registerAgain bool) {
-			registerAgain = true
+func (h *ValueHistory) watch(ctx *Context, v InMemorySnapshotable) {
+	_, err := v.OnMutation(ctx, func(ctx *Context, mutation Mutation) (registerAgain bool) {
+		registerAgain = true
 
-			history.AddChange(ctx, NewChange(mutation, DateTime(time.Now())))
+		h.AddChange(ctx, NewChange(mutation, DateTime(time.Now())))
 
-			return
-		}, MutationWatchingConfiguration{
-			Depth: ShallowWatching,
-		})
+		return
+	}, MutationWatchingConfiguration{
+		Depth: ShallowWatching,
+	})
 
-		if err != nil {
-			panic(err)
-		}
+	if err != nil {
+		panic(err)
 	}
-
-	history.startValue = utils.Must(TakeSnapshot(ctx, current, false))
-	registerMutationCallback(ctx)
-
-	return history
 }
 
 func (h *ValueHistory) RenderCurrentToHTMLFn() *InoxFunction {
@@ -97,15 +164,59 @@ func (h *ValueHistory) AddChange(ctx *Context, c Change) {
 	defer h.lock.Unlock()
 	h.changes = append(h.changes, c)
 
+	if h.log != nil {
+		if err := h.logChange(ctx, c); err != nil {
+			panic(err)
+		}
+	}
+
 	if len(h.changes) == h.maxItemCount {
 		h.forgetChangesBeforeIndex(ctx, 1)
 	}
 }
 
+// logChange records a change in the log, a snapshot of the value is written every .snapshotInterval changes.
+func (h *ValueHistory) logChange(ctx *Context, c Change) error {
+	if err := h.log.appendChange(h.nextSeq, c); err != nil {
+		return err
+	}
+	h.nextSeq++
+	h.changesSinceSnapshot++
+
+	if h.changesSinceSnapshot < h.snapshotInterval {
+		return nil
+	}
+
+	value, err := h.lastValue(ctx)
+	if err != nil {
+		return err
+	}
+
+	snapshot, err := TakeSnapshot(ctx, value, false)
+	if err != nil {
+		return err
+	}
+	snapshot.date = c.datetime
+
+	if err := h.log.writeSnapshot(ctx, h.nextSeq, snapshot); err != nil {
+		return err
+	}
+	h.changesSinceSnapshot = 0
+	return nil
+}
+
 func (h *ValueHistory) ValueAt(ctx *Context, d DateTime) Value {
 	h.lock.Lock()
 	defer h.lock.Unlock()
 
+	if h.log != nil && time.Time(d).Before(time.Time(h.startValue.date)) {
+		v, err := h.valueAtFromLog(ctx, d)
+		if err != nil {
+			panic(err)
+		}
+		return v
+	}
+
 	v, err := h.startValue.InstantiateValue(ctx)
 	if err != nil {
 		panic(err)
@@ -125,22 +236,60 @@ func (h *ValueHistory) ValueAt(ctx *Context, d DateTime) Value {
 	return v
 }
 
+// valueAtFromLog computes the value at d from the closest snapshot in the log, it is used for moments
+// preceding the changes kept in memory.
+func (h *ValueHistory) valueAtFromLog(ctx *Context, d DateTime) (Serializable, error) {
+	seq, snapshot, err := h.log.snapshotAtOrBefore(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := snapshot.InstantiateValue(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, _, err := h.log.changesFrom(seq)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range changes {
+		if time.Time(c.datetime).After(time.Time(d)) {
+			break
+		}
+		if err := c.mutation.ApplyTo(ctx, v); err != nil {
+			return nil, err
+		}
+	}
+
+	return v, nil
+}
+
 func (h *ValueHistory) LastValue(ctx *Context) Value {
 	h.lock.Lock()
 	defer h.lock.Unlock()
 
-	v, err := h.startValue.InstantiateValue(ctx)
+	v, err := h.lastValue(ctx)
 	if err != nil {
 		panic(err)
 	}
+	return v
+}
+
+func (h *ValueHistory) lastValue(ctx *Context) (Serializable, error) {
+	v, err := h.startValue.InstantiateValue(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	for _, c := range h.changes {
 		if err := c.mutation.ApplyTo(ctx, v); err != nil {
-			panic(err)
+			return nil, err
 		}
 	}
 
-	return v
+	return v, nil
 }
 
 func (h *ValueHistory) SelectDate(ctx *Context, d DateTime) {
@@ -162,6 +311,13 @@ func (h *ValueHistory) ForgetChangesBeforeDate(ctx *Context, d DateTime) {
 	h.lock.Lock()
 	defer h.lock.Unlock()
 
+	if h.log != nil {
+		//the snapshots and changes preceding d are removed from the log.
+		if err := h.log.compact(d); err != nil {
+			panic(err)
+		}
+	}
+
 	index, ok := h.indexAtOrAfterMoment(d)
 
 	if !ok {
@@ -179,6 +335,16 @@ func (h *ValueHistory) ForgetLast(ctx *Context) {
 		return
 	}
 
+	if h.log != nil {
+		if err := h.log.removeLastChange(h.nextSeq - 1); err != nil {
+			panic(err)
+		}
+		h.nextSeq--
+		if h.changesSinceSnapshot > 0 {
+			h.changesSinceSnapshot--
+		}
+	}
+
 	newLength := len(h.changes) - 1
 	h.changes = h.changes[:newLength]
 }
@@ -0,0 +1,268 @@
+package core
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+const (
+	VALUE_HISTORY_LOG_FILE_MODE       = 0600
+	VALUE_HISTORY_LOG_OPEN_TIMEOUT    = time.Second
+	DEFAULT_HISTORY_SNAPSHOT_INTERVAL = 50
+)
+
+var (
+	ErrEmptyValueHistoryLog = errors.New("value history log is empty")
+
+	valueHistoryLogSnapshotsBucket = []byte("snapshots")
+	valueHistoryLogChangesBucket   = []byte("changes")
+)
+
+// A ValueHistoryLog is an append-only log (bbolt database) in which a ValueHistory records its changes and periodic
+// snapshots of the value. Changes are identified by a sequence number, a snapshot is identified by the sequence
+// number of the first change that is not included in it. Snapshots are stored in the binary representation
+// (see WriteBinaryRepresentation).
+type ValueHistoryLog struct {
+	db *bbolt.DB
+}
+
+// OpenValueHistoryLog opens the log stored at path, the file is created if it does not exist.
+func OpenValueHistoryLog(path string) (*ValueHistoryLog, error) {
+	db, err := bbolt.Open(path, VALUE_HISTORY_LOG_FILE_MODE, &bbolt.Options{Timeout: VALUE_HISTORY_LOG_OPEN_TIMEOUT})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open value history log: %w", err)
+	}
+
+	err = db.Update(func(btx *bbolt.Tx) error {
+		if _, err := btx.CreateBucketIfNotExists(valueHistoryLogSnapshotsBucket); err != nil {
+			return err
+		}
+		_, err := btx.CreateBucketIfNotExists(valueHistoryLogChangesBucket)
+		return err
+	})
+
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize value history log: %w", err)
+	}
+
+	return &ValueHistoryLog{db: db}, nil
+}
+
+func (l *ValueHistoryLog) Close() error {
+	return l.db.Close()
+}
+
+type loggedSnapshotRecord struct {
+	Date  time.Time `json:"date"`
+	Value []byte    `json:"value"`
+}
+
+type loggedChangeRecord struct {
+	Date                    time.Time               `json:"date"`
+	Kind                    MutationKind            `json:"kind"`
+	Complete                bool                    `json:"complete"`
+	SpecificMutationVersion SpecificMutationVersion `json:"specificMutationVersion,omitempty"`
+	SpecificMutationKind    SpecificMutationKind    `json:"specificMutationKind,omitempty"`
+	Data                    []byte                  `json:"data"`
+	DataElementLengths      [6]int32                `json:"dataElementLengths"`
+	Path                    Path                    `json:"path,omitempty"`
+	Depth                   WatchingDepth           `json:"depth"`
+}
+
+func (r loggedChangeRecord) change() Change {
+	return NewChange(Mutation{
+		Kind:                    r.Kind,
+		Complete:                r.Complete,
+		SpecificMutationVersion: r.SpecificMutationVersion,
+		SpecificMutationKind:    r.SpecificMutationKind,
+		Data:                    r.Data,
+		DataElementLengths:      r.DataElementLengths,
+		Path:                    r.Path,
+		Depth:                   r.Depth,
+	}, DateTime(r.Date))
+}
+
+// isEmpty returns true if the log contains no snapshot.
+func (l *ValueHistoryLog) isEmpty() (empty bool, _ error) {
+	err := l.db.View(func(btx *bbolt.Tx) error {
+		k, _ := btx.Bucket(valueHistoryLogSnapshotsBucket).Cursor().First()
+		empty = k == nil
+		return nil
+	})
+	return empty, err
+}
+
+// writeSnapshot records a snapshot of the value, seq is the sequence number of the first change that is not
+// included in the snapshot.
+func (l *ValueHistoryLog) writeSnapshot(ctx *Context, seq uint64, snapshot *Snapshot) error {
+	value, err := snapshot.InstantiateValue(ctx)
+	if err != nil {
+		return err
+	}
+
+	repr, err := GetBinaryRepresentation(ctx, value, BinarySerializationConfig{ReprConfig: ALL_VISIBLE_REPR_CONFIG})
+	if err != nil {
+		return fmt.Errorf("failed to write snapshot to value history log: %w", err)
+	}
+
+	record, err := json.Marshal(loggedSnapshotRecord{Date: time.Time(snapshot.date), Value: repr})
+	if err != nil {
+		return err
+	}
+
+	return l.db.Update(func(btx *bbolt.Tx) error {
+		return btx.Bucket(valueHistoryLogSnapshotsBucket).Put(encodeValueHistoryLogSeq(seq), record)
+	})
+}
+
+func (l *ValueHistoryLog) appendChange(seq uint64, c Change) error {
+	m := c.mutation
+
+	record, err := json.Marshal(loggedChangeRecord{
+		Date:                    time.Time(c.datetime),
+		Kind:                    m.Kind,
+		Complete:                m.Complete,
+		SpecificMutationVersion: m.SpecificMutationVersion,
+		SpecificMutationKind:    m.SpecificMutationKind,
+		Data:                    m.Data,
+		DataElementLengths:      m.DataElementLengths,
+		Path:                    m.Path,
+		Depth:                   m.Depth,
+	})
+	if err != nil {
+		return err
+	}
+
+	return l.db.Update(func(btx *bbolt.Tx) error {
+		return btx.Bucket(valueHistoryLogChangesBucket).Put(encodeValueHistoryLogSeq(seq), record)
+	})
+}
+
+// removeLastChange removes the change having the sequence number seq as well as the snapshot including it.
+func (l *ValueHistoryLog) removeLastChange(seq uint64) error {
+	return l.db.Update(func(btx *bbolt.Tx) error {
+		if err := btx.Bucket(valueHistoryLogChangesBucket).Delete(encodeValueHistoryLogSeq(seq)); err != nil {
+			return err
+		}
+		return btx.Bucket(valueHistoryLogSnapshotsBucket).Delete(encodeValueHistoryLogSeq(seq + 1))
+	})
+}
+
+// snapshotAtOrBefore returns the most recent snapshot taken at or before d, if there is no such snapshot the oldest
+// snapshot is returned. ErrEmptyValueHistoryLog is returned if the log contains no snapshot.
+func (l *ValueHistoryLog) snapshotAtOrBefore(ctx *Context, d DateTime) (seq uint64, _ *Snapshot, _ error) {
+	var (
+		key    []byte
+		record loggedSnapshotRecord
+	)
+
+	err := l.db.View(func(btx *bbolt.Tx) error {
+		cursor := btx.Bucket(valueHistoryLogSnapshotsBucket).Cursor()
+
+		for k, v := cursor.Last(); k != nil; k, v = cursor.Prev() {
+			key, record = slices.Clone(k), loggedSnapshotRecord{}
+			if err := json.Unmarshal(v, &record); err != nil {
+				return fmt.Errorf("invalid snapshot record in value history log: %w", err)
+			}
+			if !record.Date.After(time.Time(d)) {
+				break
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		return 0, nil, err
+	}
+	if key == nil {
+		return 0, nil, ErrEmptyValueHistoryLog
+	}
+
+	value, err := ParseBinaryRepresentation(ctx, record.Value, nil)
+	if err != nil {
+		return 0, nil, fmt.Errorf("invalid snapshot in value history log: %w", err)
+	}
+
+	snapshot, err := TakeSnapshot(ctx, value, false)
+	if err != nil {
+		return 0, nil, err
+	}
+	snapshot.date = DateTime(record.Date)
+
+	return binary.BigEndian.Uint64(key), snapshot, nil
+}
+
+// changesFrom returns the changes whose sequence number is greater or equal to seq, as well as the sequence number
+// of the next change.
+func (l *ValueHistoryLog) changesFrom(seq uint64) (changes []Change, nextSeq uint64, _ error) {
+	nextSeq = seq
+
+	err := l.db.View(func(btx *bbolt.Tx) error {
+		cursor := btx.Bucket(valueHistoryLogChangesBucket).Cursor()
+
+		//keys are big-endian encoded so the changes are iterated in the order they were made.
+		for k, v := cursor.Seek(encodeValueHistoryLogSeq(seq)); k != nil; k, v = cursor.Next() {
+			var record loggedChangeRecord
+			if err := json.Unmarshal(v, &record); err != nil {
+				return fmt.Errorf("invalid change record in value history log: %w", err)
+			}
+			changes = append(changes, record.change())
+			nextSeq = binary.BigEndian.Uint64(k) + 1
+		}
+		return nil
+	})
+
+	return changes, nextSeq, err
+}
+
+// compact removes the snapshots and changes that are not needed to compute the value at moments after d: only the
+// most recent snapshot taken at or before d and the changes following it are kept.
+func (l *ValueHistoryLog) compact(d DateTime) error {
+	return l.db.Update(func(btx *bbolt.Tx) error {
+		snapshots := btx.Bucket(valueHistoryLogSnapshotsBucket)
+		cursor := snapshots.Cursor()
+
+		var keptSnapshotKey []byte
+
+		for k, v := cursor.Last(); k != nil; k, v = cursor.Prev() {
+			var record loggedSnapshotRecord
+			if err := json.Unmarshal(v, &record); err != nil {
+				return fmt.Errorf("invalid snapshot record in value history log: %w", err)
+			}
+			if !record.Date.After(time.Time(d)) {
+				keptSnapshotKey = slices.Clone(k)
+				break
+			}
+		}
+
+		if keptSnapshotKey == nil {
+			return nil
+		}
+
+		if err := deleteKeysBefore(snapshots, keptSnapshotKey); err != nil {
+			return err
+		}
+		return deleteKeysBefore(btx.Bucket(valueHistoryLogChangesBucket), keptSnapshotKey)
+	})
+}
+
+func deleteKeysBefore(bucket *bbolt.Bucket, key []byte) error {
+	cursor := bucket.Cursor()
+	for k, _ := cursor.First(); k != nil && string(k) < string(key); k, _ = cursor.First() {
+		if err := cursor.Delete(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func encodeValueHistoryLogSeq(seq uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, seq)
+}
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -57,3 +58,136 @@ func TestValueHistory(t *testing.T) {
 
 	//TODO: add test for dynamic value
 }
+
+func TestPersistentValueHistory(t *testing.T) {
+
+	openLog := func(t *testing.T, path string) *ValueHistoryLog {
+		log, err := OpenValueHistoryLog(path)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		return log
+	}
+
+	t.Run("the value should be rebuilt from the log", func(t *testing.T) {
+		ctx := NewContext(ContextConfig{})
+		NewGlobalState(ctx)
+		defer ctx.CancelGracefully()
+
+		path := filepath.Join(t.TempDir(), "history.db")
+		config := NewObjectFromMap(ValMap{"max-length": Int(3), "snapshot-interval": Int(2)}, ctx)
+
+		log := openLog(t, path)
+
+		history, value, err := OpenValueHistory(ctx, log, NewRuneSlice(nil), config)
+		if !assert.NoError(t, err) {
+			return
+		}
+		val := value.(*RuneSlice)
+
+		timeBeforeFirstChange := time.Now()
+		val.insertElement(ctx, Rune('1'), 0)
+		timeBeforeSecondChange := time.Now()
+		val.insertElement(ctx, Rune('2'), 1)
+		val.insertElement(ctx, Rune('3'), 2)
+		val.insertElement(ctx, Rune('4'), 3)
+		timeAfterLastChange := time.Now()
+
+		//changes before the in-memory window should be retrieved from the log.
+		item := history.ValueAt(ctx, DateTime(timeBeforeSecondChange))
+		assert.Equal(t, []rune{'1'}, item.(*RuneSlice).elements)
+
+		assert.NoError(t, log.Close())
+
+		//reopen the log
+
+		log = openLog(t, path)
+		defer log.Close()
+
+		history, value, err = OpenValueHistory(ctx, log, NewRuneSlice(nil), config)
+		if !assert.NoError(t, err) {
+			return
+		}
+		val = value.(*RuneSlice)
+		assert.Equal(t, []rune{'1', '2', '3', '4'}, val.elements)
+
+		item = history.ValueAt(ctx, DateTime(timeBeforeFirstChange))
+		assert.Equal(t, []rune{}, item.(*RuneSlice).elements)
+
+		item = history.ValueAt(ctx, DateTime(timeBeforeSecondChange))
+		assert.Equal(t, []rune{'1'}, item.(*RuneSlice).elements)
+
+		item = history.ValueAt(ctx, DateTime(timeAfterLastChange))
+		assert.Equal(t, []rune{'1', '2', '3', '4'}, item.(*RuneSlice).elements)
+
+		//new changes should be recorded
+		val.insertElement(ctx, Rune('5'), 4)
+		assert.Equal(t, []rune{'1', '2', '3', '4', '5'}, history.LastValue(ctx).(*RuneSlice).elements)
+	})
+
+	t.Run("forgotten changes should be removed from the log", func(t *testing.T) {
+		ctx := NewContext(ContextConfig{})
+		NewGlobalState(ctx)
+		defer ctx.CancelGracefully()
+
+		path := filepath.Join(t.TempDir(), "history.db")
+		config := NewObjectFromMap(ValMap{"snapshot-interval": Int(1)}, ctx)
+
+		log := openLog(t, path)
+		defer log.Close()
+
+		history, value, err := OpenValueHistory(ctx, log, NewRuneSlice(nil), config)
+		if !assert.NoError(t, err) {
+			return
+		}
+		val := value.(*RuneSlice)
+
+		timeBeforeFirstChange := time.Now()
+		val.insertElement(ctx, Rune('1'), 0)
+		val.insertElement(ctx, Rune('2'), 1)
+		timeAfterSecondChange := time.Now()
+		val.insertElement(ctx, Rune('3'), 2)
+
+		history.ForgetChangesBeforeDate(ctx, DateTime(timeAfterSecondChange))
+
+		changes, _, err := log.changesFrom(0)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Len(t, changes, 1)
+
+		//the oldest remaining state is returned for moments preceding the compaction.
+		item := history.ValueAt(ctx, DateTime(timeBeforeFirstChange))
+		assert.Equal(t, []rune{'1', '2'}, item.(*RuneSlice).elements)
+	})
+
+	t.Run("ForgetLast", func(t *testing.T) {
+		ctx := NewContext(ContextConfig{})
+		NewGlobalState(ctx)
+		defer ctx.CancelGracefully()
+
+		path := filepath.Join(t.TempDir(), "history.db")
+		config := NewObjectFromMap(ValMap{}, ctx)
+
+		log := openLog(t, path)
+
+		history, value, err := OpenValueHistory(ctx, log, NewRuneSlice(nil), config)
+		if !assert.NoError(t, err) {
+			return
+		}
+		val := value.(*RuneSlice)
+
+		val.insertElement(ctx, Rune('1'), 0)
+		val.insertElement(ctx, Rune('2'), 1)
+		history.ForgetLast(ctx)
+		assert.NoError(t, log.Close())
+
+		log = openLog(t, path)
+		defer log.Close()
+
+		_, value, err = OpenValueHistory(ctx, log, NewRuneSlice(nil), config)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []rune{'1'}, value.(*RuneSlice).elements)
+		}
+	})
+}
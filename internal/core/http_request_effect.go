@@ -0,0 +1,151 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/inoxlang/inox/internal/core/permbase"
+)
+
+const (
+	DEFAULT_HTTP_REQUEST_EFFECT_TIMEOUT       = 20 * time.Second
+	DEFAULT_HTTP_REQUEST_EFFECT_MAX_BODY_SIZE = 10_000_000
+)
+
+var (
+	ErrHttpResponseBodyTooLarge = errors.New("response body is too large")
+)
+
+// An HttpRequestEffect is the sending of an HTTP request whose method is not safe (POST, PUT, PATCH, DELETE, ...).
+// The effect is irreversible, so it is applied immediately by PerformEffect unless the current transaction is a
+// dry-run transaction. The status code and the body of the response are available after the application.
+type HttpRequestEffect struct {
+	config HttpRequestEffectConfig
+
+	applying atomic.Bool
+	applied  atomic.Bool
+
+	responseStatusCode int
+	responseBody       []byte
+}
+
+type HttpRequestEffectConfig struct {
+	Method string
+	URL    URL
+	Header http.Header //can be nil
+	Body   []byte      //can be nil
+
+	Timeout             time.Duration //defaults to DEFAULT_HTTP_REQUEST_EFFECT_TIMEOUT
+	MaxResponseBodySize int           //defaults to DEFAULT_HTTP_REQUEST_EFFECT_MAX_BODY_SIZE
+}
+
+func NewHttpRequestEffect(config HttpRequestEffectConfig) *HttpRequestEffect {
+	if config.Timeout <= 0 {
+		config.Timeout = DEFAULT_HTTP_REQUEST_EFFECT_TIMEOUT
+	}
+	if config.MaxResponseBodySize <= 0 {
+		config.MaxResponseBodySize = DEFAULT_HTTP_REQUEST_EFFECT_MAX_BODY_SIZE
+	}
+	return &HttpRequestEffect{config: config}
+}
+
+func (e *HttpRequestEffect) Resources() []ResourceName {
+	return []ResourceName{e.config.URL}
+}
+
+func (e *HttpRequestEffect) PermissionKind() PermissionKind {
+	return HttpMethodToPermissionKind(e.config.Method)
+}
+
+func (e *HttpRequestEffect) Reversability(*Context) Reversability {
+	return Irreversible
+}
+
+func (e *HttpRequestEffect) IsApplied() bool {
+	return e.applied.Load()
+}
+
+func (e *HttpRequestEffect) IsApplying() bool {
+	return e.applying.Load()
+}
+
+func (e *HttpRequestEffect) Apply(ctx *Context) error {
+	if e.applied.Load() {
+		return ErrEffectAlreadyApplied
+	}
+
+	if err := ctx.CheckHasPermission(HttpPermission{Kind_: e.PermissionKind(), Entity: e.config.URL}); err != nil {
+		return err
+	}
+
+	e.applying.Store(true)
+	defer e.applying.Store(false)
+
+	var body io.Reader
+	if e.config.Body != nil {
+		body = bytes.NewReader(e.config.Body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, e.config.Method, string(e.config.URL), body)
+	if err != nil {
+		return err
+	}
+	for name, values := range e.config.Header {
+		req.Header[name] = values
+	}
+
+	err = ctx.DoIO(func() error {
+		e.responseStatusCode, e.responseBody, err = doHttpRequest(req, e.config.Timeout, e.config.MaxResponseBodySize)
+		return err
+	})
+
+	if err != nil {
+		return err
+	}
+
+	e.applied.Store(true)
+	return nil
+}
+
+func (e *HttpRequestEffect) Reverse(*Context) error {
+	return ErrIrreversible
+}
+
+// Response returns the status code and the body of the response, it should only be called after the application.
+func (e *HttpRequestEffect) Response() (statusCode int, body []byte) {
+	return e.responseStatusCode, e.responseBody
+}
+
+// doHttpRequest sends the request and reads the body of the response.
+func doHttpRequest(req *http.Request, timeout time.Duration, maxBodySize int) (statusCode int, body []byte, _ error) {
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err = io.ReadAll(io.LimitReader(resp.Body, int64(maxBodySize)+1))
+	if err == nil && len(body) > maxBodySize {
+		err = ErrHttpResponseBodyTooLarge
+	}
+	return resp.StatusCode, body, err
+}
+
+// HttpMethodToPermissionKind returns the kind of the HTTP permission required to send a request with the given method.
+func HttpMethodToPermissionKind(method string) PermissionKind {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return permbase.Read
+	case http.MethodPost:
+		return permbase.Create
+	case http.MethodDelete:
+		return permbase.Delete
+	default:
+		return permbase.Update
+	}
+}
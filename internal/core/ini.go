@@ -0,0 +1,193 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/inoxlang/inox/internal/mimeconsts"
+	"gopkg.in/ini.v1"
+)
+
+var (
+	ErrNoIniRepresentation   = errors.New("value has no INI representation")
+	ErrIniNotMatchingPattern = errors.New("INI document does not match the pattern")
+	ErrIniKeySectionConflict = errors.New("a key of the default section has the same name as a section")
+)
+
+func init() {
+	RegisterParser(mimeconsts.INI_CTYPE, &iniParser{})
+}
+
+// ParseINI parses an INI document: the keys of the default section are top-level properties and each section is a
+// nested object (record if immutable is true). INI values are untyped, if pattern is a record or object pattern the
+// values are coerced to the type expected by the pattern (see coerceTextToPatternType) and the result is checked
+// against the pattern.
+func ParseINI(ctx *Context, s string, pattern Pattern, immutable bool) (Serializable, error) {
+	file, err := ini.Load([]byte(s))
+	if err != nil {
+		return nil, err
+	}
+
+	propsPattern, _ := pattern.(IPropsPattern)
+
+	var (
+		keys   []string
+		values []Serializable
+	)
+
+	for _, section := range file.Sections() {
+		name := section.Name()
+
+		if name == ini.DefaultSection {
+			sectionKeys, sectionValues, err := convertIniSection(ctx, section, propsPattern)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, sectionKeys...)
+			values = append(values, sectionValues...)
+			continue
+		}
+
+		if slices.Contains(keys, name) {
+			return nil, fmt.Errorf("%w: %s", ErrIniKeySectionConflict, name)
+		}
+
+		var sectionPattern IPropsPattern
+		if propsPattern != nil {
+			if p, _, ok := propsPattern.ValuePropPattern(name); ok {
+				sectionPattern, _ = p.(IPropsPattern)
+			}
+		}
+
+		sectionKeys, sectionValues, err := convertIniSection(ctx, section, sectionPattern)
+		if err != nil {
+			return nil, fmt.Errorf("section %s: %w", name, err)
+		}
+
+		keys = append(keys, name)
+		if immutable {
+			values = append(values, NewRecordFromKeyValLists(sectionKeys, sectionValues))
+		} else {
+			values = append(values, objFromLists(sectionKeys, sectionValues))
+		}
+	}
+
+	var result Serializable
+	if immutable {
+		result = NewRecordFromKeyValLists(keys, values)
+	} else {
+		result = objFromLists(keys, values)
+	}
+
+	if pattern != nil && !pattern.Test(ctx, result) {
+		return nil, ErrIniNotMatchingPattern
+	}
+	return result, nil
+}
+
+func convertIniSection(ctx *Context, section *ini.Section, pattern IPropsPattern) ([]string, []Serializable, error) {
+	var (
+		keys   []string
+		values []Serializable
+	)
+
+	for _, key := range section.Keys() {
+		var value Serializable = String(key.String())
+
+		if pattern != nil {
+			if keyPattern, _, ok := pattern.ValuePropPattern(key.Name()); ok {
+				var err error
+				value, err = coerceTextToPatternType(ctx, key.String(), keyPattern)
+				if err != nil {
+					return nil, nil, fmt.Errorf("key %s: %w", key.Name(), err)
+				}
+			}
+		}
+
+		keys = append(keys, key.Name())
+		values = append(values, value)
+	}
+
+	return keys, values, nil
+}
+
+// WriteINI writes the INI representation of a record or an object: nested records and objects are written as sections,
+// other properties are written in the default section. Keys and sections are written in lexicographic order.
+func WriteINI(ctx *Context, w io.Writer, v Serializable) error {
+	if !isRecordOrObject(v) {
+		return fmt.Errorf("%w: the document should be a record or an object", ErrNoIniRepresentation)
+	}
+	document := v.(IProps)
+
+	file := ini.Empty()
+	defaultSection := file.Section(ini.DefaultSection)
+
+	names := document.PropertyNames(ctx)
+	slices.Sort(names)
+
+	var sectionNames []string
+
+	for _, name := range names {
+		value := document.Prop(ctx, name)
+
+		if isRecordOrObject(value) {
+			sectionNames = append(sectionNames, name)
+			continue
+		}
+
+		if err := writeIniKey(defaultSection, name, value); err != nil {
+			return err
+		}
+	}
+
+	for _, sectionName := range sectionNames {
+		section, err := file.NewSection(sectionName)
+		if err != nil {
+			return err
+		}
+
+		props := document.Prop(ctx, sectionName).(IProps)
+		keys := props.PropertyNames(ctx)
+		slices.Sort(keys)
+
+		for _, key := range keys {
+			if err := writeIniKey(section, key, props.Prop(ctx, key)); err != nil {
+				return fmt.Errorf("section %s: %w", sectionName, err)
+			}
+		}
+	}
+
+	_, err := file.WriteTo(w)
+	return err
+}
+
+func writeIniKey(section *ini.Section, name string, value Value) error {
+	text, err := formatValueAsText(value)
+	if err != nil {
+		return fmt.Errorf("%w: key %s: %T", ErrNoIniRepresentation, name, value)
+	}
+	_, err = section.NewKey(name, text)
+	return err
+}
+
+type iniParser struct {
+}
+
+func (p *iniParser) Validate(ctx *Context, s string) bool {
+	if len(s) > DEFAULT_MAX_TESTED_STRING_BYTE_LENGTH {
+		panic(ErrTestedStringTooLarge)
+	}
+
+	_, err := ini.Load([]byte(s))
+	return err == nil
+}
+
+func (p *iniParser) Parse(ctx *Context, s string) (Serializable, error) {
+	if len(s) > DEFAULT_MAX_TESTED_STRING_BYTE_LENGTH {
+		return nil, ErrTestedStringTooLarge
+	}
+
+	return ParseINI(ctx, s, nil, false)
+}
@@ -0,0 +1,117 @@
+package core
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/inoxlang/inox/internal/mimeconsts"
+	"github.com/stretchr/testify/assert"
+)
+
+const TEST_INI_DOCUMENT = `
+name = app
+
+[server]
+port = 8080
+timeout = 10s
+
+[database]
+host = localhost
+`
+
+func TestParseINI(t *testing.T) {
+	ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+	defer ctx.CancelGracefully()
+
+	t.Run("without pattern", func(t *testing.T) {
+		result, err := ParseINI(ctx, TEST_INI_DOCUMENT, nil, false)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		obj := result.(*Object)
+		assert.Equal(t, String("app"), obj.Prop(ctx, "name"))
+
+		server := obj.Prop(ctx, "server").(*Object)
+		assert.Equal(t, String("8080"), server.Prop(ctx, "port"))
+	})
+
+	t.Run("values should be coerced using the pattern", func(t *testing.T) {
+		pattern := NewInexactRecordPattern([]RecordPatternEntry{
+			{Name: "name", Pattern: STR_PATTERN},
+			{
+				Name: "server",
+				Pattern: NewExactRecordPattern([]RecordPatternEntry{
+					{Name: "port", Pattern: INT_PATTERN},
+					{Name: "timeout", Pattern: DURATION_PATTERN},
+				}),
+			},
+		})
+
+		result, err := ParseINI(ctx, TEST_INI_DOCUMENT, pattern, true)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		record := result.(*Record)
+		server := record.Prop(ctx, "server").(*Record)
+		assert.Equal(t, Int(8080), server.Prop(ctx, "port"))
+		assert.Equal(t, Duration(10*time.Second), server.Prop(ctx, "timeout"))
+
+		database := record.Prop(ctx, "database").(*Record)
+		assert.Equal(t, String("localhost"), database.Prop(ctx, "host"))
+	})
+
+	t.Run("not matching pattern", func(t *testing.T) {
+		pattern := NewInexactRecordPattern([]RecordPatternEntry{{Name: "missing", Pattern: STR_PATTERN}})
+
+		_, err := ParseINI(ctx, TEST_INI_DOCUMENT, pattern, true)
+		assert.ErrorIs(t, err, ErrIniNotMatchingPattern)
+	})
+
+	t.Run("invalid value", func(t *testing.T) {
+		pattern := NewInexactRecordPattern([]RecordPatternEntry{{Name: "name", Pattern: INT_PATTERN}})
+
+		_, err := ParseINI(ctx, TEST_INI_DOCUMENT, pattern, true)
+		assert.Error(t, err)
+	})
+
+	t.Run("registered parser", func(t *testing.T) {
+		parser, ok := GetParser(mimeconsts.INI_CTYPE)
+		if !assert.True(t, ok) {
+			return
+		}
+
+		assert.True(t, parser.Validate(ctx, TEST_INI_DOCUMENT))
+		assert.False(t, parser.Validate(ctx, "[a"))
+	})
+}
+
+func TestWriteINI(t *testing.T) {
+	ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+	defer ctx.CancelGracefully()
+
+	t.Run("keys and sections should be sorted", func(t *testing.T) {
+		document := NewRecordFromMap(ValMap{
+			"name": String("app"),
+			"server": NewRecordFromMap(ValMap{
+				"timeout": Duration(10 * time.Second),
+				"port":    Int(8080),
+			}),
+			"database": NewRecordFromMap(ValMap{"host": String("localhost")}),
+		})
+
+		var buf bytes.Buffer
+		if !assert.NoError(t, WriteINI(ctx, &buf, document)) {
+			return
+		}
+
+		assert.Equal(t, "name = app\n\n[database]\nhost = localhost\n\n[server]\nport    = 8080\ntimeout = 10s\n", buf.String())
+	})
+
+	t.Run("values without representation", func(t *testing.T) {
+		err := WriteINI(ctx, &bytes.Buffer{}, NewRecordFromMap(ValMap{"a": NewTupleVariadic()}))
+		assert.ErrorIs(t, err, ErrNoIniRepresentation)
+	})
+}
@@ -63,56 +63,71 @@ func ToJSONVal(ctx *Context, v Serializable) interface{} {
 	return jsonVal
 }
 
+// ConvertJSONValToInoxVal converts a value obtained by unmarshalling JSON, it panics if a number cannot be converted.
 func ConvertJSONValToInoxVal(v any, immutable bool) Serializable {
+	result, err := TryConvertJSONValToInoxVal(v, immutable)
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+// TryConvertJSONValToInoxVal converts a value obtained by unmarshalling JSON, an error is returned if a number
+// (json.Number) cannot be converted.
+func TryConvertJSONValToInoxVal(v any, immutable bool) (Serializable, error) {
 	switch val := v.(type) {
 	case nil:
-		return Nil
+		return Nil, nil
 	case map[string]any:
-		if immutable {
-			valMap := ValMap{}
-			for key, value := range val {
-				valMap[key] = ConvertJSONValToInoxVal(value, immutable)
-			}
-			return NewRecordFromMap(valMap)
-		} else {
-			valMap := ValMap{}
-			for key, value := range val {
-				valMap[key] = ConvertJSONValToInoxVal(value, immutable)
+		valMap := ValMap{}
+		for key, value := range val {
+			converted, err := TryConvertJSONValToInoxVal(value, immutable)
+			if err != nil {
+				return nil, err
 			}
-			return NewObjectFromMapNoInit(valMap)
+			valMap[key] = converted
+		}
+		if immutable {
+			return NewRecordFromMap(valMap), nil
 		}
+		return NewObjectFromMapNoInit(valMap), nil
 	case []any:
 		l := make([]Serializable, len(val))
 		for i, e := range val {
-			l[i] = ConvertJSONValToInoxVal(e, immutable)
+			converted, err := TryConvertJSONValToInoxVal(e, immutable)
+			if err != nil {
+				return nil, err
+			}
+			l[i] = converted
 		}
 		if immutable {
-			return NewTuple(l)
+			return NewTuple(l), nil
 		}
-		return &List{underlyingList: &ValueList{elements: l}}
+		return &List{underlyingList: &ValueList{elements: l}}, nil
 	case int:
-		return Int(val)
+		return Int(val), nil
 	case float64:
-		return Float(val)
+		return Float(val), nil
 	case json.Number:
-		if strings.Contains(val.String(), ".") {
+		//numbers having a fractional part or an exponent (e.g. 1e3) are floats.
+		if strings.ContainsAny(val.String(), ".eE") {
 			float, err := val.Float64()
 			if err != nil {
-				panic(fmt.Errorf("failed to parse float `%s`: %w", val.String(), err))
+				return nil, fmt.Errorf("failed to parse float `%s`: %w", val.String(), err)
 			}
-			return Float(float)
+			return Float(float), nil
 		}
 		integer, err := val.Int64()
 		if err != nil {
-			panic(fmt.Errorf("failed to parse integer `%s`: %w", val.String(), err))
+			return nil, fmt.Errorf("failed to parse integer `%s`: %w", val.String(), err)
 		}
-		return Int(integer)
+		return Int(integer), nil
 	case bool:
-		return Bool(val)
+		return Bool(val), nil
 	case string:
-		return String(val)
+		return String(val), nil
 	default:
-		panic(fmt.Errorf("cannot convert value of type %T to Inox Value", val))
+		return nil, fmt.Errorf("cannot convert value of type %T to Inox Value", val)
 	}
 }
 
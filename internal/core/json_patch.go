@@ -0,0 +1,424 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	JSON_PATCH_ADD_OP     = "add"
+	JSON_PATCH_REMOVE_OP  = "remove"
+	JSON_PATCH_REPLACE_OP = "replace"
+	JSON_PATCH_MOVE_OP    = "move"
+	JSON_PATCH_COPY_OP    = "copy"
+	JSON_PATCH_TEST_OP    = "test"
+
+	JSON_POINTER_END_OF_ARRAY_TOKEN = "-"
+)
+
+var (
+	ErrMutationNotConvertibleToJSONPatch = errors.New("mutation is not convertible to a JSON Patch")
+	ErrUnsupportedJSONPatchOperation     = errors.New("unsupported JSON Patch operation")
+	ErrInvalidJSONPointer                = errors.New("invalid JSON pointer")
+	ErrJSONPointerTargetNotFound         = errors.New("target of JSON pointer not found")
+	ErrJSONPatchTestFailed               = errors.New("JSON Patch test operation failed")
+)
+
+// A JSONPatch is a RFC 6902 JSON Patch document.
+type JSONPatch []JSONPatchOperation
+
+// A JSONPatchOperation is an operation of a JSON Patch document. Values are in the JSON representation
+// of Inox values (see WriteJSONRepresentation), plain JSON values are also accepted when applying a patch.
+type JSONPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// MutationsToJSONPatch converts a stream of mutations to a JSON Patch document, see MutationToJSONPatch.
+func MutationsToJSONPatch(ctx *Context, mutations []Mutation) (JSONPatch, error) {
+	var patch JSONPatch
+
+	for i, m := range mutations {
+		ops, err := MutationToJSONPatch(ctx, m)
+		if err != nil {
+			return nil, fmt.Errorf("mutation %d: %w", i, err)
+		}
+		patch = append(patch, ops...)
+	}
+	return patch, nil
+}
+
+// MutationToJSONPatch converts a mutation to JSON Patch operations, the paths of the operations are relative to
+// the watched value that emitted the mutation. Incomplete, unspecified and specific mutations are not convertible.
+func MutationToJSONPatch(ctx *Context, m Mutation) (JSONPatch, error) {
+	if !m.Complete {
+		return nil, fmt.Errorf("%w: incomplete mutation", ErrMutationNotConvertibleToJSONPatch)
+	}
+
+	switch m.Kind {
+	case AddProp, UpdateProp:
+		name := m.AffectedProperty(ctx)
+		pointer := jsonPointerOfMutationTarget(m, name) + "/" + escapeJSONPointerToken(name)
+
+		op := JSON_PATCH_ADD_OP
+		if m.Kind == UpdateProp {
+			op = JSON_PATCH_REPLACE_OP
+		}
+		return JSONPatch{{Op: op, Path: pointer, Value: m.dataElemRepr(1)}}, nil
+	case AddEntry, UpdateEntry:
+		keyRepr := string(m.dataElemRepr(0))
+		pointer := jsonPointerOfMutationTarget(m, keyRepr) + "/" + escapeJSONPointerToken(keyRepr)
+
+		op := JSON_PATCH_ADD_OP
+		if m.Kind == UpdateEntry {
+			op = JSON_PATCH_REPLACE_OP
+		}
+		return JSONPatch{{Op: op, Path: pointer, Value: m.dataElemRepr(1)}}, nil
+	case InsertElemAtIndex, SetElemAtIndex, RemovePosition:
+		index := strconv.Itoa(int(m.AffectedIndex(ctx)))
+		pointer := jsonPointerOfMutationTarget(m, index) + "/" + index
+
+		switch m.Kind {
+		case InsertElemAtIndex:
+			return JSONPatch{{Op: JSON_PATCH_ADD_OP, Path: pointer, Value: m.dataElemRepr(1)}}, nil
+		case SetElemAtIndex:
+			return JSONPatch{{Op: JSON_PATCH_REPLACE_OP, Path: pointer, Value: m.dataElemRepr(1)}}, nil
+		default:
+			return JSONPatch{{Op: JSON_PATCH_REMOVE_OP, Path: pointer}}, nil
+		}
+	case InsertSequenceAtIndex:
+		index := int(m.AffectedIndex(ctx))
+		container := jsonPointerOfMutationTarget(m, strconv.Itoa(index))
+
+		return sequenceElementOperations(ctx, JSON_PATCH_ADD_OP, container, index, m.Sequence(ctx))
+	case SetSliceAtRange:
+		intRange := m.AffectedRange(ctx)
+		container := jsonPointerOfMutationTarget(m, rangePathSegment(intRange))
+
+		return sequenceElementOperations(ctx, JSON_PATCH_REPLACE_OP, container, int(intRange.KnownStart()), m.DataElem(ctx, 1).(Sequence))
+	case RemovePositionRange:
+		intRange := m.AffectedRange(ctx)
+		container := jsonPointerOfMutationTarget(m, rangePathSegment(intRange))
+
+		//the elements are removed from the end so that the indexes of the remaining elements do not change.
+		var patch JSONPatch
+		for i := int(intRange.InclusiveEnd()); i >= int(intRange.KnownStart()); i-- {
+			patch = append(patch, JSONPatchOperation{Op: JSON_PATCH_REMOVE_OP, Path: container + "/" + strconv.Itoa(i)})
+		}
+		return patch, nil
+	case RemovePositions:
+		indexes := m.DataElem(ctx, 0).(*List).GetOrBuildElements(ctx)
+		container := jsonPointerOfMutationTarget(m, "")
+
+		positions := make([]int, len(indexes))
+		for i, index := range indexes {
+			positions[i] = int(index.(Int))
+		}
+		slices.Sort(positions)
+
+		var patch JSONPatch
+		for i := len(positions) - 1; i >= 0; i-- {
+			patch = append(patch, JSONPatchOperation{Op: JSON_PATCH_REMOVE_OP, Path: container + "/" + strconv.Itoa(positions[i])})
+		}
+		return patch, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrMutationNotConvertibleToJSONPatch, m.Kind)
+	}
+}
+
+func sequenceElementOperations(ctx *Context, op string, container string, start int, seq Sequence) (JSONPatch, error) {
+	var patch JSONPatch
+
+	for i := 0; i < seq.Len(); i++ {
+		elem, ok := seq.At(ctx, i).(Serializable)
+		if !ok {
+			return nil, fmt.Errorf("%w: element is not serializable", ErrMutationNotConvertibleToJSONPatch)
+		}
+		repr, _, err := WriteSingleJSONRepresentation(ctx, elem)
+		if err != nil {
+			return nil, err
+		}
+		patch = append(patch, JSONPatchOperation{Op: op, Path: container + "/" + strconv.Itoa(start+i), Value: repr})
+	}
+	return patch, nil
+}
+
+func rangePathSegment(r IntRange) string {
+	return strconv.Itoa(int(r.KnownStart())) + ".." + strconv.Itoa(int(r.InclusiveEnd()))
+}
+
+func (m Mutation) dataElemRepr(index int) json.RawMessage {
+	start := int32(0)
+	for i := 0; i < index; i++ {
+		start += m.DataElementLengths[i]
+	}
+	return json.RawMessage(m.Data[start : start+m.DataElementLengths[index]])
+}
+
+// jsonPointerOfMutationTarget returns the JSON pointer of the value on which the mutation was applied, the path
+// of a mutation ends with a segment describing the modified location (e.g. /a, /0, /0..2).
+func jsonPointerOfMutationTarget(m Mutation, lastSegment string) string {
+	path := string(m.Path)
+	if lastSegment != "" && strings.HasSuffix(path, "/"+lastSegment) {
+		path = strings.TrimSuffix(path, "/"+lastSegment)
+	} else if index := strings.LastIndexByte(path, '/'); index >= 0 {
+		path = path[:index]
+	}
+
+	if path == "" {
+		return ""
+	}
+
+	segments := strings.Split(path[1:], "/")
+	for i, segment := range segments {
+		segments[i] = escapeJSONPointerToken(segment)
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+func escapeJSONPointerToken(token string) string {
+	token = strings.ReplaceAll(token, "~", "~0")
+	return strings.ReplaceAll(token, "/", "~1")
+}
+
+func parseJSONPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if pointer[0] != '/' {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidJSONPointer, pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		token = strings.ReplaceAll(token, "~1", "/")
+		tokens[i] = strings.ReplaceAll(token, "~0", "~")
+	}
+	return tokens, nil
+}
+
+// ApplyJSONPatch applies the operations of a JSON Patch document to v, each operation is converted to a Mutation that
+// is applied to the targeted value by calling Mutation.ApplyTo. The operations are applied in order, if an operation
+// fails the previous operations are not reverted. Removing properties of objects and entries of dictionaries is not
+// supported.
+func ApplyJSONPatch(ctx *Context, v Value, patch JSONPatch) error {
+	for i, op := range patch {
+		if err := applyJSONPatchOperation(ctx, v, op); err != nil {
+			return fmt.Errorf("operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+	return nil
+}
+
+func applyJSONPatchOperation(ctx *Context, root Value, op JSONPatchOperation) error {
+	switch op.Op {
+	case JSON_PATCH_ADD_OP, JSON_PATCH_REPLACE_OP, JSON_PATCH_TEST_OP:
+		if len(op.Value) == 0 {
+			return fmt.Errorf("missing value")
+		}
+		value, err := ParseJSONRepresentation(ctx, string(op.Value), nil)
+		if err != nil {
+			return err
+		}
+
+		if op.Op == JSON_PATCH_TEST_OP {
+			tokens, err := parseJSONPointer(op.Path)
+			if err != nil {
+				return err
+			}
+			target, err := resolveJSONPointerTokens(ctx, root, tokens)
+			if err != nil {
+				return err
+			}
+			if !value.Equal(ctx, target, map[uintptr]uintptr{}, 0) {
+				return ErrJSONPatchTestFailed
+			}
+			return nil
+		}
+		return applyJSONPatchValueOperation(ctx, root, op.Op, op.Path, value)
+	case JSON_PATCH_REMOVE_OP:
+		return applyJSONPatchRemoveOperation(ctx, root, op.Path)
+	case JSON_PATCH_COPY_OP, JSON_PATCH_MOVE_OP:
+		tokens, err := parseJSONPointer(op.From)
+		if err != nil {
+			return err
+		}
+		from, err := resolveJSONPointerTokens(ctx, root, tokens)
+		if err != nil {
+			return err
+		}
+		value, ok := from.(Serializable)
+		if !ok {
+			return fmt.Errorf("value at %s is not serializable", op.From)
+		}
+
+		if op.Op == JSON_PATCH_MOVE_OP {
+			if err := applyJSONPatchRemoveOperation(ctx, root, op.From); err != nil {
+				return err
+			}
+		}
+		return applyJSONPatchValueOperation(ctx, root, JSON_PATCH_ADD_OP, op.Path, value)
+	default:
+		return fmt.Errorf("%w: %q", ErrUnsupportedJSONPatchOperation, op.Op)
+	}
+}
+
+// applyJSONPatchValueOperation applies an add or replace operation.
+func applyJSONPatchValueOperation(ctx *Context, root Value, op string, pointer string, value Serializable) error {
+	container, lastToken, err := resolveJSONPointerContainer(ctx, root, pointer)
+	if err != nil {
+		return err
+	}
+
+	path := Path(pointer)
+	var mutation Mutation
+
+	switch c := container.(type) {
+	case *Dictionary:
+		key, err := ParseJSONRepresentation(ctx, lastToken, nil)
+		if err != nil {
+			return fmt.Errorf("%w: invalid dictionary key: %w", ErrInvalidJSONPointer, err)
+		}
+		_, exists := c.Value(ctx, key)
+
+		if exists {
+			mutation = NewUpdateEntryMutation(ctx, key, value, ShallowWatching, path)
+		} else if op == JSON_PATCH_ADD_OP {
+			mutation = NewAddEntryMutation(ctx, key, value, ShallowWatching, path)
+		} else {
+			return ErrJSONPointerTargetNotFound
+		}
+	case MutableLengthSequence:
+		length := c.Len()
+		var index int
+
+		if op == JSON_PATCH_ADD_OP && lastToken == JSON_POINTER_END_OF_ARRAY_TOKEN {
+			index = length
+		} else {
+			index, err = strconv.Atoi(lastToken)
+			if err != nil || index < 0 {
+				return fmt.Errorf("%w: invalid index %q", ErrInvalidJSONPointer, lastToken)
+			}
+		}
+
+		if op == JSON_PATCH_ADD_OP {
+			if index > length {
+				return ErrJSONPointerTargetNotFound
+			}
+			mutation = NewInsertElemAtIndexMutation(ctx, index, value, ShallowWatching, path)
+		} else {
+			if index >= length {
+				return ErrJSONPointerTargetNotFound
+			}
+			mutation = NewSetElemAtIndexMutation(ctx, index, value, ShallowWatching, path)
+		}
+	case IProps:
+		exists := slices.Contains(c.PropertyNames(ctx), lastToken)
+
+		if exists {
+			mutation = NewUpdatePropMutation(ctx, lastToken, value, ShallowWatching, path)
+		} else if op == JSON_PATCH_ADD_OP {
+			mutation = NewAddPropMutation(ctx, lastToken, value, ShallowWatching, path)
+		} else {
+			return ErrJSONPointerTargetNotFound
+		}
+	default:
+		return fmt.Errorf("%w: %s on a value of type %T", ErrUnsupportedJSONPatchOperation, op, container)
+	}
+
+	if !mutation.Complete {
+		return fmt.Errorf("failed to serialize the value of the operation")
+	}
+	return mutation.ApplyTo(ctx, container)
+}
+
+func applyJSONPatchRemoveOperation(ctx *Context, root Value, pointer string) error {
+	container, lastToken, err := resolveJSONPointerContainer(ctx, root, pointer)
+	if err != nil {
+		return err
+	}
+
+	seq, ok := container.(MutableLengthSequence)
+	if !ok {
+		return fmt.Errorf("%w: remove on a value of type %T", ErrUnsupportedJSONPatchOperation, container)
+	}
+
+	index, err := strconv.Atoi(lastToken)
+	if err != nil || index < 0 {
+		return fmt.Errorf("%w: invalid index %q", ErrInvalidJSONPointer, lastToken)
+	}
+	if index >= seq.Len() {
+		return ErrJSONPointerTargetNotFound
+	}
+
+	return NewRemovePositionMutation(ctx, index, ShallowWatching, Path(pointer)).ApplyTo(ctx, container)
+}
+
+// resolveJSONPointerContainer returns the value containing the location designated by pointer, as well as the last
+// token of the pointer.
+func resolveJSONPointerContainer(ctx *Context, root Value, pointer string) (Value, string, error) {
+	tokens, err := parseJSONPointer(pointer)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(tokens) == 0 {
+		return nil, "", fmt.Errorf("%w: the root value cannot be the target of the operation", ErrUnsupportedJSONPatchOperation)
+	}
+
+	container, err := resolveJSONPointerTokens(ctx, root, tokens[:len(tokens)-1])
+	if err != nil {
+		return nil, "", err
+	}
+	return container, tokens[len(tokens)-1], nil
+}
+
+func resolveJSONPointerTokens(ctx *Context, root Value, tokens []string) (Value, error) {
+	current := root
+
+	for _, token := range tokens {
+		switch v := current.(type) {
+		case *Dictionary:
+			key, err := ParseJSONRepresentation(ctx, token, nil)
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid dictionary key: %w", ErrInvalidJSONPointer, err)
+			}
+			value, ok := v.Value(ctx, key)
+			if !ok {
+				return nil, ErrJSONPointerTargetNotFound
+			}
+			current = value
+		case Indexable:
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || index >= v.Len() {
+				return nil, ErrJSONPointerTargetNotFound
+			}
+			current = v.At(ctx, index)
+		case IProps:
+			if !slices.Contains(v.PropertyNames(ctx), token) {
+				return nil, ErrJSONPointerTargetNotFound
+			}
+			current = v.Prop(ctx, token)
+		default:
+			return nil, ErrJSONPointerTargetNotFound
+		}
+	}
+
+	return current, nil
+}
+
+// OnJSONPatch registers a mutation callback on v that calls fn with the JSON Patch equivalent of each mutation, it
+// allows external processes and UIs to mirror v. If a mutation is not convertible fn is called with a nil patch
+// and the conversion error, the consumer should then fetch the whole value.
+func OnJSONPatch(ctx *Context, v Watchable, depth WatchingDepth, fn func(ctx *Context, patch JSONPatch, err error)) (CallbackHandle, error) {
+	return v.OnMutation(ctx, func(ctx *Context, mutation Mutation) (registerAgain bool) {
+		patch, err := MutationToJSONPatch(ctx, mutation)
+		fn(ctx, patch, err)
+		return true
+	}, MutationWatchingConfiguration{Depth: depth})
+}
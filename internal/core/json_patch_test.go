@@ -0,0 +1,195 @@
+package core
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMutationToJSONPatch(t *testing.T) {
+	ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+	defer ctx.CancelGracefully()
+
+	testCases := []struct {
+		name     string
+		mutation Mutation
+		expected string
+	}{
+		{
+			"add property",
+			NewAddPropMutation(ctx, "a", Int(1), ShallowWatching, "/a"),
+			`[{"op":"add","path":"/a","value":{"int__value":1}}]`,
+		},
+		{
+			"update nested property",
+			NewUpdatePropMutation(ctx, "b", String("x"), ShallowWatching, "/a/b"),
+			`[{"op":"replace","path":"/a/b","value":"x"}]`,
+		},
+		{
+			"add dictionary entry",
+			NewAddEntryMutation(ctx, String("a"), Int(1), ShallowWatching, `/"a"`),
+			`[{"op":"add","path":"/\"a\"","value":{"int__value":1}}]`,
+		},
+		{
+			"insert element",
+			NewInsertElemAtIndexMutation(ctx, 1, Int(1), ShallowWatching, "/list/1"),
+			`[{"op":"add","path":"/list/1","value":{"int__value":1}}]`,
+		},
+		{
+			"remove element",
+			NewRemovePositionMutation(ctx, 0, ShallowWatching, "/0"),
+			`[{"op":"remove","path":"/0"}]`,
+		},
+		{
+			"insert sequence",
+			NewInsertSequenceAtIndexMutation(ctx, 0, NewWrappedValueList(Int(1), Int(2)), ShallowWatching, "/0"),
+			`[{"op":"add","path":"/0","value":{"int__value":1}},{"op":"add","path":"/1","value":{"int__value":2}}]`,
+		},
+		{
+			"remove position range",
+			NewRemovePositionRangeMutation(ctx, NewIntRange(0, 1), ShallowWatching, "/0..1"),
+			`[{"op":"remove","path":"/1"},{"op":"remove","path":"/0"}]`,
+		},
+		{
+			"remove positions",
+			NewRemovePositionsMutation(ctx, []Int{2, 0}, ShallowWatching, "/"),
+			`[{"op":"remove","path":"/2"},{"op":"remove","path":"/0"}]`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			patch, err := MutationToJSONPatch(ctx, testCase.mutation)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			bytes, err := json.Marshal(patch)
+			if assert.NoError(t, err) {
+				assert.JSONEq(t, testCase.expected, string(bytes))
+			}
+		})
+	}
+
+	t.Run("incomplete mutation", func(t *testing.T) {
+		_, err := MutationToJSONPatch(ctx, NewUnspecifiedMutation(ShallowWatching, "/"))
+		assert.ErrorIs(t, err, ErrMutationNotConvertibleToJSONPatch)
+	})
+}
+
+func TestApplyJSONPatch(t *testing.T) {
+
+	parsePatch := func(t *testing.T, s string) JSONPatch {
+		var patch JSONPatch
+		if !assert.NoError(t, json.Unmarshal([]byte(s), &patch)) {
+			t.FailNow()
+		}
+		return patch
+	}
+
+	t.Run("plain JSON values", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		obj := objFrom(ValMap{"list": NewWrappedValueList(String("a"))})
+
+		err := ApplyJSONPatch(ctx, obj, parsePatch(t, `[
+			{"op": "add", "path": "/name", "value": "b"},
+			{"op": "replace", "path": "/name", "value": "c"},
+			{"op": "add", "path": "/list/-", "value": "d"},
+			{"op": "add", "path": "/list/0", "value": "e"},
+			{"op": "remove", "path": "/list/1"},
+			{"op": "copy", "from": "/name", "path": "/list/0"},
+			{"op": "test", "path": "/list/2", "value": "d"}
+		]`))
+
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.Equal(t, String("c"), obj.Prop(ctx, "name"))
+		assert.Equal(t, []Serializable{String("c"), String("e"), String("d")}, obj.Prop(ctx, "list").(*List).GetOrBuildElements(ctx))
+	})
+
+	t.Run("failed test", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		obj := objFrom(ValMap{"a": String("a")})
+
+		err := ApplyJSONPatch(ctx, obj, parsePatch(t, `[{"op": "test", "path": "/a", "value": "b"}]`))
+		assert.ErrorIs(t, err, ErrJSONPatchTestFailed)
+	})
+
+	t.Run("removing a property is not supported", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		obj := objFrom(ValMap{"a": String("a")})
+
+		err := ApplyJSONPatch(ctx, obj, parsePatch(t, `[{"op": "remove", "path": "/a"}]`))
+		assert.ErrorIs(t, err, ErrUnsupportedJSONPatchOperation)
+	})
+
+	t.Run("target not found", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		obj := objFrom(ValMap{})
+
+		err := ApplyJSONPatch(ctx, obj, parsePatch(t, `[{"op": "replace", "path": "/a", "value": 1}]`))
+		assert.ErrorIs(t, err, ErrJSONPointerTargetNotFound)
+
+		err = ApplyJSONPatch(ctx, obj, parsePatch(t, `[{"op": "add", "path": "/a/b", "value": 1}]`))
+		assert.ErrorIs(t, err, ErrJSONPointerTargetNotFound)
+	})
+
+	t.Run("mirroring a watched value", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		source := NewObjectFromMap(ValMap{
+			"list": NewWrappedValueList(Int(1)),
+			"dict": NewDictionary(ValMap{`"a"`: Int(1)}),
+		}, ctx)
+
+		mirror := NewObjectFromMap(ValMap{
+			"list": NewWrappedValueList(Int(1)),
+			"dict": NewDictionary(ValMap{`"a"`: Int(1)}),
+		}, ctx)
+
+		var patches []JSONPatch
+		_, err := OnJSONPatch(ctx, source, DeepWatching, func(ctx *Context, patch JSONPatch, err error) {
+			if assert.NoError(t, err) {
+				patches = append(patches, patch)
+			}
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		source.SetProp(ctx, "name", String("x"))
+		source.Prop(ctx, "list").(*List).append(ctx, Int(2))
+		source.Prop(ctx, "list").(*List).set(ctx, 0, Int(0))
+		source.Prop(ctx, "dict").(*Dictionary).SetValue(ctx, String("b"), Int(2))
+
+		if !assert.Len(t, patches, 4) {
+			return
+		}
+
+		for _, patch := range patches {
+			//serialize the patch as an external consumer would do.
+			bytes, err := json.Marshal(patch)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			if !assert.NoError(t, ApplyJSONPatch(ctx, mirror, parsePatch(t, string(bytes)))) {
+				return
+			}
+		}
+
+		assert.True(t, source.Equal(ctx, mirror, map[uintptr]uintptr{}, 0))
+	})
+}
@@ -0,0 +1,388 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+)
+
+const (
+	JSON_SCHEMA_DRAFT_2020_12_URI = "https://json-schema.org/draft/2020-12/schema"
+	MAX_JSON_SCHEMA_EXPORT_DEPTH  = 50
+)
+
+var (
+	ErrPatternNotConvertibleToJsonSchema = errors.New("pattern is not convertible to JSON Schema")
+	ErrJsonSchemaExportDepthExceeded     = errors.New("maximum depth reached during JSON Schema export")
+)
+
+// ConvertPatternToJsonSchema converts an Inox pattern to a JSON Schema (draft 2020-12). The named patterns of ctx that
+// are not default patterns are exported in $defs and referenced with $ref, this allows recursive patterns to be
+// exported. An error wrapping ErrPatternNotConvertibleToJsonSchema is returned if the pattern or one of its sub patterns
+// has no JSON Schema equivalent.
+func ConvertPatternToJsonSchema(ctx *Context, pattern Pattern) (string, error) {
+	exporter := &jsonSchemaExporter{
+		ctx:   ctx,
+		names: map[Pattern]string{},
+		defs:  map[string]any{},
+	}
+
+	ctx.ForEachNamedPattern(func(name string, namedPattern Pattern) error {
+		if defaultPattern, ok := DEFAULT_NAMED_PATTERNS[name]; ok && defaultPattern == namedPattern {
+			return nil
+		}
+		exporter.names[namedPattern] = name
+		return nil
+	})
+
+	schema, err := exporter.convert(pattern, 0)
+	if err != nil {
+		return "", err
+	}
+
+	root, ok := schema.(map[string]any)
+	if !ok { //boolean schema
+		root = map[string]any{}
+		if schema == false {
+			root["not"] = map[string]any{}
+		}
+	}
+
+	root["$schema"] = JSON_SCHEMA_DRAFT_2020_12_URI
+	if len(exporter.defs) > 0 {
+		root["$defs"] = exporter.defs
+	}
+
+	bytes, err := json.Marshal(root)
+	if err != nil {
+		return "", err
+	}
+	return string(bytes), nil
+}
+
+type jsonSchemaExporter struct {
+	ctx   *Context
+	names map[Pattern]string //non-default named patterns
+	defs  map[string]any
+}
+
+// convert returns the schema of a pattern: either a map or a boolean.
+func (e *jsonSchemaExporter) convert(pattern Pattern, depth int) (any, error) {
+	if depth > MAX_JSON_SCHEMA_EXPORT_DEPTH {
+		return nil, ErrJsonSchemaExportDepthExceeded
+	}
+
+	if name, ok := e.names[pattern]; ok {
+		ref := map[string]any{"$ref": "#/$defs/" + name}
+
+		if _, ok := e.defs[name]; ok {
+			return ref, nil
+		}
+
+		//set a placeholder to support recursive patterns.
+		e.defs[name] = true
+
+		def, err := e.convertUnnamed(pattern, depth)
+		if err != nil {
+			return nil, fmt.Errorf("%%%s: %w", name, err)
+		}
+		e.defs[name] = def
+		return ref, nil
+	}
+
+	return e.convertUnnamed(pattern, depth)
+}
+
+func (e *jsonSchemaExporter) convertUnnamed(pattern Pattern, depth int) (any, error) {
+	switch pattern {
+	case ANYVAL_PATTERN, SERIALIZABLE_PATTERN:
+		return true, nil
+	case NEVER_PATTERN:
+		return false, nil
+	case INT_PATTERN:
+		return map[string]any{"type": "integer"}, nil
+	case FLOAT_PATTERN:
+		return map[string]any{"type": "number"}, nil
+	case STR_PATTERN:
+		return map[string]any{"type": "string"}, nil
+	case BOOL_PATTERN:
+		return map[string]any{"type": "boolean"}, nil
+	case NIL_PATTERN:
+		return map[string]any{"type": "null"}, nil
+	case OBJECT_PATTERN, RECORD_PATTERN:
+		return map[string]any{"type": "object"}, nil
+	case LIST_PATTERN, TUPLE_PATTERN:
+		return map[string]any{"type": "array"}, nil
+	}
+
+	switch p := pattern.(type) {
+	case *ExactValuePattern:
+		constant, err := convertSerializableToJsonSchemaConst(p.value)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"const": constant}, nil
+	case *ExactStringPattern:
+		return map[string]any{"const": string(p.value)}, nil
+	case *UnionPattern:
+		cases, err := e.convertAll(p.cases, depth)
+		if err != nil {
+			return nil, err
+		}
+		if p.disjoint {
+			return map[string]any{"oneOf": cases}, nil
+		}
+		return map[string]any{"anyOf": cases}, nil
+	case *IntersectionPattern:
+		cases, err := e.convertAll(p.cases, depth)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"allOf": cases}, nil
+	case *DifferencePattern:
+		removed, err := e.convert(p.removed, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		negation := map[string]any{"not": removed}
+
+		if p.base == ANYVAL_PATTERN || p.base == SERIALIZABLE_PATTERN {
+			return negation, nil
+		}
+
+		base, err := e.convert(p.base, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"allOf": []any{base, negation}}, nil
+	case *OptionalPattern:
+		schema, err := e.convert(p.pattern, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"anyOf": []any{schema, map[string]any{"type": "null"}}}, nil
+	case *IntRangePattern:
+		schema := map[string]any{"type": "integer"}
+		if p.intRange.HasKnownStart() && p.intRange.start != math.MinInt64 {
+			schema["minimum"] = p.intRange.start
+		}
+		if p.intRange.InclusiveEnd() != math.MaxInt64 {
+			schema["maximum"] = p.intRange.InclusiveEnd()
+		}
+		if p.multipleOfFloat != nil {
+			schema["multipleOf"] = float64(*p.multipleOfFloat)
+		} else if p.multipleOf > 0 {
+			schema["multipleOf"] = int64(p.multipleOf)
+		}
+		return schema, nil
+	case *FloatRangePattern:
+		schema := map[string]any{"type": "number"}
+		if p.floatRange.HasKnownStart() && !math.IsInf(p.floatRange.start, -1) {
+			schema["minimum"] = p.floatRange.start
+		}
+		if !math.IsInf(p.floatRange.end, 1) {
+			if p.floatRange.inclusiveEnd {
+				schema["maximum"] = p.floatRange.end
+			} else {
+				schema["exclusiveMaximum"] = p.floatRange.end
+			}
+		}
+		if p.multipleOf > 0 {
+			schema["multipleOf"] = float64(p.multipleOf)
+		}
+		return schema, nil
+	case *LengthCheckingStringPattern:
+		schema := map[string]any{"type": "string"}
+		addJsonSchemaLengthRange(schema, p.lengthRange)
+		return schema, nil
+	case *RegexPattern:
+		//like JSON Schema's pattern keyword regex patterns are not anchored.
+		schema := map[string]any{"type": "string", "pattern": p.Regex()}
+		if p.hasEffectiveLengthRange {
+			addJsonSchemaLengthRange(schema, p.effectiveLengthRange)
+		}
+		return schema, nil
+	case StringPattern:
+		if !p.HasRegex() {
+			return nil, fmt.Errorf("%w: string pattern without regex (%T)", ErrPatternNotConvertibleToJsonSchema, p)
+		}
+		//other string patterns match entire strings.
+		schema := map[string]any{"type": "string", "pattern": "^(?:" + p.Regex() + ")$"}
+		addJsonSchemaLengthRange(schema, p.EffectiveLengthRange())
+		return schema, nil
+	case *ObjectPattern:
+		if len(p.complexPropertyPatterns) > 0 {
+			return nil, fmt.Errorf("%w: object patterns with complex property patterns", ErrPatternNotConvertibleToJsonSchema)
+		}
+
+		schema := map[string]any{"type": "object"}
+		properties := map[string]any{}
+		required := []string{}
+		dependentRequired := map[string]any{}
+		dependentSchemas := map[string]any{}
+
+		err := p.ForEachEntry(func(entry ObjectPatternEntry) error {
+			propSchema, err := e.convert(entry.Pattern, depth+1)
+			if err != nil {
+				return fmt.Errorf(".%s: %w", entry.Name, err)
+			}
+			properties[entry.Name] = propSchema
+			if !entry.IsOptional {
+				required = append(required, entry.Name)
+			}
+
+			if len(entry.Dependencies.RequiredKeys) > 0 {
+				dependentRequired[entry.Name] = entry.Dependencies.RequiredKeys
+			}
+			if entry.Dependencies.Pattern != nil {
+				dependencySchema, err := e.convert(entry.Dependencies.Pattern, depth+1)
+				if err != nil {
+					return fmt.Errorf("dependencies of .%s: %w", entry.Name, err)
+				}
+				dependentSchemas[entry.Name] = dependencySchema
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		addJsonSchemaProperties(schema, properties, required, p.inexact)
+		if len(dependentRequired) > 0 {
+			schema["dependentRequired"] = dependentRequired
+		}
+		if len(dependentSchemas) > 0 {
+			schema["dependentSchemas"] = dependentSchemas
+		}
+		return schema, nil
+	case *RecordPattern:
+		schema := map[string]any{"type": "object"}
+		properties := map[string]any{}
+		required := []string{}
+
+		err := p.ForEachEntry(func(entry RecordPatternEntry) error {
+			propSchema, err := e.convert(entry.Pattern, depth+1)
+			if err != nil {
+				return fmt.Errorf(".%s: %w", entry.Name, err)
+			}
+			properties[entry.Name] = propSchema
+			if !entry.IsOptional {
+				required = append(required, entry.Name)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		addJsonSchemaProperties(schema, properties, required, p.inexact)
+		return schema, nil
+	case *ListPattern:
+		schema, err := e.convertSequence(p.elementPatterns, p.generalElementPattern, depth)
+		if err != nil {
+			return nil, err
+		}
+
+		if p.elementPatterns == nil && p.minElemCountPlusOne > 0 {
+			if minCount := p.MinElementCount(); minCount > 0 {
+				schema["minItems"] = minCount
+			}
+			if maxCount := p.MaxElementCount(); maxCount != math.MaxInt64 {
+				schema["maxItems"] = maxCount
+			}
+		}
+
+		if p.containedElement != nil {
+			contained, err := e.convert(p.containedElement, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			schema["contains"] = contained
+		}
+		return schema, nil
+	case *TuplePattern:
+		return e.convertSequence(p.elementPatterns, p.generalElementPattern, depth)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrPatternNotConvertibleToJsonSchema, Stringify(pattern, e.ctx))
+	}
+}
+
+func (e *jsonSchemaExporter) convertAll(patterns []Pattern, depth int) ([]any, error) {
+	schemas := make([]any, len(patterns))
+	for i, pattern := range patterns {
+		schema, err := e.convert(pattern, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		schemas[i] = schema
+	}
+	return schemas, nil
+}
+
+// convertSequence converts the element patterns of a list or tuple pattern.
+func (e *jsonSchemaExporter) convertSequence(elementPatterns []Pattern, generalElementPattern Pattern, depth int) (map[string]any, error) {
+	schema := map[string]any{"type": "array"}
+
+	if elementPatterns != nil {
+		prefixItems, err := e.convertAll(elementPatterns, depth)
+		if err != nil {
+			return nil, err
+		}
+		if len(prefixItems) > 0 {
+			schema["prefixItems"] = prefixItems
+		}
+		schema["items"] = false
+		schema["minItems"] = len(elementPatterns)
+		return schema, nil
+	}
+
+	items, err := e.convert(generalElementPattern, depth+1)
+	if err != nil {
+		return nil, err
+	}
+	if items != true {
+		schema["items"] = items
+	}
+	return schema, nil
+}
+
+func addJsonSchemaProperties(schema map[string]any, properties map[string]any, required []string, inexact bool) {
+	if len(properties) > 0 {
+		schema["properties"] = properties
+	}
+	if len(required) > 0 {
+		slices.Sort(required)
+		schema["required"] = required
+	}
+	if !inexact {
+		schema["additionalProperties"] = false
+	}
+}
+
+func addJsonSchemaLengthRange(schema map[string]any, lengthRange IntRange) {
+	if lengthRange.HasKnownStart() && lengthRange.start > 0 {
+		schema["minLength"] = lengthRange.start
+	}
+	if lengthRange.end >= 0 && lengthRange.end != math.MaxInt64 {
+		schema["maxLength"] = lengthRange.end
+	}
+}
+
+func convertSerializableToJsonSchemaConst(v Serializable) (any, error) {
+	switch val := v.(type) {
+	case Int:
+		return int64(val), nil
+	case Float:
+		return float64(val), nil
+	case String:
+		return string(val), nil
+	case Bool:
+		return bool(val), nil
+	case NilT:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("%w: exact value pattern with a value of type %T", ErrPatternNotConvertibleToJsonSchema, v)
+	}
+}
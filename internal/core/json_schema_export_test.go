@@ -0,0 +1,305 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertPatternToJsonSchema(t *testing.T) {
+
+	ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+	defer ctx.CancelGracefully()
+
+	//convert converts the pattern and removes the $schema keyword.
+	convert := func(t *testing.T, ctx *Context, pattern Pattern) map[string]any {
+		s, err := ConvertPatternToJsonSchema(ctx, pattern)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		var schema map[string]any
+		if !assert.NoError(t, json.Unmarshal([]byte(s), &schema)) {
+			t.FailNow()
+		}
+
+		assert.Equal(t, JSON_SCHEMA_DRAFT_2020_12_URI, schema["$schema"])
+		delete(schema, "$schema")
+		return schema
+	}
+
+	t.Run("base patterns", func(t *testing.T) {
+		assert.Equal(t, map[string]any{"type": "integer"}, convert(t, ctx, INT_PATTERN))
+		assert.Equal(t, map[string]any{"type": "string"}, convert(t, ctx, STR_PATTERN))
+		assert.Equal(t, map[string]any{}, convert(t, ctx, ANYVAL_PATTERN))
+		assert.Equal(t, map[string]any{"not": map[string]any{}}, convert(t, ctx, NEVER_PATTERN))
+	})
+
+	t.Run("object pattern", func(t *testing.T) {
+		pattern := NewExactObjectPattern([]ObjectPatternEntry{
+			{Name: "a", Pattern: INT_PATTERN},
+			{Name: "b", Pattern: STR_PATTERN, IsOptional: true},
+		})
+
+		assert.Equal(t, map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"a": map[string]any{"type": "integer"},
+				"b": map[string]any{"type": "string"},
+			},
+			"required":             []any{"a"},
+			"additionalProperties": false,
+		}, convert(t, ctx, pattern))
+
+		inexactPattern := NewInexactObjectPattern([]ObjectPatternEntry{{Name: "a", Pattern: INT_PATTERN}})
+		assert.NotContains(t, convert(t, ctx, inexactPattern), "additionalProperties")
+	})
+
+	t.Run("record pattern", func(t *testing.T) {
+		pattern := NewInexactRecordPattern([]RecordPatternEntry{{Name: "a", Pattern: BOOL_PATTERN}})
+
+		assert.Equal(t, map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"a": map[string]any{"type": "boolean"},
+			},
+			"required": []any{"a"},
+		}, convert(t, ctx, pattern))
+	})
+
+	t.Run("list pattern", func(t *testing.T) {
+		assert.Equal(t, map[string]any{
+			"type":  "array",
+			"items": map[string]any{"type": "integer"},
+		}, convert(t, ctx, NewListPatternOf(INT_PATTERN)))
+
+		assert.Equal(t, map[string]any{
+			"type":        "array",
+			"prefixItems": []any{map[string]any{"type": "integer"}, map[string]any{"type": "string"}},
+			"items":       false,
+			"minItems":    float64(2),
+		}, convert(t, ctx, NewListPatternVariadic(INT_PATTERN, STR_PATTERN)))
+
+		assert.Equal(t, map[string]any{
+			"type":     "array",
+			"items":    map[string]any{"type": "integer"},
+			"minItems": float64(1),
+			"maxItems": float64(3),
+		}, convert(t, ctx, NewListPatternOf(INT_PATTERN).WithMinMaxElements(1, 3)))
+	})
+
+	t.Run("tuple pattern", func(t *testing.T) {
+		assert.Equal(t, map[string]any{
+			"type":  "array",
+			"items": map[string]any{"type": "boolean"},
+		}, convert(t, ctx, NewTuplePatternOf(BOOL_PATTERN)))
+	})
+
+	t.Run("union pattern", func(t *testing.T) {
+		pattern := NewUnionPattern([]Pattern{INT_PATTERN, STR_PATTERN}, nil)
+
+		assert.Equal(t, map[string]any{
+			"anyOf": []any{map[string]any{"type": "integer"}, map[string]any{"type": "string"}},
+		}, convert(t, ctx, pattern))
+	})
+
+	t.Run("int range pattern", func(t *testing.T) {
+		assert.Equal(t, map[string]any{
+			"type":       "integer",
+			"minimum":    float64(1),
+			"maximum":    float64(10),
+			"multipleOf": float64(2),
+		}, convert(t, ctx, NewIncludedEndIntRangePattern(1, 10, 2)))
+	})
+
+	t.Run("string patterns", func(t *testing.T) {
+		assert.Equal(t, map[string]any{
+			"type":      "string",
+			"minLength": float64(1),
+			"maxLength": float64(5),
+		}, convert(t, ctx, NewLengthCheckingStringPattern(1, 5)))
+
+		assert.Equal(t, map[string]any{
+			"type":    "string",
+			"pattern": "a+",
+		}, convert(t, ctx, NewRegexPattern("a+")))
+
+		sequence, err := NewSequenceStringPattern(nil, nil, []StringPattern{
+			NewExactStringPattern("id-"),
+			NewIntRangeStringPattern(0, 9, nil),
+		}, []string{"", ""})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		schema := convert(t, ctx, sequence)
+		if !assert.Contains(t, schema, "pattern") {
+			return
+		}
+		regex := schema["pattern"].(string)
+		assert.True(t, strings.HasPrefix(regex, "^(?:"))
+		assert.True(t, strings.HasSuffix(regex, ")$"))
+	})
+
+	t.Run("named patterns should be exported in $defs", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		user := NewInexactObjectPattern([]ObjectPatternEntry{{Name: "name", Pattern: STR_PATTERN}})
+		ctx.AddNamedPattern("user", user)
+
+		pattern := NewListPatternOf(user)
+
+		assert.Equal(t, map[string]any{
+			"type":  "array",
+			"items": map[string]any{"$ref": "#/$defs/user"},
+			"$defs": map[string]any{
+				"user": map[string]any{
+					"type":       "object",
+					"properties": map[string]any{"name": map[string]any{"type": "string"}},
+					"required":   []any{"name"},
+				},
+			},
+		}, convert(t, ctx, pattern))
+	})
+
+	t.Run("default named patterns should not be exported in $defs", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		for name, pattern := range DEFAULT_NAMED_PATTERNS {
+			ctx.AddNamedPattern(name, pattern)
+		}
+
+		assert.Equal(t, map[string]any{"type": "integer"}, convert(t, ctx, INT_PATTERN))
+	})
+
+	t.Run("not convertible pattern", func(t *testing.T) {
+		_, err := ConvertPatternToJsonSchema(ctx, PATH_PATTERN)
+		assert.ErrorIs(t, err, ErrPatternNotConvertibleToJsonSchema)
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		//Each schema of the test suites is converted to a pattern, the pattern is exported to JSON Schema and
+		//the exported schema should accept the same values as the pattern.
+
+		runTestSuites := func(t *testing.T, suites []jsonDrafTestSuite, skippedTests [][2]string) {
+			for _, testSuite := range suites {
+				t.Run(testSuite.Description, func(t *testing.T) {
+					pattern, err := ConvertJsonSchemaToPattern(string(testSuite.Schema))
+					if err != nil {
+						t.Skip("schema not supported")
+					}
+
+					exported, err := ConvertPatternToJsonSchema(ctx, pattern)
+					if err != nil {
+						t.Skip("pattern not convertible")
+					}
+
+					compiler := jsonschema.NewCompiler()
+					compiler.Draft = jsonschema.Draft2020
+					if !assert.NoError(t, compiler.AddResource("exported.json", strings.NewReader(exported))) {
+						return
+					}
+					schema, err := compiler.Compile("exported.json")
+					if !assert.NoError(t, err, exported) {
+						return
+					}
+
+					for _, test := range testSuite.Tests {
+						t.Run(test.Description, func(t *testing.T) {
+							for _, skippedTest := range skippedTests {
+								if testSuite.Description == skippedTest[0] && skippedTest[1] == test.Description {
+									t.SkipNow()
+								}
+							}
+
+							_, err := ParseJSONRepresentation(ctx, string(test.Data), pattern)
+							accepted := err == nil
+
+							decoder := json.NewDecoder(bytes.NewReader(test.Data))
+							decoder.UseNumber()
+
+							var data any
+							if !assert.NoError(t, decoder.Decode(&data)) {
+								return
+							}
+
+							err = schema.Validate(data)
+							assert.Equal(t, accepted, err == nil, "exported schema: %s", exported)
+						})
+					}
+				})
+			}
+		}
+
+		t.Run("AnyOf", func(t *testing.T) {
+			runTestSuites(t, jsonDraft7.AnyOf, nil)
+		})
+
+		t.Run("Const", func(t *testing.T) {
+			runTestSuites(t, jsonDraft7.Const, nil)
+		})
+
+		t.Run("Enum", func(t *testing.T) {
+			runTestSuites(t, jsonDraft7.Enum, nil)
+		})
+
+		t.Run("MaxItems", func(t *testing.T) {
+			runTestSuites(t, jsonDraft7.MaxItems, nil)
+		})
+
+		t.Run("MaxLength", func(t *testing.T) {
+			runTestSuites(t, jsonDraft7.MaxLength, nil)
+		})
+
+		t.Run("Maximum", func(t *testing.T) {
+			runTestSuites(t, jsonDraft7.Maximum, nil)
+		})
+
+		t.Run("MinItems", func(t *testing.T) {
+			runTestSuites(t, jsonDraft7.MinItems, nil)
+		})
+
+		t.Run("MinLength", func(t *testing.T) {
+			runTestSuites(t, jsonDraft7.MinLength, nil)
+		})
+
+		t.Run("Minimum", func(t *testing.T) {
+			runTestSuites(t, jsonDraft7.Minimum, nil)
+		})
+
+		t.Run("MultipleOf", func(t *testing.T) {
+			runTestSuites(t, jsonDraft7.MultipleOf, [][2]string{
+				//1e308 is an integer in JSON Schema but not in Inox.
+				{"float division = inf", "always invalid, but naive implementations may raise an overflow error"},
+			})
+		})
+
+		t.Run("OneOf", func(t *testing.T) {
+			runTestSuites(t, jsonDraft7.OneOf, nil)
+		})
+
+		t.Run("Pattern", func(t *testing.T) {
+			runTestSuites(t, jsonDraft7.Pattern, nil)
+		})
+
+		t.Run("Properties", func(t *testing.T) {
+			runTestSuites(t, jsonDraft7.Properties, nil)
+		})
+
+		t.Run("Required", func(t *testing.T) {
+			runTestSuites(t, jsonDraft7.Required, nil)
+		})
+
+		t.Run("Type", func(t *testing.T) {
+			runTestSuites(t, jsonDraft7.Type, [][2]string{
+				{"integer type matches integers", "a string is still not an integer, even if it looks like one"},
+			})
+		})
+	})
+}
@@ -0,0 +1,393 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	DEFAULT_MAX_JSON_STREAM_RECORD_SIZE = 100_000_000
+	DEFAULT_JSON_STREAM_READ_TIMEOUT    = 10 * time.Second
+	JSON_STREAM_READ_BUFFER_SIZE        = 64_000
+	MAX_REPORTED_SKIPPED_JSON_RECORDS   = 1000
+)
+
+var (
+	ErrJSONStreamRecordTooLarge   = errors.New("JSON record is too large")
+	ErrJSONStreamNotAnArray       = errors.New("the JSON stream should be an array")
+	ErrUnterminatedJSONArray      = errors.New("unterminated JSON array")
+	ErrContentAfterJSONArray      = errors.New("unexpected content after the JSON array")
+	ErrUnexpectedCharInJSONStream = errors.New("unexpected character in the JSON stream")
+)
+
+type JSONStreamFormat int
+
+const (
+	JSONArrayStream JSONStreamFormat = iota //a single JSON array, the records are the elements.
+	JSONLinesStream                         //JSONL, each non-empty line is a record.
+)
+
+type JSONStreamReaderConfig struct {
+	Format JSONStreamFormat
+
+	//Pattern of the records, if nil the records are parsed as untyped JSON representations.
+	Pattern Pattern
+
+	//If true invalid records are skipped instead of stopping the reading, they are still reported by SkippedRecords.
+	//Syntax errors that make it impossible to find the next record are never skipped.
+	SkipInvalidRecords bool
+
+	MaxRecordSize int //defaults to DEFAULT_MAX_JSON_STREAM_RECORD_SIZE
+}
+
+// An InvalidJSONRecordError is returned (or recorded in skip-on-error mode) when a record does not match the pattern
+// or is not valid JSON.
+type InvalidJSONRecordError struct {
+	Index  int   //index of the record in the stream
+	Offset int64 //byte offset of the start of the record
+	Err    error
+}
+
+func (e *InvalidJSONRecordError) Error() string {
+	return fmt.Sprintf("invalid record at index %d (byte offset %d): %s", e.Index, e.Offset, e.Err)
+}
+
+func (e *InvalidJSONRecordError) Unwrap() error {
+	return e.Err
+}
+
+// A JSONStreamReader reads records from a JSON array or a JSONL stream one at a time, each record is validated against
+// the pattern of the configuration. The stream is never fully loaded in memory: the reader only buffers the current
+// record.
+type JSONStreamReader struct {
+	ctx    *Context
+	reader *bufio.Reader
+	config JSONStreamReaderConfig
+
+	offset       int64 //offset of the next byte to read
+	recordIndex  int
+	started      bool
+	ended        bool
+	fatalErr     error
+	skippedCount int
+	skipped      []*InvalidJSONRecordError
+
+	recordBuf []byte
+}
+
+func NewJSONStreamReader(ctx *Context, r io.Reader, config JSONStreamReaderConfig) *JSONStreamReader {
+	if config.MaxRecordSize <= 0 {
+		config.MaxRecordSize = DEFAULT_MAX_JSON_STREAM_RECORD_SIZE
+	}
+
+	return &JSONStreamReader{
+		ctx:    ctx,
+		reader: bufio.NewReaderSize(r, JSON_STREAM_READ_BUFFER_SIZE),
+		config: config,
+	}
+}
+
+// NewJSONStreamReaderFromByteStream creates a JSONStreamReader reading from a byte stream, readTimeout is the
+// maximum duration to wait for the next bytes (DEFAULT_JSON_STREAM_READ_TIMEOUT if zero).
+func NewJSONStreamReaderFromByteStream(ctx *Context, stream *ReadableByteStream, readTimeout time.Duration, config JSONStreamReaderConfig) *JSONStreamReader {
+	if readTimeout <= 0 {
+		readTimeout = DEFAULT_JSON_STREAM_READ_TIMEOUT
+	}
+	return NewJSONStreamReader(ctx, &byteStreamReader{ctx: ctx, stream: stream, timeout: readTimeout}, config)
+}
+
+// Next returns the next valid record, ErrEndOfStream is returned after the last record. If a record is invalid
+// and the skip-on-error mode is disabled an *InvalidJSONRecordError is returned and the reading can be continued.
+func (r *JSONStreamReader) Next() (Serializable, error) {
+	for {
+		if r.fatalErr != nil {
+			return nil, r.fatalErr
+		}
+
+		if r.ctx.IsDoneSlowCheck() {
+			return nil, r.ctx.Err()
+		}
+
+		record, recordOffset, err := r.readRecord()
+		if err != nil {
+			r.fatalErr = err
+			return nil, err
+		}
+
+		if record == nil {
+			r.fatalErr = ErrEndOfStream
+			return nil, ErrEndOfStream
+		}
+
+		index := r.recordIndex
+		r.recordIndex++
+
+		value, err := ParseJSONRepresentation(r.ctx, string(record), r.config.Pattern)
+		if err == nil {
+			return value, nil
+		}
+
+		recordErr := &InvalidJSONRecordError{
+			Index:  index,
+			Offset: recordOffset,
+			Err:    err,
+		}
+
+		if !r.config.SkipInvalidRecords {
+			return nil, recordErr
+		}
+
+		r.skippedCount++
+		if len(r.skipped) < MAX_REPORTED_SKIPPED_JSON_RECORDS {
+			r.skipped = append(r.skipped, recordErr)
+		}
+	}
+}
+
+// SkippedRecords returns the number of records skipped because they were invalid and the errors of the first skipped
+// records (at most MAX_REPORTED_SKIPPED_JSON_RECORDS).
+func (r *JSONStreamReader) SkippedRecords() (int, []*InvalidJSONRecordError) {
+	return r.skippedCount, r.skipped
+}
+
+// Offset returns the number of bytes consumed.
+func (r *JSONStreamReader) Offset() int64 {
+	return r.offset
+}
+
+// readRecord returns the bytes of the next record, (nil, 0, nil) is returned if there are no more records.
+func (r *JSONStreamReader) readRecord() ([]byte, int64, error) {
+	if r.ended {
+		return nil, 0, nil
+	}
+
+	switch r.config.Format {
+	case JSONLinesStream:
+		return r.readLine()
+	default:
+		return r.readArrayElement()
+	}
+}
+
+func (r *JSONStreamReader) readByte() (byte, error) {
+	b, err := r.reader.ReadByte()
+	if err == nil {
+		r.offset++
+	}
+	return b, err
+}
+
+func (r *JSONStreamReader) unreadByte() {
+	r.reader.UnreadByte()
+	r.offset--
+}
+
+func (r *JSONStreamReader) appendToRecord(b byte) error {
+	if len(r.recordBuf) >= r.config.MaxRecordSize {
+		return ErrJSONStreamRecordTooLarge
+	}
+	r.recordBuf = append(r.recordBuf, b)
+	return nil
+}
+
+func (r *JSONStreamReader) readLine() ([]byte, int64, error) {
+	for {
+		r.recordBuf = r.recordBuf[:0]
+		lineOffset := r.offset
+
+		for {
+			b, err := r.readByte()
+			if err == io.EOF {
+				r.ended = true
+				break
+			}
+			if err != nil {
+				return nil, 0, err
+			}
+			if b == '\n' {
+				break
+			}
+			if err := r.appendToRecord(b); err != nil {
+				return nil, 0, fmt.Errorf("line at byte offset %d: %w", lineOffset, err)
+			}
+		}
+
+		line := bytes.TrimSpace(r.recordBuf)
+		if len(line) != 0 {
+			return line, lineOffset + int64(bytes.Index(r.recordBuf, line[:1])), nil
+		}
+
+		if r.ended {
+			return nil, 0, nil
+		}
+	}
+}
+
+func (r *JSONStreamReader) skipWhitespace() (byte, error) {
+	for {
+		b, err := r.readByte()
+		if err != nil {
+			return 0, err
+		}
+		switch b {
+		case ' ', '\t', '\n', '\r':
+		default:
+			return b, nil
+		}
+	}
+}
+
+func (r *JSONStreamReader) readArrayElement() ([]byte, int64, error) {
+	if !r.started {
+		r.started = true
+		b, err := r.skipWhitespace()
+		if err == io.EOF || (err == nil && b != '[') {
+			return nil, 0, fmt.Errorf("%w (byte offset %d)", ErrJSONStreamNotAnArray, r.offset)
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+
+		b, err = r.skipWhitespace()
+		if err == io.EOF {
+			return nil, 0, ErrUnterminatedJSONArray
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		if b == ']' { //empty array
+			return nil, 0, r.end()
+		}
+		r.unreadByte()
+	}
+
+	b, err := r.skipWhitespace()
+	if err == io.EOF {
+		return nil, 0, ErrUnterminatedJSONArray
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	elementOffset := r.offset - 1
+	r.unreadByte()
+	r.recordBuf = r.recordBuf[:0]
+
+	if b == ',' || b == ']' {
+		return nil, 0, fmt.Errorf("%w %q at byte offset %d", ErrUnexpectedCharInJSONStream, b, elementOffset)
+	}
+
+	//read until the comma or closing bracket that ends the element.
+
+	depth := 0
+	inString := false
+	escaped := false
+
+	for {
+		b, err := r.readByte()
+		if err == io.EOF {
+			return nil, 0, ErrUnterminatedJSONArray
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case b == '\\':
+				escaped = true
+			case b == '"':
+				inString = false
+			}
+		} else {
+			switch b {
+			case '"':
+				inString = true
+			case '[', '{':
+				depth++
+			case ']', '}':
+				if depth == 0 {
+					if b == '}' {
+						return nil, 0, fmt.Errorf("%w '}' at byte offset %d", ErrUnexpectedCharInJSONStream, r.offset-1)
+					}
+					//end of the array
+					if err := r.end(); err != nil {
+						return nil, 0, err
+					}
+					return bytes.TrimSpace(r.recordBuf), elementOffset, nil
+				}
+				depth--
+			case ',':
+				if depth == 0 {
+					return bytes.TrimSpace(r.recordBuf), elementOffset, nil
+				}
+			}
+		}
+
+		if err := r.appendToRecord(b); err != nil {
+			return nil, 0, fmt.Errorf("element at byte offset %d: %w", elementOffset, err)
+		}
+	}
+}
+
+// end is called after the closing bracket of the array, only whitespace is allowed after it.
+func (r *JSONStreamReader) end() error {
+	r.ended = true
+
+	_, err := r.skipWhitespace()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("%w (byte offset %d)", ErrContentAfterJSONArray, r.offset-1)
+}
+
+// byteStreamReader is an io.Reader reading from a ReadableByteStream.
+type byteStreamReader struct {
+	ctx     *Context
+	stream  *ReadableByteStream
+	timeout time.Duration
+	ended   bool
+}
+
+func (r *byteStreamReader) Read(p []byte) (int, error) {
+	if r.ended {
+		return 0, io.EOF
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	chunk, err := r.stream.WaitNextChunk(r.ctx, nil, NewIntRange(1, int64(len(p))), r.timeout)
+	if errors.Is(err, ErrEndOfStream) {
+		r.ended = true
+		err = nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	//the stream ended without returning a last chunk.
+	if chunk == nil {
+		return 0, io.EOF
+	}
+
+	data, err := chunk.Data(r.ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	n := copy(p, data.(*ByteSlice).UnderlyingBytes())
+	if n == 0 && r.ended {
+		return 0, io.EOF
+	}
+	return n, nil
+}
@@ -0,0 +1,258 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONStreamReader(t *testing.T) {
+
+	//readAll reads all records, invalid records are returned as errors.
+	readAll := func(reader *JSONStreamReader) (values []Serializable, errs []error, finalErr error) {
+		for {
+			v, err := reader.Next()
+			if err == ErrEndOfStream {
+				return
+			}
+			if _, ok := err.(*InvalidJSONRecordError); ok {
+				errs = append(errs, err)
+				continue
+			}
+			if err != nil {
+				finalErr = err
+				return
+			}
+			values = append(values, v)
+		}
+	}
+
+	t.Run("JSON array", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		reader := NewJSONStreamReader(ctx, strings.NewReader(` [1, 2 , 3] `), JSONStreamReaderConfig{
+			Format:  JSONArrayStream,
+			Pattern: INT_PATTERN,
+		})
+
+		values, errs, err := readAll(reader)
+		assert.NoError(t, err)
+		assert.Empty(t, errs)
+		assert.Equal(t, []Serializable{Int(1), Int(2), Int(3)}, values)
+
+		//ErrEndOfStream should be returned again.
+		_, err = reader.Next()
+		assert.ErrorIs(t, err, ErrEndOfStream)
+	})
+
+	t.Run("empty JSON array", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		reader := NewJSONStreamReader(ctx, strings.NewReader(`[ ]`), JSONStreamReaderConfig{Pattern: INT_PATTERN})
+
+		values, errs, err := readAll(reader)
+		assert.NoError(t, err)
+		assert.Empty(t, errs)
+		assert.Empty(t, values)
+	})
+
+	t.Run("JSON array of objects containing delimiters in strings", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+		pattern := NewInexactObjectPattern([]ObjectPatternEntry{{Name: "s", Pattern: STR_PATTERN}})
+
+		reader := NewJSONStreamReader(ctx, strings.NewReader(`[{"s": "],}\""}, {"s": "[{,"}]`), JSONStreamReaderConfig{
+			Pattern: pattern,
+		})
+
+		values, errs, err := readAll(reader)
+		assert.NoError(t, err)
+		assert.Empty(t, errs)
+		if assert.Len(t, values, 2) {
+			assert.Equal(t, String(`],}"`), values[0].(*Object).Prop(ctx, "s"))
+			assert.Equal(t, String(`[{,`), values[1].(*Object).Prop(ctx, "s"))
+		}
+	})
+
+	t.Run("JSONL", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		reader := NewJSONStreamReader(ctx, strings.NewReader("1\n\n 2\n3"), JSONStreamReaderConfig{
+			Format:  JSONLinesStream,
+			Pattern: INT_PATTERN,
+		})
+
+		values, errs, err := readAll(reader)
+		assert.NoError(t, err)
+		assert.Empty(t, errs)
+		assert.Equal(t, []Serializable{Int(1), Int(2), Int(3)}, values)
+	})
+
+	t.Run("the index and byte offset of invalid records should be reported", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		reader := NewJSONStreamReader(ctx, strings.NewReader("1\n\"a\"\n3\n  true"), JSONStreamReaderConfig{
+			Format:  JSONLinesStream,
+			Pattern: INT_PATTERN,
+		})
+
+		values, errs, err := readAll(reader)
+		assert.NoError(t, err)
+		assert.Equal(t, []Serializable{Int(1), Int(3)}, values)
+
+		if assert.Len(t, errs, 2) {
+			firstErr := errs[0].(*InvalidJSONRecordError)
+			assert.Equal(t, 1, firstErr.Index)
+			assert.EqualValues(t, 2, firstErr.Offset)
+
+			secondErr := errs[1].(*InvalidJSONRecordError)
+			assert.Equal(t, 3, secondErr.Index)
+			assert.EqualValues(t, 10, secondErr.Offset)
+		}
+	})
+
+	t.Run("skip-on-error mode", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		reader := NewJSONStreamReader(ctx, strings.NewReader(`[1, "a", {"x": }, 4]`), JSONStreamReaderConfig{
+			Pattern:            INT_PATTERN,
+			SkipInvalidRecords: true,
+		})
+
+		values, errs, err := readAll(reader)
+		assert.NoError(t, err)
+		assert.Empty(t, errs)
+		assert.Equal(t, []Serializable{Int(1), Int(4)}, values)
+
+		count, skipped := reader.SkippedRecords()
+		assert.Equal(t, 2, count)
+		if assert.Len(t, skipped, 2) {
+			assert.EqualValues(t, 4, skipped[0].Offset)
+			assert.EqualValues(t, 9, skipped[1].Offset)
+		}
+	})
+
+	t.Run("structural errors", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		testCases := []struct {
+			input string
+			err   error
+		}{
+			{`{"a": 1}`, ErrJSONStreamNotAnArray},
+			{``, ErrJSONStreamNotAnArray},
+			{`[1, 2`, ErrUnterminatedJSONArray},
+			{`[1] 2`, ErrContentAfterJSONArray},
+			{`[1,, 2]`, ErrUnexpectedCharInJSONStream},
+		}
+
+		for _, testCase := range testCases {
+			reader := NewJSONStreamReader(ctx, strings.NewReader(testCase.input), JSONStreamReaderConfig{
+				Pattern:            INT_PATTERN,
+				SkipInvalidRecords: true,
+			})
+
+			_, _, err := readAll(reader)
+			assert.ErrorIs(t, err, testCase.err, testCase.input)
+		}
+	})
+
+	t.Run("too large record", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		reader := NewJSONStreamReader(ctx, strings.NewReader(`["aaaaaaaaaa"]`), JSONStreamReaderConfig{
+			MaxRecordSize:      5,
+			SkipInvalidRecords: true,
+		})
+
+		_, _, err := readAll(reader)
+		assert.ErrorIs(t, err, ErrJSONStreamRecordTooLarge)
+	})
+
+	t.Run("byte stream", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		stream := ToReadableStream(ctx, String("{\"a\": 1}\n{\"a\": 2}\n"), ANYVAL_PATTERN).(*ReadableByteStream)
+		pattern := NewInexactObjectPattern([]ObjectPatternEntry{{Name: "a", Pattern: INT_PATTERN}})
+
+		reader := NewJSONStreamReaderFromByteStream(ctx, stream, 0, JSONStreamReaderConfig{
+			Format:  JSONLinesStream,
+			Pattern: pattern,
+		})
+
+		values, errs, err := readAll(reader)
+		assert.NoError(t, err)
+		assert.Empty(t, errs)
+		if assert.Len(t, values, 2) {
+			assert.Equal(t, Int(2), values[1].(*Object).Prop(ctx, "a"))
+		}
+	})
+
+	t.Run("stopped byte stream", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		stream := ToReadableStream(ctx, String("{\"a\": 1}\n"), ANYVAL_PATTERN).(*ReadableByteStream)
+		stream.Stop()
+
+		reader := NewJSONStreamReaderFromByteStream(ctx, stream, 0, JSONStreamReaderConfig{Format: JSONLinesStream})
+
+		values, errs, err := readAll(reader)
+		assert.NoError(t, err)
+		assert.Empty(t, errs)
+		assert.Empty(t, values)
+	})
+
+	t.Run("the records should be read while the input is written", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		pipeReader, pipeWriter := io.Pipe()
+		const recordCount = 10_000
+
+		go func() {
+			defer pipeWriter.Close()
+			for i := 0; i < recordCount; i++ {
+				fmt.Fprintf(pipeWriter, "{\"id\": %d, \"message\": \"message %d\"}\n", i, i)
+			}
+		}()
+
+		pattern := NewInexactObjectPattern([]ObjectPatternEntry{
+			{Name: "id", Pattern: INT_PATTERN},
+			{Name: "message", Pattern: STR_PATTERN},
+		})
+
+		reader := NewJSONStreamReader(ctx, pipeReader, JSONStreamReaderConfig{
+			Format:  JSONLinesStream,
+			Pattern: pattern,
+		})
+
+		count := 0
+		for {
+			v, err := reader.Next()
+			if err == ErrEndOfStream {
+				break
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			if !assert.Equal(t, Int(count), v.(*Object).Prop(ctx, "id")) {
+				return
+			}
+			count++
+		}
+
+		assert.Equal(t, recordCount, count)
+	})
+}
@@ -26,6 +26,7 @@ type SmartLock struct {
 	holderState       *GlobalState
 	holdStart         RelativeTimeInstant64
 	firstEntry        string
+	holderLocation    string         //location where the holder acquired the lock, only set if deadlock detection is enabled.
 	totalWaitPressure ModulePriority //TODO: use max(new value, math.MaxInt32) to update this field.
 	takeover          bool
 	isValueShared     bool
@@ -57,6 +58,14 @@ func (lock *SmartLock) IsHeld() bool {
 	return lock.holderState != nil
 }
 
+// isHeldBy tells whether the lock is held by state.
+func (lock *SmartLock) isHeldBy(state *GlobalState) bool {
+	lock.lockLock.Lock()
+	defer lock.lockLock.Unlock()
+
+	return lock.holderState == state
+}
+
 func (lock *SmartLock) Lock(state *GlobalState, embedder PotentiallySharable, ignoreLockedValues ...bool) {
 	if state == nil {
 		panic(errors.New("cannot lock smart lock: nil state"))
@@ -91,6 +100,13 @@ func (lock *SmartLock) Lock(state *GlobalState, embedder PotentiallySharable, ig
 		}
 	}()
 
+	waitRecorded := false //true if a wait has been recorded in the wait-for graph (deadlock detection).
+	defer func() {
+		if waitRecorded {
+			lockWaitForGraph.removeWait(state)
+		}
+	}()
+
 	//priority := state.ComputePriority()
 	//waitPressure := priority
 
@@ -116,6 +132,7 @@ func (lock *SmartLock) Lock(state *GlobalState, embedder PotentiallySharable, ig
 					lock.takeover = false
 					lock.holdStart = GetRelativeTimeInstant64()
 					lock.firstEntry = string(debug.Stack())
+					lock.setHolderLocation(state)
 				}()
 				return
 			}
@@ -128,6 +145,7 @@ func (lock *SmartLock) Lock(state *GlobalState, embedder PotentiallySharable, ig
 					lock.takeover = false
 					lock.holdStart = GetRelativeTimeInstant64()
 					lock.firstEntry = string(debug.Stack())
+					lock.setHolderLocation(state)
 				}()
 				return
 			}
@@ -148,6 +166,7 @@ func (lock *SmartLock) Lock(state *GlobalState, embedder PotentiallySharable, ig
 					lock.holderState = state
 					lock.holdStart = GetRelativeTimeInstant64()
 					lock.firstEntry = string(debug.Stack())
+					lock.setHolderLocation(state)
 
 					//Release the internal lock.
 					needUnlock = false
@@ -162,6 +181,21 @@ func (lock *SmartLock) Lock(state *GlobalState, embedder PotentiallySharable, ig
 				return
 			}
 
+			//Record the wait in the wait-for graph, the lock is not acquired if the wait would cause a deadlock.
+			if deadlockDetectionEnabled.Load() && !lockWaitForGraph.hasWait(state, lock, lock.holderState) {
+				waitRecorded = true
+				err := lockWaitForGraph.addWait(LockWait{
+					Waiter:         state,
+					Holder:         lock.holderState,
+					Lock:           lock,
+					HolderLocation: lock.getHolderLocation(),
+				})
+				if err != nil {
+					lock.lockLock.Unlock()
+					panic(err)
+				}
+			}
+
 			lock.lockLock.Unlock()
 			runtime.Gosched()
 		}
@@ -182,6 +216,7 @@ func (lock *SmartLock) tryLockIfNoPressure(state *GlobalState) bool {
 		lock.takeover = false
 		lock.holdStart = GetRelativeTimeInstant64()
 		lock.firstEntry = string(debug.Stack())
+		lock.setHolderLocation(state)
 		return true
 	}
 	return false
@@ -224,4 +259,25 @@ func (lock *SmartLock) Unlock(state *GlobalState, embedder PotentiallySharable,
 
 	lock.holderState = nil
 	lock.lockLock.Unlock()
+
+	if deadlockDetectionEnabled.Load() {
+		lockWaitForGraph.removeWaitsFor(lock, state)
+	}
+}
+
+// setHolderLocation should be called with lock.lockLock held, right after state acquired the lock.
+func (lock *SmartLock) setHolderLocation(state *GlobalState) {
+	if deadlockDetectionEnabled.Load() {
+		lock.holderLocation = state.lockAcquisitionLocation
+	} else {
+		lock.holderLocation = ""
+	}
+}
+
+// getHolderLocation should be called with lock.lockLock held.
+func (lock *SmartLock) getHolderLocation() string {
+	if lock.holderLocation == "" {
+		return UNKNOWN_LOCK_ACQUISITION_LOCATION
+	}
+	return lock.holderLocation
 }
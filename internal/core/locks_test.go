@@ -157,3 +157,111 @@ func TestSmartLock(t *testing.T) {
 		}
 	})
 }
+
+func TestSmartLockDeadlockDetection(t *testing.T) {
+	EnableDeadlockDetection()
+	t.Cleanup(DisableDeadlockDetection)
+
+	t.Run("two modules locking two values in different orders", func(t *testing.T) {
+		state1 := NewGlobalState(NewContext(ContextConfig{}))
+		defer state1.Ctx.CancelGracefully()
+		state2 := NewGlobalState(NewContext(ContextConfig{}))
+		defer state2.Ctx.CancelGracefully()
+
+		embedder1, embedder2 := NewObject(), NewObject()
+		var lock1, lock2 SmartLock
+		lock1.Share(state1, func() {})
+		lock2.Share(state1, func() {})
+
+		state1.lockAcquisitionLocation = "location1"
+		lock1.Lock(state1, embedder1)
+		state1.lockAcquisitionLocation = ""
+
+		state2.lockAcquisitionLocation = "location2"
+		lock2.Lock(state2, embedder2)
+		state2.lockAcquisitionLocation = ""
+
+		state1Panic := make(chan any, 1)
+		go func() {
+			defer func() {
+				state1Panic <- recover()
+			}()
+			lock2.Lock(state1, embedder2)
+		}()
+
+		assert.Eventually(t, func() bool {
+			return lockWaitForGraph.hasWait(state1, &lock2, state2)
+		}, time.Second, time.Millisecond)
+
+		//state2 closes the cycle.
+		var deadlockErr any
+		func() {
+			defer func() {
+				deadlockErr = recover()
+			}()
+			lock1.Lock(state2, embedder1)
+		}()
+
+		if !assert.IsType(t, DeadlockError{}, deadlockErr) {
+			return
+		}
+		err := deadlockErr.(DeadlockError)
+		assert.ErrorIs(t, err, ErrDeadlock)
+
+		if assert.Len(t, err.Waits, 2) {
+			assert.Same(t, state2, err.Waits[0].Waiter)
+			assert.Equal(t, "location1", err.Waits[0].HolderLocation)
+			assert.Same(t, state1, err.Waits[1].Waiter)
+			assert.Equal(t, "location2", err.Waits[1].HolderLocation)
+		}
+		assert.Contains(t, err.Error(), "location1")
+		assert.Contains(t, err.Error(), "location2")
+
+		//state2 releases its lock after failing, state1 should acquire the lock.
+		lock2.Unlock(state2, embedder2)
+
+		select {
+		case e := <-state1Panic:
+			assert.Nil(t, e)
+		case <-time.After(time.Second):
+			assert.FailNow(t, "state1 should have acquired the lock")
+		}
+
+		assert.False(t, lockWaitForGraph.hasWait(state1, &lock2, state2))
+	})
+
+	t.Run("waiting for a lock released by its holder should not be considered as a deadlock", func(t *testing.T) {
+		state1 := NewGlobalState(NewContext(ContextConfig{}))
+		defer state1.Ctx.CancelGracefully()
+		state2 := NewGlobalState(NewContext(ContextConfig{}))
+		defer state2.Ctx.CancelGracefully()
+
+		embedder1, embedder2 := NewObject(), NewObject()
+		var lock1, lock2 SmartLock
+		lock1.Share(state1, func() {})
+		lock2.Share(state1, func() {})
+
+		lock2.Lock(state2, embedder2)
+
+		state1Panic := make(chan any, 1)
+		go func() {
+			defer func() {
+				state1Panic <- recover()
+			}()
+			lock2.Lock(state1, embedder2)
+			lock2.Unlock(state1, embedder2)
+		}()
+
+		assert.Eventually(t, func() bool {
+			return lockWaitForGraph.hasWait(state1, &lock2, state2)
+		}, time.Second, time.Millisecond)
+
+		lock2.Unlock(state2, embedder2)
+
+		//the wait of state1 should have been removed by the unlocking.
+		lock1.Lock(state2, embedder1)
+		lock1.Unlock(state2, embedder1)
+
+		assert.Nil(t, <-state1Panic)
+	})
+}
@@ -24,7 +24,7 @@ const (
 )
 
 var (
-	ROUTINE_PROPNAMES       = []string{"wait_result", "cancel", "steps"}
+	ROUTINE_PROPNAMES       = []string{"wait_result", "cancel", "steps", "cpu_time", "cpu_budget"}
 	ROUTINE_GROUP_PROPNAMES = []string{"wait_results", "cancel_all"}
 	EXECUTED_STEP_PROPNAMES = []string{"result", "end_time"}
 )
@@ -42,6 +42,7 @@ type LThread struct {
 	module      *Module
 	state       *GlobalState
 	lock        sync.Mutex
+	vm          *VM //set when the execution starts in bytecode mode
 
 	//steps
 	executedSteps          []*ExecutedStep
@@ -54,6 +55,9 @@ type LThread struct {
 	err         Error
 	done        atomic.Bool
 	wait_result chan struct{}
+	finished    chan struct{} //closed when the result is set
+
+	spawnArgs *LthreadSpawnArgs //nil if the lthread has not been created by SpawnLThread
 }
 
 type LthreadSpawnArgs struct {
@@ -82,6 +86,10 @@ type LthreadSpawnArgs struct {
 	// Even if true a token is taken for the threads/simul-instances limit
 	IgnoreCreateLThreadPermCheck bool
 	PauseAfterYield              bool
+
+	//(optional) Checkpoint from which the execution is resumed, UseBytecode should be true.
+	//The checkpointed global variables override the provided ones.
+	Checkpoint *LThreadCheckpoint
 }
 
 // SpawnLThread spawns a new lthread, if .LthreadCtx is nil a minimal context is created for the lthread.
@@ -170,7 +178,7 @@ func SpawnLThread(args LthreadSpawnArgs) (*LThread, error) {
 	// }
 	modState.OutputFieldsInitialized.Store(true)
 
-	return SpawnLthreadWithState(LthreadWithStateSpawnArgs{
+	lthread, err := SpawnLthreadWithState(LthreadWithStateSpawnArgs{
 		Timeout:         args.Timeout,
 		SpawnerState:    args.SpawnerState,
 		State:           modState,
@@ -178,7 +186,14 @@ func SpawnLThread(args LthreadSpawnArgs) (*LThread, error) {
 		PauseAfterYield: args.PauseAfterYield,
 		StartPaused:     args.StartPaused,
 		Self:            args.Self,
+		Checkpoint:      args.Checkpoint,
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	lthread.spawnArgs = &args
+	return lthread, nil
 }
 
 type LthreadWithStateSpawnArgs struct {
@@ -191,6 +206,12 @@ type LthreadWithStateSpawnArgs struct {
 
 	Self Value
 
+	//(optional) Checkpoint from which the execution is resumed, UseBytecode should be true.
+	Checkpoint *LThreadCheckpoint
+
+	//(optional) Function executed by the lthread instead of the main chunk of the state's module.
+	Run func(state *GlobalState) (Value, error)
+
 	//(optional) Function called by the lthread when its execution is finished.
 	//$result is nil if and only if $err is not nil. The function should not
 	//mutate the result or store a reference to it.
@@ -204,6 +225,7 @@ func SpawnLthreadWithState(args LthreadWithStateSpawnArgs) (*LThread, error) {
 		module:           modState.Module,
 		state:            modState,
 		wait_result:      make(chan struct{}, 1),
+		finished:         make(chan struct{}),
 		continueExecChan: make(chan struct{}, 1),
 		useBytecode:      args.UseBytecode,
 		executedStepCallbackFn: func(step ExecutedStep, lthread *LThread) (continueExec bool) {
@@ -212,6 +234,27 @@ func SpawnLthreadWithState(args LthreadWithStateSpawnArgs) (*LThread, error) {
 	}
 	modState.LThread = lthread
 
+	var resumedVM *VM
+	if args.Checkpoint != nil {
+		if !args.UseBytecode {
+			return nil, ErrCheckpointRequiresBytecode
+		}
+
+		vm, err := NewVM(VMConfig{
+			Bytecode: modState.Bytecode,
+			State:    modState,
+			Self:     args.Self,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if err := vm.restoreCheckpoint(args.Checkpoint); err != nil {
+			return nil, fmt.Errorf("cannot resume lthread from checkpoint: %w", err)
+		}
+		resumedVM = vm
+	}
+
 	if args.Timeout != 0 {
 		go func(d time.Duration) {
 			<-time.After(d)
@@ -227,6 +270,11 @@ func SpawnLthreadWithState(args LthreadWithStateSpawnArgs) (*LThread, error) {
 		return nil, fmt.Errorf("cannot spawn lthread: %s", err.Error())
 	}
 
+	var mainChunk ast.Node
+	if args.Run == nil {
+		mainChunk = modState.Module.MainChunk.Node
+	}
+
 	// goroutine in which the lthread's module is evaluated
 	go func(modState *GlobalState, chunk ast.Node, lthread *LThread, startPaused bool, self Value) {
 		var res Value
@@ -242,6 +290,7 @@ func SpawnLthreadWithState(args LthreadWithStateSpawnArgs) (*LThread, error) {
 
 			lthread.lock.Lock()
 			defer lthread.lock.Unlock()
+			defer close(lthread.finished)
 
 			close(lthread.continueExecChan)
 			lthread.done.Store(true)
@@ -255,6 +304,10 @@ func SpawnLthreadWithState(args LthreadWithStateSpawnArgs) (*LThread, error) {
 				res, err = ShareOrClone(res, lthread.state)
 			}
 
+			if err != nil && modState.Ctx.IsCPUTimeBudgetExhausted() {
+				err = fmt.Errorf("%w (%s): %w", ErrCPUTimeBudgetExhausted, modState.Ctx.CPUTimeBudget(), err)
+			}
+
 			if err != nil {
 				if !isSpawnerDone || !errors.Is(err, context.Canceled) { //do not log if the error is about an "expected" cancellation.
 					modState.Logger.Print("a lthread failed or was cancelled: " + utils.AddCarriageReturnAfterNewlines(err.Error()))
@@ -283,19 +336,39 @@ func SpawnLthreadWithState(args LthreadWithStateSpawnArgs) (*LThread, error) {
 		}()
 
 		if startPaused {
-			select {
-			case <-lthread.continueExecChan:
-				//
-			case <-modState.Ctx.Done():
-				panic(context.Canceled)
-			}
+			//The time spent waiting is not counted as CPU time.
+			modState.Ctx.DoIO(func() error {
+				select {
+				case <-lthread.continueExecChan:
+					return nil
+				case <-modState.Ctx.Done():
+					panic(context.Canceled)
+				}
+			})
 		}
 
 		defer modState.Ctx.CancelGracefully()
 		defer modState.Ctx.DefinitelyStopCPUTimeDepletion()
 
-		if args.UseBytecode {
-			res, err = EvalBytecode(lthread.state.Bytecode, modState, args.Self)
+		if args.Run != nil {
+			res, err = args.Run(modState)
+		} else if args.UseBytecode {
+			vm := resumedVM
+			if vm == nil {
+				vm, err = NewVM(VMConfig{
+					Bytecode: lthread.state.Bytecode,
+					State:    modState,
+					Self:     args.Self,
+				})
+			}
+
+			if err == nil {
+				lthread.lock.Lock()
+				lthread.vm = vm
+				lthread.lock.Unlock()
+
+				res, err = vm.Run()
+			}
 		} else {
 			state := NewTreeWalkStateWithGlobal(modState)
 			state.self = args.Self
@@ -326,7 +399,7 @@ func SpawnLthreadWithState(args LthreadWithStateSpawnArgs) (*LThread, error) {
 			res, err = TreeWalkEval(chunk, state)
 		}
 
-	}(modState, modState.Module.MainChunk.Node, lthread, args.StartPaused, args.Self)
+	}(modState, mainChunk, lthread, args.StartPaused, args.Self)
 
 	return lthread, nil
 }
@@ -352,6 +425,14 @@ func (r *LThread) Prop(ctx *Context, name string) Value {
 			steps[i] = r.executedSteps[i]
 		}
 		return NewArrayFrom(steps...)
+	case "cpu_time":
+		return Duration(r.CPUTime())
+	case "cpu_budget":
+		budget := r.state.Ctx.CPUTimeBudget()
+		if budget == 0 {
+			return Nil
+		}
+		return Duration(budget)
 	}
 	method, ok := r.GetGoMethod(name)
 	if !ok {
@@ -381,6 +462,38 @@ func (lthread *LThread) Module() *Module {
 	return lthread.state.Module
 }
 
+// Finished returns a channel that is closed when the execution of the lthread is finished and its result is set.
+func (lthread *LThread) Finished() <-chan struct{} {
+	return lthread.finished
+}
+
+// Err returns the error of a finished lthread, the returned error is nil if the lthread succeeded or is not finished.
+func (lthread *LThread) Err() error {
+	lthread.lock.Lock()
+	defer lthread.lock.Unlock()
+
+	return lthread.err.goError
+}
+
+// Respawn spawns a new lthread executing the same module with the same globals, the context of the new lthread
+// is created by calling .New() on the context of the lthread. Only the lthreads created by SpawnLThread can be respawned.
+func (lthread *LThread) Respawn() (*LThread, error) {
+	if lthread.spawnArgs == nil {
+		return nil, ErrLThreadNotRespawnable
+	}
+
+	args := *lthread.spawnArgs
+	args.LthreadCtx = args.LthreadCtx.New()
+	args.StartPaused = false
+	return SpawnLThread(args)
+}
+
+// CPUTime returns the pseudo CPU time consumed by the lthread, the time spent doing IO, sleeping,
+// waiting for locks or paused after a yield is not counted.
+func (lthread *LThread) CPUTime() time.Duration {
+	return lthread.state.Ctx.ConsumedCPUTime()
+}
+
 // yield creates a new ExecutedStep with the given result, if a the step callback function is set it is executed
 // to determinate if execution will be paused, if not set the execution is paused.
 func (lthread *LThread) yield(ctx *Context, value Value) {
@@ -589,24 +702,47 @@ func Sleep(ctx *Context, d Duration) {
 	ctx.Sleep(time.Duration(d))
 }
 
-func readLThreadMeta(meta map[string]Value, explicitlyPassedGlobals map[string]Value, ctx *Context) (group *LThreadGroup, globalsDesc Value, permListing *Object, err error) {
+// lthreadMeta is the content of the metadata of a spawn expression.
+type lthreadMeta struct {
+	group         *LThreadGroup
+	globalsDesc   Value
+	permListing   *Object
+	capabilities  []*Capability
+	cpuTimeBudget time.Duration
+}
+
+func readLThreadMeta(meta map[string]Value, explicitlyPassedGlobals map[string]Value, ctx *Context) (result lthreadMeta, _ error) {
 	if val, ok := meta[symbolic.LTHREAD_META_GROUP_SECTION]; ok {
 		if rtGroup, ok := val.(*LThreadGroup); ok {
-			group = rtGroup
+			result.group = rtGroup
 		} else {
-			return nil, nil, nil, fmt.Errorf("<meta>.%s should be a lthread group", symbolic.LTHREAD_META_GROUP_SECTION)
+			return lthreadMeta{}, fmt.Errorf("<meta>.%s should be a lthread group", symbolic.LTHREAD_META_GROUP_SECTION)
 		}
 	}
 	if val, ok := meta[symbolic.LTHREAD_META_GLOBALS_SECTION]; ok {
-		globalsDesc = val
+		result.globalsDesc = val
 	} else {
-		globalsDesc = NewMutableEntriesNamespace("", explicitlyPassedGlobals)
+		result.globalsDesc = NewMutableEntriesNamespace("", explicitlyPassedGlobals)
 	}
 	if val, ok := meta[symbolic.LTHREAD_META_ALLOW_SECTION]; ok {
 		if obj, ok := val.(*Object); ok {
-			permListing = obj
+			result.permListing = obj
+		} else {
+			return lthreadMeta{}, fmt.Errorf("<meta>.%s should be an object", symbolic.LTHREAD_META_ALLOW_SECTION)
+		}
+	}
+	if val, ok := meta[symbolic.LTHREAD_META_CAPABILITIES_SECTION]; ok {
+		capabilities, err := getCapabilitiesFromValue(val)
+		if err != nil {
+			return lthreadMeta{}, fmt.Errorf("<meta>.%s: %w", symbolic.LTHREAD_META_CAPABILITIES_SECTION, err)
+		}
+		result.capabilities = capabilities
+	}
+	if val, ok := meta[symbolic.LTHREAD_META_CPU_BUDGET_SECTION]; ok {
+		if budget, ok := val.(Duration); ok && budget > 0 {
+			result.cpuTimeBudget = time.Duration(budget)
 		} else {
-			return nil, nil, nil, fmt.Errorf("<meta>.%s should be an object", symbolic.LTHREAD_META_ALLOW_SECTION)
+			return lthreadMeta{}, fmt.Errorf("<meta>.%s should be a positive duration", symbolic.LTHREAD_META_CPU_BUDGET_SECTION)
 		}
 	}
 
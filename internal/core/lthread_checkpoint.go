@@ -0,0 +1,297 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+
+	jsoniter "github.com/inoxlang/inox/internal/jsoniter"
+)
+
+const (
+	LTHREAD_CHECKPOINT_VERSION = 1
+)
+
+var (
+	ErrLThreadNotPausedAfterYield        = errors.New("lthread is not paused after a coyield statement")
+	ErrCheckpointRequiresBytecode        = errors.New("only lthreads executed in bytecode mode can be checkpointed and restored")
+	ErrCheckpointInSynchronizedBlock     = errors.New("lthreads cannot be checkpointed inside a synchronized block")
+	ErrNotCheckpointableValue            = errors.New("value is not checkpointable")
+	ErrIncompatibleCheckpoint            = errors.New("checkpoint is not compatible with the bytecode of the module")
+	ErrUnsupportedCheckpointVersion      = errors.New("unsupported checkpoint version")
+	ErrCheckpointRestorationNotSupported = errors.New("checkpoint restoration is not supported for function calls")
+	ErrSharedCheckpointValue             = errors.New("mutable values referenced more than once cannot be checkpointed")
+)
+
+// An LThreadCheckpoint is the serialized state of an lthread paused after a coyield statement: it contains the
+// non-constant global variables, the local variables of the module, the operand stack and the instruction pointer.
+// Values are stored using their untyped JSON representation, which does not preserve references: a mutable value
+// referenced more than once cannot be checkpointed. Since coyield statements are only allowed at the top level of
+// embedded modules there is a single call frame to capture.
+//
+// A checkpoint can be written to disk with WriteTo and read back in another process with ReadLThreadCheckpoint,
+// the lthread is then resumed by passing the checkpoint to SpawnLThread along with the bytecode of the same module.
+type LThreadCheckpoint struct {
+	Version    int    `json:"version"`
+	ModuleName string `json:"module"`
+
+	//Hash of the instructions of the module, the bytecode used during restoration should have the same hash.
+	BytecodeHash string `json:"bytecodeHash"`
+
+	InstructionPointer int                        `json:"ip"`
+	Globals            map[string]json.RawMessage `json:"globals"`
+	Locals             []json.RawMessage          `json:"locals"` //uninitialized locals are stored as null.
+	OperandStack       []json.RawMessage          `json:"operandStack"`
+	ExecutedStepCount  int                        `json:"executedStepCount"`
+}
+
+// WriteTo writes the JSON encoding of the checkpoint to w.
+func (c *LThreadCheckpoint) WriteTo(w io.Writer) (int64, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+// ReadLThreadCheckpoint reads a checkpoint written by (*LThreadCheckpoint).WriteTo.
+func ReadLThreadCheckpoint(r io.Reader) (*LThreadCheckpoint, error) {
+	var checkpoint LThreadCheckpoint
+
+	if err := json.NewDecoder(r).Decode(&checkpoint); err != nil {
+		return nil, fmt.Errorf("failed to read lthread checkpoint: %w", err)
+	}
+
+	if checkpoint.Version != LTHREAD_CHECKPOINT_VERSION {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedCheckpointVersion, checkpoint.Version)
+	}
+	return &checkpoint, nil
+}
+
+// Checkpoint captures the state of the lthread, the lthread should be executed in bytecode mode and be paused after a
+// coyield statement (see PauseAfterYield). The lthread stays paused. An error wrapping ErrNotCheckpointableValue is
+// returned if a captured value (or a value reachable from it) is not serializable, an error wrapping
+// ErrSharedCheckpointValue is returned if a mutable value is reachable more than once from the captured values.
+func (lthread *LThread) Checkpoint(ctx *Context) (*LThreadCheckpoint, error) {
+	if lthread.IsDone() {
+		return nil, ErrLThreadIsDone
+	}
+
+	if !lthread.useBytecode {
+		return nil, ErrCheckpointRequiresBytecode
+	}
+
+	//the lock prevents the lthread from being resumed during the capture.
+	lthread.lock.Lock()
+	defer lthread.lock.Unlock()
+
+	vm := lthread.vm
+	if !lthread.paused.Load() || vm == nil || !vm.isPausedAfterYield() {
+		return nil, ErrLThreadNotPausedAfterYield
+	}
+
+	if len(lthread.state.lockedValues) != 0 {
+		return nil, ErrCheckpointInSynchronizedBlock
+	}
+
+	location := vm.curFrame.fn.GetSourcePositionRange(vm.ip - 1).String()
+
+	checkpoint := &LThreadCheckpoint{
+		Version:            LTHREAD_CHECKPOINT_VERSION,
+		ModuleName:         lthread.module.Name(),
+		BytecodeHash:       computeCheckpointBytecodeHash(ctx, vm.curFrame.bytecode),
+		InstructionPointer: vm.ip,
+		Globals:            map[string]json.RawMessage{},
+		ExecutedStepCount:  len(lthread.executedSteps),
+	}
+
+	//mutable values reachable from the captured values.
+	visitedMutableValues := map[uintptr]struct{}{}
+
+	err := lthread.state.Globals.Foreach(func(name string, v Value, isConstant bool) error {
+		if isConstant {
+			return nil
+		}
+		if err := checkCheckpointValueNotShared(ctx, v, visitedMutableValues); err != nil {
+			return fmt.Errorf("global variable '%s': %w", name, err)
+		}
+		repr, err := getCheckpointValueRepr(ctx, v)
+		if err != nil {
+			return fmt.Errorf("global variable '%s': %w", name, err)
+		}
+		checkpoint.Globals[name] = repr
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to checkpoint lthread at %s: %w", location, err)
+	}
+
+	for i, v := range vm.stack[:vm.moduleLocalCount] {
+		if err := checkCheckpointValueNotShared(ctx, v, visitedMutableValues); err != nil {
+			return nil, fmt.Errorf("failed to checkpoint lthread at %s: local variable #%d: %w", location, i, err)
+		}
+		repr, err := getCheckpointValueRepr(ctx, v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to checkpoint lthread at %s: local variable #%d: %w", location, i, err)
+		}
+		checkpoint.Locals = append(checkpoint.Locals, repr)
+	}
+
+	for i, v := range vm.stack[vm.moduleLocalCount:vm.sp] {
+		if err := checkCheckpointValueNotShared(ctx, v, visitedMutableValues); err != nil {
+			return nil, fmt.Errorf("failed to checkpoint lthread at %s: operand stack value #%d: %w", location, i, err)
+		}
+		repr, err := getCheckpointValueRepr(ctx, v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to checkpoint lthread at %s: operand stack value #%d: %w", location, i, err)
+		}
+		checkpoint.OperandStack = append(checkpoint.OperandStack, repr)
+	}
+
+	return checkpoint, nil
+}
+
+// checkCheckpointValueNotShared returns an error wrapping ErrSharedCheckpointValue if v or a mutable value reachable
+// from it has already been visited. Immutable values are not visited because they only contain immutable values.
+func checkCheckpointValueNotShared(ctx *Context, v Value, visited map[uintptr]struct{}) error {
+	if v == nil || !v.IsMutable() {
+		return nil
+	}
+
+	if reflectVal := reflect.ValueOf(v); reflectVal.Kind() == reflect.Pointer {
+		ptr := reflectVal.Pointer()
+		if _, ok := visited[ptr]; ok {
+			return fmt.Errorf("%w: value of type %T", ErrSharedCheckpointValue, v)
+		}
+		visited[ptr] = struct{}{}
+	}
+
+	switch val := v.(type) {
+	case *Dictionary:
+		return val.ForEachEntry(ctx, func(_ string, _ Serializable, entryValue Serializable) error {
+			return checkCheckpointValueNotShared(ctx, entryValue, visited)
+		})
+	case *Object:
+		for _, name := range val.PropertyNames(ctx) {
+			if err := checkCheckpointValueNotShared(ctx, val.Prop(ctx, name), visited); err != nil {
+				return err
+			}
+		}
+	case Indexable:
+		for i := 0; i < val.Len(); i++ {
+			if err := checkCheckpointValueNotShared(ctx, val.At(ctx, i), visited); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func getCheckpointValueRepr(ctx *Context, v Value) (json.RawMessage, error) {
+	if v == nil { //uninitialized local variable
+		return json.RawMessage("null"), nil
+	}
+
+	serializable, ok := v.(Serializable)
+	if !ok {
+		return nil, fmt.Errorf("%w: value of type %T is not serializable", ErrNotCheckpointableValue, v)
+	}
+
+	stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 0)
+	err := serializable.WriteJSONRepresentation(ctx, stream, JSONSerializationConfig{ReprConfig: &ReprConfig{AllVisible: true}}, 0)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrNotCheckpointableValue, err)
+	}
+
+	return json.RawMessage(stream.Buffer()), nil
+}
+
+// computeCheckpointBytecodeHash computes a hash of the main function's instructions and of the constants, only the type
+// of mutable and non-serializable constants is taken into account except for compiled functions whose instructions
+// are hashed.
+func computeCheckpointBytecodeHash(ctx *Context, bytecode *Bytecode) string {
+	hash := sha256.New()
+	hash.Write(binary.BigEndian.AppendUint64(nil, uint64(bytecode.main.LocalCount)))
+	hash.Write(bytecode.main.Instructions)
+
+	for _, constant := range bytecode.constants {
+		fmt.Fprintf(hash, "%T", constant)
+
+		if fn, ok := constant.(*InoxFunction); ok && fn.compiledFunction != nil {
+			compiledFn := fn.compiledFunction
+			fmt.Fprintf(hash, "%d%t%d", compiledFn.ParamCount, compiledFn.IsVariadic, compiledFn.LocalCount)
+			hash.Write(compiledFn.Instructions)
+			continue
+		}
+
+		if serializable, ok := constant.(Serializable); ok && !constant.IsMutable() {
+			repr, err := getCheckpointValueRepr(ctx, serializable)
+			if err == nil {
+				hash.Write(repr)
+			}
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// restoreCheckpoint sets the global variables of the VM's state and the state of the main frame, the next call to
+// Run will resume the execution after the coyield statement.
+func (v *VM) restoreCheckpoint(checkpoint *LThreadCheckpoint) error {
+	if v.runFn {
+		return ErrCheckpointRestorationNotSupported
+	}
+
+	ctx := v.global.Ctx
+	bytecode := v.frames[0].bytecode
+
+	if checkpoint.BytecodeHash != computeCheckpointBytecodeHash(ctx, bytecode) ||
+		len(checkpoint.Locals) != v.moduleLocalCount ||
+		checkpoint.InstructionPointer < 0 || checkpoint.InstructionPointer >= len(bytecode.main.Instructions) {
+		return ErrIncompatibleCheckpoint
+	}
+
+	if v.moduleLocalCount+len(checkpoint.OperandStack) > VM_STACK_SIZE {
+		return fmt.Errorf("%w: the operand stack is too large", ErrIncompatibleCheckpoint)
+	}
+
+	for name, repr := range checkpoint.Globals {
+		value, err := ParseJSONRepresentation(ctx, string(repr), nil)
+		if err != nil {
+			return fmt.Errorf("failed to restore global variable '%s': %w", name, err)
+		}
+		v.global.Globals.Set(name, value)
+	}
+
+	for i, repr := range checkpoint.Locals {
+		value, err := ParseJSONRepresentation(ctx, string(repr), nil)
+		if err != nil {
+			return fmt.Errorf("failed to restore local variable #%d: %w", i, err)
+		}
+		v.stack[i] = value
+	}
+
+	for i, repr := range checkpoint.OperandStack {
+		value, err := ParseJSONRepresentation(ctx, string(repr), nil)
+		if err != nil {
+			return fmt.Errorf("failed to restore operand stack value #%d: %w", i, err)
+		}
+		v.stack[v.moduleLocalCount+i] = value
+	}
+
+	v.resumedFromCheckpoint = true
+	v.resumeSP = v.moduleLocalCount + len(checkpoint.OperandStack)
+	v.resumeIP = checkpoint.InstructionPointer
+	return nil
+}
+
+// isPausedAfterYield should only be called while the lthread executing the VM is paused.
+func (v *VM) isPausedAfterYield() bool {
+	return v.framesIndex == 1 && v.ip > 0 && v.curInsts[v.ip-1] == OpCoyield
+}
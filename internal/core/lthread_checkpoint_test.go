@@ -0,0 +1,323 @@
+package core
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/inoxlang/inox/internal/core/inoxmod"
+	"github.com/inoxlang/inox/internal/core/limitbase"
+	"github.com/inoxlang/inox/internal/core/permbase"
+	"github.com/inoxlang/inox/internal/parse"
+	"github.com/inoxlang/inox/internal/sourcecode"
+	utils "github.com/inoxlang/inox/internal/utils/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLThreadCheckpoint(t *testing.T) {
+
+	perms := []Permission{
+		GlobalVarPermission{Kind_: permbase.Read, Name: "*"},
+		GlobalVarPermission{Kind_: permbase.Use, Name: "*"},
+		GlobalVarPermission{Kind_: permbase.Create, Name: "*"},
+		LThreadPermission{permbase.Create},
+	}
+
+	limits := []Limit{
+		limitbase.MustMakeNotAutoDepletingCountLimit(limitbase.THREADS_SIMULTANEOUS_INSTANCES_LIMIT_NAME, 100_000),
+	}
+
+	//makeModule parses and compiles the code of an lthread module, a new process would do the same before restoring a checkpoint.
+	makeModule := func(t *testing.T, state *GlobalState, code string, globals map[string]Value) (*Module, *Bytecode) {
+		chunk := utils.Must(parse.ParseChunkSource(sourcecode.InMemorySource{
+			NameString: "lthread-test",
+			CodeString: code,
+		}))
+
+		mod := WrapLowerModule(&inoxmod.Module{
+			MainChunk:    chunk,
+			TopLevelNode: chunk.Node,
+			Kind:         UserLThreadModule,
+		})
+
+		bytecode, err := Compile(CompilationInput{
+			Mod:     mod,
+			Globals: globals,
+			Context: state.Ctx,
+		})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		return mod, bytecode
+	}
+
+	waitPaused := func(t *testing.T, lthread *LThread) {
+		assert.Eventually(t, lthread.IsPaused, time.Second, time.Millisecond)
+	}
+
+	t.Run("checkpoint and resume in a new lthread", func(t *testing.T) {
+		code := "var y = {a: x}; var list = [x]; coyield 0; return [x, y, list]"
+
+		state := NewGlobalState(NewContext(ContextConfig{Permissions: perms, Limits: limits}))
+		defer state.Ctx.CancelGracefully()
+
+		globalMap := map[string]Value{"x": Int(5)}
+		globals := GlobalVariablesFromMap(globalMap, nil)
+		mod, bytecode := makeModule(t, state, code, globalMap)
+
+		lthread, err := SpawnLThread(LthreadSpawnArgs{
+			SpawnerState:    state,
+			Globals:         globals,
+			Module:          mod,
+			Bytecode:        bytecode,
+			UseBytecode:     true,
+			PauseAfterYield: true,
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		waitPaused(t, lthread)
+
+		checkpoint, err := lthread.Checkpoint(state.Ctx)
+		if !assert.NoError(t, err) {
+			return
+		}
+		lthread.Cancel(state.Ctx)
+
+		//serialize the checkpoint and restore it as if it were another process.
+
+		buf := bytes.NewBuffer(nil)
+		_, err = checkpoint.WriteTo(buf)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		restoredCheckpoint, err := ReadLThreadCheckpoint(buf)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		newState := NewGlobalState(NewContext(ContextConfig{Permissions: perms, Limits: limits}))
+		defer newState.Ctx.CancelGracefully()
+
+		//the checkpointed value of x should override this one.
+		newGlobalMap := map[string]Value{"x": Int(100)}
+		newGlobals := GlobalVariablesFromMap(newGlobalMap, nil)
+		newMod, newBytecode := makeModule(t, newState, code, newGlobalMap)
+
+		resumed, err := SpawnLThread(LthreadSpawnArgs{
+			SpawnerState: newState,
+			Globals:      newGlobals,
+			Module:       newMod,
+			Bytecode:     newBytecode,
+			UseBytecode:  true,
+			Checkpoint:   restoredCheckpoint,
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		result, err := resumed.WaitResult(newState.Ctx)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		if !assert.IsType(t, &List{}, result) {
+			return
+		}
+		resultList := result.(*List)
+		assert.Equal(t, 3, resultList.Len())
+		assert.Equal(t, Int(5), resultList.At(newState.Ctx, 0))
+
+		obj, ok := resultList.At(newState.Ctx, 1).(*Object)
+		if assert.True(t, ok) {
+			assert.Equal(t, map[string]Serializable{"a": Int(5)}, obj.EntryMap(newState.Ctx))
+		}
+
+		list, ok := resultList.At(newState.Ctx, 2).(*List)
+		if assert.True(t, ok) {
+			assert.Equal(t, []Serializable{Int(5)}, list.GetOrBuildElements(newState.Ctx))
+		}
+	})
+
+	t.Run("the lthread should be paused after a coyield statement", func(t *testing.T) {
+		state := NewGlobalState(NewContext(ContextConfig{Permissions: perms, Limits: limits}))
+		defer state.Ctx.CancelGracefully()
+
+		globals := GlobalVariablesFromMap(map[string]Value{}, nil)
+		mod, bytecode := makeModule(t, state, "coyield 0", nil)
+
+		lthread, err := SpawnLThread(LthreadSpawnArgs{
+			SpawnerState:    state,
+			Globals:         globals,
+			Module:          mod,
+			Bytecode:        bytecode,
+			UseBytecode:     true,
+			PauseAfterYield: true,
+			StartPaused:     true,
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		_, err = lthread.Checkpoint(state.Ctx)
+		assert.ErrorIs(t, err, ErrLThreadNotPausedAfterYield)
+	})
+
+	t.Run("lthreads executed by the tree-walk interpreter cannot be checkpointed", func(t *testing.T) {
+		state := NewGlobalState(NewContext(ContextConfig{Permissions: perms, Limits: limits}))
+		defer state.Ctx.CancelGracefully()
+
+		globals := GlobalVariablesFromMap(map[string]Value{}, nil)
+		mod, _ := makeModule(t, state, "coyield 0", nil)
+
+		lthread, err := SpawnLThread(LthreadSpawnArgs{
+			SpawnerState:    state,
+			Globals:         globals,
+			Module:          mod,
+			PauseAfterYield: true,
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		waitPaused(t, lthread)
+
+		_, err = lthread.Checkpoint(state.Ctx)
+		assert.ErrorIs(t, err, ErrCheckpointRequiresBytecode)
+	})
+
+	t.Run("a non-serializable value should make the checkpoint fail", func(t *testing.T) {
+		state := NewGlobalState(NewContext(ContextConfig{Permissions: perms, Limits: limits}))
+		defer state.Ctx.CancelGracefully()
+
+		globalMap := map[string]Value{"LThreadGroup": WrapGoFunction(NewLThreadGroup)}
+		globals := GlobalVariablesFromMap(globalMap, []string{"LThreadGroup"})
+		mod, bytecode := makeModule(t, state, "var group = LThreadGroup(); coyield 0", globalMap)
+
+		lthread, err := SpawnLThread(LthreadSpawnArgs{
+			SpawnerState:    state,
+			Globals:         globals,
+			Module:          mod,
+			Bytecode:        bytecode,
+			UseBytecode:     true,
+			PauseAfterYield: true,
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		waitPaused(t, lthread)
+
+		_, err = lthread.Checkpoint(state.Ctx)
+		if assert.ErrorIs(t, err, ErrNotCheckpointableValue) {
+			assert.ErrorContains(t, err, "local variable #0")
+		}
+	})
+
+	t.Run("a mutable value referenced more than once should make the checkpoint fail", func(t *testing.T) {
+		state := NewGlobalState(NewContext(ContextConfig{Permissions: perms, Limits: limits}))
+		defer state.Ctx.CancelGracefully()
+
+		globals := GlobalVariablesFromMap(map[string]Value{}, nil)
+		mod, bytecode := makeModule(t, state, "var y = {a: 1}; var list = [y]; coyield 0; y.a = 2; return list", nil)
+
+		lthread, err := SpawnLThread(LthreadSpawnArgs{
+			SpawnerState:    state,
+			Globals:         globals,
+			Module:          mod,
+			Bytecode:        bytecode,
+			UseBytecode:     true,
+			PauseAfterYield: true,
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		waitPaused(t, lthread)
+
+		_, err = lthread.Checkpoint(state.Ctx)
+		if assert.ErrorIs(t, err, ErrSharedCheckpointValue) {
+			assert.ErrorContains(t, err, "local variable #1")
+		}
+	})
+
+	t.Run("a checkpoint should not be restored with the bytecode of another module", func(t *testing.T) {
+		state := NewGlobalState(NewContext(ContextConfig{Permissions: perms, Limits: limits}))
+		defer state.Ctx.CancelGracefully()
+
+		globals := GlobalVariablesFromMap(map[string]Value{}, nil)
+		mod, bytecode := makeModule(t, state, "coyield 0; return 1", nil)
+
+		lthread, err := SpawnLThread(LthreadSpawnArgs{
+			SpawnerState:    state,
+			Globals:         globals,
+			Module:          mod,
+			Bytecode:        bytecode,
+			UseBytecode:     true,
+			PauseAfterYield: true,
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		waitPaused(t, lthread)
+
+		checkpoint, err := lthread.Checkpoint(state.Ctx)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		otherMod, otherBytecode := makeModule(t, state, "coyield 0; return 2", nil)
+
+		_, err = SpawnLThread(LthreadSpawnArgs{
+			SpawnerState: state,
+			Globals:      globals,
+			Module:       otherMod,
+			Bytecode:     otherBytecode,
+			UseBytecode:  true,
+			Checkpoint:   checkpoint,
+		})
+		assert.ErrorIs(t, err, ErrIncompatibleCheckpoint)
+	})
+
+	t.Run("a checkpoint should not be restored if the body of a function has changed", func(t *testing.T) {
+		state := NewGlobalState(NewContext(ContextConfig{Permissions: perms, Limits: limits}))
+		defer state.Ctx.CancelGracefully()
+
+		globals := GlobalVariablesFromMap(map[string]Value{}, nil)
+		mod, bytecode := makeModule(t, state, "coyield 0; var f = fn(){ return 1 }; return f()", nil)
+
+		lthread, err := SpawnLThread(LthreadSpawnArgs{
+			SpawnerState:    state,
+			Globals:         globals,
+			Module:          mod,
+			Bytecode:        bytecode,
+			UseBytecode:     true,
+			PauseAfterYield: true,
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		waitPaused(t, lthread)
+
+		checkpoint, err := lthread.Checkpoint(state.Ctx)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		otherMod, otherBytecode := makeModule(t, state, "coyield 0; var f = fn(){ return [1] }; return f()", nil)
+
+		_, err = SpawnLThread(LthreadSpawnArgs{
+			SpawnerState: state,
+			Globals:      globals,
+			Module:       otherMod,
+			Bytecode:     otherBytecode,
+			UseBytecode:  true,
+			Checkpoint:   checkpoint,
+		})
+		assert.ErrorIs(t, err, ErrIncompatibleCheckpoint)
+	})
+}
@@ -209,4 +209,103 @@ func TestSpawnLThread(t *testing.T) {
 		assert.Equal(t, map[string]Serializable{"a": Int(1)}, obj.EntryMap(state.Ctx))
 	})
 
+	t.Run("the time during which the lthread is paused should not be counted as CPU time", func(t *testing.T) {
+		state := NewGlobalState(NewContext(ContextConfig{
+			Permissions: []Permission{
+				GlobalVarPermission{Kind_: permbase.Read, Name: "*"},
+				GlobalVarPermission{Kind_: permbase.Use, Name: "*"},
+				GlobalVarPermission{Kind_: permbase.Create, Name: "*"},
+				LThreadPermission{permbase.Create},
+			},
+			Limits: []Limit{permissiveLthreadLimit},
+		}))
+		defer state.Ctx.CancelGracefully()
+
+		chunk := utils.Must(parse.ParseChunkSource(sourcecode.InMemorySource{
+			NameString: "lthread-test",
+			CodeString: "coyield 0; return 1",
+		}))
+
+		lthread, err := SpawnLThread(LthreadSpawnArgs{
+			SpawnerState: state,
+			Globals:      GlobalVariablesFromMap(map[string]Value{}, nil),
+			Module: WrapLowerModule(&inoxmod.Module{
+				MainChunk:    chunk,
+				TopLevelNode: chunk.Node,
+				Kind:         UserLThreadModule,
+			}),
+			PauseAfterYield: true,
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		for !lthread.IsPaused() {
+			time.Sleep(10 * time.Millisecond)
+		}
+		time.Sleep(100 * time.Millisecond)
+
+		if !assert.NoError(t, lthread.ResumeAsync()) {
+			return
+		}
+
+		_, err = lthread.WaitResult(state.Ctx)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.Less(t, lthread.CPUTime(), 50*time.Millisecond)
+		assert.Equal(t, Duration(lthread.CPUTime()), lthread.Prop(state.Ctx, "cpu_time"))
+		assert.Equal(t, Nil, lthread.Prop(state.Ctx, "cpu_budget"))
+	})
+
+	t.Run("a lthread should be cancelled when its CPU time budget is exhausted", func(t *testing.T) {
+		state := NewGlobalState(NewContext(ContextConfig{
+			Permissions: []Permission{
+				GlobalVarPermission{Kind_: permbase.Read, Name: "*"},
+				GlobalVarPermission{Kind_: permbase.Use, Name: "*"},
+				GlobalVarPermission{Kind_: permbase.Create, Name: "*"},
+				LThreadPermission{permbase.Create},
+			},
+			Limits: []Limit{permissiveLthreadLimit},
+		}))
+		defer state.Ctx.CancelGracefully()
+
+		chunk := utils.Must(parse.ParseChunkSource(sourcecode.InMemorySource{
+			NameString: "lthread-test",
+			CodeString: "for i in 1..100_000_000_000 {}",
+		}))
+
+		lthreadCtx := NewContext(ContextConfig{
+			Permissions: []Permission{
+				GlobalVarPermission{Kind_: permbase.Read, Name: "*"},
+				GlobalVarPermission{Kind_: permbase.Use, Name: "*"},
+				GlobalVarPermission{Kind_: permbase.Create, Name: "*"},
+			},
+			CPUTimeBudget: 50 * time.Millisecond,
+			ParentContext: state.Ctx,
+		})
+
+		lthread, err := SpawnLThread(LthreadSpawnArgs{
+			SpawnerState: state,
+			Globals:      GlobalVariablesFromMap(map[string]Value{}, nil),
+			Module: WrapLowerModule(&inoxmod.Module{
+				MainChunk:    chunk,
+				TopLevelNode: chunk.Node,
+				Kind:         UserLThreadModule,
+			}),
+			LthreadCtx: lthreadCtx,
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		start := time.Now()
+		_, err = lthread.WaitResult(state.Ctx)
+
+		assert.ErrorIs(t, err, ErrCPUTimeBudgetExhausted)
+		assert.Less(t, time.Since(start), time.Second)
+		assert.GreaterOrEqual(t, lthread.CPUTime(), 50*time.Millisecond)
+		assert.Equal(t, Duration(50*time.Millisecond), lthread.Prop(state.Ctx, "cpu_budget"))
+	})
 }
@@ -74,12 +74,14 @@ type Manifest struct {
 	//note: permissions required for reading the preinit files are in .PreinitFiles.
 	RequiredPermissions []Permission
 	Limits              []Limit
+	explicitLimitNames  []string //names of the limits specified in the limits section
 
 	HostDefinitions map[Host]Value
 	EnvPattern      *ObjectPattern
 	Parameters      ModuleParameters
 	PreinitFiles    PreinitFiles
 	AutoInvocation  *AutoInvocationConfig //can be nil
+	RetryPolicy     *RetryPolicy          //can be nil
 
 	InitialWorkingDirectory Path
 }
@@ -119,6 +121,15 @@ type PreinitFile struct {
 	Content            []byte
 	Parsed             Serializable
 	ReadParseError     error
+
+	//set if the file is an OpenAPI document, the pattern namespace of the document is defined by the preinit
+	//and is named after the file.
+	IsOpenAPIDocument bool
+	OpenAPIDocument   *OpenAPIDocument
+
+	//set if the file is a configuration file (e.g. TOML), one of inoxconsts.PREINIT_FILE_FORMATS.
+	//The content is parsed into an immutable value that should match the pattern.
+	Format string
 }
 
 func (p *ModuleParameters) PositionalParameters() []ModuleParameter {
@@ -670,7 +681,9 @@ func (m *Module) createManifest(ctx *Context, object *Object, config manifestObj
 	)
 	permListing := NewObject()
 	limits := make(map[string]Limit, 0)
+	var explicitLimitNames []string
 	hostDefinitions := make(map[Host]Value, 0)
+	var retryPolicy *RetryPolicy
 	specifiedGlobalPermKinds := map[PermissionKind]bool{}
 	actualModuleKind := m.Kind
 	manifestModuleKind := UnspecifiedModuleKind
@@ -701,12 +714,23 @@ func (m *Module) createManifest(ctx *Context, object *Object, config manifestObj
 				return err
 			}
 			maps.Copy(limits, l)
+			explicitLimitNames = append(explicitLimitNames, maps.Keys(l)...)
 		case inoxconsts.MANIFEST_HOST_DEFINITIONS_SECTION_NAME:
 			definitions, err := getHostDefinitions(v)
 			if err != nil {
 				return err
 			}
 			hostDefinitions = definitions
+		case inoxconsts.MANIFEST_RETRY_POLICY_SECTION_NAME:
+			desc, ok := v.(*Record)
+			if !ok {
+				return fmt.Errorf("invalid manifest, the " + k + " section should have a value of type record")
+			}
+			policy, err := NewRetryPolicyFromRecord(ctx, desc)
+			if err != nil {
+				return fmt.Errorf("invalid manifest: %w", err)
+			}
+			retryPolicy = policy
 		case inoxconsts.MANIFEST_PERMS_SECTION_NAME:
 			listing, ok := v.(*Object)
 			if !ok {
@@ -779,11 +803,13 @@ func (m *Module) createManifest(ctx *Context, object *Object, config manifestObj
 		explicitModuleKind:      manifestModuleKind,
 		RequiredPermissions:     perms,
 		Limits:                  maps.Values(limits),
+		explicitLimitNames:      explicitLimitNames,
 		HostDefinitions:         hostDefinitions,
 		EnvPattern:              envPattern,
 		Parameters:              moduleParams,
 		PreinitFiles:            config.preinitFileConfigs,
 		AutoInvocation:          autoInvocation,
+		RetryPolicy:             retryPolicy,
 		InitialWorkingDirectory: config.initialWorkingDirectory,
 	}, nil
 }
@@ -0,0 +1,411 @@
+package core
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/inoxlang/inox/internal/core/symbolic"
+)
+
+// A ManifestDiff describes the differences between the manifests of two versions of a module,
+// it is mostly used to review the upgrade of a dependency. The zero value represents an absence of differences.
+type ManifestDiff struct {
+	//permissions required by the new manifest that neither include nor are included in an old permission.
+	AddedPermissions []Permission
+
+	//permissions of the old manifest that are not included in any new permission and that include no new permission.
+	RemovedPermissions []Permission
+
+	//permissions of the old manifest that are included in a broader new permission.
+	WidenedPermissions []PermissionChange
+
+	//permissions of the old manifest that are replaced by narrower new permissions.
+	NarrowedPermissions []PermissionChange
+
+	LimitChanges []LimitChange
+
+	AddedEnvEntries   []ManifestPatternEntryChange
+	RemovedEnvEntries []ManifestPatternEntryChange
+	ChangedEnvEntries []ManifestPatternEntryChange
+
+	AddedParameters   []ManifestParameterChange
+	RemovedParameters []ManifestParameterChange
+	ChangedParameters []ManifestParameterChange
+}
+
+type PermissionChange struct {
+	Old Permission
+	New Permission
+}
+
+type LimitChange struct {
+	Name string
+	Old  *Limit //nil if the limit is added
+	New  *Limit //nil if the limit is removed
+}
+
+// IsLoosened returns true if the new limit is less restrictive than the old one, or if the limit is removed.
+func (c LimitChange) IsLoosened() bool {
+	if c.New == nil {
+		return true
+	}
+	if c.Old == nil || c.Old.Kind != c.New.Kind {
+		return false
+	}
+	return c.New.Value > c.Old.Value
+}
+
+// A ManifestPatternEntryChange describes a change of an entry in a pattern of the manifest (e.g. the env pattern),
+// the patterns are stringified.
+type ManifestPatternEntryChange struct {
+	Name        string
+	OldPattern  string //empty if the entry is added
+	NewPattern  string //empty if the entry is removed
+	OldOptional bool
+	NewOptional bool
+}
+
+type ManifestParameterChange struct {
+	Name          string
+	OldPattern    string //empty if the parameter is added
+	NewPattern    string //empty if the parameter is removed
+	OldPositional bool
+	NewPositional bool
+}
+
+// DiffModuleManifests pre-initializes two versions of a module and computes the differences between their manifests.
+// The .GlobalConsts & .PreinitStatement fields of preinitArgs are set for each version.
+func DiffModuleManifests(oldVersion, newVersion *Module, preinitArgs PreinitArgs) (*ManifestDiff, error) {
+	oldManifest, err := preinitModuleForDiff(oldVersion, preinitArgs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pre-initialize the old version of the module: %w", err)
+	}
+
+	newManifest, err := preinitModuleForDiff(newVersion, preinitArgs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pre-initialize the new version of the module: %w", err)
+	}
+
+	return DiffManifests(oldManifest, newManifest), nil
+}
+
+func preinitModuleForDiff(mod *Module, preinitArgs PreinitArgs) (*Manifest, error) {
+	chunk := mod.MainChunk.Node
+	preinitArgs.GlobalConsts = chunk.GlobalConstantDeclarations
+	preinitArgs.PreinitStatement = chunk.Preinit
+	preinitArgs.RunningState = nil
+
+	manifest, _, _, err := mod.PreInit(preinitArgs)
+	if err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// DiffManifests computes the differences between two manifests, the result is deterministic.
+func DiffManifests(oldManifest, newManifest *Manifest) *ManifestDiff {
+	diff := &ManifestDiff{}
+
+	diff.diffPermissions(oldManifest.RequiredPermissions, newManifest.RequiredPermissions)
+	diff.diffLimits(oldManifest.Limits, newManifest.Limits)
+	diff.diffEnvPatterns(oldManifest.EnvPattern, newManifest.EnvPattern)
+	diff.diffParameters(oldManifest.Parameters, newManifest.Parameters)
+
+	return diff
+}
+
+func (d *ManifestDiff) diffPermissions(oldPerms, newPerms []Permission) {
+	for _, newPerm := range newPerms {
+		coveredByOld := slices.ContainsFunc(oldPerms, func(oldPerm Permission) bool {
+			return oldPerm.Includes(newPerm)
+		})
+		if coveredByOld {
+			continue
+		}
+
+		widened := false
+		for _, oldPerm := range oldPerms {
+			if newPerm.Includes(oldPerm) {
+				widened = true
+				d.WidenedPermissions = append(d.WidenedPermissions, PermissionChange{Old: oldPerm, New: newPerm})
+			}
+		}
+
+		if !widened {
+			d.AddedPermissions = append(d.AddedPermissions, newPerm)
+		}
+	}
+
+	for _, oldPerm := range oldPerms {
+		coveredByNew := slices.ContainsFunc(newPerms, func(newPerm Permission) bool {
+			return newPerm.Includes(oldPerm)
+		})
+		if coveredByNew {
+			continue
+		}
+
+		narrowed := false
+		for _, newPerm := range newPerms {
+			if oldPerm.Includes(newPerm) {
+				narrowed = true
+				d.NarrowedPermissions = append(d.NarrowedPermissions, PermissionChange{Old: oldPerm, New: newPerm})
+			}
+		}
+
+		if !narrowed {
+			d.RemovedPermissions = append(d.RemovedPermissions, oldPerm)
+		}
+	}
+}
+
+func (d *ManifestDiff) diffLimits(oldLimits, newLimits []Limit) {
+	findLimit := func(limits []Limit, name string) *Limit {
+		index := slices.IndexFunc(limits, func(l Limit) bool { return l.Name == name })
+		if index < 0 {
+			return nil
+		}
+		limit := limits[index]
+		return &limit
+	}
+
+	var names []string
+	for _, limit := range oldLimits {
+		names = append(names, limit.Name)
+	}
+	for _, limit := range newLimits {
+		if !slices.Contains(names, limit.Name) {
+			names = append(names, limit.Name)
+		}
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		oldLimit := findLimit(oldLimits, name)
+		newLimit := findLimit(newLimits, name)
+
+		if oldLimit != nil && newLimit != nil && oldLimit.Kind == newLimit.Kind && oldLimit.Value == newLimit.Value {
+			continue
+		}
+
+		d.LimitChanges = append(d.LimitChanges, LimitChange{Name: name, Old: oldLimit, New: newLimit})
+	}
+}
+
+func (d *ManifestDiff) diffEnvPatterns(oldPattern, newPattern *ObjectPattern) {
+	var oldEntries, newEntries []ObjectPatternEntry
+	if oldPattern != nil {
+		oldEntries = oldPattern.entries
+	}
+	if newPattern != nil {
+		newEntries = newPattern.entries
+	}
+
+	for _, newEntry := range newEntries {
+		change := ManifestPatternEntryChange{
+			Name:        newEntry.Name,
+			NewPattern:  stringifyManifestPattern(newEntry.Pattern),
+			NewOptional: newEntry.IsOptional,
+		}
+
+		oldEntry, ok := ObjectPatternEntriesHelper(oldEntries).CompleteEntry(newEntry.Name)
+		if !ok {
+			d.AddedEnvEntries = append(d.AddedEnvEntries, change)
+			continue
+		}
+
+		change.OldPattern = stringifyManifestPattern(oldEntry.Pattern)
+		change.OldOptional = oldEntry.IsOptional
+
+		if change.OldPattern != change.NewPattern || change.OldOptional != change.NewOptional {
+			d.ChangedEnvEntries = append(d.ChangedEnvEntries, change)
+		}
+	}
+
+	for _, oldEntry := range oldEntries {
+		if !ObjectPatternEntriesHelper(newEntries).HasRequiredOrOptionalEntry(oldEntry.Name) {
+			d.RemovedEnvEntries = append(d.RemovedEnvEntries, ManifestPatternEntryChange{
+				Name:        oldEntry.Name,
+				OldPattern:  stringifyManifestPattern(oldEntry.Pattern),
+				OldOptional: oldEntry.IsOptional,
+			})
+		}
+	}
+}
+
+func (d *ManifestDiff) diffParameters(oldParams, newParams ModuleParameters) {
+	oldList := append(oldParams.PositionalParameters(), oldParams.NonPositionalParameters()...)
+	newList := append(newParams.PositionalParameters(), newParams.NonPositionalParameters()...)
+
+	findParam := func(params []ModuleParameter, name string) (ModuleParameter, bool) {
+		index := slices.IndexFunc(params, func(p ModuleParameter) bool { return p.Name() == name })
+		if index < 0 {
+			return ModuleParameter{}, false
+		}
+		return params[index], true
+	}
+
+	for _, newParam := range newList {
+		change := ManifestParameterChange{
+			Name:          newParam.Name(),
+			NewPattern:    newParam.StringifiedPattern(),
+			NewPositional: newParam.positional,
+		}
+
+		oldParam, ok := findParam(oldList, newParam.Name())
+		if !ok {
+			d.AddedParameters = append(d.AddedParameters, change)
+			continue
+		}
+
+		change.OldPattern = oldParam.StringifiedPattern()
+		change.OldPositional = oldParam.positional
+
+		if change.OldPattern != change.NewPattern || change.OldPositional != change.NewPositional {
+			d.ChangedParameters = append(d.ChangedParameters, change)
+		}
+	}
+
+	for _, oldParam := range oldList {
+		if _, ok := findParam(newList, oldParam.Name()); !ok {
+			d.RemovedParameters = append(d.RemovedParameters, ManifestParameterChange{
+				Name:          oldParam.Name(),
+				OldPattern:    oldParam.StringifiedPattern(),
+				OldPositional: oldParam.positional,
+			})
+		}
+	}
+}
+
+// IsEmpty returns true if the manifests have no differences.
+func (d *ManifestDiff) IsEmpty() bool {
+	return len(d.AddedPermissions) == 0 && len(d.RemovedPermissions) == 0 &&
+		len(d.WidenedPermissions) == 0 && len(d.NarrowedPermissions) == 0 &&
+		len(d.LimitChanges) == 0 &&
+		len(d.AddedEnvEntries) == 0 && len(d.RemovedEnvEntries) == 0 && len(d.ChangedEnvEntries) == 0 &&
+		len(d.AddedParameters) == 0 && len(d.RemovedParameters) == 0 && len(d.ChangedParameters) == 0
+}
+
+// RequiresReview returns true if the new manifest requires more than the old one: added or widened permissions,
+// loosened limits, new required env entries or new parameters.
+func (d *ManifestDiff) RequiresReview() bool {
+	if len(d.AddedPermissions) > 0 || len(d.WidenedPermissions) > 0 || len(d.AddedEnvEntries) > 0 ||
+		len(d.ChangedEnvEntries) > 0 || len(d.AddedParameters) > 0 || len(d.ChangedParameters) > 0 {
+		return true
+	}
+
+	return slices.ContainsFunc(d.LimitChanges, func(c LimitChange) bool {
+		return c.IsLoosened()
+	})
+}
+
+// String returns a human-readable report of the differences, suitable for code review.
+func (d *ManifestDiff) String() string {
+	if d.IsEmpty() {
+		return "no changes\n"
+	}
+
+	buf := &strings.Builder{}
+
+	writeSection := func(title string, lines []string) {
+		if len(lines) == 0 {
+			return
+		}
+		buf.WriteString(title)
+		buf.WriteString(":\n")
+		for _, line := range lines {
+			buf.WriteString("  ")
+			buf.WriteString(line)
+			buf.WriteByte('\n')
+		}
+	}
+
+	var lines []string
+
+	//permissions
+
+	for _, perm := range d.AddedPermissions {
+		lines = append(lines, "+ "+perm.String())
+	}
+	for _, change := range d.WidenedPermissions {
+		lines = append(lines, fmt.Sprintf("~ %s (widened, was %s)", change.New.String(), change.Old.String()))
+	}
+	for _, change := range d.NarrowedPermissions {
+		lines = append(lines, fmt.Sprintf("~ %s (narrowed, was %s)", change.New.String(), change.Old.String()))
+	}
+	for _, perm := range d.RemovedPermissions {
+		lines = append(lines, "- "+perm.String())
+	}
+	writeSection("permissions", lines)
+	lines = nil
+
+	//limits
+
+	for _, change := range d.LimitChanges {
+		switch {
+		case change.Old == nil:
+			lines = append(lines, fmt.Sprintf("+ %s: %d", change.Name, change.New.Value))
+		case change.New == nil:
+			lines = append(lines, fmt.Sprintf("- %s: %d", change.Name, change.Old.Value))
+		default:
+			lines = append(lines, fmt.Sprintf("~ %s: %d -> %d", change.Name, change.Old.Value, change.New.Value))
+		}
+	}
+	writeSection("limits", lines)
+	lines = nil
+
+	//env
+
+	optionalSuffix := func(optional bool) string {
+		if optional {
+			return "?"
+		}
+		return ""
+	}
+
+	for _, change := range d.AddedEnvEntries {
+		lines = append(lines, fmt.Sprintf("+ %s%s: %s", change.Name, optionalSuffix(change.NewOptional), change.NewPattern))
+	}
+	for _, change := range d.ChangedEnvEntries {
+		lines = append(lines, fmt.Sprintf("~ %s%s: %s -> %s%s: %s",
+			change.Name, optionalSuffix(change.OldOptional), change.OldPattern,
+			change.Name, optionalSuffix(change.NewOptional), change.NewPattern))
+	}
+	for _, change := range d.RemovedEnvEntries {
+		lines = append(lines, fmt.Sprintf("- %s%s: %s", change.Name, optionalSuffix(change.OldOptional), change.OldPattern))
+	}
+	writeSection("env", lines)
+	lines = nil
+
+	//parameters
+
+	positionalSuffix := func(positional bool) string {
+		if positional {
+			return " (positional)"
+		}
+		return ""
+	}
+
+	for _, change := range d.AddedParameters {
+		lines = append(lines, fmt.Sprintf("+ %s: %s%s", change.Name, change.NewPattern, positionalSuffix(change.NewPositional)))
+	}
+	for _, change := range d.ChangedParameters {
+		lines = append(lines, fmt.Sprintf("~ %s: %s%s -> %s%s", change.Name,
+			change.OldPattern, positionalSuffix(change.OldPositional),
+			change.NewPattern, positionalSuffix(change.NewPositional)))
+	}
+	for _, change := range d.RemovedParameters {
+		lines = append(lines, fmt.Sprintf("- %s: %s%s", change.Name, change.OldPattern, positionalSuffix(change.OldPositional)))
+	}
+	writeSection("parameters", lines)
+
+	return buf.String()
+}
+
+func stringifyManifestPattern(patt Pattern) string {
+	symb, err := patt.ToSymbolicValue(nil, map[uintptr]symbolic.Value{})
+	if err != nil {
+		return fmt.Sprintf("<%T>", patt)
+	}
+	return symbolic.Stringify(symb.(symbolic.Pattern).SymbolicValue())
+}
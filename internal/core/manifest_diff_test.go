@@ -0,0 +1,134 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/inoxlang/inox/internal/core/permbase"
+	"github.com/inoxlang/inox/internal/testconfig"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffManifests(t *testing.T) {
+	testconfig.AllowParallelization(t)
+
+	readTmp := FilesystemPermission{Kind_: permbase.Read, Entity: PathPattern("/tmp/...")}
+	readTmpFile := FilesystemPermission{Kind_: permbase.Read, Entity: Path("/tmp/file.txt")}
+	readAny := FilesystemPermission{Kind_: permbase.Read, Entity: PathPattern("/...")}
+	writeTmp := FilesystemPermission{Kind_: permbase.Write, Entity: PathPattern("/tmp/...")}
+
+	t.Run("identical manifests", func(t *testing.T) {
+		manifest := &Manifest{RequiredPermissions: []Permission{readTmp}}
+		diff := DiffManifests(manifest, manifest)
+
+		assert.True(t, diff.IsEmpty())
+		assert.False(t, diff.RequiresReview())
+		assert.Equal(t, "no changes\n", diff.String())
+	})
+
+	t.Run("permissions", func(t *testing.T) {
+		diff := DiffManifests(
+			&Manifest{RequiredPermissions: []Permission{readTmp}},
+			&Manifest{RequiredPermissions: []Permission{readAny, writeTmp}},
+		)
+
+		assert.Equal(t, []Permission{writeTmp}, diff.AddedPermissions)
+		assert.Equal(t, []PermissionChange{{Old: readTmp, New: readAny}}, diff.WidenedPermissions)
+		assert.Empty(t, diff.RemovedPermissions)
+		assert.Empty(t, diff.NarrowedPermissions)
+		assert.True(t, diff.RequiresReview())
+
+		diff = DiffManifests(
+			&Manifest{RequiredPermissions: []Permission{readTmp, writeTmp}},
+			&Manifest{RequiredPermissions: []Permission{readTmpFile}},
+		)
+
+		assert.Empty(t, diff.AddedPermissions)
+		assert.Empty(t, diff.WidenedPermissions)
+		assert.Equal(t, []Permission{writeTmp}, diff.RemovedPermissions)
+		assert.Equal(t, []PermissionChange{{Old: readTmp, New: readTmpFile}}, diff.NarrowedPermissions)
+		assert.False(t, diff.RequiresReview())
+	})
+
+	t.Run("limits", func(t *testing.T) {
+		limitA := Limit{Name: "a", Kind: TotalLimit, Value: 10}
+		limitAIncreased := Limit{Name: "a", Kind: TotalLimit, Value: 20}
+		limitB := Limit{Name: "b", Kind: TotalLimit, Value: 1}
+		limitC := Limit{Name: "c", Kind: TotalLimit, Value: 1}
+
+		diff := DiffManifests(
+			&Manifest{Limits: []Limit{limitA, limitB}},
+			&Manifest{Limits: []Limit{limitC, limitAIncreased}},
+		)
+
+		assert.Equal(t, []LimitChange{
+			{Name: "a", Old: &limitA, New: &limitAIncreased},
+			{Name: "b", Old: &limitB},
+			{Name: "c", New: &limitC},
+		}, diff.LimitChanges)
+		assert.True(t, diff.LimitChanges[0].IsLoosened())
+		assert.True(t, diff.LimitChanges[1].IsLoosened())
+		assert.False(t, diff.LimitChanges[2].IsLoosened())
+		assert.True(t, diff.RequiresReview())
+
+		assert.Equal(t, "limits:\n  ~ a: 10 -> 20\n  - b: 1\n  + c: 1\n", diff.String())
+	})
+
+	t.Run("env", func(t *testing.T) {
+		diff := DiffManifests(
+			&Manifest{EnvPattern: NewInexactObjectPattern([]ObjectPatternEntry{
+				{Name: "A", Pattern: STR_PATTERN},
+				{Name: "B", Pattern: STR_PATTERN},
+			})},
+			&Manifest{EnvPattern: NewInexactObjectPattern([]ObjectPatternEntry{
+				{Name: "A", Pattern: INT_PATTERN},
+				{Name: "C", Pattern: STR_PATTERN, IsOptional: true},
+			})},
+		)
+
+		assert.Equal(t, []ManifestPatternEntryChange{{Name: "C", NewPattern: "%string-like", NewOptional: true}}, diff.AddedEnvEntries)
+		assert.Equal(t, []ManifestPatternEntryChange{{Name: "A", OldPattern: "%string-like", NewPattern: "%int"}}, diff.ChangedEnvEntries)
+		assert.Equal(t, []ManifestPatternEntryChange{{Name: "B", OldPattern: "%string-like"}}, diff.RemovedEnvEntries)
+	})
+}
+
+func TestDiffModuleManifests(t *testing.T) {
+	testconfig.AllowParallelization(t)
+
+	parse := func(t *testing.T, code string) *Module {
+		mod, err := ParseInMemoryModule(code, InMemoryModuleParsingConfig{Name: "/main.ix"})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		return mod
+	}
+
+	oldVersion := parse(t, `
+		manifest {
+			parameters: {
+				name: %str
+			}
+		}
+	`)
+
+	newVersion := parse(t, `
+		manifest {
+			parameters: {
+				name: %int
+				verbose: %bool
+			}
+		}
+	`)
+
+	diff, err := DiffModuleManifests(oldVersion, newVersion, PreinitArgs{})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, []ManifestParameterChange{{Name: "verbose", NewPattern: "%boolean"}}, diff.AddedParameters)
+	assert.Equal(t, []ManifestParameterChange{{Name: "name", OldPattern: "%string-like", NewPattern: "%int"}}, diff.ChangedParameters)
+	assert.Empty(t, diff.RemovedParameters)
+	assert.Empty(t, diff.LimitChanges)
+	assert.True(t, diff.RequiresReview())
+
+	assert.Equal(t, "parameters:\n  + verbose: %boolean\n  ~ name: %string-like -> %int\n", diff.String())
+}
@@ -0,0 +1,347 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/inoxlang/inox/internal/core/inoxmod"
+	"github.com/inoxlang/inox/internal/core/permbase"
+	"github.com/inoxlang/inox/internal/inoxconsts"
+	"golang.org/x/exp/maps"
+)
+
+const (
+	MANIFEST_POLICY_DEFAULT_RULES_KEY = "default"
+
+	MANIFEST_POLICY_ALLOWED_PERMS_PROPNAME   = "allowed-permissions"
+	MANIFEST_POLICY_FORBIDDEN_PERMS_PROPNAME = "forbidden-permissions"
+	MANIFEST_POLICY_MAX_LIMITS_PROPNAME      = "max-limits"
+	MANIFEST_POLICY_REQUIRED_LIMITS_PROPNAME = "required-limits"
+)
+
+var (
+	ErrInvalidManifestPolicy = errors.New("invalid manifest policy")
+)
+
+// A ManifestPolicy constrains what the manifests of modules are allowed to declare, it is typically defined
+// at the organization level. The policy is checked during the pre-initialization of modules (see PreinitArgs),
+// so a non-compliant module fails before its execution. A ManifestPolicy should not be modified after its creation.
+type ManifestPolicy struct {
+	defaultRules *ManifestPolicyRules //can be nil
+	kindRules    map[ModuleKind]*ManifestPolicyRules
+}
+
+// ManifestPolicyRules are the rules a manifest should comply with.
+type ManifestPolicyRules struct {
+	//If not nil each permission required by the manifest should be included in at least one of the allowed permissions.
+	AllowedPermissions []Permission
+
+	//The manifest should not require a permission that includes one of the forbidden permissions.
+	ForbiddenPermissions []Permission
+
+	//The value of a limit specified in the manifest should not be greater than the maximum value.
+	MaxLimits []Limit
+
+	//Names of the limits that should be specified in the manifest.
+	RequiredLimits []string
+}
+
+// NewManifestPolicy creates a policy, kindRules contains the rules for specific module kinds,
+// defaultRules (optional) apply to the module kinds that have no specific rules.
+func NewManifestPolicy(defaultRules *ManifestPolicyRules, kindRules map[ModuleKind]*ManifestPolicyRules) *ManifestPolicy {
+	policy := &ManifestPolicy{
+		defaultRules: defaultRules,
+		kindRules:    map[ModuleKind]*ManifestPolicyRules{},
+	}
+	maps.Copy(policy.kindRules, kindRules)
+	return policy
+}
+
+// NewManifestPolicyFromValue creates a policy from a record or an object (usually the result of a policy module).
+// The keys of the description are module kind names (e.g. application, spec, testsuite, testcase) or 'default'.
+// Each value describes the rules for the module kind:
+//
+//	#{
+//	    application: #{
+//	        allowed-permissions: #{read: %/..., write: %/tmp/...},
+//	        forbidden-permissions: #{delete: %/...},
+//	        max-limits: #{"fs/read": 10MB/s},
+//	        required-limits: #["fs/read"]
+//	    }
+//	}
+func NewManifestPolicyFromValue(ctx *Context, desc Value) (*ManifestPolicy, error) {
+	entries, ok := getManifestPolicyDescriptionEntries(ctx, desc)
+	if !ok {
+		return nil, fmt.Errorf("%w: the description should be a record or an object", ErrInvalidManifestPolicy)
+	}
+
+	policy := &ManifestPolicy{kindRules: map[ModuleKind]*ManifestPolicyRules{}}
+
+	keys := maps.Keys(entries)
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		rules, err := getManifestPolicyRules(ctx, entries[key])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalidManifestPolicy, key, err)
+		}
+
+		if key == MANIFEST_POLICY_DEFAULT_RULES_KEY {
+			policy.defaultRules = rules
+			continue
+		}
+
+		kind, err := inoxmod.ParseModuleKind(key)
+		if err != nil {
+			return nil, fmt.Errorf("%w: '%s' is not a module kind", ErrInvalidManifestPolicy, key)
+		}
+		policy.kindRules[kind] = rules
+	}
+
+	return policy, nil
+}
+
+// RulesFor returns the rules applying to modules of the given kind, nil is returned if no rules apply.
+func (p *ManifestPolicy) RulesFor(kind ModuleKind) *ManifestPolicyRules {
+	rules, ok := p.kindRules[kind]
+	if ok {
+		return rules
+	}
+	return p.defaultRules
+}
+
+// Check checks that the manifest of a module of the given kind complies with the policy,
+// a *ManifestPolicyViolationError listing all violations is returned if it does not.
+func (p *ManifestPolicy) Check(moduleName string, kind ModuleKind, manifest *Manifest) error {
+	rules := p.RulesFor(kind)
+	if rules == nil {
+		return nil
+	}
+
+	var violations []ManifestPolicyViolation
+
+	//permissions
+
+	for _, requiredPerm := range manifest.RequiredPermissions {
+		if rules.AllowedPermissions != nil {
+			allowed := slices.ContainsFunc(rules.AllowedPermissions, func(allowedPerm Permission) bool {
+				return allowedPerm.Includes(requiredPerm)
+			})
+
+			if !allowed {
+				violations = append(violations, ManifestPolicyViolation{
+					Section: inoxconsts.MANIFEST_PERMS_SECTION_NAME,
+					Message: fmt.Sprintf("the permission %s is not included in any of the allowed permissions", requiredPerm.String()),
+				})
+			}
+		}
+	}
+
+	for _, forbiddenPerm := range rules.ForbiddenPermissions {
+		for _, requiredPerm := range manifest.RequiredPermissions {
+			switch {
+			case forbiddenPerm.Includes(requiredPerm):
+				violations = append(violations, ManifestPolicyViolation{
+					Section: inoxconsts.MANIFEST_PERMS_SECTION_NAME,
+					Message: fmt.Sprintf("the permission %s is included in the forbidden permission %s", requiredPerm.String(), forbiddenPerm.String()),
+				})
+			case requiredPerm.Includes(forbiddenPerm):
+				violations = append(violations, ManifestPolicyViolation{
+					Section: inoxconsts.MANIFEST_PERMS_SECTION_NAME,
+					Message: fmt.Sprintf("the permission %s includes the forbidden permission %s", requiredPerm.String(), forbiddenPerm.String()),
+				})
+			}
+		}
+	}
+
+	//limits
+
+	for _, maxLimit := range rules.MaxLimits {
+		limit, ok := manifest.explicitLimit(maxLimit.Name)
+		if !ok {
+			continue
+		}
+		if limit.Kind != maxLimit.Kind {
+			violations = append(violations, ManifestPolicyViolation{
+				Section: inoxconsts.MANIFEST_LIMITS_SECTION_NAME,
+				Message: fmt.Sprintf("the limit '%s' has not the same kind as the maximum limit defined by the policy", limit.Name),
+			})
+			continue
+		}
+		if limit.Value > maxLimit.Value {
+			violations = append(violations, ManifestPolicyViolation{
+				Section: inoxconsts.MANIFEST_LIMITS_SECTION_NAME,
+				Message: fmt.Sprintf("the value of the limit '%s' (%d) is greater than the maximum allowed value (%d)", limit.Name, limit.Value, maxLimit.Value),
+			})
+		}
+	}
+
+	for _, limitName := range rules.RequiredLimits {
+		if _, ok := manifest.explicitLimit(limitName); !ok {
+			violations = append(violations, ManifestPolicyViolation{
+				Section: inoxconsts.MANIFEST_LIMITS_SECTION_NAME,
+				Message: fmt.Sprintf("the limit '%s' is required by the policy but is not specified", limitName),
+			})
+		}
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+
+	return &ManifestPolicyViolationError{
+		ModuleName: moduleName,
+		ModuleKind: kind,
+		Violations: violations,
+	}
+}
+
+// A ManifestPolicyViolation describes why a manifest does not comply with a ManifestPolicy.
+type ManifestPolicyViolation struct {
+	Section string //name of the manifest section
+	Message string
+}
+
+type ManifestPolicyViolationError struct {
+	ModuleName string
+	ModuleKind ModuleKind
+	Violations []ManifestPolicyViolation
+}
+
+func (err *ManifestPolicyViolationError) Error() string {
+	buf := strings.Builder{}
+	buf.WriteString(fmt.Sprintf("the manifest of %s (%s module) does not comply with the manifest policy:", err.ModuleName, err.ModuleKind))
+
+	for _, violation := range err.Violations {
+		buf.WriteString("\n- ")
+		buf.WriteString(violation.Section)
+		buf.WriteString(": ")
+		buf.WriteString(violation.Message)
+	}
+
+	return buf.String()
+}
+
+func getManifestPolicyDescriptionEntries(ctx *Context, desc Value) (map[string]Value, bool) {
+	switch d := desc.(type) {
+	case *Record:
+		return d.ValueEntryMap(), true
+	case *Object:
+		return d.ValueEntryMap(ctx), true
+	}
+	return nil, false
+}
+
+func getManifestPolicyRules(ctx *Context, desc Value) (*ManifestPolicyRules, error) {
+	entries, ok := getManifestPolicyDescriptionEntries(ctx, desc)
+	if !ok {
+		return nil, errors.New("rules should be described by a record or an object")
+	}
+
+	rules := &ManifestPolicyRules{}
+
+	for propName, propValue := range entries {
+		switch propName {
+		case MANIFEST_POLICY_ALLOWED_PERMS_PROPNAME, MANIFEST_POLICY_FORBIDDEN_PERMS_PROPNAME:
+			perms, err := getManifestPolicyPermissions(ctx, propValue)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", propName, err)
+			}
+			if propName == MANIFEST_POLICY_ALLOWED_PERMS_PROPNAME {
+				rules.AllowedPermissions = perms
+			} else {
+				rules.ForbiddenPermissions = perms
+			}
+		case MANIFEST_POLICY_MAX_LIMITS_PROPNAME:
+			limitEntries, ok := getManifestPolicyDescriptionEntries(ctx, propValue)
+			if !ok {
+				return nil, fmt.Errorf("%s: description of limits should be a record or an object", propName)
+			}
+
+			for limitName, limitValue := range limitEntries {
+				serializable, ok := limitValue.(Serializable)
+				if !ok {
+					return nil, fmt.Errorf("%s: invalid value for limit '%s'", propName, limitName)
+				}
+
+				limit, err := GetLimit(ctx, limitName, serializable)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", propName, err)
+				}
+				rules.MaxLimits = append(rules.MaxLimits, limit)
+			}
+
+			slices.SortFunc(rules.MaxLimits, func(a, b Limit) int {
+				return strings.Compare(a.Name, b.Name)
+			})
+		case MANIFEST_POLICY_REQUIRED_LIMITS_PROPNAME:
+			list, ok := propValue.(Indexable)
+			if !ok {
+				return nil, fmt.Errorf("%s: a list or a tuple of limit names is expected", propName)
+			}
+
+			for i := 0; i < list.Len(); i++ {
+				name, ok := list.At(ctx, i).(StringLike)
+				if !ok {
+					return nil, fmt.Errorf("%s: a list or a tuple of limit names is expected", propName)
+				}
+				rules.RequiredLimits = append(rules.RequiredLimits, name.GetOrBuildString())
+			}
+		default:
+			return nil, fmt.Errorf("unknown property '%s'", propName)
+		}
+	}
+
+	return rules, nil
+}
+
+func getManifestPolicyPermissions(ctx *Context, desc Value) ([]Permission, error) {
+	entries, ok := getManifestPolicyDescriptionEntries(ctx, desc)
+	if !ok {
+		return nil, errors.New("description of permissions should be a record or an object")
+	}
+
+	perms := make([]Permission, 0)
+	kindNames := maps.Keys(entries)
+	slices.Sort(kindNames)
+
+	for _, kindName := range kindNames {
+		permKind, ok := permbase.PermissionKindFromString(kindName)
+		if !ok {
+			return nil, fmt.Errorf("invalid permission kind: %s", kindName)
+		}
+
+		p, err := getSingleKindPermissions(permKind, entries[kindName], map[PermissionKind]bool{}, nil)
+		if err != nil {
+			return nil, err
+		}
+		perms = append(perms, p...)
+	}
+
+	return perms, nil
+}
+
+// explicitLimit returns the limit with the given name if it is specified in the limits section of the manifest.
+func (m *Manifest) explicitLimit(name string) (Limit, bool) {
+	if !slices.Contains(m.explicitLimitNames, name) {
+		return Limit{}, false
+	}
+
+	for _, limit := range m.Limits {
+		if limit.Name == name {
+			return limit, true
+		}
+	}
+	return Limit{}, false
+}
+
+func (m *Manifest) checkPolicy(policy *ManifestPolicy, mod *Module) error {
+	kind := mod.Kind
+	if kind == UnspecifiedModuleKind {
+		kind = m.explicitModuleKind
+	}
+
+	return policy.Check(mod.Name(), kind, m)
+}
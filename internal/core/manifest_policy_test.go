@@ -0,0 +1,145 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/inoxlang/inox/internal/core/limitbase"
+	"github.com/inoxlang/inox/internal/core/permbase"
+	"github.com/inoxlang/inox/internal/testconfig"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManifestPolicy(t *testing.T) {
+	testconfig.AllowParallelization(t)
+
+	readTmp := FilesystemPermission{Kind_: permbase.Read, Entity: PathPattern("/tmp/...")}
+	readAny := FilesystemPermission{Kind_: permbase.Read, Entity: PathPattern("/...")}
+	readEtcPasswd := FilesystemPermission{Kind_: permbase.Read, Entity: Path("/etc/passwd")}
+
+	t.Run("allowed permissions", func(t *testing.T) {
+		policy := NewManifestPolicy(&ManifestPolicyRules{AllowedPermissions: []Permission{readTmp}}, nil)
+
+		err := policy.Check("main.ix", ApplicationModule, &Manifest{RequiredPermissions: []Permission{readTmp}})
+		assert.NoError(t, err)
+
+		err = policy.Check("main.ix", ApplicationModule, &Manifest{RequiredPermissions: []Permission{readAny}})
+		if !assert.IsType(t, (*ManifestPolicyViolationError)(nil), err) {
+			return
+		}
+		violations := err.(*ManifestPolicyViolationError).Violations
+		if !assert.Len(t, violations, 1) {
+			return
+		}
+		assert.Equal(t, "permissions", violations[0].Section)
+		assert.Contains(t, violations[0].Message, readAny.String())
+	})
+
+	t.Run("forbidden permissions", func(t *testing.T) {
+		policy := NewManifestPolicy(&ManifestPolicyRules{ForbiddenPermissions: []Permission{readEtcPasswd}}, nil)
+
+		err := policy.Check("main.ix", ApplicationModule, &Manifest{RequiredPermissions: []Permission{readTmp}})
+		assert.NoError(t, err)
+
+		err = policy.Check("main.ix", ApplicationModule, &Manifest{RequiredPermissions: []Permission{readAny}})
+		if !assert.Error(t, err) {
+			return
+		}
+		assert.Contains(t, err.Error(), "includes the forbidden permission "+readEtcPasswd.String())
+	})
+
+	t.Run("permission included in a forbidden permission", func(t *testing.T) {
+		deleteAny := FilesystemPermission{Kind_: permbase.Delete, Entity: PathPattern("/...")}
+		deleteTmpFile := FilesystemPermission{Kind_: permbase.Delete, Entity: Path("/tmp/x")}
+
+		policy := NewManifestPolicy(&ManifestPolicyRules{ForbiddenPermissions: []Permission{deleteAny}}, nil)
+
+		err := policy.Check("main.ix", ApplicationModule, &Manifest{RequiredPermissions: []Permission{readTmp}})
+		assert.NoError(t, err)
+
+		err = policy.Check("main.ix", ApplicationModule, &Manifest{RequiredPermissions: []Permission{deleteTmpFile}})
+		if !assert.IsType(t, (*ManifestPolicyViolationError)(nil), err) {
+			return
+		}
+		violations := err.(*ManifestPolicyViolationError).Violations
+		if !assert.Len(t, violations, 1) {
+			return
+		}
+		assert.Contains(t, violations[0].Message, "is included in the forbidden permission "+deleteAny.String())
+	})
+
+	t.Run("rules specific to a module kind take precedence over the default rules", func(t *testing.T) {
+		policy := NewManifestPolicy(
+			&ManifestPolicyRules{AllowedPermissions: []Permission{readTmp}},
+			map[ModuleKind]*ManifestPolicyRules{
+				SpecModule: {AllowedPermissions: []Permission{readAny}},
+			},
+		)
+
+		manifest := &Manifest{RequiredPermissions: []Permission{readAny}}
+
+		assert.NoError(t, policy.Check("main.spec.ix", SpecModule, manifest))
+		assert.Error(t, policy.Check("main.ix", ApplicationModule, manifest))
+	})
+
+	t.Run("all violations should be reported", func(t *testing.T) {
+		policy := NewManifestPolicy(&ManifestPolicyRules{
+			AllowedPermissions: []Permission{readTmp},
+			RequiredLimits:     []string{limitbase.THREADS_SIMULTANEOUS_INSTANCES_LIMIT_NAME},
+		}, nil)
+
+		err := policy.Check("main.ix", ApplicationModule, &Manifest{RequiredPermissions: []Permission{readAny}})
+		if !assert.IsType(t, (*ManifestPolicyViolationError)(nil), err) {
+			return
+		}
+		assert.Len(t, err.(*ManifestPolicyViolationError).Violations, 2)
+	})
+
+	t.Run("policy created from a record", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		desc := NewRecordFromMap(ValMap{
+			"default": NewRecordFromMap(ValMap{
+				"required-limits": NewTuple([]Serializable{String(limitbase.THREADS_SIMULTANEOUS_INSTANCES_LIMIT_NAME)}),
+			}),
+			"testcase": NewRecordFromMap(ValMap{
+				"max-limits": NewRecordFromMap(ValMap{
+					limitbase.THREADS_SIMULTANEOUS_INSTANCES_LIMIT_NAME: Int(10),
+				}),
+			}),
+		})
+
+		policy, err := NewManifestPolicyFromValue(ctx, desc)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.Equal(t, &ManifestPolicyRules{
+			RequiredLimits: []string{limitbase.THREADS_SIMULTANEOUS_INSTANCES_LIMIT_NAME},
+		}, policy.RulesFor(ApplicationModule))
+
+		assert.Equal(t, &ManifestPolicyRules{
+			MaxLimits: []Limit{{Name: limitbase.THREADS_SIMULTANEOUS_INSTANCES_LIMIT_NAME, Kind: TotalLimit, Value: 10}},
+		}, policy.RulesFor(TestCaseModule))
+	})
+
+	t.Run("invalid policy descriptions", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		_, err := NewManifestPolicyFromValue(ctx, NewRecordFromMap(ValMap{
+			"?": NewRecordFromMap(ValMap{}),
+		}))
+		assert.ErrorIs(t, err, ErrInvalidManifestPolicy)
+
+		_, err = NewManifestPolicyFromValue(ctx, NewRecordFromMap(ValMap{
+			"default": NewRecordFromMap(ValMap{"unknown": Int(1)}),
+		}))
+		assert.ErrorIs(t, err, ErrInvalidManifestPolicy)
+
+		_, err = NewManifestPolicyFromValue(ctx, NewRecordFromMap(ValMap{
+			"default": NewRecordFromMap(ValMap{"max-limits": NewRecordFromMap(ValMap{"non-registered": Int(1)})}),
+		}))
+		assert.ErrorIs(t, err, ErrInvalidManifestPolicy)
+	})
+}
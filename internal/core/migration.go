@@ -0,0 +1,517 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/inoxlang/inox/internal/commonfmt"
+)
+
+const (
+	MIGRATION_PSEUDO_PATH_WILDCARD = "*" //segment matching all the elements of a list
+)
+
+var (
+	ErrMigratedValueNotMatchingNextPattern = errors.New("migrated value does not match the next pattern")
+	ErrInvalidMigrationHandler             = errors.New("invalid migration handler: .Function or .InitialValue should be set")
+
+	_ = []MigrationOp{
+		RemovalMigrationOp{}, ReplacementMigrationOp{}, InclusionMigrationOp{}, InitializationMigrationOp{},
+	}
+)
+
+// A MigrationOp is an operation required to make values matching a pattern match a newer version of the pattern.
+// The location of the affected values is described by a pseudo path: a path whose segments are property names or
+// MIGRATION_PSEUDO_PATH_WILDCARD (list elements), e.g. /users/*/name. The pseudo path of the root value is "/".
+type MigrationOp interface {
+	GetPseudoPath() string
+	Kind() MigrationOpKind
+}
+
+type MigrationOpKind int
+
+const (
+	RemovalMigration MigrationOpKind = iota + 1
+	ReplacementMigration
+	InclusionMigration
+	InitializationMigration
+)
+
+func (k MigrationOpKind) String() string {
+	switch k {
+	case RemovalMigration:
+		return "removal"
+	case ReplacementMigration:
+		return "replacement"
+	case InclusionMigration:
+		return "inclusion"
+	case InitializationMigration:
+		return "initialization"
+	}
+	return "unknown"
+}
+
+type MigrationMixin struct {
+	PseudoPath string
+}
+
+func (m MigrationMixin) GetPseudoPath() string {
+	return m.PseudoPath
+}
+
+// A RemovalMigrationOp is the removal of a property that is no longer present in the next pattern.
+type RemovalMigrationOp struct {
+	Value Pattern
+	MigrationMixin
+}
+
+func (RemovalMigrationOp) Kind() MigrationOpKind {
+	return RemovalMigration
+}
+
+// A ReplacementMigrationOp is the replacement of values whose pattern has changed in an incompatible way.
+type ReplacementMigrationOp struct {
+	Current Pattern
+	Next    Pattern
+	MigrationMixin
+}
+
+func (ReplacementMigrationOp) Kind() MigrationOpKind {
+	return ReplacementMigration
+}
+
+// An InclusionMigrationOp is the addition of a property that is not present in the current pattern.
+type InclusionMigrationOp struct {
+	Value    Pattern
+	Optional bool
+	MigrationMixin
+}
+
+func (InclusionMigrationOp) Kind() MigrationOpKind {
+	return InclusionMigration
+}
+
+// An InitializationMigrationOp is the initialization of a property that is optional in the current pattern
+// and required in the next pattern, it only affects the values that do not have the property.
+type InitializationMigrationOp struct {
+	Value Pattern
+	MigrationMixin
+}
+
+func (InitializationMigrationOp) Kind() MigrationOpKind {
+	return InitializationMigration
+}
+
+// GetMigrationOperations computes the operations required to make values matching current match next.
+// Object patterns are compared entry by entry and list patterns with a general element pattern are compared
+// element-wise, any other change is a replacement. Property dependencies and complex property constraints
+// are not taken into account.
+func GetMigrationOperations(ctx *Context, current, next Pattern, pseudoPath string) ([]MigrationOp, error) {
+	if current.Equal(ctx, next, map[uintptr]uintptr{}, 0) {
+		return nil, nil
+	}
+
+	switch currentPattern := current.(type) {
+	case *ObjectPattern:
+		nextPattern, ok := next.(*ObjectPattern)
+		if !ok {
+			break
+		}
+		return getObjectMigrationOperations(ctx, currentPattern, nextPattern, pseudoPath)
+	case *ListPattern:
+		nextPattern, ok := next.(*ListPattern)
+		if !ok || currentPattern.generalElementPattern == nil || nextPattern.generalElementPattern == nil {
+			break
+		}
+		if nextPattern.MinElementCount() > currentPattern.MinElementCount() ||
+			nextPattern.MaxElementCount() < currentPattern.MaxElementCount() {
+			//the number of elements cannot be migrated element-wise.
+			break
+		}
+		return GetMigrationOperations(ctx,
+			currentPattern.generalElementPattern, nextPattern.generalElementPattern,
+			joinMigrationPseudoPath(pseudoPath, MIGRATION_PSEUDO_PATH_WILDCARD))
+	}
+
+	return []MigrationOp{
+		ReplacementMigrationOp{Current: current, Next: next, MigrationMixin: MigrationMixin{PseudoPath: rootIfEmpty(pseudoPath)}},
+	}, nil
+}
+
+func getObjectMigrationOperations(ctx *Context, current, next *ObjectPattern, pseudoPath string) ([]MigrationOp, error) {
+	var ops []MigrationOp
+
+	err := current.ForEachEntry(func(currentEntry ObjectPatternEntry) error {
+		propertyPath := joinMigrationPseudoPath(pseudoPath, currentEntry.Name)
+
+		nextEntry, ok := next.CompleteEntry(currentEntry.Name)
+		if !ok {
+			ops = append(ops, RemovalMigrationOp{
+				Value:          currentEntry.Pattern,
+				MigrationMixin: MigrationMixin{PseudoPath: propertyPath},
+			})
+			return nil
+		}
+
+		propertyOps, err := GetMigrationOperations(ctx, currentEntry.Pattern, nextEntry.Pattern, propertyPath)
+		if err != nil {
+			return err
+		}
+
+		//The operations on the property are applied before the initialization, so they only receive
+		//existing values and not the values created by the initialization.
+		ops = append(ops, propertyOps...)
+
+		if currentEntry.IsOptional && !nextEntry.IsOptional {
+			ops = append(ops, InitializationMigrationOp{
+				Value:          nextEntry.Pattern,
+				MigrationMixin: MigrationMixin{PseudoPath: propertyPath},
+			})
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = next.ForEachEntry(func(nextEntry ObjectPatternEntry) error {
+		if current.HasRequiredOrOptionalEntry(nextEntry.Name) {
+			return nil
+		}
+		ops = append(ops, InclusionMigrationOp{
+			Value:          nextEntry.Pattern,
+			Optional:       nextEntry.IsOptional,
+			MigrationMixin: MigrationMixin{PseudoPath: joinMigrationPseudoPath(pseudoPath, nextEntry.Name)},
+		})
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ops, nil
+}
+
+// A MigrationHandler is provided by the user to compute the values required by a migration operation.
+type MigrationHandler struct {
+	//Function is called with the current value for replacements and removals (the result is ignored),
+	//and with the parent object for inclusions and initializations.
+	Function func(ctx *Context, value Serializable) (Serializable, error)
+
+	//InitialValue is cloned and used as the new value if Function is nil.
+	InitialValue Serializable
+}
+
+// MigrationHandlers maps pseudo paths to handlers, there is a map per kind of operation.
+type MigrationHandlers struct {
+	Removals        map[string]*MigrationHandler
+	Replacements    map[string]*MigrationHandler
+	Inclusions      map[string]*MigrationHandler
+	Initializations map[string]*MigrationHandler
+}
+
+func (h MigrationHandlers) handlerFor(op MigrationOp) (*MigrationHandler, bool) {
+	var handlers map[string]*MigrationHandler
+
+	switch op.Kind() {
+	case RemovalMigration:
+		handlers = h.Removals
+	case ReplacementMigration:
+		handlers = h.Replacements
+	case InclusionMigration:
+		handlers = h.Inclusions
+	case InitializationMigration:
+		handlers = h.Initializations
+	}
+
+	handler, ok := handlers[op.GetPseudoPath()]
+	return handler, ok
+}
+
+// A Migration migrates values matching a pattern (the current pattern) to a newer version of the pattern.
+type Migration struct {
+	current    Pattern
+	next       Pattern
+	operations []MigrationOp
+	handlers   MigrationHandlers
+}
+
+// NewMigration computes the operations required to migrate from current to next and checks that a handler
+// is provided for each operation that requires one: replacements, initializations and inclusions of required
+// properties. A handler that does not correspond to any operation is an error.
+func NewMigration(ctx *Context, current, next *ObjectPattern, handlers MigrationHandlers) (*Migration, error) {
+	ops, err := GetMigrationOperations(ctx, current, next, "")
+	if err != nil {
+		return nil, err
+	}
+
+	handledOps := 0
+
+	for _, op := range ops {
+		handler, ok := handlers.handlerFor(op)
+		if ok {
+			handledOps++
+			if handler == nil || (handler.Function == nil && handler.InitialValue == nil) {
+				return nil, fmt.Errorf("%w (%s of %s)", ErrInvalidMigrationHandler, op.Kind(), op.GetPseudoPath())
+			}
+			continue
+		}
+
+		if inclusion, ok := op.(InclusionMigrationOp); op.Kind() == RemovalMigration || (ok && inclusion.Optional) {
+			continue
+		}
+		return nil, fmt.Errorf("missing handler for the %s of %s", op.Kind(), op.GetPseudoPath())
+	}
+
+	handlerCount := len(handlers.Removals) + len(handlers.Replacements) + len(handlers.Inclusions) + len(handlers.Initializations)
+	if handlerCount != handledOps {
+		return nil, errors.New("some migration handlers do not correspond to any migration operation")
+	}
+
+	return &Migration{
+		current:    current,
+		next:       next,
+		operations: ops,
+		handlers:   handlers,
+	}, nil
+}
+
+func (m *Migration) Operations() []MigrationOp {
+	return slices.Clone(m.operations)
+}
+
+// A MigrationReport lists the changes made (or that would be made during a dry run) by a migration.
+type MigrationReport struct {
+	Changes []MigrationChange
+}
+
+// A MigrationChange is the application of a migration operation to a single location.
+type MigrationChange struct {
+	Op       MigrationOp
+	Path     string       //concrete path of the location, e.g. /users/0/name
+	OldValue Serializable //nil for inclusions and initializations
+	NewValue Serializable //nil for removals
+}
+
+func (r *MigrationReport) String() string {
+	buf := &strings.Builder{}
+
+	for _, change := range r.Changes {
+		buf.WriteString(change.Op.Kind().String())
+		buf.WriteString(" of ")
+		buf.WriteString(change.Path)
+		buf.WriteByte('\n')
+	}
+
+	return buf.String()
+}
+
+// MigrateValue applies the migration to v and checks that the result matches the next pattern, v is not modified.
+func (m *Migration) MigrateValue(ctx *Context, v Serializable) (Serializable, *MigrationReport, error) {
+	if !m.current.Test(ctx, v) {
+		return nil, nil, errors.New("value to migrate does not match the current pattern")
+	}
+
+	report := &MigrationReport{}
+	migrated := v
+
+	for _, op := range m.operations {
+		handler, _ := m.handlers.handlerFor(op)
+
+		var segments []string
+		if path := op.GetPseudoPath(); path != "/" {
+			segments = strings.Split(path[1:], "/")
+		}
+
+		var err error
+		migrated, err = m.applyOperation(ctx, migrated, op, handler, segments, nil, report)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if !m.next.Test(ctx, migrated) {
+		return nil, nil, ErrMigratedValueNotMatchingNextPattern
+	}
+
+	return migrated, report, nil
+}
+
+// MigrateSnapshot migrates the value of a snapshot, the returned snapshot has the same date. If dryRun is true
+// no snapshot is returned, the report describes the changes the migration would make.
+func (m *Migration) MigrateSnapshot(ctx *Context, snapshot *Snapshot, dryRun bool) (*Snapshot, *MigrationReport, error) {
+	value, err := snapshot.InstantiateValue(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	migrated, report, err := m.MigrateValue(ctx, value)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if dryRun {
+		return nil, report, nil
+	}
+
+	migratedSnapshot, err := TakeSnapshot(ctx, migrated, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	migratedSnapshot.date = snapshot.date
+
+	return migratedSnapshot, report, nil
+}
+
+// applyOperation applies op to the locations matching the remaining segments of its pseudo path, the values
+// containing the affected locations are rebuilt.
+func (m *Migration) applyOperation(
+	ctx *Context, v Serializable, op MigrationOp, handler *MigrationHandler,
+	remainingSegments, currentSegments []string, report *MigrationReport,
+) (Serializable, error) {
+
+	if len(remainingSegments) == 0 {
+		//only replacements can target the root value.
+		newValue, err := m.computeNewValue(ctx, op, handler, v, currentSegments)
+		if err != nil {
+			return nil, err
+		}
+		report.Changes = append(report.Changes, MigrationChange{Op: op, Path: migrationPath(currentSegments), OldValue: v, NewValue: newValue})
+		return newValue, nil
+	}
+
+	segment := remainingSegments[0]
+	segments := append(slices.Clone(currentSegments), segment)
+
+	if segment == MIGRATION_PSEUDO_PATH_WILDCARD {
+		list, ok := v.(*List)
+		if !ok {
+			return nil, commonfmt.FmtValueAtPathSegmentsIsNotMigrationCapable(currentSegments)
+		}
+
+		elements := slices.Clone(list.GetOrBuildElements(ctx))
+		for i, elem := range elements {
+			elementSegments := append(slices.Clone(currentSegments), fmt.Sprint(i))
+
+			newElem, err := m.applyOperation(ctx, elem, op, handler, remainingSegments[1:], elementSegments, report)
+			if err != nil {
+				return nil, err
+			}
+			elements[i] = newElem
+		}
+		return NewWrappedValueList(elements...), nil
+	}
+
+	obj, ok := v.(*Object)
+	if !ok {
+		return nil, commonfmt.FmtValueAtPathSegmentsIsNotMigrationCapable(currentSegments)
+	}
+
+	entries := ValMap(obj.EntryMap(ctx))
+	propValue, hasProp := entries[segment]
+
+	if len(remainingSegments) > 1 {
+		if !hasProp { //optional property
+			return obj, nil
+		}
+
+		newPropValue, err := m.applyOperation(ctx, propValue, op, handler, remainingSegments[1:], segments, report)
+		if err != nil {
+			return nil, err
+		}
+		entries[segment] = newPropValue
+		return NewObjectFromMap(entries, ctx), nil
+	}
+
+	change := MigrationChange{Op: op, Path: migrationPath(segments)}
+
+	switch op.(type) {
+	case RemovalMigrationOp:
+		if !hasProp {
+			return obj, nil
+		}
+		if handler != nil && handler.Function != nil {
+			if _, err := handler.Function(ctx, propValue); err != nil {
+				return nil, fmt.Errorf("%w: %w", commonfmt.FmtErrWhileCallingMigrationHandler(segments, err), err)
+			}
+		}
+		delete(entries, segment)
+		change.OldValue = propValue
+	case ReplacementMigrationOp:
+		if !hasProp {
+			return obj, nil
+		}
+		newValue, err := m.computeNewValue(ctx, op, handler, propValue, segments)
+		if err != nil {
+			return nil, err
+		}
+		entries[segment] = newValue
+		change.OldValue, change.NewValue = propValue, newValue
+	case InclusionMigrationOp, InitializationMigrationOp:
+		if hasProp || handler == nil {
+			//optional inclusion without handler.
+			return obj, nil
+		}
+		newValue, err := m.computeNewValue(ctx, op, handler, obj, segments)
+		if err != nil {
+			return nil, err
+		}
+		entries[segment] = newValue
+		change.NewValue = newValue
+	}
+
+	report.Changes = append(report.Changes, change)
+	return NewObjectFromMap(entries, ctx), nil
+}
+
+func (m *Migration) computeNewValue(ctx *Context, op MigrationOp, handler *MigrationHandler, arg Serializable, segments []string) (Serializable, error) {
+	var newValue Serializable
+
+	if handler.Function != nil {
+		v, err := handler.Function(ctx, arg)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", commonfmt.FmtErrWhileCallingMigrationHandler(segments, err), err)
+		}
+		newValue = v
+	} else {
+		v, err := RepresentationBasedClone(ctx, handler.InitialValue)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", commonfmt.FmtErrWhileCloningValueFor(segments, err), err)
+		}
+		newValue = v
+	}
+
+	var pattern Pattern
+	switch op := op.(type) {
+	case ReplacementMigrationOp:
+		pattern = op.Next
+	case InclusionMigrationOp:
+		pattern = op.Value
+	case InitializationMigrationOp:
+		pattern = op.Value
+	}
+
+	if pattern != nil && !pattern.Test(ctx, newValue) {
+		return nil, fmt.Errorf("the value computed for %s does not match the next pattern", migrationPath(segments))
+	}
+	return newValue, nil
+}
+
+func joinMigrationPseudoPath(pseudoPath string, segment string) string {
+	return pseudoPath + "/" + segment
+}
+
+func migrationPath(segments []string) string {
+	return "/" + strings.Join(segments, "/")
+}
+
+func rootIfEmpty(pseudoPath string) string {
+	if pseudoPath == "" {
+		return "/"
+	}
+	return pseudoPath
+}
@@ -0,0 +1,313 @@
+package core
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetMigrationOperations(t *testing.T) {
+	ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+	defer ctx.CancelGracefully()
+
+	t.Run("equal patterns", func(t *testing.T) {
+		current := NewExactObjectPattern([]ObjectPatternEntry{{Name: "a", Pattern: INT_PATTERN}})
+		next := NewExactObjectPattern([]ObjectPatternEntry{{Name: "a", Pattern: INT_PATTERN}})
+
+		ops, err := GetMigrationOperations(ctx, current, next, "")
+		if assert.NoError(t, err) {
+			assert.Empty(t, ops)
+		}
+	})
+
+	t.Run("property changes", func(t *testing.T) {
+		current := NewExactObjectPattern([]ObjectPatternEntry{
+			{Name: "removed", Pattern: INT_PATTERN},
+			{Name: "replaced", Pattern: INT_PATTERN},
+			{Name: "initialized", Pattern: INT_PATTERN, IsOptional: true},
+		})
+		next := NewExactObjectPattern([]ObjectPatternEntry{
+			{Name: "replaced", Pattern: STR_PATTERN},
+			{Name: "initialized", Pattern: INT_PATTERN},
+			{Name: "included", Pattern: BOOL_PATTERN, IsOptional: true},
+		})
+
+		ops, err := GetMigrationOperations(ctx, current, next, "")
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.ElementsMatch(t, []MigrationOp{
+			RemovalMigrationOp{Value: INT_PATTERN, MigrationMixin: MigrationMixin{PseudoPath: "/removed"}},
+			ReplacementMigrationOp{Current: INT_PATTERN, Next: STR_PATTERN, MigrationMixin: MigrationMixin{PseudoPath: "/replaced"}},
+			InitializationMigrationOp{Value: INT_PATTERN, MigrationMixin: MigrationMixin{PseudoPath: "/initialized"}},
+			InclusionMigrationOp{Value: BOOL_PATTERN, Optional: true, MigrationMixin: MigrationMixin{PseudoPath: "/included"}},
+		}, ops)
+	})
+
+	t.Run("list elements", func(t *testing.T) {
+		current := NewExactObjectPattern([]ObjectPatternEntry{{
+			Name: "users",
+			Pattern: NewListPatternOf(NewExactObjectPattern([]ObjectPatternEntry{
+				{Name: "name", Pattern: STR_PATTERN},
+			})),
+		}})
+		next := NewExactObjectPattern([]ObjectPatternEntry{{
+			Name: "users",
+			Pattern: NewListPatternOf(NewExactObjectPattern([]ObjectPatternEntry{
+				{Name: "name", Pattern: STR_PATTERN},
+				{Name: "age", Pattern: INT_PATTERN},
+			})),
+		}})
+
+		ops, err := GetMigrationOperations(ctx, current, next, "")
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.Equal(t, []MigrationOp{
+			InclusionMigrationOp{Value: INT_PATTERN, MigrationMixin: MigrationMixin{PseudoPath: "/users/*/age"}},
+		}, ops)
+	})
+
+	t.Run("root replacement", func(t *testing.T) {
+		ops, err := GetMigrationOperations(ctx, INT_PATTERN, STR_PATTERN, "")
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.Equal(t, []MigrationOp{
+			ReplacementMigrationOp{Current: INT_PATTERN, Next: STR_PATTERN, MigrationMixin: MigrationMixin{PseudoPath: "/"}},
+		}, ops)
+	})
+}
+
+func TestMigration(t *testing.T) {
+
+	current := NewExactObjectPattern([]ObjectPatternEntry{
+		{Name: "version", Pattern: INT_PATTERN},
+		{Name: "nickname", Pattern: STR_PATTERN, IsOptional: true},
+		{
+			Name: "users",
+			Pattern: NewListPatternOf(NewExactObjectPattern([]ObjectPatternEntry{
+				{Name: "name", Pattern: STR_PATTERN},
+				{Name: "password", Pattern: STR_PATTERN},
+			})),
+		},
+	})
+
+	next := NewExactObjectPattern([]ObjectPatternEntry{
+		{Name: "version", Pattern: STR_PATTERN},
+		{Name: "nickname", Pattern: STR_PATTERN},
+		{
+			Name: "users",
+			Pattern: NewListPatternOf(NewExactObjectPattern([]ObjectPatternEntry{
+				{Name: "name", Pattern: STR_PATTERN},
+				{Name: "active", Pattern: BOOL_PATTERN},
+			})),
+		},
+	})
+
+	handlers := func() MigrationHandlers {
+		return MigrationHandlers{
+			Replacements: map[string]*MigrationHandler{
+				"/version": {
+					Function: func(ctx *Context, value Serializable) (Serializable, error) {
+						return String("v" + Stringify(value, ctx)), nil
+					},
+				},
+			},
+			Initializations: map[string]*MigrationHandler{
+				"/nickname": {InitialValue: String("anonymous")},
+			},
+			Inclusions: map[string]*MigrationHandler{
+				"/users/*/active": {InitialValue: True},
+			},
+		}
+	}
+
+	makeValue := func(ctx *Context) *Object {
+		return NewObjectFromMap(ValMap{
+			"version": Int(1),
+			"users": NewWrappedValueList(
+				NewObjectFromMap(ValMap{"name": String("a"), "password": String("x")}, ctx),
+				NewObjectFromMap(ValMap{"name": String("b"), "password": String("y")}, ctx),
+			),
+		}, ctx)
+	}
+
+	t.Run("missing handler", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		h := handlers()
+		delete(h.Inclusions, "/users/*/active")
+
+		_, err := NewMigration(ctx, current, next, h)
+		assert.ErrorContains(t, err, "missing handler for the inclusion of /users/*/active")
+	})
+
+	t.Run("handler not corresponding to any operation", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		h := handlers()
+		h.Removals = map[string]*MigrationHandler{"/version": {InitialValue: Int(0)}}
+
+		_, err := NewMigration(ctx, current, next, h)
+		assert.Error(t, err)
+	})
+
+	t.Run("migrate value", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		migration, err := NewMigration(ctx, current, next, handlers())
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		value := makeValue(ctx)
+
+		migrated, report, err := migration.MigrateValue(ctx, value)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		expected := NewObjectFromMap(ValMap{
+			"version":  String("v1"),
+			"nickname": String("anonymous"),
+			"users": NewWrappedValueList(
+				NewObjectFromMap(ValMap{"name": String("a"), "active": True}, ctx),
+				NewObjectFromMap(ValMap{"name": String("b"), "active": True}, ctx),
+			),
+		}, ctx)
+
+		assert.True(t, expected.Equal(ctx, migrated, map[uintptr]uintptr{}, 0))
+
+		//the original value should not be modified.
+		assert.True(t, makeValue(ctx).Equal(ctx, value, map[uintptr]uintptr{}, 0))
+
+		assert.Equal(t, ""+
+			"initialization of /nickname\n"+
+			"removal of /users/0/password\n"+
+			"removal of /users/1/password\n"+
+			"inclusion of /users/0/active\n"+
+			"inclusion of /users/1/active\n"+
+			"replacement of /version\n",
+			report.String())
+	})
+
+	t.Run("handler returning a value not matching the next pattern", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		h := handlers()
+		h.Initializations["/nickname"] = &MigrationHandler{InitialValue: Int(1)}
+
+		migration, err := NewMigration(ctx, current, next, h)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		_, _, err = migration.MigrateValue(ctx, makeValue(ctx))
+		assert.ErrorContains(t, err, "/nickname does not match the next pattern")
+	})
+
+	t.Run("handler error", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		handlerErr := errors.New("handler error")
+
+		h := handlers()
+		h.Replacements["/version"] = &MigrationHandler{
+			Function: func(ctx *Context, value Serializable) (Serializable, error) {
+				return nil, handlerErr
+			},
+		}
+
+		migration, err := NewMigration(ctx, current, next, h)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		_, _, err = migration.MigrateValue(ctx, makeValue(ctx))
+		assert.ErrorIs(t, err, handlerErr)
+	})
+
+	t.Run("optional property becoming required with another pattern", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		current := NewExactObjectPattern([]ObjectPatternEntry{{Name: "a", Pattern: INT_PATTERN, IsOptional: true}})
+		next := NewExactObjectPattern([]ObjectPatternEntry{{Name: "a", Pattern: STR_PATTERN}})
+
+		migration, err := NewMigration(ctx, current, next, MigrationHandlers{
+			Replacements: map[string]*MigrationHandler{
+				"/a": {
+					Function: func(ctx *Context, value Serializable) (Serializable, error) {
+						return String(Stringify(value.(Int), ctx)), nil
+					},
+				},
+			},
+			Initializations: map[string]*MigrationHandler{
+				"/a": {InitialValue: String("initial")},
+			},
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		//the replacement handler should only receive existing values.
+		migrated, report, err := migration.MigrateValue(ctx, NewObjectFromMap(ValMap{}, ctx))
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, String("initial"), migrated.(*Object).Prop(ctx, "a"))
+		assert.Equal(t, "initialization of /a\n", report.String())
+
+		migrated, report, err = migration.MigrateValue(ctx, NewObjectFromMap(ValMap{"a": Int(1)}, ctx))
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, String("1"), migrated.(*Object).Prop(ctx, "a"))
+		assert.Equal(t, "replacement of /a\n", report.String())
+	})
+
+	t.Run("migrate snapshot", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		migration, err := NewMigration(ctx, current, next, handlers())
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		snapshot, err := TakeSnapshot(ctx, makeValue(ctx), false)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		//dry run
+		migratedSnapshot, report, err := migration.MigrateSnapshot(ctx, snapshot, true)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Nil(t, migratedSnapshot)
+		assert.Len(t, report.Changes, 6)
+
+		migratedSnapshot, _, err = migration.MigrateSnapshot(ctx, snapshot, false)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.Equal(t, snapshot.Date(), migratedSnapshot.Date())
+
+		migrated, err := migratedSnapshot.InstantiateValue(ctx)
+		if assert.NoError(t, err) {
+			assert.True(t, next.Test(ctx, migrated))
+		}
+	})
+}
@@ -55,12 +55,15 @@ type ImportConfig struct {
 	ValidationString   String  //hash of the imported module
 	ArgObj             *Object //arguments for the evaluation of the imported module
 	GrantedPermListing *Object
+	Capabilities       []*Capability //capabilities delegated to the imported module
 	ParentState        *GlobalState  //the state of the module doing the import
 	Insecure           bool          //if true certificate verification is ignored when making HTTP requests
 	Timeout            time.Duration //total timeout for combined fetching + evaluation of the imported module
 }
 
-func buildImportConfig(obj *Object, importSource ResourceName, parentState *GlobalState) (ImportConfig, error) {
+// buildImportConfig builds the configuration of an import statement, $capabilities is the value of the capabilities
+// section (nil or Nil if absent) that is evaluated separately because objects cannot contain capabilities.
+func buildImportConfig(obj *Object, capabilities Value, importSource ResourceName, parentState *GlobalState) (ImportConfig, error) {
 	src, err := inoxmod.GetSourceFromImportSource(importSource, parentState.Module.Module, parentState.Ctx)
 	if err != nil {
 		return ImportConfig{}, err
@@ -89,6 +92,13 @@ func buildImportConfig(obj *Object, importSource ResourceName, parentState *Glob
 		return ImportConfig{}, err
 	}
 
+	if capabilities != nil && capabilities != Nil {
+		config.Capabilities, err = getCapabilitiesFromValue(capabilities)
+		if err != nil {
+			return ImportConfig{}, fmt.Errorf("invalid import configuration, section '%s': %w", inoxconsts.IMPORT_CONFIG__CAPABILITIES_PROPNAME, err)
+		}
+	}
+
 	return config, nil
 }
 
@@ -113,6 +123,12 @@ func ImportModule(config ImportConfig) (*LThread, error) {
 		}
 	}
 
+	for _, capability := range config.Capabilities {
+		if !capability.isHeldBy(parentState.Ctx) {
+			return nil, fmt.Errorf("import: cannot delegate capability: %w", ErrCapabilityNotHeld)
+		}
+	}
+
 	importedModLower, ok := parentState.Module.DirectlyImportedModules[config.Src.ResourceName()]
 	if !ok {
 		panic(ErrUnreachable)
@@ -143,6 +159,7 @@ func ImportModule(config ImportConfig) (*LThread, error) {
 	routineCtx := NewContext(ContextConfig{
 		Permissions:          grantedPerms,
 		ForbiddenPermissions: forbiddenPerms,
+		Capabilities:         config.Capabilities,
 		ParentContext:        config.ParentState.Ctx,
 	})
 
@@ -74,6 +74,14 @@ type ModulePreparationArgs struct {
 	EnableTesting bool
 	//TestFilters   TestFilters
 
+	//If true a dry-run transaction is started (see StartNewDryRunTransaction), the effects performed by the module
+	//through PerformEffect are recorded but not applied. Other side effects are not prevented.
+	//The transaction is accessible via GlobalState.DryRunTransaction.
+	DryRun bool
+
+	//Format of the plan written to .Out when the module's context is teared down, only used if .DryRun is true.
+	DryRunPlanFormat DryRunPlanFormat
+
 	// If set this function is called just before the context creation,
 	// the preparation is aborted if an error is returned.
 	// The returned limits are used instead of the manifest limits.
@@ -99,6 +107,9 @@ type ModulePreparationArgs struct {
 	//should not be set if ParentContext is set
 	AdditionalPermissions []Permission
 
+	//If set the preparation fails if the manifest does not comply with the policy.
+	ManifestPolicy *ManifestPolicy
+
 	//should only be set if the module is a main module
 	MemberAuthToken                string
 	ListeningPort                  uint16 //optional, defaults to inoxconsts.DEV_PORT_0
@@ -268,6 +279,7 @@ func PrepareLocalModule(args ModulePreparationArgs) (state *GlobalState, mod *Mo
 			AddDefaultPermissions: true,
 			IgnoreUnknownSections: args.DataExtractionMode,
 			IgnoreConstDeclErrors: args.DataExtractionMode,
+			ManifestPolicy:        args.ManifestPolicy,
 
 			AdditionalGlobals: additionalGlobals,
 		})
@@ -314,6 +326,7 @@ func PrepareLocalModule(args ModulePreparationArgs) (state *GlobalState, mod *Mo
 
 		Limits:                  limits,
 		HostDefinitions:         manifest.HostDefinitions,
+		RetryPolicy:             manifest.RetryPolicy,
 		ParentContext:           parentContext,
 		ParentStdLibContext:     args.StdlibCtx,
 		InitialWorkingDirectory: manifest.InitialWorkingDirectory,
@@ -381,6 +394,17 @@ func PrepareLocalModule(args ModulePreparationArgs) (state *GlobalState, mod *Mo
 
 	state.EffectivePreparationParameters = effectiveParams
 
+	if args.DryRun {
+		tx := StartNewDryRunTransaction(ctx)
+		state.DryRunTransaction = tx
+
+		//The plan is written when the module run ends.
+		planFormat := args.DryRunPlanFormat
+		ctx.OnGracefulTearDown(func(ctx *Context) error {
+			return tx.DryRunPlan(ctx).Write(out, planFormat)
+		})
+	}
+
 	var patternsFromPreinit map[string]struct{}
 	var patternNamespacesFromPreinit map[string]struct{}
 
@@ -407,7 +431,15 @@ func PrepareLocalModule(args ModulePreparationArgs) (state *GlobalState, mod *Mo
 					return nil
 				}
 				ctxData.PatternNamespaces[name] = namespace
-				patternNamespacesFromPreinit[name] = struct{}{}
+
+				//The namespaces of OpenAPI documents are not defined by the preinit statement so the symbolic
+				//evaluation cannot re-define them.
+				isOpenAPIDocumentNamespace := slices.ContainsFunc(manifest.PreinitFiles, func(file *PreinitFile) bool {
+					return file.IsOpenAPIDocument && file.Name == name
+				})
+				if !isOpenAPIDocumentNamespace {
+					patternNamespacesFromPreinit[name] = struct{}{}
+				}
 				return nil
 			})
 
@@ -360,6 +360,14 @@ func (g *LThreadGroup) IsMutable() bool {
 	return true
 }
 
+func (c *Capability) IsMutable() bool {
+	return true
+}
+
+func (p *RetryPolicy) IsMutable() bool {
+	return false
+}
+
 func (fn *InoxFunction) IsMutable() bool {
 	return true
 }
@@ -555,10 +563,38 @@ func (*ConfluenceStream) IsMutable() bool {
 	return true
 }
 
+func (*CSVRowStream) IsMutable() bool {
+	return true
+}
+
 func (*RingBuffer) IsMutable() bool {
 	return true
 }
 
+func (*Channel) IsMutable() bool {
+	return true
+}
+
+func (*channelStream) IsMutable() bool {
+	return true
+}
+
+func (*Supervisor) IsMutable() bool {
+	return true
+}
+
+func (*CrashReport) IsMutable() bool {
+	return false
+}
+
+func (*Scheduler) IsMutable() bool {
+	return true
+}
+
+func (*ScheduledJob) IsMutable() bool {
+	return true
+}
+
 func (*DataChunk) IsMutable() bool {
 	return true
 }
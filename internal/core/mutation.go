@@ -416,6 +416,8 @@ func (m Mutation) ApplyTo(ctx *Context, v Value) error {
 		v.(IProps).SetProp(ctx, m.AffectedProperty(ctx), m.PropValue(ctx))
 	case UpdateProp:
 		v.(IProps).SetProp(ctx, m.AffectedProperty(ctx), m.PropValue(ctx))
+	case AddEntry, UpdateEntry:
+		v.(*Dictionary).SetValue(ctx, m.DataElem(ctx, 0).(Serializable), m.DataElem(ctx, 1).(Serializable))
 	case SetElemAtIndex:
 		v.(MutableLengthSequence).set(ctx, int(m.AffectedIndex(ctx)), m.Element(ctx))
 	case SetSliceAtRange:
@@ -33,7 +33,8 @@ type additionalObjectFields struct {
 
 	//Locking and transaction related fields
 
-	lock SmartLock
+	lock       SmartLock
+	txIsolator TransactionIsolator //only used if the object is shared
 	//pendingChanges []pendingObjectEntryChange //only visible by the current read-write tx
 	//TODO: make sure the .IsEmpty and .Contains methods use them.
 
@@ -178,7 +179,29 @@ func (obj *Object) _lock(state *GlobalState) {
 	if obj.additionalObjectFields == nil { //not shared.
 		return
 	}
-	obj.lock.Lock(state, obj)
+	obj.lockIsolated(state, false)
+}
+
+// lockIsolated locks the object after having waited for the transaction owning the object (if any) to finish,
+// see TransactionIsolator.
+func (obj *Object) lockIsolated(state *GlobalState, ignoreLockedValues bool) {
+	for {
+		if !obj.lock.IsValueShared() {
+			return
+		}
+
+		if _, err := obj.txIsolator.WaitForOtherTxsToTerminate(state.Ctx); err != nil {
+			panic(err)
+		}
+
+		obj.lock.Lock(state, obj, ignoreLockedValues)
+
+		//Another transaction may have become the owner before the lock was acquired.
+		if !obj.txIsolator.IsOwnedByOtherTx(getCurrentTxNoCheck(state.Ctx)) {
+			return
+		}
+		obj.lock.Unlock(state, obj, ignoreLockedValues)
+	}
 }
 
 func (obj *Object) _unlock(state *GlobalState) {
@@ -190,7 +213,7 @@ func (obj *Object) _unlock(state *GlobalState) {
 
 func (obj *Object) SmartLock(state *GlobalState) {
 	obj.ensureAdditionalFields()
-	obj.lock.Lock(state, obj, true)
+	obj.lockIsolated(state, true)
 }
 
 func (obj *Object) SmartUnlock(state *GlobalState) {
@@ -348,6 +371,14 @@ func (obj *Object) SetProp(ctx *Context, name string, value Value) error {
 		//return nil
 	}
 
+	//If the object is shared the transaction becomes its owner until it finishes.
+	if tx != nil && obj.IsShared() {
+		err := obj.txIsolator.PrepareMutation(tx, obj.takeTxIsolationSnapshot, obj.restoreTxIsolationSnapshot, obj.notifyMutation)
+		if err != nil {
+			return err
+		}
+	}
+
 	for i, key := range obj.keys {
 		if key == name { // property is already present
 			prevValue := obj.values[i]
@@ -382,6 +413,10 @@ func (obj *Object) SetProp(ctx *Context, name string, value Value) error {
 				//Create mutation and inform watchers about it.
 				mutation := NewUpdatePropMutation(ctx, name, serializableVal, ShallowWatching, Path("/"+name))
 
+				if obj.IsShared() && obj.txIsolator.DeferMutationNotification(mutation) {
+					return nil
+				}
+
 				obj.watchers.InformAboutAsync(ctx, mutation, mutation.Depth, true)
 
 				if obj.mutationCallbacks != nil {
@@ -425,6 +460,10 @@ func (obj *Object) SetProp(ctx *Context, name string, value Value) error {
 		//Inform watchers & microtasks about the update.
 		mutation := NewAddPropMutation(ctx, name, serializableVal, ShallowWatching, Path("/"+name))
 
+		if obj.IsShared() && obj.txIsolator.DeferMutationNotification(mutation) {
+			return nil
+		}
+
 		obj.watchers.InformAboutAsync(ctx, mutation, mutation.Depth, true)
 
 		if obj.mutationCallbacks != nil {
@@ -438,6 +477,59 @@ func (obj *Object) SetProp(ctx *Context, name string, value Value) error {
 	return nil
 }
 
+type objectTxIsolationSnapshot struct {
+	keys   []string
+	values []Serializable
+}
+
+func (obj *Object) takeTxIsolationSnapshot() any {
+	return objectTxIsolationSnapshot{
+		keys:   slices.Clone(obj.keys),
+		values: slices.Clone(obj.values),
+	}
+}
+
+func (obj *Object) restoreTxIsolationSnapshot(ctx *Context, snapshot any) {
+	entries := snapshot.(objectTxIsolationSnapshot)
+
+	//If the state of the transaction holds the lock (e.g. rollback inside a synchronized block) the snapshot is
+	//restored under the current holder. Otherwise the rollback may be performed by the goroutine handling the
+	//timeout of the transaction while the lthread of the transaction is accessing the object: since a SmartLock
+	//cannot be acquired twice by the same state, the object is locked with a dedicated state.
+	if txState, ok := ctx.getClosestState(); !ok || !obj.lock.isHeldBy(txState) {
+		restorationState := getTxIsolationRestorationState()
+		obj.lock.Lock(restorationState, obj)
+		defer obj.lock.Unlock(restorationState, obj)
+	}
+
+	if obj.hasPropMutationCallbacks() {
+		for i, val := range obj.values {
+			obj.removePropMutationCallbackNoLock(ctx, i, val)
+		}
+	}
+
+	obj.keys = entries.keys
+	obj.values = entries.values
+
+	if obj.hasPropMutationCallbacks() {
+		obj.propMutationCallbacks = make([]CallbackHandle, len(obj.keys))
+		for i, val := range obj.values {
+			obj.propMutationCallbacks[i] = FIRST_VALID_CALLBACK_HANDLE - 1
+			obj.addPropMutationCallbackNoLock(ctx, i, val)
+		}
+	}
+}
+
+// notifyMutation informs the watchers and calls the mutation callbacks, it is called by the transaction isolator when
+// the transaction owning the object commits.
+func (obj *Object) notifyMutation(ctx *Context, mutation Mutation) {
+	obj.watchers.InformAboutAsync(ctx, mutation, mutation.Depth, true)
+
+	if obj.mutationCallbacks != nil {
+		obj.mutationCallbacks.CallMicrotasks(ctx, mutation)
+	}
+}
+
 func (obj *Object) PropertyNames(ctx *Context) []string {
 
 	closestState := ctx.MustGetClosestState()
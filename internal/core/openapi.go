@@ -0,0 +1,375 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml"
+	"github.com/inoxlang/inox/internal/core/permbase"
+	"github.com/inoxlang/inox/internal/mimeconsts"
+	"github.com/pb33f/libopenapi"
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+const (
+	OPENAPI_REQUEST_PATTERN_SUFFIX  = "-request"
+	OPENAPI_RESPONSE_PATTERN_SUFFIX = "-response"
+
+	MAX_OPENAPI_RESPONSE_BODY_SIZE = 10_000_000
+	OPENAPI_CLIENT_TIMEOUT         = 20 * time.Second
+)
+
+var (
+	ErrInvalidOpenAPIDocument        = errors.New("invalid OpenAPI 3 document")
+	ErrMissingOpenAPIOperationId     = errors.New("all operations should have an operationId")
+	ErrInvalidOpenAPIOperationArgs   = errors.New("invalid arguments for OpenAPI operation")
+	ErrUnexpectedOpenAPIResponse     = errors.New("unexpected response")
+	ErrOpenAPIResponseBodyTooLarge   = ErrHttpResponseBodyTooLarge
+	ErrRequestBodyDoesNotMatchSchema = errors.New("request body does not match the schema of the operation")
+
+	OPENAPI_PATH_PARAM_REGEX = regexp.MustCompile(`\{([^{}]+)\}`)
+)
+
+// An OpenAPIDocument is an OpenAPI 3 document whose schemas have been converted to patterns, see ParseOpenAPIDocument.
+type OpenAPIDocument struct {
+	Title      string
+	Schemas    map[string]Pattern //patterns of the schemas in #/components/schemas
+	Operations []*OpenAPIOperation
+}
+
+type OpenAPIOperation struct {
+	Id             string
+	Method         string   //uppercase
+	Path           string   //path template, example: /users/{id}
+	PathParameters []string //in order of appearance in .Path
+
+	RequestPattern  Pattern //pattern of the JSON request body, nil if the operation has no JSON request body.
+	ResponsePattern Pattern //pattern of the JSON body of the first 2xx response, nil if there is no such body.
+}
+
+// ParseOpenAPIDocument parses an OpenAPI 3 document (JSON or YAML) and converts its schemas to patterns by using
+// ConvertJsonSchemaToPattern. References are resolved, recursive schemas are not supported. All operations
+// should have an operationId.
+func ParseOpenAPIDocument(content []byte) (*OpenAPIDocument, error) {
+	doc, err := libopenapi.NewDocument(content)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidOpenAPIDocument, err)
+	}
+
+	model, errs := doc.BuildV3Model()
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidOpenAPIDocument, errors.Join(errs...))
+	}
+
+	document := &OpenAPIDocument{
+		Schemas: map[string]Pattern{},
+	}
+
+	if model.Model.Info != nil {
+		document.Title = model.Model.Info.Title
+	}
+
+	if components := model.Model.Components; components != nil {
+		for name, schemaProxy := range components.Schemas {
+			pattern, err := convertOpenAPISchemaToPattern(schemaProxy)
+			if err != nil {
+				return nil, fmt.Errorf("schema %s: %w", name, err)
+			}
+			document.Schemas[name] = pattern
+		}
+	}
+
+	if paths := model.Model.Paths; paths != nil {
+		for path, pathItem := range paths.PathItems {
+			operations := map[string]*v3.Operation{
+				http.MethodGet:     pathItem.Get,
+				http.MethodPut:     pathItem.Put,
+				http.MethodPost:    pathItem.Post,
+				http.MethodDelete:  pathItem.Delete,
+				http.MethodOptions: pathItem.Options,
+				http.MethodHead:    pathItem.Head,
+				http.MethodPatch:   pathItem.Patch,
+			}
+
+			for method, operation := range operations {
+				if operation == nil {
+					continue
+				}
+				op, err := convertOpenAPIOperation(method, path, operation)
+				if err != nil {
+					return nil, fmt.Errorf("%s %s: %w", method, path, err)
+				}
+				document.Operations = append(document.Operations, op)
+			}
+		}
+	}
+
+	slices.SortFunc(document.Operations, func(a, b *OpenAPIOperation) int {
+		return strings.Compare(a.Id, b.Id)
+	})
+
+	for i := 1; i < len(document.Operations); i++ {
+		if document.Operations[i].Id == document.Operations[i-1].Id {
+			return nil, fmt.Errorf("%w: duplicate operationId %q", ErrInvalidOpenAPIDocument, document.Operations[i].Id)
+		}
+	}
+
+	return document, nil
+}
+
+func convertOpenAPIOperation(method, path string, operation *v3.Operation) (*OpenAPIOperation, error) {
+	if operation.OperationId == "" {
+		return nil, ErrMissingOpenAPIOperationId
+	}
+
+	op := &OpenAPIOperation{
+		Id:     operation.OperationId,
+		Method: method,
+		Path:   path,
+	}
+
+	for _, match := range OPENAPI_PATH_PARAM_REGEX.FindAllStringSubmatch(path, -1) {
+		op.PathParameters = append(op.PathParameters, match[1])
+	}
+
+	if operation.RequestBody != nil {
+		mediaType, ok := operation.RequestBody.Content[mimeconsts.JSON_CTYPE]
+		if ok && mediaType.Schema != nil {
+			pattern, err := convertOpenAPISchemaToPattern(mediaType.Schema)
+			if err != nil {
+				return nil, fmt.Errorf("request body: %w", err)
+			}
+			op.RequestPattern = pattern
+		}
+	}
+
+	if operation.Responses != nil {
+		var statusCodes []string
+		for code := range operation.Responses.Codes {
+			if strings.HasPrefix(code, "2") {
+				statusCodes = append(statusCodes, code)
+			}
+		}
+		slices.Sort(statusCodes)
+
+		if len(statusCodes) > 0 {
+			response := operation.Responses.Codes[statusCodes[0]]
+
+			mediaType, ok := response.Content[mimeconsts.JSON_CTYPE]
+			if ok && mediaType.Schema != nil {
+				pattern, err := convertOpenAPISchemaToPattern(mediaType.Schema)
+				if err != nil {
+					return nil, fmt.Errorf("response %s: %w", statusCodes[0], err)
+				}
+				op.ResponsePattern = pattern
+			}
+		}
+	}
+
+	return op, nil
+}
+
+// convertOpenAPISchemaToPattern renders the schema with its references inlined and converts it to a pattern.
+func convertOpenAPISchemaToPattern(schemaProxy *base.SchemaProxy) (Pattern, error) {
+	schema, err := schemaProxy.BuildSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	yamlSchema, err := schema.RenderInline()
+	if err != nil {
+		return nil, err
+	}
+
+	jsonSchema, err := yaml.YAMLToJSON(yamlSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	return ConvertJsonSchemaToPattern(string(jsonSchema))
+}
+
+// PatternNamespace returns a pattern namespace containing a pattern for each schema and the request & response patterns
+// of the operations. The request and response patterns are named <operation id>-request and <operation id>-response.
+func (d *OpenAPIDocument) PatternNamespace() *PatternNamespace {
+	namespace := &PatternNamespace{
+		Patterns: map[string]Pattern{},
+	}
+
+	for name, pattern := range d.Schemas {
+		namespace.Patterns[name] = pattern
+	}
+
+	for _, op := range d.Operations {
+		if op.RequestPattern != nil {
+			namespace.Patterns[op.Id+OPENAPI_REQUEST_PATTERN_SUFFIX] = op.RequestPattern
+		}
+		if op.ResponsePattern != nil {
+			namespace.Patterns[op.Id+OPENAPI_RESPONSE_PATTERN_SUFFIX] = op.ResponsePattern
+		}
+	}
+
+	return namespace
+}
+
+// NewClient returns a namespace containing a function for each operation. The arguments of a function are the path
+// parameters followed by the request body (if the operation has a JSON request body). The request body is checked
+// against the request pattern and the response body is parsed with the response pattern. Calling a function requires
+// an HTTP permission for the URL of the request. Requests with an unsafe method (POST, PUT, ...) are performed as
+// effects (see HttpRequestEffect): during a dry run they are only recorded and the function returns nil.
+func (d *OpenAPIDocument) NewClient(baseURL URL) *Namespace {
+	entries := map[string]Value{}
+
+	for _, op := range d.Operations {
+		op := op
+		entries[op.Id] = WrapGoClosure(func(ctx *Context, args ...Serializable) (Serializable, error) {
+			return op.Call(ctx, baseURL, args...)
+		})
+	}
+
+	return NewMutableEntriesNamespace(d.Title, entries)
+}
+
+// Call sends a request for the operation to the server at baseURL, see (*OpenAPIDocument).NewClient.
+func (op *OpenAPIOperation) Call(ctx *Context, baseURL URL, args ...Serializable) (Serializable, error) {
+	expectedArgCount := len(op.PathParameters)
+	if op.RequestPattern != nil {
+		expectedArgCount++
+	}
+
+	if len(args) != expectedArgCount {
+		return nil, fmt.Errorf("%w: %s expects %d argument(s) but got %d", ErrInvalidOpenAPIOperationArgs, op.Id, expectedArgCount, len(args))
+	}
+
+	//build the URL
+
+	paramIndex := 0
+	var paramErr error
+
+	path := OPENAPI_PATH_PARAM_REGEX.ReplaceAllStringFunc(op.Path, func(string) string {
+		arg := args[paramIndex]
+		name := op.PathParameters[paramIndex]
+		paramIndex++
+
+		switch a := arg.(type) {
+		case StringLike:
+			return url.PathEscape(a.GetOrBuildString())
+		case Int:
+			return strconv.FormatInt(int64(a), 10)
+		default:
+			paramErr = fmt.Errorf("%w: path parameter %s should be a string or an integer", ErrInvalidOpenAPIOperationArgs, name)
+			return ""
+		}
+	})
+
+	if paramErr != nil {
+		return nil, paramErr
+	}
+
+	requestURL := URL(strings.TrimSuffix(string(baseURL), "/") + path)
+
+	permKind := HttpMethodToPermissionKind(op.Method)
+
+	if err := ctx.CheckHasPermission(HttpPermission{Kind_: permKind, Entity: requestURL}); err != nil {
+		return nil, err
+	}
+
+	//build the body
+
+	header := http.Header{}
+	header.Set("Accept", mimeconsts.JSON_CTYPE)
+
+	var body []byte
+
+	if op.RequestPattern != nil {
+		requestBody := args[len(args)-1]
+		if !op.RequestPattern.Test(ctx, requestBody) {
+			return nil, fmt.Errorf("%s: %w", op.Id, ErrRequestBodyDoesNotMatchSchema)
+		}
+
+		json, err := GetJSONRepresentationWithConfig(requestBody, ctx, JSONSerializationConfig{
+			ReprConfig: &ReprConfig{AllVisible: true},
+			Pattern:    op.RequestPattern,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to serialize the request body: %w", op.Id, err)
+		}
+		body = []byte(json)
+		header.Set("Content-Type", mimeconsts.JSON_CTYPE)
+	}
+
+	//send the request
+
+	var responseBody []byte
+	var statusCode int
+
+	if permKind == permbase.Read {
+		//Requests with a safe method are retried according to the retry policy of the context,
+		//server errors (5xx) are retried as well.
+		err := ctx.DoIOWithRetry(func(attemptCtx context.Context) error {
+			req, err := http.NewRequestWithContext(attemptCtx, op.Method, string(requestURL), nil)
+			if err != nil {
+				return NewNonRetryableError(err)
+			}
+			req.Header = header
+
+			statusCode, responseBody, err = doHttpRequest(req, OPENAPI_CLIENT_TIMEOUT, MAX_OPENAPI_RESPONSE_BODY_SIZE)
+			switch {
+			case errors.Is(err, ErrHttpResponseBodyTooLarge):
+				return NewNonRetryableError(err)
+			case err != nil:
+				return err
+			case statusCode >= 500:
+				return fmt.Errorf("%w: status %d", ErrUnexpectedOpenAPIResponse, statusCode)
+			}
+			return nil
+		})
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op.Id, err)
+		}
+	} else {
+		//Requests with an unsafe method are effects: they are recorded instead of being sent during a dry run.
+		effect := NewHttpRequestEffect(HttpRequestEffectConfig{
+			Method:              op.Method,
+			URL:                 requestURL,
+			Header:              header,
+			Body:                body,
+			Timeout:             OPENAPI_CLIENT_TIMEOUT,
+			MaxResponseBodySize: MAX_OPENAPI_RESPONSE_BODY_SIZE,
+		})
+
+		if err := PerformEffect(ctx, effect); err != nil {
+			return nil, fmt.Errorf("%s: %w", op.Id, err)
+		}
+
+		if !effect.IsApplied() {
+			return Nil, nil
+		}
+		statusCode, responseBody = effect.Response()
+	}
+
+	if statusCode < 200 || statusCode > 299 {
+		return nil, fmt.Errorf("%s: %w: status %d", op.Id, ErrUnexpectedOpenAPIResponse, statusCode)
+	}
+
+	if op.ResponsePattern == nil || len(bytes.TrimSpace(responseBody)) == 0 {
+		return Nil, nil
+	}
+
+	result, err := ParseJSONRepresentation(ctx, string(responseBody), op.ResponsePattern)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w: the body does not match the response schema: %w", op.Id, ErrUnexpectedOpenAPIResponse, err)
+	}
+	return result, nil
+}
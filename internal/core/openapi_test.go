@@ -0,0 +1,456 @@
+package core
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/inoxlang/inox/internal/ast"
+	"github.com/inoxlang/inox/internal/core/inoxmod"
+	"github.com/inoxlang/inox/internal/core/permbase"
+	"github.com/inoxlang/inox/internal/parse"
+	"github.com/inoxlang/inox/internal/sourcecode"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/exp/maps"
+)
+
+const TEST_OPENAPI_DOCUMENT = `
+openapi: 3.0.3
+info:
+  title: users
+  version: 1.0.0
+paths:
+  /users/{id}:
+    get:
+      operationId: getUser
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: integer
+      responses:
+        '200':
+          description: user
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/User'
+  /users:
+    post:
+      operationId: createUser
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/NewUser'
+      responses:
+        '201':
+          description: created user
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/User'
+    delete:
+      operationId: deleteUsers
+      responses:
+        '204':
+          description: users deleted
+components:
+  schemas:
+    User:
+      type: object
+      required: [id, name]
+      properties:
+        id:
+          type: integer
+        name:
+          type: string
+    NewUser:
+      type: object
+      required: [name]
+      properties:
+        name:
+          type: string
+          minLength: 1
+`
+
+func TestParseOpenAPIDocument(t *testing.T) {
+
+	t.Run("schemas and operations", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		doc, err := ParseOpenAPIDocument([]byte(TEST_OPENAPI_DOCUMENT))
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.Equal(t, "users", doc.Title)
+
+		if !assert.Contains(t, doc.Schemas, "User") || !assert.Contains(t, doc.Schemas, "NewUser") {
+			return
+		}
+
+		user := NewObjectFromMap(ValMap{"id": Int(1), "name": String("a")}, ctx)
+		assert.True(t, doc.Schemas["User"].Test(ctx, user))
+		assert.False(t, doc.Schemas["NewUser"].Test(ctx, NewObjectFromMap(ValMap{"name": String("")}, ctx)))
+
+		if !assert.Len(t, doc.Operations, 3) {
+			return
+		}
+
+		createUser := doc.Operations[0]
+		assert.Equal(t, "createUser", createUser.Id)
+		assert.Equal(t, http.MethodPost, createUser.Method)
+		assert.NotNil(t, createUser.RequestPattern)
+		assert.NotNil(t, createUser.ResponsePattern)
+
+		deleteUsers := doc.Operations[1]
+		assert.Equal(t, "deleteUsers", deleteUsers.Id)
+		assert.Nil(t, deleteUsers.RequestPattern)
+		assert.Nil(t, deleteUsers.ResponsePattern)
+
+		getUser := doc.Operations[2]
+		assert.Equal(t, "getUser", getUser.Id)
+		assert.Equal(t, "/users/{id}", getUser.Path)
+		assert.Equal(t, []string{"id"}, getUser.PathParameters)
+		assert.Nil(t, getUser.RequestPattern)
+		assert.True(t, getUser.ResponsePattern.Test(ctx, user))
+	})
+
+	t.Run("pattern namespace", func(t *testing.T) {
+		doc, err := ParseOpenAPIDocument([]byte(TEST_OPENAPI_DOCUMENT))
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		namespace := doc.PatternNamespace()
+		assert.ElementsMatch(t, []string{
+			"User", "NewUser", "createUser-request", "createUser-response", "getUser-response",
+		}, maps.Keys(namespace.Patterns))
+	})
+
+	t.Run("operations should have an id", func(t *testing.T) {
+		_, err := ParseOpenAPIDocument([]byte(`
+openapi: 3.0.3
+info:
+  title: api
+  version: 1.0.0
+paths:
+  /a:
+    get:
+      responses:
+        '200':
+          description: ok
+`))
+		assert.ErrorIs(t, err, ErrMissingOpenAPIOperationId)
+	})
+
+	t.Run("not an OpenAPI 3 document", func(t *testing.T) {
+		_, err := ParseOpenAPIDocument([]byte(`swagger: "2.0"`))
+		assert.ErrorIs(t, err, ErrInvalidOpenAPIDocument)
+	})
+}
+
+func TestOpenAPIClient(t *testing.T) {
+
+	doc, err := ParseOpenAPIDocument([]byte(TEST_OPENAPI_DOCUMENT))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var lastRequestBody map[string]any
+	var unavailableUserRequestCount atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/users/1":
+			w.Write([]byte(`{"id": 1, "name": "foo"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/users/2":
+			w.Write([]byte(`{"id": "2"}`)) //does not match the response schema.
+		case r.Method == http.MethodGet && r.URL.Path == "/users/3":
+			//the first request of each pair fails.
+			if unavailableUserRequestCount.Add(1)%2 == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte(`{"id": 3, "name": "bar"}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/users":
+			body, _ := io.ReadAll(r.Body)
+			lastRequestBody = nil
+			json.Unmarshal(body, &lastRequestBody)
+
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id": 3, "name": "bar"}`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/users":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	perms := []Permission{
+		HttpPermission{Kind_: permbase.Read, AnyEntity: true},
+		HttpPermission{Kind_: permbase.Write, AnyEntity: true},
+		HttpPermission{Kind_: permbase.Delete, AnyEntity: true},
+	}
+
+	//call calls a function of the client as Inox code would do.
+	call := func(ctx *Context, client *Namespace, name string, args ...any) (result Value, err error) {
+		defer func() {
+			if e := recover(); e != nil {
+				err = e.(error)
+			}
+		}()
+
+		fn := client.Prop(ctx, name).(*GoFunction)
+		return fn.Call(args, ctx.MustGetClosestState(), nil, false, true)
+	}
+
+	t.Run("GET with path parameter", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{Permissions: perms}, nil)
+		defer ctx.CancelGracefully()
+
+		client := doc.NewClient(URL(server.URL))
+
+		result, err := call(ctx, client, "getUser", Int(1))
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		obj, ok := result.(*Object)
+		if assert.True(t, ok) {
+			assert.Equal(t, Int(1), obj.Prop(ctx, "id"))
+			assert.Equal(t, String("foo"), obj.Prop(ctx, "name"))
+		}
+	})
+
+	t.Run("POST with body", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{Permissions: perms}, nil)
+		defer ctx.CancelGracefully()
+
+		client := doc.NewClient(URL(server.URL))
+
+		result, err := call(ctx, client, "createUser", NewObjectFromMap(ValMap{"name": String("bar")}, ctx))
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, map[string]any{"name": "bar"}, lastRequestBody)
+
+		obj, ok := result.(*Object)
+		if assert.True(t, ok) {
+			assert.Equal(t, Int(3), obj.Prop(ctx, "id"))
+		}
+	})
+
+	t.Run("operation without response body", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{Permissions: perms}, nil)
+		defer ctx.CancelGracefully()
+
+		client := doc.NewClient(URL(server.URL))
+
+		result, err := call(ctx, client, "deleteUsers")
+		if assert.NoError(t, err) {
+			assert.Equal(t, Nil, result)
+		}
+	})
+
+	t.Run("a body not matching the request schema should not be sent", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{Permissions: perms}, nil)
+		defer ctx.CancelGracefully()
+
+		client := doc.NewClient(URL(server.URL))
+		lastRequestBody = nil
+
+		_, err := call(ctx, client, "createUser", NewObjectFromMap(ValMap{"name": String("")}, ctx))
+		assert.ErrorIs(t, err, ErrRequestBodyDoesNotMatchSchema)
+		assert.Nil(t, lastRequestBody)
+	})
+
+	t.Run("a response not matching the response schema should be an error", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{Permissions: perms}, nil)
+		defer ctx.CancelGracefully()
+
+		client := doc.NewClient(URL(server.URL))
+
+		_, err := call(ctx, client, "getUser", Int(2))
+		assert.ErrorIs(t, err, ErrUnexpectedOpenAPIResponse)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{Permissions: perms}, nil)
+		defer ctx.CancelGracefully()
+
+		client := doc.NewClient(URL(server.URL))
+
+		_, err := call(ctx, client, "getUser", Int(100))
+		assert.ErrorIs(t, err, ErrUnexpectedOpenAPIResponse)
+	})
+
+	t.Run("invalid number of arguments", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{Permissions: perms}, nil)
+		defer ctx.CancelGracefully()
+
+		client := doc.NewClient(URL(server.URL))
+
+		_, err := call(ctx, client, "getUser")
+		assert.ErrorIs(t, err, ErrInvalidOpenAPIOperationArgs)
+	})
+
+	t.Run("requests with an unsafe method should only be recorded during a dry run", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{Permissions: perms}, nil)
+		defer ctx.CancelGracefully()
+
+		client := doc.NewClient(URL(server.URL))
+		lastRequestBody = nil
+
+		tx := StartNewDryRunTransaction(ctx)
+		defer tx.Rollback(ctx)
+
+		result, err := call(ctx, client, "createUser", NewObjectFromMap(ValMap{"name": String("bar")}, ctx))
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, Nil, result)
+		assert.Nil(t, lastRequestBody)
+
+		//requests with a safe method are sent.
+		_, err = call(ctx, client, "getUser", Int(1))
+		assert.NoError(t, err)
+
+		assert.Equal(t, []DryRunPlanEntry{
+			{
+				Type:           "*core.HttpRequestEffect",
+				Resources:      []string{server.URL + "/users"},
+				PermissionKind: "create",
+				Reversibility:  "irreversible",
+			},
+		}, tx.DryRunPlan(ctx).Effects)
+	})
+
+	t.Run("requests with a safe method should be retried according to the retry policy of the context", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{
+			Permissions: []Permission{
+				HttpPermission{Kind_: permbase.Read, AnyEntity: true},
+			},
+			RetryPolicy: &RetryPolicy{MaxAttempts: 2},
+		}, nil)
+		defer ctx.CancelGracefully()
+
+		client := doc.NewClient(URL(server.URL))
+		unavailableUserRequestCount.Store(0)
+
+		result, err := call(ctx, client, "getUser", Int(3))
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, int32(2), unavailableUserRequestCount.Load())
+
+		obj, ok := result.(*Object)
+		if assert.True(t, ok) {
+			assert.Equal(t, Int(3), obj.Prop(ctx, "id"))
+		}
+
+		//client errors are not retried.
+		_, err = call(ctx, client, "getUser", Int(100))
+		assert.ErrorIs(t, err, ErrUnexpectedOpenAPIResponse)
+		assert.NotErrorIs(t, err, ErrRetryAttemptsExhausted)
+	})
+
+	t.Run("requests should not be retried if the context has no retry policy", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{Permissions: perms}, nil)
+		defer ctx.CancelGracefully()
+
+		client := doc.NewClient(URL(server.URL))
+		unavailableUserRequestCount.Store(0)
+
+		_, err := call(ctx, client, "getUser", Int(3))
+		assert.ErrorIs(t, err, ErrUnexpectedOpenAPIResponse)
+		assert.Equal(t, int32(1), unavailableUserRequestCount.Load())
+	})
+
+	t.Run("an HTTP permission is required", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{
+			Permissions: []Permission{
+				HttpPermission{Kind_: permbase.Read, AnyEntity: true},
+			},
+		}, nil)
+		defer ctx.CancelGracefully()
+
+		client := doc.NewClient(URL(server.URL))
+
+		_, err := call(ctx, client, "deleteUsers")
+		assert.IsType(t, &NotAllowedError{}, err)
+	})
+}
+
+func TestOpenAPIPreinitFile(t *testing.T) {
+	documentPath := filepath.Join(t.TempDir(), "api.yaml")
+	if !assert.NoError(t, os.WriteFile(documentPath, []byte(TEST_OPENAPI_DOCUMENT), 0600)) {
+		return
+	}
+
+	code := `manifest {
+		preinit-files: {
+			api: {
+				path: ` + documentPath + `
+				pattern: %str
+				openapi: true
+			}
+		}
+	}`
+
+	chunk, err := parse.ParseChunk(code, "<chunk>")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	modulePath := filepath.Join(t.TempDir(), "main.ix")
+
+	mod := WrapLowerModule(&inoxmod.Module{
+		MainChunk: parse.NewParsedChunkSource(chunk, sourcecode.File{
+			NameString:  modulePath,
+			Resource:    modulePath,
+			ResourceDir: filepath.Dir(modulePath),
+			CodeString:  code,
+		}),
+		TopLevelNode:          chunk,
+		ManifestTemplate:      chunk.Manifest,
+		InclusionStatementMap: map[*ast.InclusionImportStatement]*IncludedChunk{},
+		IncludedChunkMap:      map[string]*IncludedChunk{},
+	})
+
+	manifest, state, _, err := mod.PreInit(PreinitArgs{
+		GlobalConsts:     chunk.GlobalConstantDeclarations,
+		PreinitStatement: chunk.Preinit,
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer state.Global.Ctx.CancelGracefully()
+
+	if !assert.Len(t, manifest.PreinitFiles, 1) {
+		return
+	}
+
+	file := manifest.PreinitFiles[0]
+	assert.True(t, file.IsOpenAPIDocument)
+	if !assert.NotNil(t, file.OpenAPIDocument) {
+		return
+	}
+
+	namespace := state.Global.Ctx.ResolvePatternNamespace("api")
+	if assert.NotNil(t, namespace) {
+		assert.Contains(t, namespace.Patterns, "User")
+		assert.Contains(t, namespace.Patterns, "createUser-request")
+	}
+}
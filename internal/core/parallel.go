@@ -0,0 +1,299 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/inoxlang/inox/internal/core/permbase"
+	"github.com/inoxlang/inox/internal/core/symbolic"
+)
+
+const (
+	DEFAULT_PARALLEL_WORKER_COUNT = 4
+	MAX_PARALLEL_WORKER_COUNT     = 100
+)
+
+var (
+	ErrInvalidWorkerCount       = errors.New("the number of workers should be in the range 1..100")
+	ErrInvalidParallelCallee    = errors.New("only sharable Inox functions and Go functions can be called in parallel")
+	ErrNonBoolParallelPredicate = errors.New("the predicate should return a boolean")
+)
+
+func init() {
+	checkCallee := func(ctx *symbolic.Context, fn symbolic.Value) symbolic.Value {
+		switch f := fn.(type) {
+		case *symbolic.InoxFunction:
+			return f.Result()
+		case *symbolic.GoFunction:
+			return f.Result()
+		default:
+			ctx.AddSymbolicGoFunctionError(ErrInvalidParallelCallee.Error())
+			return symbolic.ANY
+		}
+	}
+
+	RegisterSymbolicGoFunctions([]any{
+		ParallelMap, func(ctx *symbolic.Context, iterable symbolic.Iterable, fn symbolic.Value, workerCount *symbolic.OptionalParam[*symbolic.Int]) (*symbolic.List, *symbolic.Error) {
+			result, ok := symbolic.AsSerializable(checkCallee(ctx, fn)).(symbolic.Serializable)
+			if !ok {
+				ctx.AddSymbolicGoFunctionError("the mapper should always return a serializable value")
+				result = symbolic.ANY_SERIALIZABLE
+			}
+			return symbolic.NewListOf(result), nil
+		},
+		ParallelFilter, func(ctx *symbolic.Context, iterable symbolic.Iterable, fn symbolic.Value, workerCount *symbolic.OptionalParam[*symbolic.Int]) (*symbolic.List, *symbolic.Error) {
+			checkCallee(ctx, fn)
+
+			element, ok := symbolic.AsSerializable(iterable.IteratorElementValue()).(symbolic.Serializable)
+			if !ok {
+				element = symbolic.ANY_SERIALIZABLE
+			}
+			return symbolic.NewListOf(element), nil
+		},
+		ParallelForEach, func(ctx *symbolic.Context, iterable symbolic.Iterable, fn symbolic.Value, workerCount *symbolic.OptionalParam[*symbolic.Int]) *symbolic.Error {
+			checkCallee(ctx, fn)
+			return nil
+		},
+	})
+}
+
+// ParallelMap is the value of the 'parallel_map' global, it calls fn on each element of iterable in worker lthreads
+// and returns the results in the order of the elements. See RunInParallel.
+func ParallelMap(ctx *Context, iterable Iterable, fn Value, workerCount *OptionalParam[Int]) (*List, error) {
+	_, results, err := runParallelCalls(ctx, iterable, fn, workerCount)
+	if err != nil {
+		return nil, err
+	}
+
+	elements := make([]Serializable, len(results))
+	for i, result := range results {
+		serializable, ok := result.(Serializable)
+		if !ok {
+			return nil, fmt.Errorf("parallel map: the result for the element at index %d is not serializable", i)
+		}
+		elements[i] = serializable
+	}
+
+	return NewWrappedValueListFrom(elements), nil
+}
+
+// ParallelFilter is the value of the 'parallel_filter' global, it calls the predicate fn on each element of iterable
+// in worker lthreads and returns the elements for which the predicate returned true. The order of the elements
+// is preserved. See RunInParallel.
+func ParallelFilter(ctx *Context, iterable Iterable, fn Value, workerCount *OptionalParam[Int]) (*List, error) {
+	elements, results, err := runParallelCalls(ctx, iterable, fn, workerCount)
+	if err != nil {
+		return nil, err
+	}
+
+	var kept []Serializable
+	for i, result := range results {
+		keep, ok := result.(Bool)
+		if !ok {
+			return nil, fmt.Errorf("parallel filter: %w", ErrNonBoolParallelPredicate)
+		}
+		if !keep {
+			continue
+		}
+
+		serializable, ok := elements[i].(Serializable)
+		if !ok {
+			return nil, fmt.Errorf("parallel filter: the element at index %d is not serializable", i)
+		}
+		kept = append(kept, serializable)
+	}
+
+	return NewWrappedValueListFrom(kept), nil
+}
+
+// ParallelForEach is the value of the 'parallel_for_each' global, it calls fn on each element of iterable in worker
+// lthreads. See RunInParallel.
+func ParallelForEach(ctx *Context, iterable Iterable, fn Value, workerCount *OptionalParam[Int]) error {
+	_, _, err := runParallelCalls(ctx, iterable, fn, workerCount)
+	return err
+}
+
+// runParallelCalls calls fn on the elements of iterable by using RunInParallel, it returns the elements and the results.
+func runParallelCalls(ctx *Context, iterable Iterable, fn Value, workerCountParam *OptionalParam[Int]) (elements []Value, results []Value, _ error) {
+	workerCount := DEFAULT_PARALLEL_WORKER_COUNT
+	if workerCountParam != nil {
+		workerCount = int(workerCountParam.Value)
+	}
+
+	state := ctx.MustGetClosestState()
+
+	var call func(workerState *GlobalState, element Value) (Value, error)
+
+	switch f := fn.(type) {
+	case *InoxFunction:
+		if ok, expl := f.IsSharable(f.originState); !ok {
+			return nil, nil, fmt.Errorf("%w: %s", ErrInvalidParallelCallee, expl)
+		}
+		f.Share(state)
+
+		call = func(workerState *GlobalState, element Value) (Value, error) {
+			result, err := f.Call(workerState, nil, []Value{element}, nil)
+			if err != nil {
+				return nil, err
+			}
+			return checkTransformInoxMustCallResult(result)
+		}
+	case *GoFunction:
+		call = func(workerState *GlobalState, element Value) (Value, error) {
+			return f.Call([]any{element}, workerState, nil, false, true)
+		}
+	default:
+		return nil, nil, ErrInvalidParallelCallee
+	}
+
+	it := iterable.Iterator(ctx, IteratorConfiguration{})
+	for it.Next(ctx) {
+		elements = append(elements, it.Value(ctx))
+	}
+
+	results, err := RunInParallel(ctx, elements, workerCount, call)
+	if err != nil {
+		return nil, nil, err
+	}
+	return elements, results, nil
+}
+
+// RunInParallel spawns at most workerCount lthreads that call fn on the elements. The results are returned
+// in the order of the elements. The elements are shared or cloned (see ShareOrClone) before being passed to the workers
+// and the results are shared or cloned before being returned. The first error stops all the workers and is returned.
+// Spawning the workers requires the permission to create lthreads, each worker takes a token of the
+// simultaneous-instances limit.
+func RunInParallel(ctx *Context, elements []Value, workerCount int, fn func(workerState *GlobalState, element Value) (Value, error)) ([]Value, error) {
+	if workerCount < 1 || workerCount > MAX_PARALLEL_WORKER_COUNT {
+		return nil, ErrInvalidWorkerCount
+	}
+
+	if err := ctx.CheckHasPermission(LThreadPermission{Kind_: permbase.Create}); err != nil {
+		return nil, fmt.Errorf("cannot spawn worker lthreads: %w", err)
+	}
+
+	if len(elements) == 0 {
+		return nil, nil
+	}
+
+	state := ctx.MustGetClosestState()
+
+	sharedElements := make([]Value, len(elements))
+	for i, element := range elements {
+		shared, err := ShareOrClone(element, state)
+		if err != nil {
+			return nil, fmt.Errorf("failed to share or clone the element at index %d: %w", i, err)
+		}
+		sharedElements[i] = shared
+	}
+
+	workerCount = min(workerCount, len(elements))
+
+	var (
+		results   = make([]Value, len(elements))
+		nextIndex atomic.Int64
+
+		lock     sync.Mutex
+		firstErr error
+		workers  []*LThread
+	)
+
+	//fail records the first error and cancels all the workers.
+	fail := func(err error) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		if firstErr != nil {
+			return
+		}
+		firstErr = err
+
+		for _, worker := range workers {
+			worker.Cancel(ctx)
+		}
+	}
+
+	hasFailed := func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return firstErr != nil
+	}
+
+	work := func(workerState *GlobalState) (Value, error) {
+		for !hasFailed() {
+			index := int(nextIndex.Add(1) - 1)
+			if index >= len(sharedElements) {
+				break
+			}
+
+			result, err := fn(workerState, sharedElements[index])
+			if err == nil {
+				result, err = ShareOrClone(result, workerState)
+			}
+
+			if err != nil {
+				err = fmt.Errorf("element at index %d: %w", index, err)
+				fail(err)
+				return nil, err
+			}
+			results[index] = result
+		}
+		return Nil, nil
+	}
+
+	remainingPerms := RemovePerms(ctx.GetGrantedPermissions(), IMPLICITLY_REMOVED_ROUTINE_PERMS)
+
+	for i := 0; i < workerCount && !hasFailed(); i++ {
+		workerState := NewGlobalState(NewContext(ContextConfig{
+			Permissions:          remainingPerms,
+			ForbiddenPermissions: IMPLICITLY_REMOVED_ROUTINE_PERMS,
+			ParentContext:        ctx,
+		}))
+		workerState.Module = state.Module
+		workerState.MainState = state.MainState
+		workerState.Logger = state.Logger
+		workerState.LogLevels = state.LogLevels
+		workerState.Out = state.Out
+		workerState.OutputFieldsInitialized.Store(true)
+
+		worker, err := SpawnLthreadWithState(LthreadWithStateSpawnArgs{
+			SpawnerState: state,
+			State:        workerState,
+			Run:          work,
+		})
+		if err != nil {
+			workerState.Ctx.CancelGracefully()
+			fail(err)
+			break
+		}
+
+		lock.Lock()
+		workers = append(workers, worker)
+		if firstErr != nil {
+			worker.Cancel(ctx)
+		}
+		lock.Unlock()
+	}
+
+	ctx.DoIO(func() error {
+		for _, worker := range workers {
+			<-worker.Finished()
+		}
+		return nil
+	})
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	//a worker can be cancelled without having failed if the context is done.
+	for _, worker := range workers {
+		if err := worker.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return results, nil
+}
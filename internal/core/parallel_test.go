@@ -0,0 +1,169 @@
+package core
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/inoxlang/inox/internal/core/limitbase"
+	"github.com/inoxlang/inox/internal/core/permbase"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunInParallel(t *testing.T) {
+
+	perms := []Permission{
+		GlobalVarPermission{Kind_: permbase.Read, Name: "*"},
+		GlobalVarPermission{Kind_: permbase.Use, Name: "*"},
+		GlobalVarPermission{Kind_: permbase.Create, Name: "*"},
+		LThreadPermission{permbase.Create},
+	}
+
+	limits := []Limit{
+		limitbase.MustMakeNotAutoDepletingCountLimit(limitbase.THREADS_SIMULTANEOUS_INSTANCES_LIMIT_NAME, 100_000),
+	}
+
+	ints := func(n int) []Value {
+		var values []Value
+		for i := 0; i < n; i++ {
+			values = append(values, Int(i))
+		}
+		return values
+	}
+
+	t.Run("the order of the results should be preserved", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{Permissions: perms, Limits: limits}, nil)
+		defer ctx.CancelGracefully()
+
+		results, err := RunInParallel(ctx, ints(20), 4, func(workerState *GlobalState, element Value) (Value, error) {
+			//make the workers finish in a different order.
+			time.Sleep(time.Duration(20-element.(Int)) * time.Millisecond)
+			return element.(Int) * 2, nil
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		for i, result := range results {
+			assert.Equal(t, Int(2*i), result)
+		}
+	})
+
+	t.Run("the number of workers should be bounded", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{Permissions: perms, Limits: limits}, nil)
+		defer ctx.CancelGracefully()
+
+		var current, maxCurrent atomic.Int64
+		_, err := RunInParallel(ctx, ints(30), 3, func(workerState *GlobalState, element Value) (Value, error) {
+			n := current.Add(1)
+			defer current.Add(-1)
+
+			for {
+				prev := maxCurrent.Load()
+				if n <= prev || maxCurrent.CompareAndSwap(prev, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			return Nil, nil
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.LessOrEqual(t, maxCurrent.Load(), int64(3))
+		assert.Greater(t, maxCurrent.Load(), int64(1))
+	})
+
+	t.Run("the first error should be returned and the other workers should be cancelled", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{Permissions: perms, Limits: limits}, nil)
+		defer ctx.CancelGracefully()
+
+		failure := errors.New("failure")
+		var cancelled atomic.Int64
+
+		_, err := RunInParallel(ctx, ints(3), 3, func(workerState *GlobalState, element Value) (Value, error) {
+			if element == Int(0) {
+				time.Sleep(10 * time.Millisecond)
+				return nil, failure
+			}
+
+			select {
+			case <-workerState.Ctx.Done():
+				cancelled.Add(1)
+				return nil, workerState.Ctx.Err()
+			case <-time.After(time.Second):
+				return Nil, nil
+			}
+		})
+
+		assert.ErrorIs(t, err, failure)
+		assert.EqualValues(t, 2, cancelled.Load())
+	})
+
+	t.Run("the elements should be shared or cloned", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{Permissions: perms, Limits: limits}, nil)
+		defer ctx.CancelGracefully()
+
+		obj := NewObjectFromMap(ValMap{"a": Int(1)}, ctx)
+
+		results, err := RunInParallel(ctx, []Value{obj}, 1, func(workerState *GlobalState, element Value) (Value, error) {
+			return Bool(element.(*Object).IsShared()), nil
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, []Value{True}, results)
+	})
+
+	t.Run("the permission to create lthreads is required", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		_, err := RunInParallel(ctx, ints(1), 1, func(workerState *GlobalState, element Value) (Value, error) {
+			return Nil, nil
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid worker count", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{Permissions: perms, Limits: limits}, nil)
+		defer ctx.CancelGracefully()
+
+		_, err := RunInParallel(ctx, ints(1), 0, func(workerState *GlobalState, element Value) (Value, error) {
+			return Nil, nil
+		})
+		assert.ErrorIs(t, err, ErrInvalidWorkerCount)
+	})
+
+	t.Run("parallel map & filter", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{Permissions: perms, Limits: limits}, nil)
+		defer ctx.CancelGracefully()
+		list := NewWrappedValueList(Int(1), Int(2), Int(3), Int(4))
+
+		mapped, err := ParallelMap(ctx, list, WrapGoFunction(func(ctx *Context, i Int) Int {
+			return i * 10
+		}), &OptionalParam[Int]{Value: 2})
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, []Serializable{Int(10), Int(20), Int(30), Int(40)}, mapped.GetOrBuildElements(ctx))
+
+		filtered, err := ParallelFilter(ctx, list, WrapGoFunction(func(ctx *Context, i Int) Bool {
+			return i%2 == 0
+		}), nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, []Serializable{Int(2), Int(4)}, filtered.GetOrBuildElements(ctx))
+
+		var sum atomic.Int64
+		err = ParallelForEach(ctx, list, WrapGoFunction(func(ctx *Context, i Int) {
+			sum.Add(int64(i))
+		}), nil)
+		if assert.NoError(t, err) {
+			assert.EqualValues(t, 10, sum.Load())
+		}
+	})
+}
@@ -0,0 +1,831 @@
+package core
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	MAX_BINARY_REPR_PARSING_DEPTH = MAX_BINARY_REPR_WRITING_DEPTH
+)
+
+var (
+	ErrInvalidBinaryRepr                    = errors.New("invalid binary representation")
+	ErrUnsupportedBinaryReprVersion         = errors.New("unsupported binary representation version")
+	ErrTruncatedBinaryRepr                  = errors.New("truncated binary representation")
+	ErrMaximumBinaryReprParsingDepthReached = errors.New("maximum binary representation parsing depth reached")
+	ErrBinaryReprNotMatchingPattern         = errors.New("binary representation is not matching the pattern")
+)
+
+// ParseBinaryRepresentation decodes a binary representation written by WriteBinaryRepresentation. If pattern is
+// not nil the decoding is guided by it: the patterns of properties and elements are determined while descending
+// into containers and values having a non-container pattern are checked as soon as they are decoded, so
+// mismatches are reported with their location. The whole value is then checked against the pattern.
+func ParseBinaryRepresentation(ctx *Context, data []byte, pattern Pattern) (Serializable, error) {
+	if len(data) < BINARY_REPR_HEADER_LENGTH || string(data[:len(BINARY_REPR_MAGIC)]) != BINARY_REPR_MAGIC {
+		return nil, fmt.Errorf("%w: missing header", ErrInvalidBinaryRepr)
+	}
+
+	version := data[len(BINARY_REPR_MAGIC)]
+	if version == 0 || version > BINARY_REPR_VERSION {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedBinaryReprVersion, version)
+	}
+
+	r := &binaryReprReader{
+		ctx:  ctx,
+		data: data,
+		pos:  BINARY_REPR_HEADER_LENGTH,
+	}
+
+	if NoPatternOrAny(pattern) {
+		pattern = nil
+	}
+
+	v, err := r.readValue(pattern, "", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.pos != len(r.data) {
+		return nil, fmt.Errorf("%w: unexpected bytes after the value", ErrInvalidBinaryRepr)
+	}
+
+	if pattern != nil && !pattern.Test(ctx, v) {
+		return nil, ErrBinaryReprNotMatchingPattern
+	}
+	return v, nil
+}
+
+type binaryReprReader struct {
+	ctx  *Context
+	data []byte
+	pos  int
+}
+
+func (r *binaryReprReader) readByte() (byte, error) {
+	if r.pos >= len(r.data) {
+		return 0, ErrTruncatedBinaryRepr
+	}
+	b := r.data[r.pos]
+	r.pos++
+	return b, nil
+}
+
+func (r *binaryReprReader) readBool() (bool, error) {
+	b, err := r.readByte()
+	if err != nil {
+		return false, err
+	}
+	switch b {
+	case 0:
+		return false, nil
+	case 1:
+		return true, nil
+	default:
+		return false, fmt.Errorf("%w: invalid boolean", ErrInvalidBinaryRepr)
+	}
+}
+
+func (r *binaryReprReader) readInt() (int64, error) {
+	i, n := binary.Varint(r.data[r.pos:])
+	if n <= 0 {
+		return 0, ErrTruncatedBinaryRepr
+	}
+	r.pos += n
+	return i, nil
+}
+
+func (r *binaryReprReader) readUint() (uint64, error) {
+	i, n := binary.Uvarint(r.data[r.pos:])
+	if n <= 0 {
+		return 0, ErrTruncatedBinaryRepr
+	}
+	r.pos += n
+	return i, nil
+}
+
+// readCount reads a length or an element count, the count is checked against the number of remaining
+// bytes (each counted item takes at least one byte) to prevent large allocations.
+func (r *binaryReprReader) readCount() (int, error) {
+	count, err := r.readUint()
+	if err != nil {
+		return 0, err
+	}
+	if count > uint64(len(r.data)-r.pos) {
+		return 0, ErrTruncatedBinaryRepr
+	}
+	return int(count), nil
+}
+
+func (r *binaryReprReader) readFloat() (float64, error) {
+	if len(r.data)-r.pos < 8 {
+		return 0, ErrTruncatedBinaryRepr
+	}
+	bits := binary.LittleEndian.Uint64(r.data[r.pos:])
+	r.pos += 8
+	return math.Float64frombits(bits), nil
+}
+
+func (r *binaryReprReader) readBytes() ([]byte, error) {
+	length, err := r.readCount()
+	if err != nil {
+		return nil, err
+	}
+	b := r.data[r.pos : r.pos+length]
+	r.pos += length
+	return b, nil
+}
+
+func (r *binaryReprReader) readString() (string, error) {
+	b, err := r.readBytes()
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func (r *binaryReprReader) readFixedSize(size int) ([]byte, error) {
+	if len(r.data)-r.pos < size {
+		return nil, ErrTruncatedBinaryRepr
+	}
+	b := r.data[r.pos : r.pos+size]
+	r.pos += size
+	return b, nil
+}
+
+func (r *binaryReprReader) readTime() (time.Time, error) {
+	var t time.Time
+	b, err := r.readBytes()
+	if err != nil {
+		return t, err
+	}
+	if err := t.UnmarshalBinary(b); err != nil {
+		return t, fmt.Errorf("%w: %w", ErrInvalidBinaryRepr, err)
+	}
+	return t, nil
+}
+
+// readValue reads a value, pattern is nil if the value is not constrained. location is the location of the
+// value in the top-level value and is only used in error messages.
+func (r *binaryReprReader) readValue(pattern Pattern, location string, depth int) (Serializable, error) {
+	if depth > MAX_BINARY_REPR_PARSING_DEPTH {
+		return nil, ErrMaximumBinaryReprParsingDepthReached
+	}
+
+	tagByte, err := r.readByte()
+	if err != nil {
+		return nil, err
+	}
+	tag := binaryReprTag(tagByte)
+
+	if optionalPattern, ok := pattern.(*OptionalPattern); ok {
+		if tag == binNil {
+			return Nil, nil
+		}
+		pattern = optionalPattern.pattern
+	}
+
+	var value Serializable
+
+	switch tag {
+	case binNil:
+		value = Nil
+	case binFalse:
+		value = False
+	case binTrue:
+		value = True
+	case binInt:
+		i, err := r.readInt()
+		if err != nil {
+			return nil, err
+		}
+		value = Int(i)
+	case binFloat:
+		f, err := r.readFloat()
+		if err != nil {
+			return nil, err
+		}
+		value = Float(f)
+	case binByte:
+		b, err := r.readByte()
+		if err != nil {
+			return nil, err
+		}
+		value = Byte(b)
+	case binRune:
+		i, err := r.readInt()
+		if err != nil {
+			return nil, err
+		}
+		value = Rune(i)
+
+	//string-like values
+	case binString, binPath, binPathPattern, binURL, binURLPattern, binHost, binHostPattern, binScheme,
+		binIdentifier, binPropertyName, binEmailAddress, binMimetype:
+		s, err := r.readString()
+		if err != nil {
+			return nil, err
+		}
+		value = makeStringLikeBinaryReprValue(tag, s)
+
+	//quantities & time
+	case binByteCount, binLineCount, binRuneCount, binByteRate, binDuration:
+		i, err := r.readInt()
+		if err != nil {
+			return nil, err
+		}
+		switch tag {
+		case binByteCount:
+			value = ByteCount(i)
+		case binLineCount:
+			value = LineCount(i)
+		case binRuneCount:
+			value = RuneCount(i)
+		case binByteRate:
+			value = ByteRate(i)
+		default:
+			value = Duration(i)
+		}
+	case binFrequency:
+		f, err := r.readFloat()
+		if err != nil {
+			return nil, err
+		}
+		value = Frequency(f)
+	case binYear, binDate, binDateTime:
+		t, err := r.readTime()
+		if err != nil {
+			return nil, err
+		}
+		switch tag {
+		case binYear:
+			if err := Year(t).Validate(); err != nil {
+				return nil, fmt.Errorf("%w: %w", ErrInvalidBinaryRepr, err)
+			}
+			value = Year(t)
+		case binDate:
+			if err := Date(t).Validate(); err != nil {
+				return nil, fmt.Errorf("%w: %w", ErrInvalidBinaryRepr, err)
+			}
+			value = Date(t)
+		default:
+			value = DateTime(t)
+		}
+
+	//other atomic values
+	case binFileMode:
+		mode, err := r.readUint()
+		if err != nil {
+			return nil, err
+		}
+		value = FileMode(fs.FileMode(mode))
+	case binULID:
+		b, err := r.readFixedSize(16)
+		if err != nil {
+			return nil, err
+		}
+		value = ULID(b)
+	case binUUIDv4:
+		b, err := r.readFixedSize(16)
+		if err != nil {
+			return nil, err
+		}
+		value = UUIDv4(b)
+	case binIntRange:
+		intRange, err := r.readIntRange()
+		if err != nil {
+			return nil, err
+		}
+		value = intRange
+	case binFloatRange:
+		floatRange, err := r.readFloatRange()
+		if err != nil {
+			return nil, err
+		}
+		value = floatRange
+	case binRuneRange:
+		start, err := r.readInt()
+		if err != nil {
+			return nil, err
+		}
+		end, err := r.readInt()
+		if err != nil {
+			return nil, err
+		}
+		value = RuneRange{Start: rune(start), End: rune(end)}
+
+	//containers
+	case binByteSlice:
+		mutable, err := r.readBool()
+		if err != nil {
+			return nil, err
+		}
+		contentType, err := r.readString()
+		if err != nil {
+			return nil, err
+		}
+		b, err := r.readBytes()
+		if err != nil {
+			return nil, err
+		}
+		if mutable && contentType != "" {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidBinaryRepr, ErrAttemptToCreateMutableSpecificTypeByteSlice)
+		}
+		value = NewByteSlice(slices.Clone(b), mutable, Mimetype(contentType))
+	case binRuneSlice:
+		count, err := r.readCount()
+		if err != nil {
+			return nil, err
+		}
+		runes := make([]rune, count)
+		for i := range runes {
+			rn, err := r.readInt()
+			if err != nil {
+				return nil, err
+			}
+			runes[i] = rune(rn)
+		}
+		value = NewRuneSlice(runes)
+	case binObject, binRecord:
+		keys, values, err := r.readProperties(pattern, location, depth)
+		if err != nil {
+			return nil, err
+		}
+		if tag == binObject {
+			value = objFromLists(keys, values)
+		} else {
+			for i, v := range values {
+				if v.IsMutable() {
+					return nil, fmt.Errorf("%w: property %s.%s of record is mutable", ErrInvalidBinaryRepr, location, keys[i])
+				}
+			}
+			value = NewRecordFromKeyValLists(keys, values)
+		}
+	case binList, binTuple:
+		elements, err := r.readElements(pattern, location, depth)
+		if err != nil {
+			return nil, err
+		}
+		if tag == binList {
+			value = NewWrappedValueListFrom(elements)
+		} else {
+			for i, e := range elements {
+				if e.IsMutable() {
+					return nil, fmt.Errorf("%w: element %s[%d] of tuple is mutable", ErrInvalidBinaryRepr, location, i)
+				}
+			}
+			value = NewTuple(elements)
+		}
+	case binKeyList:
+		count, err := r.readCount()
+		if err != nil {
+			return nil, err
+		}
+		keyList := make(KeyList, count)
+		for i := range keyList {
+			keyList[i], err = r.readString()
+			if err != nil {
+				return nil, err
+			}
+		}
+		value = keyList
+	case binDictionary:
+		count, err := r.readCount()
+		if err != nil {
+			return nil, err
+		}
+		keys := make([]Serializable, count)
+		values := make([]Serializable, count)
+		for i := 0; i < count; i++ {
+			keys[i], err = r.readValue(nil, location, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			values[i], err = r.readValue(nil, location, depth+1)
+			if err != nil {
+				return nil, err
+			}
+		}
+		value = NewDictionaryFromKeyValueLists(keys, values, r.ctx)
+	default:
+		pattern, err := r.readPattern(tag, location, depth)
+		if err != nil {
+			return nil, err
+		}
+		value = pattern
+	}
+
+	if pattern != nil && !isContainerPattern(pattern) && !pattern.Test(r.ctx, value) {
+		if location == "" {
+			return nil, ErrBinaryReprNotMatchingPattern
+		}
+		return nil, fmt.Errorf("%w: value at %s", ErrBinaryReprNotMatchingPattern, location)
+	}
+
+	return value, nil
+}
+
+// isContainerPattern returns true if pattern is a pattern whose property or element patterns are used by
+// the decoding, such patterns are checked once the whole value is decoded.
+func isContainerPattern(pattern Pattern) bool {
+	switch pattern.(type) {
+	case *ObjectPattern, *RecordPattern, *ListPattern, *TuplePattern:
+		return true
+	}
+	return false
+}
+
+func makeStringLikeBinaryReprValue(tag binaryReprTag, s string) Serializable {
+	switch tag {
+	case binString:
+		return String(s)
+	case binPath:
+		return Path(s)
+	case binPathPattern:
+		return PathPattern(s)
+	case binURL:
+		return URL(s)
+	case binURLPattern:
+		return URLPattern(s)
+	case binHost:
+		return Host(s)
+	case binHostPattern:
+		return HostPattern(s)
+	case binScheme:
+		return Scheme(s)
+	case binIdentifier:
+		return Identifier(s)
+	case binPropertyName:
+		return PropertyName(s)
+	case binEmailAddress:
+		return EmailAddress(s)
+	case binMimetype:
+		return Mimetype(s)
+	default:
+		panic(ErrUnreachable)
+	}
+}
+
+func (r *binaryReprReader) readIntRange() (IntRange, error) {
+	unknownStart, err := r.readBool()
+	if err != nil {
+		return IntRange{}, err
+	}
+	start, err := r.readInt()
+	if err != nil {
+		return IntRange{}, err
+	}
+	end, err := r.readInt()
+	if err != nil {
+		return IntRange{}, err
+	}
+	if !unknownStart && end < start {
+		return IntRange{}, fmt.Errorf("%w: int range: end < start", ErrInvalidBinaryRepr)
+	}
+	return IntRange{unknownStart: unknownStart, start: start, end: end, step: 1}, nil
+}
+
+func (r *binaryReprReader) readFloatRange() (FloatRange, error) {
+	unknownStart, err := r.readBool()
+	if err != nil {
+		return FloatRange{}, err
+	}
+	inclusiveEnd, err := r.readBool()
+	if err != nil {
+		return FloatRange{}, err
+	}
+	start, err := r.readFloat()
+	if err != nil {
+		return FloatRange{}, err
+	}
+	end, err := r.readFloat()
+	if err != nil {
+		return FloatRange{}, err
+	}
+	if !unknownStart && end < start {
+		return FloatRange{}, fmt.Errorf("%w: float range: end < start", ErrInvalidBinaryRepr)
+	}
+	return FloatRange{unknownStart: unknownStart, inclusiveEnd: inclusiveEnd, start: start, end: end}, nil
+}
+
+func (r *binaryReprReader) readProperties(pattern Pattern, location string, depth int) ([]string, []Serializable, error) {
+	count, err := r.readCount()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	propsPattern, _ := pattern.(IPropsPattern)
+
+	keys := make([]string, count)
+	values := make([]Serializable, count)
+
+	for i := 0; i < count; i++ {
+		key, err := r.readString()
+		if err != nil {
+			return nil, nil, err
+		}
+		if slices.Contains(keys[:i], key) {
+			return nil, nil, fmt.Errorf("%w: duplicate property %s.%s", ErrInvalidBinaryRepr, location, key)
+		}
+
+		var propPattern Pattern
+		if propsPattern != nil {
+			propPattern, _, _ = propsPattern.ValuePropPattern(key)
+		}
+
+		keys[i] = key
+		values[i], err = r.readValue(propPattern, location+"."+key, depth+1)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return keys, values, nil
+}
+
+func (r *binaryReprReader) readElements(pattern Pattern, location string, depth int) ([]Serializable, error) {
+	count, err := r.readCount()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		elementPatterns       []Pattern
+		generalElementPattern Pattern
+	)
+
+	switch p := pattern.(type) {
+	case *ListPattern:
+		elementPatterns, generalElementPattern = p.elementPatterns, p.generalElementPattern
+	case *TuplePattern:
+		elementPatterns, generalElementPattern = p.elementPatterns, p.generalElementPattern
+	}
+
+	elements := make([]Serializable, count)
+
+	for i := range elements {
+		elementPattern := generalElementPattern
+		if i < len(elementPatterns) {
+			elementPattern = elementPatterns[i]
+		}
+
+		elements[i], err = r.readValue(elementPattern, location+"["+strconv.Itoa(i)+"]", depth+1)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return elements, nil
+}
+
+func (r *binaryReprReader) readSubPattern(location string, depth int) (Pattern, error) {
+	v, err := r.readValue(nil, location, depth+1)
+	if err != nil {
+		return nil, err
+	}
+	pattern, ok := v.(Pattern)
+	if !ok {
+		return nil, fmt.Errorf("%w: a pattern was expected at %s", ErrInvalidBinaryRepr, location)
+	}
+	return pattern, nil
+}
+
+func (r *binaryReprReader) readPattern(tag binaryReprTag, location string, depth int) (Pattern, error) {
+	switch tag {
+	case binTypePattern:
+		name, err := r.readString()
+		if err != nil {
+			return nil, err
+		}
+		pattern, ok := DEFAULT_NAMED_PATTERNS[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown type pattern %q", ErrInvalidBinaryRepr, name)
+		}
+		return pattern, nil
+	case binExactValuePattern:
+		value, err := r.readValue(nil, location, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		if value.IsMutable() {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidBinaryRepr, ErrValueInExactPatternValueShouldBeImmutable)
+		}
+		return NewExactValuePattern(value), nil
+	case binExactStringPattern:
+		s, err := r.readString()
+		if err != nil {
+			return nil, err
+		}
+		if !utf8.ValidString(s) {
+			return nil, fmt.Errorf("%w: the value of an exact string pattern should be valid UTF-8", ErrInvalidBinaryRepr)
+		}
+		return NewExactStringPattern(String(s)), nil
+	case binRegexPattern:
+		s, err := r.readString()
+		if err != nil {
+			return nil, err
+		}
+		regex, err := regexp.Compile(s)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidBinaryRepr, err)
+		}
+		return NewRegexPatternFromPERLCompiled(regex), nil
+	case binIntRangePattern:
+		intRange, err := r.readIntRange()
+		if err != nil {
+			return nil, err
+		}
+		multipleOf, err := r.readInt()
+		if err != nil {
+			return nil, err
+		}
+		pattern := &IntRangePattern{intRange: intRange, multipleOf: Int(multipleOf)}
+
+		hasFloatMultipleOf, err := r.readBool()
+		if err != nil {
+			return nil, err
+		}
+		if hasFloatMultipleOf {
+			f, err := r.readFloat()
+			if err != nil {
+				return nil, err
+			}
+			multipleOfFloat := Float(f)
+			pattern.multipleOfFloat = &multipleOfFloat
+		}
+		return pattern, nil
+	case binFloatRangePattern:
+		floatRange, err := r.readFloatRange()
+		if err != nil {
+			return nil, err
+		}
+		multipleOf, err := r.readFloat()
+		if err != nil {
+			return nil, err
+		}
+		return &FloatRangePattern{floatRange: floatRange, multipleOf: Float(multipleOf)}, nil
+	case binObjectPattern, binRecordPattern:
+		inexact, err := r.readBool()
+		if err != nil {
+			return nil, err
+		}
+		count, err := r.readCount()
+		if err != nil {
+			return nil, err
+		}
+
+		var (
+			objectEntries []ObjectPatternEntry
+			recordEntries []RecordPatternEntry
+		)
+
+		for i := 0; i < count; i++ {
+			name, err := r.readString()
+			if err != nil {
+				return nil, err
+			}
+			isOptional, err := r.readBool()
+			if err != nil {
+				return nil, err
+			}
+			entryPattern, err := r.readSubPattern(location, depth)
+			if err != nil {
+				return nil, err
+			}
+
+			if tag == binObjectPattern {
+				objectEntries = append(objectEntries, ObjectPatternEntry{Name: name, Pattern: entryPattern, IsOptional: isOptional})
+			} else {
+				recordEntries = append(recordEntries, RecordPatternEntry{Name: name, Pattern: entryPattern, IsOptional: isOptional})
+			}
+		}
+
+		if tag == binObjectPattern {
+			return NewObjectPattern(inexact, objectEntries), nil
+		}
+		return NewRecordPattern(inexact, recordEntries), nil
+	case binListPattern:
+		elementPatterns, generalElementPattern, err := r.readSequencePattern(location, depth)
+		if err != nil {
+			return nil, err
+		}
+
+		if generalElementPattern == nil {
+			return NewListPattern(elementPatterns), nil
+		}
+
+		pattern := NewListPatternOf(generalElementPattern)
+
+		hasLengthConstraint, err := r.readBool()
+		if err != nil {
+			return nil, err
+		}
+		if hasLengthConstraint {
+			minCount, err := r.readUint()
+			if err != nil {
+				return nil, err
+			}
+			maxCount, err := r.readUint()
+			if err != nil {
+				return nil, err
+			}
+			if minCount > maxCount || maxCount > math.MaxInt32 {
+				return nil, fmt.Errorf("%w: invalid list pattern length constraint", ErrInvalidBinaryRepr)
+			}
+			pattern = pattern.WithMinMaxElements(int(minCount), int(maxCount))
+		}
+
+		hasContainedElement, err := r.readBool()
+		if err != nil {
+			return nil, err
+		}
+		if hasContainedElement {
+			containedElement, err := r.readSubPattern(location, depth)
+			if err != nil {
+				return nil, err
+			}
+			pattern = pattern.WithElement(containedElement)
+		}
+		return pattern, nil
+	case binTuplePattern:
+		elementPatterns, generalElementPattern, err := r.readSequencePattern(location, depth)
+		if err != nil {
+			return nil, err
+		}
+		if generalElementPattern != nil {
+			return NewTuplePatternOf(generalElementPattern), nil
+		}
+		return NewTuplePattern(elementPatterns), nil
+	case binUnionPattern:
+		disjoint, err := r.readBool()
+		if err != nil {
+			return nil, err
+		}
+		count, err := r.readCount()
+		if err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			return nil, fmt.Errorf("%w: a union pattern should have at least one case", ErrInvalidBinaryRepr)
+		}
+		cases := make([]Pattern, count)
+		for i := range cases {
+			cases[i], err = r.readSubPattern(location, depth)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if disjoint {
+			return NewDisjointUnionPattern(cases, nil), nil
+		}
+		return NewUnionPattern(cases, nil), nil
+	case binOptionalPattern:
+		pattern, err := r.readSubPattern(location, depth)
+		if err != nil {
+			return nil, err
+		}
+		optionalPattern, err := NewOptionalPattern(r.ctx, pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidBinaryRepr, err)
+		}
+		return optionalPattern, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown tag %d", ErrInvalidBinaryRepr, tag)
+	}
+}
+
+func (r *binaryReprReader) readSequencePattern(location string, depth int) ([]Pattern, Pattern, error) {
+	hasGeneralElementPattern, err := r.readBool()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if hasGeneralElementPattern {
+		generalElementPattern, err := r.readSubPattern(location, depth)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, generalElementPattern, nil
+	}
+
+	count, err := r.readCount()
+	if err != nil {
+		return nil, nil, err
+	}
+	elementPatterns := make([]Pattern, count)
+	for i := range elementPatterns {
+		elementPatterns[i], err = r.readSubPattern(location, depth)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return elementPatterns, nil, nil
+}
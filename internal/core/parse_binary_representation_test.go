@@ -0,0 +1,189 @@
+package core
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	utils "github.com/inoxlang/inox/internal/utils/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBinaryRepresentation(t *testing.T) {
+	ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+	defer ctx.CancelGracefully()
+
+	roundTrip := func(t *testing.T, v Serializable, pattern Pattern) (Serializable, error) {
+		repr, err := GetBinaryRepresentation(ctx, v, BinarySerializationConfig{})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		return ParseBinaryRepresentation(ctx, repr, pattern)
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		date := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+		values := []Serializable{
+			Nil, True, False, Int(0), Int(-1), Int(1 << 60), Float(1.5), Byte('a'), Rune('é'),
+			String(""), String("héllo"), Path("/a/b"), PathPattern("/a/..."), URL("https://example.com/a"),
+			URLPattern("https://example.com/..."), Host("https://example.com"), HostPattern("https://**.com"),
+			Scheme("https"), Identifier("a"), PropertyName("name"), EmailAddress("a@mail.com"), Mimetype("text/plain"),
+			ByteCount(10_000), LineCount(3), RuneCount(4), ByteRate(100), Frequency(2.5), Duration(time.Second),
+			Year(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)), Date(date), DateTime(time.Date(2024, 1, 2, 10, 0, 0, 5, time.FixedZone("", 3600))),
+			FileMode(0o755), NewULID(), NewUUIDv4(),
+			NewIntRange(1, 10), NewIncludedEndFloatRange(0, 1.5), RuneRange{Start: 'a', End: 'z'},
+			NewByteSlice([]byte{1, 2, 3}, true, ""), NewByteSlice([]byte{1}, false, "application/octet-stream"), NewRuneSlice([]rune("abc")),
+			NewRecordFromMap(ValMap{"a": Int(1), "b": NewTupleVariadic(String("x"), Path("/"))}),
+			objFrom(ValMap{"a": NewWrappedValueList(Int(1), objFrom(ValMap{}))}),
+			KeyList{"a", "b"},
+			NewDictionaryFromKeyValueLists([]Serializable{String("a"), Int(1)}, []Serializable{Int(1), Path("/")}, ctx),
+		}
+
+		for _, value := range values {
+			result, err := roundTrip(t, value, nil)
+			if !assert.NoError(t, err, Stringify(value, ctx)) {
+				continue
+			}
+			assert.True(t, value.Equal(ctx, result, map[uintptr]uintptr{}, 0), Stringify(value, ctx))
+		}
+	})
+
+	t.Run("patterns", func(t *testing.T) {
+		patterns := []Pattern{
+			INT_PATTERN,
+			NewExactValuePattern(Int(1)),
+			NewExactStringPattern("a"),
+			NewRegexPattern("a+"),
+			NewIncludedEndIntRangePattern(0, 10, 2),
+			NewFloatRangePattern(NewIncludedEndFloatRange(0, 1), -1),
+			NewInexactObjectPattern([]ObjectPatternEntry{{Name: "a", Pattern: INT_PATTERN, IsOptional: true}}),
+			NewExactRecordPattern([]RecordPatternEntry{{Name: "a", Pattern: STR_PATTERN}}),
+			NewListPatternOf(INT_PATTERN).WithMinMaxElements(1, 3),
+			NewListPattern([]Pattern{INT_PATTERN, STR_PATTERN}),
+			NewTuplePatternOf(INT_PATTERN),
+			NewUnionPattern([]Pattern{INT_PATTERN, STR_PATTERN}, nil),
+			utils.Must(NewOptionalPattern(ctx, INT_PATTERN)),
+		}
+
+		for _, pattern := range patterns {
+			result, err := roundTrip(t, pattern, nil)
+			if !assert.NoError(t, err, Stringify(pattern, ctx)) {
+				continue
+			}
+			assert.True(t, pattern.Equal(ctx, result, map[uintptr]uintptr{}, 0), Stringify(pattern, ctx))
+		}
+	})
+
+	t.Run("pattern-guided decoding", func(t *testing.T) {
+		pattern := NewInexactRecordPattern([]RecordPatternEntry{
+			{Name: "name", Pattern: STR_PATTERN},
+			{Name: "ports", Pattern: NewTuplePatternOf(INT_PATTERN)},
+			{Name: "timeout", Pattern: utils.Must(NewOptionalPattern(ctx, DURATION_PATTERN))},
+		})
+
+		t.Run("matching value", func(t *testing.T) {
+			value := NewRecordFromMap(ValMap{
+				"name":    String("app"),
+				"ports":   NewTupleVariadic(Int(80), Int(443)),
+				"timeout": Nil,
+			})
+
+			result, err := roundTrip(t, value, pattern)
+			if assert.NoError(t, err) {
+				assert.True(t, value.Equal(ctx, result, map[uintptr]uintptr{}, 0))
+			}
+		})
+
+		t.Run("mismatches should be reported with their location", func(t *testing.T) {
+			value := NewRecordFromMap(ValMap{
+				"name":  String("app"),
+				"ports": NewTupleVariadic(Int(80), String("443")),
+			})
+
+			_, err := roundTrip(t, value, pattern)
+			if assert.ErrorIs(t, err, ErrBinaryReprNotMatchingPattern) {
+				assert.Contains(t, err.Error(), ".ports[1]")
+			}
+		})
+
+		t.Run("missing property", func(t *testing.T) {
+			value := NewRecordFromMap(ValMap{"name": String("app")})
+
+			_, err := roundTrip(t, value, pattern)
+			assert.ErrorIs(t, err, ErrBinaryReprNotMatchingPattern)
+		})
+
+		t.Run("object instead of record", func(t *testing.T) {
+			value := objFrom(ValMap{"name": String("app"), "ports": NewTupleVariadic()})
+
+			_, err := roundTrip(t, value, pattern)
+			assert.ErrorIs(t, err, ErrBinaryReprNotMatchingPattern)
+		})
+	})
+
+	t.Run("invalid data", func(t *testing.T) {
+		repr, err := GetBinaryRepresentation(ctx, NewWrappedValueList(String("abc"), Int(1)), BinarySerializationConfig{})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		_, err = ParseBinaryRepresentation(ctx, []byte("{}"), nil)
+		assert.ErrorIs(t, err, ErrInvalidBinaryRepr)
+
+		for i := BINARY_REPR_HEADER_LENGTH; i < len(repr); i++ {
+			_, err = ParseBinaryRepresentation(ctx, repr[:i], nil)
+			assert.ErrorIs(t, err, ErrTruncatedBinaryRepr)
+		}
+
+		_, err = ParseBinaryRepresentation(ctx, append(repr, 0), nil)
+		assert.ErrorIs(t, err, ErrInvalidBinaryRepr)
+	})
+
+	t.Run("exact string pattern with an invalid UTF-8 value", func(t *testing.T) {
+		repr, err := GetBinaryRepresentation(ctx, NewExactStringPattern("a"), BinarySerializationConfig{})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		repr[len(repr)-1] = 0xff
+
+		_, err = ParseBinaryRepresentation(ctx, repr, nil)
+		assert.ErrorIs(t, err, ErrInvalidBinaryRepr)
+	})
+
+	t.Run("corrupted data should not cause a panic", func(t *testing.T) {
+		values := []Serializable{
+			NewWrappedValueList(String("abc"), Int(1), Path("/a")),
+			NewRecordFromMap(ValMap{"a": NewTupleVariadic(Float(1.5), True)}),
+			NewExactStringPattern("ab"),
+			NewUnionPattern([]Pattern{INT_PATTERN, STR_PATTERN}, nil),
+			NewDisjointUnionPattern([]Pattern{INT_PATTERN, NewExactStringPattern("a")}, nil),
+			NewInexactObjectPattern([]ObjectPatternEntry{{Name: "a", Pattern: NewListPatternOf(INT_PATTERN)}}),
+		}
+
+		for _, value := range values {
+			repr, err := GetBinaryRepresentation(ctx, value, BinarySerializationConfig{})
+			if !assert.NoError(t, err) {
+				continue
+			}
+
+			//every byte after the header is replaced by every possible value.
+			for i := BINARY_REPR_HEADER_LENGTH; i < len(repr); i++ {
+				for b := 0; b <= 0xff; b++ {
+					corrupted := slices.Clone(repr)
+					corrupted[i] = byte(b)
+
+					assert.NotPanics(t, func() {
+						ParseBinaryRepresentation(ctx, corrupted, nil)
+					}, "%s: byte %d set to %d", Stringify(value, ctx), i, b)
+				}
+			}
+		}
+	})
+
+	t.Run("unsupported version", func(t *testing.T) {
+		_, err := ParseBinaryRepresentation(ctx, []byte{'I', 'X', 'B', BINARY_REPR_VERSION + 1, byte(binNil)}, nil)
+		assert.ErrorIs(t, err, ErrUnsupportedBinaryReprVersion)
+	})
+}
@@ -0,0 +1,271 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/inoxlang/inox/internal/core/patternnames"
+	pprint "github.com/inoxlang/inox/internal/prettyprint"
+)
+
+const (
+	MAX_PATTERN_INFERENCE_DEPTH = 20
+)
+
+var (
+	ErrNoSampleValues                      = errors.New("at least one sample value is required to infer a pattern")
+	ErrMaximumPatternInferenceDepthReached = errors.New("maximum pattern inference depth reached")
+
+	//formats that are recognized by InferPattern, the first format matching all the sample strings is used.
+	INFERRED_STRING_FORMATS = []StringPattern{
+		ULID_STRING_PATTERN,
+		UUIDv4_STRING_PATTERN,
+		NewRegexPattern(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9-]+(?:\.[a-zA-Z0-9-]+)*\.[a-zA-Z]{2,}$`), //email address
+		NewRegexPattern(`^https?://[^\s/?#]+[^\s]*$`),                                          //URL
+		DEFAULT_PATTERN_NAMESPACES[patternnames.DATE_FORMAT_NS].Patterns["rfc3339"].(StringPattern),
+		DEFAULT_PATTERN_NAMESPACES[patternnames.DATE_FORMAT_NS].Patterns["date-only"].(StringPattern),
+	}
+
+	PATTERN_INFERENCE_PRETTY_PRINT_CONFIG = &pprint.PrettyPrintConfig{
+		MaxDepth: MAX_PATTERN_INFERENCE_DEPTH + 1,
+		Indent:   []byte{' ', ' '},
+	}
+)
+
+// InferPattern infers the tightest reasonable pattern matching all the sample values. Samples are typically
+// obtained by converting parsed JSON or YAML data (see ConvertJSONValToInoxVal).
+//   - objects and records: all the properties are inferred, properties missing in some samples are optional.
+//     Since quoted property names cannot be marked as optional in Inox source code, optional properties whose
+//     name is not a valid identifier are left out and the inferred pattern is inexact.
+//   - lists and tuples: the element pattern is inferred from the elements of all the samples.
+//   - integers and floats: ranges spanning from the smallest to the largest sample.
+//   - strings: a pattern for a recognized format (see INFERRED_STRING_FORMATS) or %str.
+//
+// Samples of different kinds (e.g. integers and strings) result in a union pattern.
+func InferPattern(ctx *Context, samples ...Serializable) (Pattern, error) {
+	if len(samples) == 0 {
+		return nil, ErrNoSampleValues
+	}
+	return inferPattern(ctx, samples, 0)
+}
+
+// InferPatternSource infers a pattern from the sample values (see InferPattern) and returns its Inox source code.
+func InferPatternSource(ctx *Context, samples ...Serializable) (string, error) {
+	pattern, err := InferPattern(ctx, samples...)
+	if err != nil {
+		return "", err
+	}
+
+	buf := bytes.NewBuffer(nil)
+	w := bufio.NewWriter(buf)
+
+	pattern.PrettyPrint(ctx, w, PATTERN_INFERENCE_PRETTY_PRINT_CONFIG, 0, 0)
+	if err := w.Flush(); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+type inferredSampleKind int
+
+const (
+	nilSample inferredSampleKind = iota
+	boolSample
+	intSample
+	floatSample
+	stringSample
+	objectSample
+	recordSample
+	listSample
+	tupleSample
+)
+
+func inferPattern(ctx *Context, samples []Serializable, depth int) (Pattern, error) {
+	if depth > MAX_PATTERN_INFERENCE_DEPTH {
+		return nil, ErrMaximumPatternInferenceDepthReached
+	}
+
+	//group the samples by kind, the order of the first appearance of each kind is preserved.
+	var kinds []inferredSampleKind
+	groups := map[inferredSampleKind][]Serializable{}
+
+	for _, sample := range samples {
+		var kind inferredSampleKind
+
+		switch sample.(type) {
+		case NilT:
+			kind = nilSample
+		case Bool:
+			kind = boolSample
+		case Int:
+			kind = intSample
+		case Float:
+			kind = floatSample
+		case String:
+			kind = stringSample
+		case *Object:
+			kind = objectSample
+		case *Record:
+			kind = recordSample
+		case *List:
+			kind = listSample
+		case *Tuple:
+			kind = tupleSample
+		default:
+			return nil, fmt.Errorf("cannot infer a pattern for a value of type %T", sample)
+		}
+
+		if _, ok := groups[kind]; !ok {
+			kinds = append(kinds, kind)
+		}
+		groups[kind] = append(groups[kind], sample)
+	}
+
+	var cases []Pattern
+
+	for _, kind := range kinds {
+		pattern, err := inferPatternFromSameKindSamples(ctx, kind, groups[kind], depth)
+		if err != nil {
+			return nil, err
+		}
+		cases = append(cases, pattern)
+	}
+
+	if len(cases) == 1 {
+		return cases[0], nil
+	}
+	return NewUnionPattern(cases, nil), nil
+}
+
+func inferPatternFromSameKindSamples(ctx *Context, kind inferredSampleKind, samples []Serializable, depth int) (Pattern, error) {
+	switch kind {
+	case nilSample:
+		return NIL_PATTERN, nil
+	case boolSample:
+		return BOOL_PATTERN, nil
+	case intSample:
+		lowest, highest := int64(math.MaxInt64), int64(math.MinInt64)
+		for _, sample := range samples {
+			n := int64(sample.(Int))
+			lowest, highest = min(lowest, n), max(highest, n)
+		}
+		return NewIncludedEndIntRangePattern(lowest, highest, -1), nil
+	case floatSample:
+		lowest, highest := math.Inf(1), math.Inf(-1)
+		for _, sample := range samples {
+			f := float64(sample.(Float))
+			if math.IsNaN(f) || math.IsInf(f, 0) {
+				return FLOAT_PATTERN, nil
+			}
+			lowest, highest = min(lowest, f), max(highest, f)
+		}
+		return NewFloatRangePattern(NewIncludedEndFloatRange(lowest, highest), -1), nil
+	case stringSample:
+	formats:
+		for _, format := range INFERRED_STRING_FORMATS {
+			for _, sample := range samples {
+				if !format.Test(ctx, sample) {
+					continue formats
+				}
+			}
+			return format, nil
+		}
+		return STR_PATTERN, nil
+	case objectSample, recordSample:
+		entries, inexact, err := inferPropertyPatterns(ctx, samples, depth)
+		if err != nil {
+			return nil, err
+		}
+		if kind == recordSample {
+			recordEntries := make([]RecordPatternEntry, len(entries))
+			for i, entry := range entries {
+				recordEntries[i] = RecordPatternEntry{Name: entry.Name, Pattern: entry.Pattern, IsOptional: entry.IsOptional}
+			}
+			if inexact {
+				return NewInexactRecordPattern(recordEntries), nil
+			}
+			return NewExactRecordPattern(recordEntries), nil
+		}
+		if inexact {
+			return NewInexactObjectPattern(entries), nil
+		}
+		return NewExactObjectPattern(entries), nil
+	case listSample, tupleSample:
+		var elements []Serializable
+		for _, sample := range samples {
+			switch sequence := sample.(type) {
+			case *List:
+				elements = append(elements, sequence.GetOrBuildElements(ctx)...)
+			case *Tuple:
+				elements = append(elements, sequence.GetOrBuildElements(ctx)...)
+			}
+		}
+
+		//only empty sequences are matched if there are no elements.
+		var elementPattern Pattern = NEVER_PATTERN
+
+		if len(elements) > 0 {
+			pattern, err := inferPattern(ctx, elements, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			elementPattern = pattern
+		}
+
+		if kind == tupleSample {
+			return NewTuplePatternOf(elementPattern), nil
+		}
+		return NewListPatternOf(elementPattern), nil
+	}
+
+	panic(ErrUnreachable)
+}
+
+// inferPropertyPatterns infers the patterns of the properties of objects or records, a property is optional if
+// it is missing in at least one sample. Optional properties whose name is not a valid identifier are left out,
+// in that case inexact is true.
+func inferPropertyPatterns(ctx *Context, samples []Serializable, depth int) (entries []ObjectPatternEntry, inexact bool, _ error) {
+	var propertyNames []string
+	propertyValues := map[string][]Serializable{}
+
+	for _, sample := range samples {
+		iprops := sample.(IProps)
+
+		for _, name := range iprops.PropertyNames(ctx) {
+			if _, ok := propertyValues[name]; !ok {
+				propertyNames = append(propertyNames, name)
+			}
+			propertyValues[name] = append(propertyValues[name], iprops.Prop(ctx, name).(Serializable))
+		}
+	}
+
+	slices.Sort(propertyNames)
+
+	for _, name := range propertyNames {
+		values := propertyValues[name]
+		isOptional := len(values) < len(samples)
+
+		if isOptional && PropertyName(name).Validate() != nil {
+			inexact = true
+			continue
+		}
+
+		pattern, err := inferPattern(ctx, values, depth+1)
+		if err != nil {
+			return nil, false, err
+		}
+
+		entries = append(entries, ObjectPatternEntry{
+			Name:       name,
+			Pattern:    pattern,
+			IsOptional: isOptional,
+		})
+	}
+
+	return entries, inexact, nil
+}
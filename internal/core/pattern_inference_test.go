@@ -0,0 +1,257 @@
+package core
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInferPattern(t *testing.T) {
+
+	fromJSON := func(t *testing.T, s string) Serializable {
+		decoder := json.NewDecoder(strings.NewReader(s))
+		decoder.UseNumber()
+
+		var v any
+		if !assert.NoError(t, decoder.Decode(&v)) {
+			t.FailNow()
+		}
+		return ConvertJSONValToInoxVal(v, false)
+	}
+
+	t.Run("no samples", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		_, err := InferPattern(ctx)
+		assert.ErrorIs(t, err, ErrNoSampleValues)
+	})
+
+	t.Run("integers", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		pattern, err := InferPattern(ctx, Int(3), Int(-1), Int(10))
+		if assert.NoError(t, err) {
+			assert.Equal(t, NewIncludedEndIntRangePattern(-1, 10, -1), pattern)
+		}
+	})
+
+	t.Run("floats", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		pattern, err := InferPattern(ctx, Float(0.5), Float(2))
+		if assert.NoError(t, err) {
+			assert.Equal(t, NewFloatRangePattern(NewIncludedEndFloatRange(0.5, 2), -1), pattern)
+		}
+	})
+
+	t.Run("string formats", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		testCases := []struct {
+			name     string
+			samples  []Serializable
+			expected string
+		}{
+			{"ULID", []Serializable{String("01HNZ4J8Q8V2B6DNE2BV1T6ZQ5")}, "%string-pattern(%ulid)"},
+			{"UUIDv4", []Serializable{String("9b2f4a6e-2f4c-4d1a-8f3e-6c1d2e3f4a5b")}, "%string-pattern(%uuidv4)"},
+			{"email addresses", []Serializable{String("foo@example.com"), String("a.b+c@mail.example.org")}, "%`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9-]+(?:\\.[a-zA-Z0-9-]+)*\\.[a-zA-Z]{2,}$`"},
+			{"URLs", []Serializable{String("https://example.com/a?b=1"), String("http://localhost:8080")}, "%`^https?://[^\\s/?#]+[^\\s]*$`"},
+			{"datetimes", []Serializable{String("2024-01-02T15:04:05Z"), String("2024-01-02T15:04:05+02:00")}, "%date-format.rfc3339"},
+			{"dates", []Serializable{String("2024-01-02"), String("1999-12-31")}, "%date-format.date-only"},
+			{"mixed formats", []Serializable{String("2024-01-02"), String("foo@example.com")}, "%str"},
+		}
+
+		for _, testCase := range testCases {
+			t.Run(testCase.name, func(t *testing.T) {
+				pattern, err := InferPattern(ctx, testCase.samples...)
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				for _, sample := range testCase.samples {
+					assert.True(t, pattern.Test(ctx, sample))
+				}
+
+				source, err := InferPatternSource(ctx, testCase.samples...)
+				if !assert.NoError(t, err) {
+					return
+				}
+				assert.Equal(t, testCase.expected, source)
+
+				//the source should evaluate to a pattern matching the samples.
+				state := NewTreeWalkState(NewContext(ContextConfig{}))
+				for name, namedPattern := range DEFAULT_NAMED_PATTERNS {
+					state.Global.Ctx.AddNamedPattern(name, namedPattern)
+				}
+				for name, namespace := range DEFAULT_PATTERN_NAMESPACES {
+					state.Global.Ctx.AddPatternNamespace(name, namespace)
+				}
+
+				evaluated, err := TreeWalkEval(assertParseExpression(t, source), state)
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				for _, sample := range testCase.samples {
+					assert.True(t, evaluated.(Pattern).Test(ctx, sample))
+				}
+			})
+		}
+	})
+
+	t.Run("objects with optional properties", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		samples := []Serializable{
+			fromJSON(t, `{"id": 1, "name": "a", "tags": ["x"]}`),
+			fromJSON(t, `{"id": 2, "name": "b", "score": 1.5, "tags": []}`),
+		}
+
+		pattern, err := InferPattern(ctx, samples...)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		expected := NewExactObjectPattern([]ObjectPatternEntry{
+			{Name: "id", Pattern: NewIncludedEndIntRangePattern(1, 2, -1)},
+			{Name: "name", Pattern: STR_PATTERN},
+			{Name: "score", Pattern: NewFloatRangePattern(NewIncludedEndFloatRange(1.5, 1.5), -1), IsOptional: true},
+			{Name: "tags", Pattern: NewListPatternOf(STR_PATTERN)},
+		})
+
+		assert.Equal(t, expected, pattern)
+
+		for _, sample := range samples {
+			assert.True(t, pattern.Test(ctx, sample))
+		}
+	})
+
+	t.Run("optional properties with a quoted name", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		samples := []Serializable{
+			fromJSON(t, `{"a b": 1, "c d": true}`),
+			fromJSON(t, `{"c d": false}`),
+		}
+
+		pattern, err := InferPattern(ctx, samples...)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		expected := NewInexactObjectPattern([]ObjectPatternEntry{{Name: "c d", Pattern: BOOL_PATTERN}})
+		assert.Equal(t, expected, pattern)
+
+		for _, sample := range samples {
+			assert.True(t, pattern.Test(ctx, sample))
+		}
+
+		source, err := InferPatternSource(ctx, samples...)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "%{\n\r  \"c d\": %bool, \n\r}", source)
+			assertParseExpression(t, source)
+		}
+	})
+
+	t.Run("empty lists", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		sample := fromJSON(t, `{"tags": []}`)
+
+		pattern, err := InferPattern(ctx, sample)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.True(t, pattern.Test(ctx, sample))
+		assert.False(t, pattern.Test(ctx, fromJSON(t, `{"tags": [1]}`)))
+
+		source, err := InferPatternSource(ctx, sample)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "%{\n\r  \"tags\": %[]%never, \n\r  otherprops no\n\r}", source)
+		}
+	})
+
+	t.Run("heterogeneous list", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		sample := NewWrappedValueList(Int(1), String("a"), Nil, Int(3))
+
+		pattern, err := InferPattern(ctx, sample)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.True(t, pattern.Test(ctx, sample))
+
+		source, err := InferPatternSource(ctx, sample)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "%[](%| %int(1..3) | %str | %nil)", source)
+		}
+	})
+
+	t.Run("immutable values", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		sample := NewRecordFromMap(ValMap{"a": NewTupleVariadic(True)})
+
+		pattern, err := InferPattern(ctx, sample)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		expected := NewExactRecordPattern([]RecordPatternEntry{{Name: "a", Pattern: NewTuplePatternOf(BOOL_PATTERN)}})
+		assert.Equal(t, expected, pattern)
+	})
+
+	t.Run("unsupported value", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		_, err := InferPattern(ctx, Path("/a"))
+		assert.Error(t, err)
+	})
+
+	t.Run("source", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		samples := []Serializable{
+			fromJSON(t, `{"email": "foo@example.com", "age": 30}`),
+			fromJSON(t, `{"email": "bar@example.com"}`),
+		}
+
+		source, err := InferPatternSource(ctx, samples...)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.Equal(t, "%{\n\r  age?: %int(30..30), \n\r  \"email\": "+
+			"%`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9-]+(?:\\.[a-zA-Z0-9-]+)*\\.[a-zA-Z]{2,}$`, \n\r  otherprops no\n\r}", source)
+
+		//the source should evaluate to the inferred pattern.
+		node := assertParseExpression(t, source)
+
+		state := NewTreeWalkState(NewContext(ContextConfig{}))
+		state.Global.Ctx.AddNamedPattern("int", INT_PATTERN)
+
+		pattern, err := TreeWalkEval(node, state)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		inferred, _ := InferPattern(ctx, samples...)
+		assert.True(t, inferred.Equal(ctx, pattern, map[uintptr]uintptr{}, 0))
+	})
+}
@@ -1,12 +1,17 @@
 package core
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"math"
 	"os"
 	"slices"
+	"strings"
+
+	yamlLex "github.com/goccy/go-yaml/lexer"
+	yamlParse "github.com/goccy/go-yaml/parser"
 
 	"github.com/inoxlang/inox/internal/ast"
 	"github.com/inoxlang/inox/internal/sourcecode"
@@ -25,7 +30,9 @@ const (
 )
 
 var (
-	ErrFileSizeExceedSpecifiedLimit = errors.New("file's size exceeds the specified limit")
+	ErrFileSizeExceedSpecifiedLimit    = errors.New("file's size exceeds the specified limit")
+	ErrPreinitFileNotMatchingPattern   = errors.New("the content of the preinit file does not match the pattern")
+	ErrUnexpectedContentAfterJSONValue = errors.New("unexpected content after the JSON value")
 )
 
 type PreinitArgs struct {
@@ -41,6 +48,9 @@ type PreinitArgs struct {
 	IgnoreUnknownSections bool
 	IgnoreConstDeclErrors bool
 
+	//If not nil the manifest should comply with the policy, a *ManifestPolicyViolationError is returned otherwise.
+	ManifestPolicy *ManifestPolicy
+
 	//used if .RunningState is nil
 	AdditionalGlobals map[string]Value
 }
@@ -57,6 +67,7 @@ type PreinitArgs struct {
 // 9)  evaluate the preinit block.
 // 10) evaluate the manifest's object literal.
 // 11) create the manifest.
+// 12) check the manifest against .ManifestPolicy (if set).
 //
 // If an error occurs at any step, the function returns.
 func (m *Module) PreInit(preinitArgs PreinitArgs) (_ *Manifest, usedRunningState *TreeWalkState, _ []*staticcheck.Error, preinitErr error) {
@@ -266,15 +277,48 @@ func (m *Module) PreInit(preinitArgs PreinitArgs) (_ *Manifest, usedRunningState
 					return fmt.Errorf("property .%s in description of preinit file %s should be an absolute path", inoxconsts.MANIFEST_PREINIT_FILE__PATH_PROP_NAME, k)
 				}
 
-				switch patt := pattern.(type) {
-				case StringPattern:
-				case *SecretPattern:
-				case *TypePattern:
-					if patt != STR_PATTERN {
+				format := ""
+				if slices.Contains(propNames, inoxconsts.MANIFEST_PREINIT_FILE__FORMAT_PROP_NAME) {
+					s, ok := desc.Prop(ctx, inoxconsts.MANIFEST_PREINIT_FILE__FORMAT_PROP_NAME).(StringLike)
+					if !ok {
+						return fmt.Errorf("property .%s in description of preinit file %s is not a string", inoxconsts.MANIFEST_PREINIT_FILE__FORMAT_PROP_NAME, k)
+					}
+					format = s.GetOrBuildString()
+
+					if !slices.Contains(inoxconsts.PREINIT_FILE_FORMATS, format) {
+						return fmt.Errorf("unsupported format '%s' for preinit file %s", format, k)
+					}
+				}
+
+				//The pattern of a file with a format is tested against the parsed value, so any pattern is allowed.
+				if format == "" {
+					switch patt := pattern.(type) {
+					case StringPattern:
+					case *SecretPattern:
+					case *TypePattern:
+						if patt != STR_PATTERN {
+							return fmt.Errorf("invalid pattern type %T for preinit file '%s'", patt, k)
+						}
+					default:
 						return fmt.Errorf("invalid pattern type %T for preinit file '%s'", patt, k)
 					}
-				default:
-					return fmt.Errorf("invalid pattern type %T for preinit file '%s'", patt, k)
+				}
+
+				isOpenAPIDocument := false
+				if slices.Contains(propNames, inoxconsts.MANIFEST_PREINIT_FILE__OPENAPI_PROP_NAME) {
+					b, ok := desc.Prop(ctx, inoxconsts.MANIFEST_PREINIT_FILE__OPENAPI_PROP_NAME).(Bool)
+					if !ok {
+						return fmt.Errorf("property .%s in description of preinit file %s is not a boolean", inoxconsts.MANIFEST_PREINIT_FILE__OPENAPI_PROP_NAME, k)
+					}
+					isOpenAPIDocument = bool(b)
+
+					if isOpenAPIDocument && pattern != STR_PATTERN {
+						return fmt.Errorf("the pattern of preinit file %s should be %%str because it is an OpenAPI document", k)
+					}
+
+					if isOpenAPIDocument && format != "" {
+						return fmt.Errorf("preinit file %s cannot both be an OpenAPI document and have a format", k)
+					}
 				}
 
 				preinitFiles = append(preinitFiles, &PreinitFile{
@@ -285,6 +329,8 @@ func (m *Module) PreInit(preinitArgs PreinitArgs) (_ *Manifest, usedRunningState
 						Kind_:  permbase.Read,
 						Entity: path,
 					},
+					IsOpenAPIDocument: isOpenAPIDocument,
+					Format:            format,
 				})
 
 				return nil
@@ -306,18 +352,30 @@ func (m *Module) PreInit(preinitArgs PreinitArgs) (_ *Manifest, usedRunningState
 					continue
 				}
 
-				switch patt := file.Pattern.(type) {
-				case StringPattern:
-					file.Parsed, file.ReadParseError = patt.Parse(ctx, string(content))
-				case *SecretPattern:
-					file.Parsed, file.ReadParseError = patt.NewSecret(ctx, string(content))
-				case *TypePattern:
-					if patt != STR_PATTERN {
+				if file.Format != "" {
+					file.Parsed, file.ReadParseError = parseFormattedPreinitFile(ctx, file.Format, string(content), file.Pattern)
+				} else {
+					switch patt := file.Pattern.(type) {
+					case StringPattern:
+						file.Parsed, file.ReadParseError = patt.Parse(ctx, string(content))
+					case *SecretPattern:
+						file.Parsed, file.ReadParseError = patt.NewSecret(ctx, string(content))
+					case *TypePattern:
+						if patt != STR_PATTERN {
+							panic(ErrUnreachable)
+						}
+						file.Parsed = String(content)
+					default:
 						panic(ErrUnreachable)
 					}
-					file.Parsed = String(content)
-				default:
-					panic(ErrUnreachable)
+				}
+
+				if file.ReadParseError == nil && file.IsOpenAPIDocument {
+					//define the pattern namespace of the document.
+					file.OpenAPIDocument, file.ReadParseError = ParseOpenAPIDocument(content)
+					if file.ReadParseError == nil {
+						ctx.AddPatternNamespace(file.Name, file.OpenAPIDocument.PatternNamespace())
+					}
 				}
 
 				if file.ReadParseError != nil {
@@ -363,9 +421,62 @@ func (m *Module) PreInit(preinitArgs PreinitArgs) (_ *Manifest, usedRunningState
 		initialWorkingDirectory: initialWorkingDirectory,
 	})
 
+	if err == nil && preinitArgs.ManifestPolicy != nil {
+		err = manifest.checkPolicy(preinitArgs.ManifestPolicy, m)
+	}
+
 	return manifest, state, nil, err
 }
 
+// parseFormattedPreinitFile parses the content of a preinit file having one of the inoxconsts.PREINIT_FILE_FORMATS,
+// the result is immutable and should match pattern. YAML files should contain a single document.
+func parseFormattedPreinitFile(ctx *Context, format string, content string, pattern Pattern) (Serializable, error) {
+	var (
+		result Serializable
+		err    error
+	)
+
+	switch format {
+	case inoxconsts.PREINIT_FILE_JSON_FORMAT:
+		decoder := json.NewDecoder(strings.NewReader(content))
+		decoder.UseNumber()
+
+		var jsonVal any
+		if err := decoder.Decode(&jsonVal); err != nil {
+			return nil, err
+		}
+		if _, err := decoder.Token(); err != io.EOF {
+			return nil, ErrUnexpectedContentAfterJSONValue
+		}
+		result, err = TryConvertJSONValToInoxVal(jsonVal, true)
+	case inoxconsts.PREINIT_FILE_YAML_FORMAT:
+		file, err := yamlParse.Parse(yamlLex.Tokenize(content), 0)
+		if err != nil {
+			return nil, err
+		}
+		if len(file.Docs) != 1 {
+			return nil, fmt.Errorf("the YAML file should contain a single document")
+		}
+		result = ConvertYamlNodeToInoxVal(ctx, file.Docs[0], true)
+	case inoxconsts.PREINIT_FILE_TOML_FORMAT:
+		result, err = ParseTOML(ctx, content, true)
+	case inoxconsts.PREINIT_FILE_INI_FORMAT:
+		//INI values are coerced using the pattern.
+		result, err = ParseINI(ctx, content, pattern, true)
+	default:
+		return nil, fmt.Errorf("unsupported format '%s'", format)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !pattern.Test(ctx, result) {
+		return nil, ErrPreinitFileNotMatchingPattern
+	}
+	return result, nil
+}
+
 // ReadFileInFS reads up to maxSize bytes from a file in the given filesystem.
 // if maxSize is <=0 the max size is set to 100MB.
 func ReadFileInFS(name string, maxSize int32) ([]byte, error) {
@@ -99,6 +99,7 @@ func TestPreInit(t *testing.T) {
 		teardown            func()
 		parentModule        string
 		parentModuleAbsPath string
+		manifestPolicy      *ManifestPolicy
 
 		//output
 		expectedModuleKind           *ModuleKind
@@ -108,6 +109,7 @@ func TestPreInit(t *testing.T) {
 		expectedResolutions          map[Host]Value
 		expectedPreinitFileConfigs   PreinitFiles
 		expectedAutoInvocationConfig *AutoInvocationConfig
+		expectedRetryPolicy          *RetryPolicy
 
 		//errors
 		error                     bool
@@ -469,6 +471,115 @@ func TestPreInit(t *testing.T) {
 				}`,
 			error: true,
 		},
+		{
+			name: "retry policy",
+			module: `manifest {
+					retry-policy: #{
+						max-attempts: 3
+						attempt-timeout: 1s
+					}
+				}`,
+			expectedPermissions: []Permission{},
+			expectedLimits:      []Limit{minLimitA, minLimitB, threadLimit},
+			expectedRetryPolicy: &RetryPolicy{MaxAttempts: 3, AttemptTimeout: time.Second},
+		},
+		{
+			name: "retry policy: invalid policy",
+			module: `manifest {
+					retry-policy: #{
+						max-attempts: -1
+					}
+				}`,
+			error:               true,
+			errorIs:             ErrInvalidRetryPolicy,
+			expectedPermissions: []Permission{},
+			expectedLimits:      []Limit{minLimitA, minLimitB, threadLimit},
+		},
+		{
+			name: "retry policy: not a record",
+			module: `manifest {
+					retry-policy: {
+						max-attempts: 3
+					}
+				}`,
+			error:                     true,
+			expectedPermissions:       []Permission{},
+			expectedLimits:            []Limit{minLimitA, minLimitB, threadLimit},
+			expectedStaticCheckErrors: []string{text.RETRY_POLICY_SECTION_SHOULD_BE_A_RECORD},
+		},
+		{
+			name: "limits: compliant with the policy",
+			module: `manifest {
+					limits: {
+						"a": 100ms
+					}
+				}`,
+			manifestPolicy: NewManifestPolicy(&ManifestPolicyRules{
+				MaxLimits:      []Limit{{Name: "a", Kind: TotalLimit, Value: int64(time.Second)}},
+				RequiredLimits: []string{"a"},
+			}, nil),
+			expectedPermissions: []Permission{},
+			expectedLimits: []Limit{
+				{Name: "a", Kind: TotalLimit, Value: int64(100 * time.Millisecond)},
+				minLimitB,
+				threadLimit,
+			},
+		},
+		{
+			name: "limits: value greater than the maximum allowed by the policy",
+			module: `manifest {
+					limits: {
+						"a": 100ms
+					}
+				}`,
+			manifestPolicy: NewManifestPolicy(&ManifestPolicyRules{
+				MaxLimits: []Limit{{Name: "a", Kind: TotalLimit, Value: int64(10 * time.Millisecond)}},
+			}, nil),
+			error:               true,
+			errorContains:       "limits: the value of the limit 'a'",
+			expectedPermissions: []Permission{},
+			expectedLimits: []Limit{
+				{Name: "a", Kind: TotalLimit, Value: int64(100 * time.Millisecond)},
+				minLimitB,
+				threadLimit,
+			},
+		},
+		{
+			name: "limits: missing limit required by the policy",
+			module: `manifest {
+					limits: {
+						"a": 100ms
+					}
+				}`,
+			manifestPolicy: NewManifestPolicy(&ManifestPolicyRules{
+				RequiredLimits: []string{"b"},
+			}, nil),
+			error:               true,
+			errorContains:       "the limit 'b' is required by the policy but is not specified",
+			expectedPermissions: []Permission{},
+			expectedLimits: []Limit{
+				{Name: "a", Kind: TotalLimit, Value: int64(100 * time.Millisecond)},
+				minLimitB,
+				threadLimit,
+			},
+		},
+		{
+			name: "limits: the policy has no rules for the module kind",
+			module: `manifest {
+					limits: {
+						"a": 100ms
+					}
+				}`,
+			manifestPolicy: NewManifestPolicy(nil, map[ModuleKind]*ManifestPolicyRules{
+				ApplicationModule: {RequiredLimits: []string{"b"}},
+			}),
+			expectedPermissions: []Permission{},
+			expectedLimits: []Limit{
+				{Name: "a", Kind: TotalLimit, Value: int64(100 * time.Millisecond)},
+				minLimitB,
+				threadLimit,
+			},
+		},
 		{
 			name: "host_with_unsupported_scheme",
 			module: `manifest {
@@ -1276,6 +1387,7 @@ func TestPreInit(t *testing.T) {
 				ParentState:           parentState,
 				AddDefaultPermissions: true,
 				AdditionalGlobals:     testCase.additionalGlobals,
+				ManifestPolicy:        testCase.manifestPolicy,
 			})
 
 			//PreInit should be fast
@@ -1339,6 +1451,7 @@ func TestPreInit(t *testing.T) {
 				assert.ElementsMatch(t, testCase.expectedLimits, manifest.Limits)
 				assert.EqualValues(t, testCase.expectedResolutions, manifest.HostDefinitions)
 				assert.EqualValues(t, testCase.expectedAutoInvocationConfig, manifest.AutoInvocation)
+				assert.Equal(t, testCase.expectedRetryPolicy, manifest.RetryPolicy)
 
 				if testCase.expectedPreinitFileErrors == nil {
 					for _, preinitFile := range manifest.PreinitFiles {
@@ -1362,3 +1475,152 @@ func TestPreInit(t *testing.T) {
 	}
 
 }
+
+func TestFormattedPreinitFiles(t *testing.T) {
+
+	//preinit pre-initializes a module having a single preinit file (config), the pattern %config is defined
+	//by the preinit block.
+	preinit := func(t *testing.T, filename, content, configPattern, fileDescription string) (*Manifest, *TreeWalkState, error) {
+		filePath := filepath.Join(t.TempDir(), filename)
+		if !assert.NoError(t, os.WriteFile(filePath, []byte(content), 0600)) {
+			t.FailNow()
+		}
+
+		code := `preinit {
+			pattern config = ` + configPattern + `
+		}
+		manifest {
+			preinit-files: {
+				config: {
+					path: ` + filePath + `
+					` + fileDescription + `
+				}
+			}
+		}`
+
+		chunk := utils.Must(parse.ParseChunk(code, "<chunk>"))
+		modulePath := filepath.Join(t.TempDir(), "main.ix")
+
+		mod := WrapLowerModule(&inoxmod.Module{
+			MainChunk: parse.NewParsedChunkSource(chunk, sourcecode.File{
+				NameString:  modulePath,
+				Resource:    modulePath,
+				ResourceDir: filepath.Dir(modulePath),
+				CodeString:  code,
+			}),
+			TopLevelNode:          chunk,
+			ManifestTemplate:      chunk.Manifest,
+			InclusionStatementMap: map[*ast.InclusionImportStatement]*IncludedChunk{},
+			IncludedChunkMap:      map[string]*IncludedChunk{},
+		})
+
+		manifest, state, _, err := mod.PreInit(PreinitArgs{
+			GlobalConsts:     chunk.GlobalConstantDeclarations,
+			PreinitStatement: chunk.Preinit,
+		})
+		if state != nil {
+			t.Cleanup(func() {
+				state.Global.Ctx.CancelGracefully()
+			})
+		}
+		return manifest, state, err
+	}
+
+	t.Run("TOML", func(t *testing.T) {
+		manifest, state, err := preinit(t, "config.toml", "port = 8080\n[db]\nhost = 'localhost'", `#{port: int, db: #{host: str}}`, `
+			pattern: %config
+			format: "toml"
+		`)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		file := manifest.PreinitFiles[0]
+		assert.Equal(t, "toml", file.Format)
+
+		record, ok := file.Parsed.(*Record)
+		if assert.True(t, ok) {
+			ctx := state.Global.Ctx
+			assert.Equal(t, Int(8080), record.Prop(ctx, "port"))
+			assert.Equal(t, String("localhost"), record.Prop(ctx, "db").(*Record).Prop(ctx, "host"))
+		}
+	})
+
+	t.Run("INI values should be coerced", func(t *testing.T) {
+		manifest, state, err := preinit(t, "config.ini", "port = 8080\n[db]\ntimeout = 10s", `#{port: int, db: #{timeout: duration}}`, `
+			pattern: %config
+			format: "ini"
+		`)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		record := manifest.PreinitFiles[0].Parsed.(*Record)
+		ctx := state.Global.Ctx
+		assert.Equal(t, Int(8080), record.Prop(ctx, "port"))
+		assert.Equal(t, Duration(10*time.Second), record.Prop(ctx, "db").(*Record).Prop(ctx, "timeout"))
+	})
+
+	t.Run("YAML", func(t *testing.T) {
+		manifest, _, err := preinit(t, "config.yaml", "hosts: [a, b]", `#{hosts: #[]str}`, `
+			pattern: %config
+			format: "yaml"
+		`)
+		if assert.NoError(t, err) {
+			assert.IsType(t, (*Record)(nil), manifest.PreinitFiles[0].Parsed)
+		}
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		manifest, _, err := preinit(t, "config.json", `{"port": 8080}`, `#{port: int}`, `
+			pattern: %config
+			format: "json"
+		`)
+		if assert.NoError(t, err) {
+			assert.IsType(t, (*Record)(nil), manifest.PreinitFiles[0].Parsed)
+		}
+	})
+
+	t.Run("JSON with a number having an exponent", func(t *testing.T) {
+		manifest, state, err := preinit(t, "config.json", `{"a": 1e3}`, `#{a: float}`, `
+			pattern: %config
+			format: "json"
+		`)
+		if assert.NoError(t, err) {
+			record := manifest.PreinitFiles[0].Parsed.(*Record)
+			assert.Equal(t, Float(1000), record.Prop(state.Global.Ctx, "a"))
+		}
+	})
+
+	t.Run("JSON with an out of range integer", func(t *testing.T) {
+		_, _, err := preinit(t, "config.json", `{"a": 100000000000000000000}`, `#{a: int}`, `
+			pattern: %config
+			format: "json"
+		`)
+		assert.ErrorContains(t, err, "failed to parse integer")
+	})
+
+	t.Run("JSON with content after the value", func(t *testing.T) {
+		_, _, err := preinit(t, "config.json", `{"a": 1} {"a": 2}`, `#{a: int}`, `
+			pattern: %config
+			format: "json"
+		`)
+		assert.ErrorIs(t, err, ErrUnexpectedContentAfterJSONValue)
+	})
+
+	t.Run("content not matching the pattern", func(t *testing.T) {
+		_, _, err := preinit(t, "config.toml", "port = 'a'", `#{port: int}`, `
+			pattern: %config
+			format: "toml"
+		`)
+		assert.ErrorIs(t, err, ErrPreinitFileNotMatchingPattern)
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		_, _, err := preinit(t, "config.xml", "<a></a>", `str`, `
+			pattern: %config
+			format: "xml"
+		`)
+		assert.ErrorContains(t, err, "unsupported format")
+	})
+}
@@ -1445,11 +1445,40 @@ func (pattern *FunctionPattern) PrettyPrint(ctx *Context, w *bufio.Writer, confi
 }
 
 func (patt *RegexPattern) PrettyPrint(ctx *Context, w *bufio.Writer, config *pprint.PrettyPrintConfig, depth int, parentIndentCount int) {
-	InspectPrint(w, patt)
+	regex := patt.Regex()
+	if strings.Contains(regex, "`") {
+		InspectPrint(w, patt)
+		return
+	}
+
+	if config.Colorize {
+		utils.Must(w.Write(config.Colors.PatternLiteral))
+	}
+
+	utils.Must(w.Write(utils.StringAsBytes("%`" + regex + "`")))
+
+	if config.Colorize {
+		utils.Must(w.Write(ANSI_RESET_SEQUENCE))
+	}
 }
 
 func (patt *UnionPattern) PrettyPrint(ctx *Context, w *bufio.Writer, config *pprint.PrettyPrintConfig, depth int, parentIndentCount int) {
-	InspectPrint(w, patt)
+	if depth > config.MaxDepth {
+		utils.Must(w.Write(utils.StringAsBytes("(%| (...))")))
+		return
+	}
+
+	utils.Must(w.Write(utils.StringAsBytes("(%|")))
+
+	for i, case_ := range patt.cases {
+		if i > 0 {
+			utils.Must(w.Write(utils.StringAsBytes(" |")))
+		}
+		utils.PanicIfErr(w.WriteByte(' '))
+		case_.PrettyPrint(ctx, w, config, depth+1, parentIndentCount)
+	}
+
+	utils.PanicIfErr(w.WriteByte(')'))
 }
 
 func (patt *IntersectionPattern) PrettyPrint(ctx *Context, w *bufio.Writer, config *pprint.PrettyPrintConfig, depth int, parentIndentCount int) {
@@ -1473,11 +1502,27 @@ func (patt *RuneRangeStringPattern) PrettyPrint(ctx *Context, w *bufio.Writer, c
 }
 
 func (patt *IntRangePattern) PrettyPrint(ctx *Context, w *bufio.Writer, config *pprint.PrettyPrintConfig, depth int, parentIndentCount int) {
-	InspectPrint(w, patt)
+	if patt.HasMultipleOfConstraint() || patt.intRange.unknownStart {
+		InspectPrint(w, patt)
+		return
+	}
+
+	INT_PATTERN.PrettyPrint(ctx, w, config, depth, parentIndentCount)
+	utils.PanicIfErr(w.WriteByte('('))
+	patt.intRange.PrettyPrint(ctx, w, config, depth, parentIndentCount)
+	utils.PanicIfErr(w.WriteByte(')'))
 }
 
 func (patt *FloatRangePattern) PrettyPrint(ctx *Context, w *bufio.Writer, config *pprint.PrettyPrintConfig, depth int, parentIndentCount int) {
-	InspectPrint(w, patt)
+	if patt.multipleOf > 0 || patt.floatRange.unknownStart {
+		InspectPrint(w, patt)
+		return
+	}
+
+	FLOAT_PATTERN.PrettyPrint(ctx, w, config, depth, parentIndentCount)
+	utils.PanicIfErr(w.WriteByte('('))
+	patt.floatRange.PrettyPrint(ctx, w, config, depth, parentIndentCount)
+	utils.PanicIfErr(w.WriteByte(')'))
 }
 
 func (patt *DynamicStringPatternElement) PrettyPrint(ctx *Context, w *bufio.Writer, config *pprint.PrettyPrintConfig, depth int, parentIndentCount int) {
@@ -1503,7 +1548,7 @@ func (patt ObjectPattern) PrettyPrint(ctx *Context, w *bufio.Writer, config *ppr
 
 	utils.Must(w.Write([]byte{'%', '{'}))
 
-	for i, entry := range patt.entries {
+	for _, entry := range patt.entries {
 
 		if !config.Compact {
 			utils.Must(w.Write(LF_CR))
@@ -1514,24 +1559,31 @@ func (patt ObjectPattern) PrettyPrint(ctx *Context, w *bufio.Writer, config *ppr
 			utils.Must(w.Write(config.Colors.IdentifierLiteral))
 		}
 
-		utils.Must(w.Write(utils.Must(utils.MarshalJsonNoHTMLEspace(entry.Name))))
+		//quoted keys cannot be marked as optional.
+		isOptional := entry.IsOptional && PropertyName(entry.Name).Validate() == nil
+
+		if isOptional {
+			utils.Must(w.Write(utils.StringAsBytes(entry.Name)))
+		} else {
+			utils.Must(w.Write(utils.Must(utils.MarshalJsonNoHTMLEspace(entry.Name))))
+		}
 
 		if config.Colorize {
 			utils.Must(w.Write(ANSI_RESET_SEQUENCE))
 		}
 
+		if isOptional {
+			utils.PanicIfErr(w.WriteByte('?'))
+		}
+
 		//colon
 		utils.Must(w.Write(COLON_SPACE))
 
 		//write entry pattern
 		entry.Pattern.PrettyPrint(ctx, w, config, depth+1, indentCount)
 
-		//comma & indent
-		isLastEntry := i == len(patt.entries)-1
-
-		if !isLastEntry || patt.inexact {
-			utils.Must(w.Write(COMMA_SPACE))
-		}
+		//comma, exact patterns end with 'otherprops no'.
+		utils.Must(w.Write(COMMA_SPACE))
 	}
 
 	// if patt.inexact {
@@ -1543,6 +1595,14 @@ func (patt ObjectPattern) PrettyPrint(ctx *Context, w *bufio.Writer, config *ppr
 	// 	utils.Must(w.Write(THREE_DOTS))
 	// }
 
+	if !patt.inexact {
+		if !config.Compact && len(patt.entries) > 0 {
+			utils.Must(w.Write(LF_CR))
+			utils.Must(w.Write(indent))
+		}
+		utils.Must(w.Write(utils.StringAsBytes("otherprops no")))
+	}
+
 	if !config.Compact && len(patt.entries) > 0 {
 		utils.Must(w.Write(LF_CR))
 	}
@@ -1575,12 +1635,23 @@ func (patt *RecordPattern) PrettyPrint(ctx *Context, w *bufio.Writer, config *pp
 			utils.Must(w.Write(config.Colors.IdentifierLiteral))
 		}
 
-		utils.Must(w.Write(utils.Must(utils.MarshalJsonNoHTMLEspace(entry.Name))))
+		//quoted keys cannot be marked as optional.
+		isOptional := entry.IsOptional && PropertyName(entry.Name).Validate() == nil
+
+		if isOptional {
+			utils.Must(w.Write(utils.StringAsBytes(entry.Name)))
+		} else {
+			utils.Must(w.Write(utils.Must(utils.MarshalJsonNoHTMLEspace(entry.Name))))
+		}
 
 		if config.Colorize {
 			utils.Must(w.Write(ANSI_RESET_SEQUENCE))
 		}
 
+		if isOptional {
+			utils.PanicIfErr(w.WriteByte('?'))
+		}
+
 		//colon
 		utils.Must(w.Write(COLON_SPACE))
 
@@ -1765,9 +1836,37 @@ func (patt *MutationPattern) PrettyPrint(ctx *Context, w *bufio.Writer, config *
 }
 
 func (patt *ParserBasedPseudoPattern) PrettyPrint(ctx *Context, w *bufio.Writer, config *pprint.PrettyPrintConfig, depth int, parentIndentCount int) {
+	//the string pattern of a default named pattern (e.g. %ulid) is printed as a call to %string-pattern.
+	for _, namedPattern := range DEFAULT_NAMED_PATTERNS {
+		typePattern, ok := namedPattern.(*TypePattern)
+		if !ok || typePattern.stringPattern == nil {
+			continue
+		}
+
+		if stringPattern, ok := typePattern.stringPattern(); ok && stringPattern == StringPattern(patt) {
+			STR_PATTERN_PATTERN.PrettyPrint(ctx, w, config, depth, parentIndentCount)
+			utils.PanicIfErr(w.WriteByte('('))
+			typePattern.PrettyPrint(ctx, w, config, depth, parentIndentCount)
+			utils.PanicIfErr(w.WriteByte(')'))
+			return
+		}
+	}
+
 	InspectPrint(w, patt)
 }
 
+func (f *DateFormat) PrettyPrint(ctx *Context, w *bufio.Writer, config *pprint.PrettyPrintConfig, depth int, parentIndentCount int) {
+	if config.Colorize {
+		utils.Must(w.Write(config.Colors.PatternIdentifier))
+	}
+
+	utils.Must(w.Write(utils.StringAsBytes("%" + f.NamespaceName + "." + f.MemberName)))
+
+	if config.Colorize {
+		utils.Must(w.Write(ANSI_RESET_SEQUENCE))
+	}
+}
+
 func (patt *IntRangeStringPattern) PrettyPrint(ctx *Context, w *bufio.Writer, config *pprint.PrettyPrintConfig, depth int, parentIndentCount int) {
 	InspectPrint(w, patt)
 }
@@ -1800,6 +1899,14 @@ func (g *LThreadGroup) PrettyPrint(ctx *Context, w *bufio.Writer, config *pprint
 	InspectPrint(w, g)
 }
 
+func (c *Capability) PrettyPrint(ctx *Context, w *bufio.Writer, config *pprint.PrettyPrintConfig, depth int, parentIndentCount int) {
+	PrintType(w, c)
+}
+
+func (p *RetryPolicy) PrettyPrint(ctx *Context, w *bufio.Writer, config *pprint.PrettyPrintConfig, depth int, parentIndentCount int) {
+	PrintType(w, p)
+}
+
 func (g *InoxFunction) PrettyPrint(ctx *Context, w *bufio.Writer, config *pprint.PrettyPrintConfig, depth int, parentIndentCount int) {
 	InspectPrint(w, g)
 }
@@ -2115,10 +2222,38 @@ func (s *ConfluenceStream) PrettyPrint(ctx *Context, w *bufio.Writer, config *pp
 	InspectPrint(w, s)
 }
 
+func (s *CSVRowStream) PrettyPrint(ctx *Context, w *bufio.Writer, config *pprint.PrettyPrintConfig, depth int, parentIndentCount int) {
+	InspectPrint(w, s)
+}
+
 func (r *RingBuffer) PrettyPrint(ctx *Context, w *bufio.Writer, config *pprint.PrettyPrintConfig, depth int, parentIndentCount int) {
 	PrintType(w, r)
 }
 
+func (c *Channel) PrettyPrint(ctx *Context, w *bufio.Writer, config *pprint.PrettyPrintConfig, depth int, parentIndentCount int) {
+	PrintType(w, c)
+}
+
+func (s *channelStream) PrettyPrint(ctx *Context, w *bufio.Writer, config *pprint.PrettyPrintConfig, depth int, parentIndentCount int) {
+	InspectPrint(w, s)
+}
+
+func (s *Supervisor) PrettyPrint(ctx *Context, w *bufio.Writer, config *pprint.PrettyPrintConfig, depth int, parentIndentCount int) {
+	PrintType(w, s)
+}
+
+func (r *CrashReport) PrettyPrint(ctx *Context, w *bufio.Writer, config *pprint.PrettyPrintConfig, depth int, parentIndentCount int) {
+	PrintType(w, r)
+}
+
+func (s *Scheduler) PrettyPrint(ctx *Context, w *bufio.Writer, config *pprint.PrettyPrintConfig, depth int, parentIndentCount int) {
+	PrintType(w, s)
+}
+
+func (j *ScheduledJob) PrettyPrint(ctx *Context, w *bufio.Writer, config *pprint.PrettyPrintConfig, depth int, parentIndentCount int) {
+	PrintType(w, j)
+}
+
 func (c *DataChunk) PrettyPrint(ctx *Context, w *bufio.Writer, config *pprint.PrettyPrintConfig, depth int, parentIndentCount int) {
 	PrintType(w, c)
 }
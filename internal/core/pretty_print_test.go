@@ -550,6 +550,26 @@ func TestObjectPatternPrettyPrint(t *testing.T) {
 		assert.Equal(t, patt, utils.Must(TreeWalkEval(node, state)))
 	})
 
+	t.Run("optional key", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		patt := NewExactObjectPattern([]ObjectPatternEntry{
+			{
+				Name:       "a",
+				Pattern:    NewExactValuePattern(Int(1)),
+				IsOptional: true,
+			},
+		})
+
+		expectedRepr := `%{a?: %(1), otherprops no}`
+		assert.Equal(t, expectedRepr, Stringify(patt, ctx))
+		node := assertParseExpression(t, expectedRepr)
+
+		state := NewTreeWalkState(NewContext(ContextConfig{}))
+		assert.Equal(t, patt, utils.Must(TreeWalkEval(node, state)))
+	})
+
 	t.Run("one of entry's value has no representation", func(t *testing.T) {
 		//TODO
 	})
@@ -1204,21 +1224,30 @@ func TestNamedSegmentPathPatternPrettyPrint(t *testing.T) {
 }
 
 func TestIntRangePatternPrettyPrint(t *testing.T) {
-	//TODO: update implementation
+	ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+	defer ctx.CancelGracefully()
 
-	// ctx := NewContexWithEmptyState(ContextConfig{}, nil)
-	// defer ctx.CancelGracefully()
+	intRangePattern := NewIncludedEndIntRangePattern(1, 2, -1)
 
-	// intRangePattern := NewIncludedEndIntRangePattern(1, 2, -1)
+	expectedRepr := `%int(1..2)`
+	assert.Equal(t, expectedRepr, Stringify(intRangePattern, ctx))
+	node := assertParseExpression(t, expectedRepr)
 
-	// expectedRepr := `%int(1..2)`
-	// assert.Equal(t, expectedRepr, Stringify(intRangePattern, ctx))
-	// node := assertParseExpression(t, expectedRepr)
+	state := NewTreeWalkState(NewContext(ContextConfig{}))
+
+	state.Global.Ctx.AddNamedPattern("int", INT_PATTERN)
+	assert.Equal(t, intRangePattern, utils.Must(TreeWalkEval(node, state)))
+}
+
+func TestUnionPatternPrettyPrint(t *testing.T) {
+	ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+	defer ctx.CancelGracefully()
 
-	// state := NewTreeWalkState(NewContext(ContextConfig{}))
+	unionPattern := NewUnionPattern([]Pattern{INT_PATTERN, NewExactValuePattern(Int(1))}, nil)
 
-	// state.Global.Ctx.AddNamedPattern("int", INT_PATTERN)
-	// assert.Equal(t, intRangePattern, utils.Must(TreeWalkEval(node, state)))
+	expectedRepr := `(%| %int | %(1))`
+	assert.Equal(t, expectedRepr, Stringify(unionPattern, ctx))
+	assertParseExpression(t, expectedRepr)
 }
 
 func TestFileModePrettyPrint(t *testing.T) {
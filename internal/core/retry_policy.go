@@ -0,0 +1,326 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/inoxlang/inox/internal/commonfmt"
+	"github.com/inoxlang/inox/internal/core/symbolic"
+)
+
+const (
+	RETRY_POLICY__MAX_ATTEMPTS_PROPNAME       = "max-attempts"
+	RETRY_POLICY__INITIAL_BACKOFF_PROPNAME    = "initial-backoff"
+	RETRY_POLICY__MAX_BACKOFF_PROPNAME        = "max-backoff"
+	RETRY_POLICY__BACKOFF_MULTIPLIER_PROPNAME = "multiplier"
+	RETRY_POLICY__JITTER_PROPNAME             = "jitter"
+	RETRY_POLICY__ATTEMPT_TIMEOUT_PROPNAME    = "attempt-timeout"
+	RETRY_POLICY__DEADLINE_PROPNAME           = "deadline"
+
+	DEFAULT_RETRY_POLICY_BACKOFF_MULTIPLIER = 2.0
+)
+
+var (
+	ErrInvalidRetryPolicy     = errors.New("invalid retry policy")
+	ErrRetryDeadlineExceeded  = errors.New("retry deadline exceeded")
+	ErrRetryAttemptsExhausted = errors.New("all attempts failed")
+
+	// SINGLE_ATTEMPT_POLICY is the policy used when no policy is set: a single attempt without timeout.
+	SINGLE_ATTEMPT_POLICY = &RetryPolicy{MaxAttempts: 1}
+)
+
+func init() {
+	RegisterSymbolicGoFunction(NewRetryPolicyFromRecord, func(ctx *symbolic.Context, desc *symbolic.Record) *symbolic.RetryPolicy {
+		if !symbolic.RETRY_POLICY_RECORD.Test(desc, symbolic.RecTestCallState{}) {
+			ctx.AddSymbolicGoFunctionErrorf("the description of the policy should match %s", symbolic.Stringify(symbolic.RETRY_POLICY_RECORD))
+		}
+		return symbolic.ANY_RETRY_POLICY
+	})
+
+	RegisterSymbolicGoFunction(Retry, func(ctx *symbolic.Context, policy *symbolic.RetryPolicy, fn *symbolic.InoxFunction) symbolic.Value {
+		if len(fn.Parameters()) != 0 {
+			ctx.AddSymbolicGoFunctionError("the retried function should have no parameters")
+		}
+		return symbolic.ANY
+	})
+}
+
+// A RetryPolicy describes how an IO operation is retried: maximum number of attempts, exponential backoff with jitter
+// between the attempts, timeout of each attempt and total deadline. A RetryPolicy can be set on a context (see ContextConfig)
+// or in the retry-policy section of a module's manifest, in that case it is used by the IO operations of the context and of
+// its descendants that do not have their own policy (e.g. requests of OpenAPI clients with a safe method).
+// RetryPolicy implements Value, it is immutable.
+type RetryPolicy struct {
+	MaxAttempts int //defaults to 1 if zero
+
+	//Wait duration before the second attempt, the duration is multiplied by .BackoffMultiplier for each subsequent attempt.
+	InitialBackoff    time.Duration
+	MaxBackoff        time.Duration //no maximum if zero
+	BackoffMultiplier float64       //defaults to DEFAULT_RETRY_POLICY_BACKOFF_MULTIPLIER if zero
+
+	//Randomization factor in [0, 1]: each backoff duration is randomly chosen in [b - jitter*b, b + jitter*b].
+	Jitter float64
+
+	AttemptTimeout time.Duration //no timeout if zero
+	Deadline       time.Duration //maximum total duration (attempts + backoffs), no deadline if zero
+}
+
+// NewRetryPolicyFromRecord creates a policy from a record such as:
+//
+//	#{max-attempts: 5, initial-backoff: 100ms, max-backoff: 2s, multiplier: 2.0, jitter: 0.2, attempt-timeout: 1s, deadline: 10s}
+//
+// All properties are optional.
+func NewRetryPolicyFromRecord(ctx *Context, desc *Record) (*RetryPolicy, error) {
+	policy := &RetryPolicy{}
+
+	err := desc.ForEachEntry(func(k string, v Value) error {
+		switch k {
+		case RETRY_POLICY__MAX_ATTEMPTS_PROPNAME:
+			maxAttempts, ok := v.(Int)
+			if !ok {
+				return commonfmt.FmtPropOfArgXShouldBeY(k, "description", "an integer")
+			}
+			policy.MaxAttempts = int(maxAttempts)
+		case RETRY_POLICY__INITIAL_BACKOFF_PROPNAME, RETRY_POLICY__MAX_BACKOFF_PROPNAME,
+			RETRY_POLICY__ATTEMPT_TIMEOUT_PROPNAME, RETRY_POLICY__DEADLINE_PROPNAME:
+			duration, ok := v.(Duration)
+			if !ok {
+				return commonfmt.FmtPropOfArgXShouldBeY(k, "description", "a duration")
+			}
+			switch k {
+			case RETRY_POLICY__INITIAL_BACKOFF_PROPNAME:
+				policy.InitialBackoff = time.Duration(duration)
+			case RETRY_POLICY__MAX_BACKOFF_PROPNAME:
+				policy.MaxBackoff = time.Duration(duration)
+			case RETRY_POLICY__ATTEMPT_TIMEOUT_PROPNAME:
+				policy.AttemptTimeout = time.Duration(duration)
+			default:
+				policy.Deadline = time.Duration(duration)
+			}
+		case RETRY_POLICY__BACKOFF_MULTIPLIER_PROPNAME, RETRY_POLICY__JITTER_PROPNAME:
+			float, ok := v.(Float)
+			if !ok {
+				return commonfmt.FmtPropOfArgXShouldBeY(k, "description", "a float")
+			}
+			if k == RETRY_POLICY__JITTER_PROPNAME {
+				policy.Jitter = float64(float)
+			} else {
+				policy.BackoffMultiplier = float64(float)
+			}
+		default:
+			return commonfmt.FmtUnexpectedPropInArgX(k, "description")
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidRetryPolicy, err)
+	}
+
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+func (p *RetryPolicy) Validate() error {
+	switch {
+	case p.MaxAttempts < 0:
+		return fmt.Errorf("%w: the maximum number of attempts should be positive", ErrInvalidRetryPolicy)
+	case p.InitialBackoff < 0 || p.MaxBackoff < 0 || p.AttemptTimeout < 0 || p.Deadline < 0:
+		return fmt.Errorf("%w: durations should be positive", ErrInvalidRetryPolicy)
+	case p.BackoffMultiplier != 0 && p.BackoffMultiplier < 1:
+		return fmt.Errorf("%w: the backoff multiplier should be greater or equal to 1", ErrInvalidRetryPolicy)
+	case p.Jitter < 0 || p.Jitter > 1:
+		return fmt.Errorf("%w: the jitter should be in the range [0, 1]", ErrInvalidRetryPolicy)
+	}
+	return nil
+}
+
+func (p *RetryPolicy) maxAttempts() int {
+	if p.MaxAttempts == 0 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// Backoff returns the wait duration after the failure of the attempt at index attemptIndex (0-based),
+// the jitter is not applied.
+func (p *RetryPolicy) Backoff(attemptIndex int) time.Duration {
+	multiplier := p.BackoffMultiplier
+	if multiplier == 0 {
+		multiplier = DEFAULT_RETRY_POLICY_BACKOFF_MULTIPLIER
+	}
+
+	backoff := float64(p.InitialBackoff)
+	for i := 0; i < attemptIndex; i++ {
+		backoff *= multiplier
+		if p.MaxBackoff > 0 && backoff >= float64(p.MaxBackoff) {
+			return p.MaxBackoff
+		}
+	}
+
+	if p.MaxBackoff > 0 && backoff > float64(p.MaxBackoff) {
+		return p.MaxBackoff
+	}
+	return time.Duration(backoff)
+}
+
+func (p *RetryPolicy) randomizedBackoff(attemptIndex int) time.Duration {
+	backoff := p.Backoff(attemptIndex)
+	if p.Jitter == 0 || backoff == 0 {
+		return backoff
+	}
+
+	delta := p.Jitter * float64(backoff)
+	return time.Duration(float64(backoff) - delta + rand.Float64()*(2*delta))
+}
+
+// A NonRetryableError is an error that stops the retrying of an operation.
+type NonRetryableError struct {
+	Err error
+}
+
+func NewNonRetryableError(err error) *NonRetryableError {
+	return &NonRetryableError{Err: err}
+}
+
+func (e *NonRetryableError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *NonRetryableError) Unwrap() error {
+	return e.Err
+}
+
+// DoIOWithRetry calls fn until it succeeds or until the policy does not allow more attempts, the passed context.Context
+// is cancelled when the attempt times out (see RetryPolicy.AttemptTimeout & RetryPolicy.Deadline). If policy is nil
+// the policy of the context is used. The CPU time depletion is paused during the attempts & the backoffs.
+// The operation is not retried if fn returns a *NonRetryableError or if the context is done.
+func DoIOWithRetry[T any](ctx *Context, policy *RetryPolicy, fn func(attemptCtx context.Context) (T, error)) (T, error) {
+	if policy == nil {
+		policy = ctx.RetryPolicy()
+	}
+
+	var (
+		zero        T
+		lastErr     error
+		deadline    time.Time
+		maxAttempts = policy.maxAttempts()
+	)
+
+	if policy.Deadline > 0 {
+		deadline = time.Now().Add(policy.Deadline)
+	}
+
+	for attemptIndex := 0; attemptIndex < maxAttempts; attemptIndex++ {
+		if attemptIndex > 0 {
+			backoff := policy.randomizedBackoff(attemptIndex - 1)
+			if !deadline.IsZero() && time.Now().Add(backoff).After(deadline) {
+				return zero, fmt.Errorf("%w: %w", ErrRetryDeadlineExceeded, lastErr)
+			}
+			ctx.Sleep(backoff)
+		}
+
+		if ctx.IsDoneSlowCheck() {
+			return zero, ctx.Err()
+		}
+
+		result, err := DoIO2(ctx, func() (T, error) {
+			attemptCtx, cancel := policy.attemptContext(ctx, deadline)
+			defer cancel()
+
+			return fn(attemptCtx)
+		})
+
+		if err == nil {
+			return result, nil
+		}
+
+		lastErr = err
+
+		var nonRetryable *NonRetryableError
+		if errors.As(err, &nonRetryable) {
+			return zero, nonRetryable.Err
+		}
+
+		if ctx.IsDoneSlowCheck() {
+			return zero, err
+		}
+	}
+
+	if maxAttempts == 1 {
+		return zero, lastErr
+	}
+	return zero, fmt.Errorf("%w (%d attempts): %w", ErrRetryAttemptsExhausted, maxAttempts, lastErr)
+}
+
+func (p *RetryPolicy) attemptContext(ctx *Context, deadline time.Time) (context.Context, context.CancelFunc) {
+	attemptDeadline := deadline
+	if p.AttemptTimeout > 0 {
+		d := time.Now().Add(p.AttemptTimeout)
+		if attemptDeadline.IsZero() || d.Before(attemptDeadline) {
+			attemptDeadline = d
+		}
+	}
+
+	if attemptDeadline.IsZero() {
+		return context.WithCancel(ctx)
+	}
+	return context.WithDeadline(ctx, attemptDeadline)
+}
+
+// DoIOWithRetry is equivalent to DoIOWithRetry(ctx, nil, fn).
+func (ctx *Context) DoIOWithRetry(fn func(attemptCtx context.Context) error) error {
+	_, err := DoIOWithRetry(ctx, nil, func(attemptCtx context.Context) (struct{}, error) {
+		return struct{}{}, fn(attemptCtx)
+	})
+	return err
+}
+
+// RetryPolicy returns the retry policy of the context, if the context has no policy the policy of the closest
+// ancestor having one is returned. SINGLE_ATTEMPT_POLICY is returned if no policy is found.
+func (ctx *Context) RetryPolicy() *RetryPolicy {
+	for c := ctx; c != nil; c = c.parentCtx {
+		if c.retryPolicy != nil {
+			return c.retryPolicy
+		}
+	}
+	return SINGLE_ATTEMPT_POLICY
+}
+
+// Retry calls an Inox function with no parameters until it succeeds or until the policy does not allow more attempts.
+// A call fails if the function returns an error or an array whose last element is an error (see 'must' calls).
+// Each attempt runs in a dedicated state whose context is cancelled when the attempt times out.
+func Retry(ctx *Context, policy *RetryPolicy, fn *InoxFunction) (Value, error) {
+	state := ctx.MustGetClosestState()
+
+	return DoIOWithRetry(ctx, policy, func(attemptCtx context.Context) (result Value, err error) {
+		//The CPU time depletion is paused by DoIOWithRetry but the function performs some computation.
+		ctx.ResumeCPUTimeDepletion()
+		defer ctx.PauseCPUTimeDepletion()
+
+		attemptState := NewGlobalState(ctx.BoundChildWithOptions(BoundChildContextOptions{
+			AdditionalParentContext: attemptCtx,
+		}))
+		defer attemptState.Ctx.CancelGracefully()
+
+		attemptState.Module = state.Module
+		attemptState.Globals = state.Globals
+		attemptState.MainState = state.MainState
+		attemptState.Logger = state.Logger
+		attemptState.LogLevels = state.LogLevels
+		attemptState.Out = state.Out
+		attemptState.OutputFieldsInitialized.Store(true)
+
+		result, err = fn.Call(attemptState, nil, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		return checkTransformInoxMustCallResult(result)
+	})
+}
@@ -0,0 +1,253 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/inoxlang/inox/internal/core/inoxmod"
+	"github.com/inoxlang/inox/internal/core/permbase"
+	"github.com/inoxlang/inox/internal/parse"
+	"github.com/inoxlang/inox/internal/sourcecode"
+	"github.com/inoxlang/inox/internal/testconfig"
+	utils "github.com/inoxlang/inox/internal/utils/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	testconfig.AllowParallelization(t)
+
+	errFailure := errors.New("failure")
+
+	t.Run("backoff", func(t *testing.T) {
+		policy := &RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+		assert.Equal(t, 100*time.Millisecond, policy.Backoff(0))
+		assert.Equal(t, 200*time.Millisecond, policy.Backoff(1))
+		assert.Equal(t, 400*time.Millisecond, policy.Backoff(2))
+		assert.Equal(t, 800*time.Millisecond, policy.Backoff(3))
+		assert.Equal(t, time.Second, policy.Backoff(4))
+		assert.Equal(t, time.Second, policy.Backoff(100))
+
+		policy = &RetryPolicy{InitialBackoff: 100 * time.Millisecond, BackoffMultiplier: 3}
+		assert.Equal(t, 900*time.Millisecond, policy.Backoff(2))
+	})
+
+	t.Run("jitter", func(t *testing.T) {
+		policy := &RetryPolicy{InitialBackoff: 100 * time.Millisecond, Jitter: 0.5}
+
+		for i := 0; i < 20; i++ {
+			backoff := policy.randomizedBackoff(0)
+			assert.GreaterOrEqual(t, backoff, 50*time.Millisecond)
+			assert.LessOrEqual(t, backoff, 150*time.Millisecond)
+		}
+	})
+
+	t.Run("from record", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		policy, err := NewRetryPolicyFromRecord(ctx, NewRecordFromMap(ValMap{
+			RETRY_POLICY__MAX_ATTEMPTS_PROPNAME:    Int(3),
+			RETRY_POLICY__INITIAL_BACKOFF_PROPNAME: Duration(time.Millisecond),
+			RETRY_POLICY__JITTER_PROPNAME:          Float(0.1),
+			RETRY_POLICY__DEADLINE_PROPNAME:        Duration(time.Second),
+		}))
+
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, &RetryPolicy{
+			MaxAttempts:    3,
+			InitialBackoff: time.Millisecond,
+			Jitter:         0.1,
+			Deadline:       time.Second,
+		}, policy)
+
+		_, err = NewRetryPolicyFromRecord(ctx, NewRecordFromMap(ValMap{RETRY_POLICY__JITTER_PROPNAME: Float(2)}))
+		assert.ErrorIs(t, err, ErrInvalidRetryPolicy)
+
+		_, err = NewRetryPolicyFromRecord(ctx, NewRecordFromMap(ValMap{"x": Int(1)}))
+		assert.ErrorIs(t, err, ErrInvalidRetryPolicy)
+	})
+
+	t.Run("the operation should be retried until it succeeds", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		attempts := 0
+		result, err := DoIOWithRetry(ctx, &RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Millisecond}, func(context.Context) (int, error) {
+			attempts++
+			if attempts < 3 {
+				return 0, errFailure
+			}
+			return 1, nil
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, result)
+		assert.Equal(t, 3, attempts)
+	})
+
+	t.Run("the number of attempts should be limited", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		attempts := 0
+		_, err := DoIOWithRetry(ctx, &RetryPolicy{MaxAttempts: 3}, func(context.Context) (int, error) {
+			attempts++
+			return 0, errFailure
+		})
+
+		assert.ErrorIs(t, err, ErrRetryAttemptsExhausted)
+		assert.ErrorIs(t, err, errFailure)
+		assert.Equal(t, 3, attempts)
+	})
+
+	t.Run("a non-retryable error should stop the retrying", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		attempts := 0
+		_, err := DoIOWithRetry(ctx, &RetryPolicy{MaxAttempts: 3}, func(context.Context) (int, error) {
+			attempts++
+			return 0, NewNonRetryableError(errFailure)
+		})
+
+		assert.Equal(t, errFailure, err)
+		assert.Equal(t, 1, attempts)
+	})
+
+	t.Run("attempt timeout", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		attempts := 0
+		_, err := DoIOWithRetry(ctx, &RetryPolicy{MaxAttempts: 2, AttemptTimeout: 10 * time.Millisecond}, func(attemptCtx context.Context) (int, error) {
+			attempts++
+			<-attemptCtx.Done()
+			return 0, attemptCtx.Err()
+		})
+
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Equal(t, 2, attempts)
+	})
+
+	t.Run("deadline", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		start := time.Now()
+		attempts := 0
+		policy := &RetryPolicy{MaxAttempts: 100, InitialBackoff: 20 * time.Millisecond, Deadline: 100 * time.Millisecond}
+
+		_, err := DoIOWithRetry(ctx, policy, func(context.Context) (int, error) {
+			attempts++
+			return 0, errFailure
+		})
+
+		assert.ErrorIs(t, err, ErrRetryDeadlineExceeded)
+		assert.ErrorIs(t, err, errFailure)
+		assert.Less(t, attempts, 5)
+		assert.Less(t, time.Since(start), 200*time.Millisecond)
+	})
+
+	t.Run("the policy of a context should be inherited by its descendants", func(t *testing.T) {
+		policy := &RetryPolicy{MaxAttempts: 2}
+
+		ctx := NewContextWithEmptyState(ContextConfig{RetryPolicy: policy}, nil)
+		defer ctx.CancelGracefully()
+
+		childCtx := NewContextWithEmptyState(ContextConfig{ParentContext: ctx}, nil)
+		defer childCtx.CancelGracefully()
+
+		assert.Same(t, policy, childCtx.RetryPolicy())
+
+		attempts := 0
+		err := childCtx.DoIOWithRetry(func(context.Context) error {
+			attempts++
+			return errFailure
+		})
+
+		assert.ErrorIs(t, err, errFailure)
+		assert.Equal(t, 2, attempts)
+
+		rootCtx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer rootCtx.CancelGracefully()
+		assert.Same(t, SINGLE_ATTEMPT_POLICY, rootCtx.RetryPolicy())
+	})
+}
+
+func TestRetry(t *testing.T) {
+
+	perms := []Permission{
+		GlobalVarPermission{Kind_: permbase.Read, Name: "*"},
+		GlobalVarPermission{Kind_: permbase.Use, Name: "*"},
+	}
+
+	//makeFunction evaluates a function expression whose body calls the Go function wait.
+	makeFunction := func(t *testing.T, state *GlobalState, wait func(ctx *Context) (Value, error)) *InoxFunction {
+		chunk := utils.Must(parse.ParseChunkSource(sourcecode.InMemorySource{
+			NameString: "retry-test",
+			CodeString: "return fn(){ return wait() }",
+		}))
+
+		state.Module = WrapLowerModule(&inoxmod.Module{
+			MainChunk:    chunk,
+			TopLevelNode: chunk.Node,
+		})
+		state.Globals = GlobalVariablesFromMap(map[string]Value{"wait": WrapGoFunction(wait)}, nil)
+
+		fn, err := TreeWalkEval(chunk.Node, NewTreeWalkStateWithGlobal(state))
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		return fn.(*InoxFunction)
+	}
+
+	t.Run("each attempt should be cancelled when it times out", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{Permissions: perms}, nil)
+		defer ctx.CancelGracefully()
+
+		attempts := 0
+		fn := makeFunction(t, ctx.MustGetClosestState(), func(ctx *Context) (Value, error) {
+			attempts++
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(time.Second):
+				return Int(attempts), nil
+			}
+		})
+
+		start := time.Now()
+		_, err := Retry(ctx, &RetryPolicy{MaxAttempts: 2, AttemptTimeout: 10 * time.Millisecond}, fn)
+
+		assert.ErrorIs(t, err, ErrRetryAttemptsExhausted)
+		assert.Equal(t, 2, attempts)
+		assert.Less(t, time.Since(start), 500*time.Millisecond)
+		assert.False(t, ctx.IsDone())
+	})
+
+	t.Run("the result of the first successful attempt should be returned", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{Permissions: perms}, nil)
+		defer ctx.CancelGracefully()
+
+		attempts := 0
+		fn := makeFunction(t, ctx.MustGetClosestState(), func(ctx *Context) (Value, error) {
+			attempts++
+			if attempts == 1 {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}
+			return Int(attempts), nil
+		})
+
+		result, err := Retry(ctx, &RetryPolicy{MaxAttempts: 3, AttemptTimeout: 10 * time.Millisecond}, fn)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, Int(2), result)
+	})
+}
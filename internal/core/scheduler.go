@@ -0,0 +1,732 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/inoxlang/inox/internal/commonfmt"
+	"github.com/inoxlang/inox/internal/core/symbolic"
+	"github.com/rs/zerolog"
+)
+
+const (
+	MAX_QUEUED_JOB_RUNS            = 100
+	SCHEDULED_RUN_CANCEL_TIMEOUT   = 5 * time.Second
+	DEFAULT_SCHEDULED_JOB_NAME_FMT = "job-%d"
+
+	JOB_SPEC__NAME_PROPNAME    = "name"
+	JOB_SPEC__CRON_PROPNAME    = "cron"
+	JOB_SPEC__EVERY_PROPNAME   = "every"
+	JOB_SPEC__AT_PROPNAME      = "at"
+	JOB_SPEC__OVERLAP_PROPNAME = "overlap"
+	JOB_SPEC__JITTER_PROPNAME  = "jitter"
+)
+
+var (
+	SCHEDULER_PROPNAMES     = []string{"schedule", "jobs", "stop"}
+	SCHEDULED_JOB_PROPNAMES = []string{"name", "next-run", "run-count", "skipped-count", "running", "last-error", "cancel"}
+
+	ErrSchedulerStopped    = errors.New("scheduler is stopped")
+	ErrInvalidJobSpec      = errors.New("invalid job specification")
+	ErrScheduleTimeInPast  = errors.New("the time of the job is in the past")
+	ErrNotSchedulableValue = errors.New("only Inox functions without parameters and lthreads can be scheduled")
+)
+
+func init() {
+	RegisterSymbolicGoFunction(NewInoxScheduler, func(ctx *symbolic.Context) *symbolic.Scheduler {
+		return symbolic.ANY_SCHEDULER
+	})
+}
+
+// JobOverlapPolicy determines what happens when a job is triggered while its previous run is not finished.
+type JobOverlapPolicy int
+
+const (
+	SkipOverlappingRun         JobOverlapPolicy = iota //the new run is skipped
+	QueueOverlappingRun                                //the new run starts after the previous one (at most MAX_QUEUED_JOB_RUNS are queued)
+	CancelPreviousRunOnOverlap                         //the previous run is cancelled and the new run starts
+)
+
+func (p JobOverlapPolicy) String() string {
+	switch p {
+	case SkipOverlappingRun:
+		return "skip"
+	case QueueOverlappingRun:
+		return "queue"
+	case CancelPreviousRunOnOverlap:
+		return "cancel-previous"
+	}
+	return "unknown"
+}
+
+// A ScheduleTrigger determines when a job runs.
+type ScheduleTrigger interface {
+	// NextTime returns the first activation time strictly after $after, ok is false if there is no such activation.
+	NextTime(after time.Time) (_ time.Time, ok bool)
+}
+
+var _ = []ScheduleTrigger{IntervalTrigger{}, AtTrigger{}, (*CronExpression)(nil)}
+
+// IntervalTrigger activates a job at a fixed interval, the first activation happens one interval after the scheduling.
+type IntervalTrigger struct {
+	Interval time.Duration
+}
+
+func (t IntervalTrigger) NextTime(after time.Time) (time.Time, bool) {
+	return after.Add(t.Interval), true
+}
+
+// AtTrigger activates a job once at a specific time.
+type AtTrigger struct {
+	Time time.Time
+}
+
+func (t AtTrigger) NextTime(after time.Time) (time.Time, bool) {
+	if t.Time.After(after) {
+		return t.Time, true
+	}
+	return time.Time{}, false
+}
+
+type JobSpec struct {
+	Name    string //if empty a name is generated
+	Trigger ScheduleTrigger
+	Overlap JobOverlapPolicy
+
+	//Each run is delayed by a random duration in [0, Jitter], the activation times of the trigger are not affected.
+	Jitter time.Duration
+}
+
+type SchedulerConfig struct {
+	Clock Clock //defaults to RealClock{}
+}
+
+// A Scheduler runs jobs on cron expressions, at fixed intervals or at specific times. Each run has its own context
+// whose parent is the context of the scheduler. The scheduler is stopped when its context is gracefully teared down:
+// the pending runs are cancelled and the running ones are gracefully cancelled.
+// A Scheduler is always shared.
+type Scheduler struct {
+	ctx         *Context
+	originState *GlobalState
+	clock       Clock
+	logger      zerolog.Logger
+
+	lock          sync.Mutex
+	jobs          []*ScheduledJob
+	addedJobCount int
+	stopped       bool
+}
+
+func NewScheduler(ctx *Context, config SchedulerConfig) *Scheduler {
+	clock := config.Clock
+	if clock == nil {
+		clock = RealClock{}
+	}
+
+	scheduler := &Scheduler{
+		ctx:         ctx,
+		originState: ctx.MustGetClosestState(),
+		clock:       clock,
+		logger:      ctx.Logger(),
+	}
+
+	ctx.OnGracefulTearDown(func(ctx *Context) error {
+		scheduler.Stop(ctx)
+		return nil
+	})
+
+	return scheduler
+}
+
+// NewInoxScheduler is the Inox constructor for schedulers, the returned scheduler uses the real clock.
+func NewInoxScheduler(ctx *Context) *Scheduler {
+	return NewScheduler(ctx, SchedulerConfig{})
+}
+
+// ScheduleFunc schedules a job executing run, the context passed to run is cancelled when the run is cancelled.
+func (s *Scheduler) ScheduleFunc(spec JobSpec, run func(ctx *Context) error) (*ScheduledJob, error) {
+	if spec.Trigger == nil {
+		return nil, fmt.Errorf("%w: missing trigger", ErrInvalidJobSpec)
+	}
+	if spec.Jitter < 0 {
+		return nil, fmt.Errorf("%w: the jitter should be positive", ErrInvalidJobSpec)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.stopped {
+		return nil, ErrSchedulerStopped
+	}
+
+	now := s.clock.Now()
+	firstRun, ok := spec.Trigger.NextTime(now)
+	if !ok {
+		return nil, ErrScheduleTimeInPast
+	}
+
+	s.addedJobCount++
+	if spec.Name == "" {
+		spec.Name = fmt.Sprintf(DEFAULT_SCHEDULED_JOB_NAME_FMT, s.addedJobCount)
+	}
+
+	job := &ScheduledJob{
+		scheduler: s,
+		name:      spec.Name,
+		trigger:   spec.Trigger,
+		overlap:   spec.Overlap,
+		jitter:    spec.Jitter,
+		run:       run,
+	}
+
+	job.lock.Lock()
+	job.scheduleNoLock(firstRun)
+	job.lock.Unlock()
+
+	s.jobs = append(s.jobs, job)
+	return job, nil
+}
+
+// Schedule is the Inox method scheduling a function without parameters or a lthread. A lthread is only used as a template:
+// each run is a respawn of the lthread (see LThread.Respawn). The specification is a record such as:
+//
+//	#{every: 10s, overlap: "queue", jitter: 1s, name: "cleanup"}
+//
+// Exactly one of the .cron (string), .every (duration) and .at (datetime) properties should be present,
+// .overlap is "skip" (default), "queue" or "cancel-previous".
+func (s *Scheduler) Schedule(ctx *Context, desc *Record, target Value) (*ScheduledJob, error) {
+	spec := JobSpec{}
+	triggerCount := 0
+
+	err := desc.ForEachEntry(func(k string, v Value) error {
+		switch k {
+		case JOB_SPEC__NAME_PROPNAME:
+			str, ok := v.(StringLike)
+			if !ok {
+				return commonfmt.FmtPropOfArgXShouldBeY(k, "specification", "a string")
+			}
+			spec.Name = str.GetOrBuildString()
+		case JOB_SPEC__CRON_PROPNAME:
+			str, ok := v.(StringLike)
+			if !ok {
+				return commonfmt.FmtPropOfArgXShouldBeY(k, "specification", "a string")
+			}
+			expr, err := ParseCronExpression(str.GetOrBuildString())
+			if err != nil {
+				return err
+			}
+			spec.Trigger = expr
+			triggerCount++
+		case JOB_SPEC__EVERY_PROPNAME:
+			interval, ok := v.(Duration)
+			if !ok || interval <= 0 {
+				return commonfmt.FmtPropOfArgXShouldBeY(k, "specification", "a positive duration")
+			}
+			spec.Trigger = IntervalTrigger{Interval: time.Duration(interval)}
+			triggerCount++
+		case JOB_SPEC__AT_PROPNAME:
+			dateTime, ok := v.(DateTime)
+			if !ok {
+				return commonfmt.FmtPropOfArgXShouldBeY(k, "specification", "a datetime")
+			}
+			spec.Trigger = AtTrigger{Time: dateTime.AsGoTime()}
+			triggerCount++
+		case JOB_SPEC__OVERLAP_PROPNAME:
+			str, ok := v.(StringLike)
+			if !ok {
+				return commonfmt.FmtPropOfArgXShouldBeY(k, "specification", "a string")
+			}
+			switch str.GetOrBuildString() {
+			case SkipOverlappingRun.String():
+				spec.Overlap = SkipOverlappingRun
+			case QueueOverlappingRun.String():
+				spec.Overlap = QueueOverlappingRun
+			case CancelPreviousRunOnOverlap.String():
+				spec.Overlap = CancelPreviousRunOnOverlap
+			default:
+				return commonfmt.FmtPropOfArgXShouldBeY(k, "specification", `"skip", "queue" or "cancel-previous"`)
+			}
+		case JOB_SPEC__JITTER_PROPNAME:
+			jitter, ok := v.(Duration)
+			if !ok {
+				return commonfmt.FmtPropOfArgXShouldBeY(k, "specification", "a duration")
+			}
+			spec.Jitter = time.Duration(jitter)
+		default:
+			return commonfmt.FmtUnexpectedPropInArgX(k, "specification")
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidJobSpec, err)
+	}
+
+	if triggerCount != 1 {
+		return nil, fmt.Errorf("%w: exactly one of the .cron, .every and .at properties should be present", ErrInvalidJobSpec)
+	}
+
+	var run func(ctx *Context) error
+
+	switch t := target.(type) {
+	case *InoxFunction:
+		t.Share(s.originState)
+		run = s.makeInoxFunctionRun(t)
+	case *LThread:
+		if t.spawnArgs == nil {
+			return nil, ErrLThreadNotRespawnable
+		}
+		run = makeLThreadRun(t)
+	default:
+		return nil, ErrNotSchedulableValue
+	}
+
+	return s.ScheduleFunc(spec, run)
+}
+
+func (s *Scheduler) makeInoxFunctionRun(fn *InoxFunction) func(ctx *Context) error {
+	return func(ctx *Context) error {
+		state := NewGlobalState(ctx)
+		state.Module = s.originState.Module
+		state.MainState = s.originState.MainState
+		state.Logger = s.originState.Logger
+		state.LogLevels = s.originState.LogLevels
+		state.Out = s.originState.Out
+		state.OutputFieldsInitialized.Store(true)
+
+		result, err := fn.Call(state, nil, nil, nil)
+		if err != nil {
+			return err
+		}
+		if errResult, ok := result.(Error); ok {
+			return errResult
+		}
+		return nil
+	}
+}
+
+func makeLThreadRun(template *LThread) func(ctx *Context) error {
+	return func(ctx *Context) error {
+		lthread, err := template.Respawn()
+		if err != nil {
+			return err
+		}
+
+		select {
+		case <-lthread.Finished():
+		case <-ctx.Done():
+			lthread.Context().CancelGracefully()
+			<-lthread.Finished()
+		}
+		return lthread.Err()
+	}
+}
+
+// Jobs returns the jobs that are not cancelled and that have a next run or a current run.
+func (s *Scheduler) Jobs() []*ScheduledJob {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var jobs []*ScheduledJob
+	for _, job := range s.jobs {
+		if job.isActive() {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs
+}
+
+// Stop cancels all the jobs, the scheduler cannot be used afterwards.
+func (s *Scheduler) Stop(*Context) {
+	s.lock.Lock()
+	if s.stopped {
+		s.lock.Unlock()
+		return
+	}
+	s.stopped = true
+	jobs := s.jobs
+	s.jobs = nil
+	s.lock.Unlock()
+
+	for _, job := range jobs {
+		job.cancel()
+	}
+}
+
+func (s *Scheduler) removeJob(job *ScheduledJob) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.jobs = slices.DeleteFunc(s.jobs, func(j *ScheduledJob) bool {
+		return j == job
+	})
+}
+
+func (s *Scheduler) GetGoMethod(name string) (*GoFunction, bool) {
+	switch name {
+	case "schedule":
+		return WrapGoMethod(s.Schedule), true
+	case "stop":
+		return WrapGoMethod(s.Stop), true
+	}
+	return nil, false
+}
+
+func (s *Scheduler) Prop(ctx *Context, name string) Value {
+	switch name {
+	case "jobs":
+		jobs := s.Jobs()
+		values := make([]Value, len(jobs))
+		for i, job := range jobs {
+			values[i] = job
+		}
+		return NewArrayFrom(values...)
+	}
+	method, ok := s.GetGoMethod(name)
+	if !ok {
+		panic(FormatErrPropertyDoesNotExist(name, s))
+	}
+	return method
+}
+
+func (*Scheduler) SetProp(ctx *Context, name string, value Value) error {
+	return ErrCannotSetProp
+}
+
+func (*Scheduler) PropertyNames(ctx *Context) []string {
+	return SCHEDULER_PROPNAMES
+}
+
+func (s *Scheduler) IsSharable(originState *GlobalState) (bool, string) {
+	return true, ""
+}
+
+func (s *Scheduler) Share(originState *GlobalState) {
+	//ok
+}
+
+func (s *Scheduler) IsShared() bool {
+	return true
+}
+
+func (s *Scheduler) SmartLock(state *GlobalState) {
+	//no-op
+}
+
+func (s *Scheduler) SmartUnlock(state *GlobalState) {
+	//no-op
+}
+
+// A ScheduledJob is a job registered in a Scheduler, it is always shared.
+type ScheduledJob struct {
+	scheduler *Scheduler
+	name      string
+	trigger   ScheduleTrigger
+	overlap   JobOverlapPolicy
+	jitter    time.Duration
+	run       func(ctx *Context) error
+
+	lock         sync.Mutex
+	nextRun      time.Time   //activation time of the next run, zero if there is no next run
+	stopTimer    func() bool //nil if there is no next run
+	current      *jobRun     //nil if the job is not running
+	queued       int
+	runCount     int
+	skippedCount int
+	lastErr      error
+	cancelled    bool
+}
+
+type jobRun struct {
+	ctx  *Context
+	done chan struct{}
+}
+
+func (j *ScheduledJob) Name() string {
+	return j.name
+}
+
+// NextRun returns the activation time of the next run, ok is false if there is no next run.
+func (j *ScheduledJob) NextRun() (_ time.Time, ok bool) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	return j.nextRun, !j.nextRun.IsZero()
+}
+
+// RunCount returns the number of started runs.
+func (j *ScheduledJob) RunCount() int {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	return j.runCount
+}
+
+// SkippedCount returns the number of activations that did not cause a run because of the overlap policy.
+func (j *ScheduledJob) SkippedCount() int {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	return j.skippedCount
+}
+
+func (j *ScheduledJob) IsRunning() bool {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	return j.current != nil
+}
+
+// LastError returns the error of the last finished run, nil is returned if the run succeeded.
+func (j *ScheduledJob) LastError() error {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	return j.lastErr
+}
+
+func (j *ScheduledJob) isActive() bool {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	return !j.cancelled && (!j.nextRun.IsZero() || j.current != nil || j.queued > 0)
+}
+
+// scheduleNoLock schedules the activation of the job at $activation, the run is delayed by the jitter.
+func (j *ScheduledJob) scheduleNoLock(activation time.Time) {
+	j.nextRun = activation
+
+	delay := activation.Sub(j.scheduler.clock.Now())
+	if j.jitter > 0 {
+		delay += time.Duration(rand.Int63n(int64(j.jitter) + 1))
+	}
+
+	j.stopTimer = j.scheduler.clock.AfterFunc(delay, func() {
+		j.activate(activation)
+	})
+}
+
+func (j *ScheduledJob) activate(activation time.Time) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	if j.cancelled {
+		return
+	}
+
+	//schedule the next activation, the missed activations are skipped.
+	now := j.scheduler.clock.Now()
+	next, ok := j.trigger.NextTime(activation)
+	for ok && !next.After(now) {
+		next, ok = j.trigger.NextTime(next)
+	}
+
+	if ok {
+		j.scheduleNoLock(next)
+	} else {
+		j.nextRun = time.Time{}
+		j.stopTimer = nil
+	}
+
+	if j.current != nil {
+		switch j.overlap {
+		case QueueOverlappingRun:
+			if j.queued < MAX_QUEUED_JOB_RUNS {
+				j.queued++
+			} else {
+				j.skippedCount++
+			}
+			return
+		case CancelPreviousRunOnOverlap:
+			previous := j.current
+			j.current = nil
+			previous.ctx.CancelGracefully()
+
+			//the lock is not held while waiting because the previous run calls onRunFinished.
+			j.lock.Unlock()
+			j.waitForCancelledRun(previous)
+			j.lock.Lock()
+
+			if j.cancelled || j.current != nil {
+				//the job has been cancelled or a run has been started by another activation.
+				return
+			}
+		default:
+			j.skippedCount++
+			return
+		}
+	}
+
+	j.startRunNoLock()
+}
+
+func (j *ScheduledJob) startRunNoLock() {
+	schedulerCtx := j.scheduler.ctx
+
+	run := &jobRun{
+		ctx: NewContext(ContextConfig{
+			Permissions:          schedulerCtx.GetGrantedPermissions(),
+			ForbiddenPermissions: schedulerCtx.GetForbiddenPermissions(),
+			Capabilities:         schedulerCtx.GetCapabilities(),
+			ParentContext:        schedulerCtx,
+		}),
+		done: make(chan struct{}),
+	}
+	j.current = run
+	j.runCount++
+
+	go func() {
+		var err error
+
+		defer func() {
+			if e := recover(); e != nil {
+				if recoveredErr, ok := e.(error); ok {
+					err = recoveredErr
+				} else {
+					err = fmt.Errorf("%#v", e)
+				}
+			}
+
+			run.ctx.CancelGracefully()
+			close(run.done)
+			j.onRunFinished(run, err)
+		}()
+
+		err = j.run(run.ctx)
+	}()
+}
+
+func (j *ScheduledJob) onRunFinished(run *jobRun, err error) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	j.lastErr = err
+	if err != nil {
+		j.scheduler.logger.Err(err).Str("job", j.name).Msg("scheduled job run failed")
+	}
+
+	if j.current != run {
+		//the run has been cancelled by a new run.
+		return
+	}
+
+	j.current = nil
+
+	if j.queued > 0 && !j.cancelled {
+		j.queued--
+		j.startRunNoLock()
+	}
+}
+
+// waitForCancelledRun waits at most SCHEDULED_RUN_CANCEL_TIMEOUT for a cancelled run to finish, it should be called
+// without holding the lock of the job.
+func (j *ScheduledJob) waitForCancelledRun(run *jobRun) {
+	select {
+	case <-run.done:
+	case <-time.After(SCHEDULED_RUN_CANCEL_TIMEOUT):
+		j.scheduler.logger.Warn().Str("job", j.name).Msg("a cancelled run of a scheduled job did not finish in time")
+	}
+}
+
+// Cancel cancels the next runs of the job and gracefully cancels the current run.
+func (j *ScheduledJob) Cancel(*Context) {
+	j.cancel()
+	j.scheduler.removeJob(j)
+}
+
+func (j *ScheduledJob) cancel() {
+	j.lock.Lock()
+
+	if j.cancelled {
+		j.lock.Unlock()
+		return
+	}
+
+	j.cancelled = true
+	j.queued = 0
+	j.nextRun = time.Time{}
+
+	if j.stopTimer != nil {
+		j.stopTimer()
+		j.stopTimer = nil
+	}
+
+	run := j.current
+	j.lock.Unlock()
+
+	//the lock is not held because the run calls onRunFinished.
+	if run != nil {
+		run.ctx.CancelGracefully()
+		j.waitForCancelledRun(run)
+
+		j.lock.Lock()
+		if j.current == run {
+			j.current = nil
+		}
+		j.lock.Unlock()
+	}
+}
+
+func (j *ScheduledJob) GetGoMethod(name string) (*GoFunction, bool) {
+	switch name {
+	case "cancel":
+		return WrapGoMethod(j.Cancel), true
+	}
+	return nil, false
+}
+
+func (j *ScheduledJob) Prop(ctx *Context, name string) Value {
+	switch name {
+	case "name":
+		return String(j.name)
+	case "next-run":
+		nextRun, ok := j.NextRun()
+		if !ok {
+			return Nil
+		}
+		return DateTime(nextRun)
+	case "run-count":
+		return Int(j.RunCount())
+	case "skipped-count":
+		return Int(j.SkippedCount())
+	case "running":
+		return Bool(j.IsRunning())
+	case "last-error":
+		err := j.LastError()
+		if err == nil {
+			return Nil
+		}
+		return NewError(err, Nil)
+	}
+	method, ok := j.GetGoMethod(name)
+	if !ok {
+		panic(FormatErrPropertyDoesNotExist(name, j))
+	}
+	return method
+}
+
+func (*ScheduledJob) SetProp(ctx *Context, name string, value Value) error {
+	return ErrCannotSetProp
+}
+
+func (*ScheduledJob) PropertyNames(ctx *Context) []string {
+	return SCHEDULED_JOB_PROPNAMES
+}
+
+func (j *ScheduledJob) IsSharable(originState *GlobalState) (bool, string) {
+	return true, ""
+}
+
+func (j *ScheduledJob) Share(originState *GlobalState) {
+	//ok
+}
+
+func (j *ScheduledJob) IsShared() bool {
+	return true
+}
+
+func (j *ScheduledJob) SmartLock(state *GlobalState) {
+	//no-op
+}
+
+func (j *ScheduledJob) SmartUnlock(state *GlobalState) {
+	//no-op
+}
@@ -0,0 +1,374 @@
+package core
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/inoxlang/inox/internal/core/permbase"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduler(t *testing.T) {
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	//blockingRun returns a run function that blocks until the returned channel is closed or the context is done.
+	blockingRun := func() (func(ctx *Context) error, chan struct{}) {
+		release := make(chan struct{})
+		return func(ctx *Context) error {
+			select {
+			case <-release:
+			case <-ctx.Done():
+			}
+			return nil
+		}, release
+	}
+
+	t.Run("interval", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		clock := NewVirtualClock(start)
+		scheduler := NewScheduler(ctx, SchedulerConfig{Clock: clock})
+
+		runs := &atomic.Int64{}
+		//the runs are queued because the activations can happen before the end of the previous runs.
+		job, err := scheduler.ScheduleFunc(JobSpec{
+			Trigger: IntervalTrigger{Interval: time.Minute},
+			Overlap: QueueOverlappingRun,
+		}, func(ctx *Context) error {
+			runs.Add(1)
+			return nil
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		nextRun, ok := job.NextRun()
+		assert.True(t, ok)
+		assert.Equal(t, start.Add(time.Minute), nextRun)
+
+		clock.Advance(59 * time.Second)
+		assert.Zero(t, job.RunCount())
+
+		clock.Advance(time.Second)
+		assert.Equal(t, 1, job.RunCount())
+
+		clock.Advance(10 * time.Minute)
+
+		assert.Eventually(t, func() bool {
+			return runs.Load() == 11
+		}, time.Second, time.Millisecond)
+		assert.Equal(t, 11, job.RunCount())
+		assert.Zero(t, job.SkippedCount())
+	})
+
+	t.Run("cron", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		clock := NewVirtualClock(start)
+		scheduler := NewScheduler(ctx, SchedulerConfig{Clock: clock})
+
+		expr, _ := ParseCronExpression("0 */6 * * *")
+		job, err := scheduler.ScheduleFunc(JobSpec{Trigger: expr, Overlap: QueueOverlappingRun}, func(ctx *Context) error {
+			return nil
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		clock.Advance(24 * time.Hour)
+		assert.Eventually(t, func() bool {
+			return job.RunCount() == 4
+		}, time.Second, time.Millisecond)
+
+		nextRun, _ := job.NextRun()
+		assert.Equal(t, start.Add(30*time.Hour), nextRun)
+	})
+
+	t.Run("at", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		clock := NewVirtualClock(start)
+		scheduler := NewScheduler(ctx, SchedulerConfig{Clock: clock})
+
+		job, err := scheduler.ScheduleFunc(JobSpec{Trigger: AtTrigger{Time: start.Add(time.Hour)}}, func(ctx *Context) error {
+			return nil
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		clock.Advance(2 * time.Hour)
+		assert.Equal(t, 1, job.RunCount())
+
+		_, ok := job.NextRun()
+		assert.False(t, ok)
+
+		_, err = scheduler.ScheduleFunc(JobSpec{Trigger: AtTrigger{Time: start}}, func(ctx *Context) error {
+			return nil
+		})
+		assert.ErrorIs(t, err, ErrScheduleTimeInPast)
+	})
+
+	t.Run("overlap: skip", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		clock := NewVirtualClock(start)
+		scheduler := NewScheduler(ctx, SchedulerConfig{Clock: clock})
+
+		run, release := blockingRun()
+		job, _ := scheduler.ScheduleFunc(JobSpec{Trigger: IntervalTrigger{Interval: time.Minute}}, run)
+
+		clock.Advance(3 * time.Minute)
+		assert.Equal(t, 1, job.RunCount())
+		assert.Equal(t, 2, job.SkippedCount())
+
+		close(release)
+		assert.Eventually(t, func() bool {
+			return !job.IsRunning()
+		}, time.Second, time.Millisecond)
+
+		clock.Advance(time.Minute)
+		assert.Equal(t, 2, job.RunCount())
+	})
+
+	t.Run("overlap: queue", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		clock := NewVirtualClock(start)
+		scheduler := NewScheduler(ctx, SchedulerConfig{Clock: clock})
+
+		run, release := blockingRun()
+		job, _ := scheduler.ScheduleFunc(JobSpec{
+			Trigger: IntervalTrigger{Interval: time.Minute},
+			Overlap: QueueOverlappingRun,
+		}, run)
+
+		clock.Advance(3 * time.Minute)
+		assert.Equal(t, 1, job.RunCount())
+		assert.Zero(t, job.SkippedCount())
+
+		close(release)
+
+		assert.Eventually(t, func() bool {
+			return job.RunCount() == 3 && !job.IsRunning()
+		}, time.Second, time.Millisecond)
+	})
+
+	t.Run("overlap: cancel previous", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		clock := NewVirtualClock(start)
+		scheduler := NewScheduler(ctx, SchedulerConfig{Clock: clock})
+
+		cancelledRuns := &atomic.Int64{}
+		job, _ := scheduler.ScheduleFunc(JobSpec{
+			Trigger: IntervalTrigger{Interval: time.Minute},
+			Overlap: CancelPreviousRunOnOverlap,
+		}, func(ctx *Context) error {
+			<-ctx.Done()
+			cancelledRuns.Add(1)
+			return ctx.Err()
+		})
+
+		clock.Advance(3 * time.Minute)
+		assert.Equal(t, 3, job.RunCount())
+		assert.EqualValues(t, 2, cancelledRuns.Load())
+		assert.True(t, job.IsRunning())
+	})
+
+	t.Run("overlap: the job should not be locked while waiting for the cancelled run", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		clock := NewVirtualClock(start)
+		scheduler := NewScheduler(ctx, SchedulerConfig{Clock: clock})
+
+		job, _ := scheduler.ScheduleFunc(JobSpec{
+			Trigger: IntervalTrigger{Interval: time.Minute},
+			Overlap: CancelPreviousRunOnOverlap,
+		}, func(ctx *Context) error {
+			//the cancellation is ignored.
+			time.Sleep(300 * time.Millisecond)
+			return nil
+		})
+
+		clock.Advance(time.Minute)
+		assert.Equal(t, 1, job.RunCount())
+
+		//the activation waits for the first run to finish.
+		go clock.Advance(time.Minute)
+		time.Sleep(20 * time.Millisecond)
+
+		callStart := time.Now()
+		job.SkippedCount()
+		assert.Less(t, time.Since(callStart), 100*time.Millisecond)
+
+		assert.Eventually(t, func() bool {
+			return job.RunCount() == 2
+		}, time.Second, time.Millisecond)
+	})
+
+	t.Run("runs should have the capabilities of the scheduler's context", func(t *testing.T) {
+		perms := []Permission{GlobalVarPermission{Kind_: permbase.Read, Name: "*"}}
+
+		issuerCtx := NewContextWithEmptyState(ContextConfig{Permissions: perms}, nil)
+		defer issuerCtx.CancelGracefully()
+
+		capability, err := NewCapability(issuerCtx, perms, CapabilityConfig{})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		ctx := NewContextWithEmptyState(ContextConfig{Capabilities: []*Capability{capability}}, nil)
+		defer ctx.CancelGracefully()
+
+		clock := NewVirtualClock(start)
+		scheduler := NewScheduler(ctx, SchedulerConfig{Clock: clock})
+
+		runCapabilities := make(chan []*Capability, 1)
+		scheduler.ScheduleFunc(JobSpec{Trigger: AtTrigger{Time: start.Add(time.Minute)}}, func(ctx *Context) error {
+			runCapabilities <- ctx.GetCapabilities()
+			return nil
+		})
+
+		clock.Advance(time.Minute)
+
+		select {
+		case capabilities := <-runCapabilities:
+			assert.Equal(t, []*Capability{capability}, capabilities)
+		case <-time.After(time.Second):
+			assert.FailNow(t, "timeout")
+		}
+	})
+
+	t.Run("jitter", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		clock := NewVirtualClock(start)
+		scheduler := NewScheduler(ctx, SchedulerConfig{Clock: clock})
+
+		job, _ := scheduler.ScheduleFunc(JobSpec{
+			Trigger: IntervalTrigger{Interval: time.Minute},
+			Jitter:  10 * time.Second,
+		}, func(ctx *Context) error {
+			return nil
+		})
+
+		clock.Advance(time.Minute - time.Second)
+		assert.Zero(t, job.RunCount())
+
+		clock.Advance(11 * time.Second)
+		assert.Equal(t, 1, job.RunCount())
+
+		//the jitter should not affect the activation times.
+		nextRun, _ := job.NextRun()
+		assert.Equal(t, start.Add(2*time.Minute), nextRun)
+	})
+
+	t.Run("the error of the last run should be available", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		clock := NewVirtualClock(start)
+		scheduler := NewScheduler(ctx, SchedulerConfig{Clock: clock})
+
+		job, _ := scheduler.ScheduleFunc(JobSpec{Trigger: IntervalTrigger{Interval: time.Minute}}, func(ctx *Context) error {
+			return errors.New("failure")
+		})
+
+		clock.Advance(time.Minute)
+
+		assert.Eventually(t, func() bool {
+			err := job.LastError()
+			return err != nil && err.Error() == "failure"
+		}, time.Second, time.Millisecond)
+	})
+
+	t.Run("listing and cancellation", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		clock := NewVirtualClock(start)
+		scheduler := NewScheduler(ctx, SchedulerConfig{Clock: clock})
+
+		run, _ := blockingRun()
+		job1, _ := scheduler.ScheduleFunc(JobSpec{Name: "a", Trigger: IntervalTrigger{Interval: time.Minute}}, run)
+		job2, _ := scheduler.ScheduleFunc(JobSpec{Trigger: IntervalTrigger{Interval: time.Minute}, Overlap: QueueOverlappingRun}, func(ctx *Context) error {
+			return nil
+		})
+
+		assert.Equal(t, "a", job1.Name())
+		assert.Equal(t, "job-2", job2.Name())
+		assert.Equal(t, []*ScheduledJob{job1, job2}, scheduler.Jobs())
+
+		clock.Advance(time.Minute)
+		assert.True(t, job1.IsRunning())
+
+		//the current run should be cancelled.
+		job1.Cancel(ctx)
+		assert.False(t, job1.IsRunning())
+		assert.Equal(t, []*ScheduledJob{job2}, scheduler.Jobs())
+
+		clock.Advance(time.Minute)
+		assert.Equal(t, 1, job1.RunCount())
+		assert.Eventually(t, func() bool {
+			return job2.RunCount() == 2
+		}, time.Second, time.Millisecond)
+	})
+
+	t.Run("graceful teardown of the context should stop the scheduler", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		clock := NewVirtualClock(start)
+		scheduler := NewScheduler(ctx, SchedulerConfig{Clock: clock})
+
+		run, _ := blockingRun()
+		job, _ := scheduler.ScheduleFunc(JobSpec{Trigger: IntervalTrigger{Interval: time.Minute}}, run)
+
+		clock.Advance(time.Minute)
+		assert.True(t, job.IsRunning())
+
+		ctx.CancelGracefully()
+
+		assert.False(t, job.IsRunning())
+		assert.Empty(t, scheduler.Jobs())
+
+		_, err := scheduler.ScheduleFunc(JobSpec{Trigger: IntervalTrigger{Interval: time.Minute}}, run)
+		assert.ErrorIs(t, err, ErrSchedulerStopped)
+	})
+
+	t.Run("invalid specification", func(t *testing.T) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		scheduler := NewScheduler(ctx, SchedulerConfig{Clock: NewVirtualClock(start)})
+
+		fn := &InoxFunction{}
+
+		_, err := scheduler.Schedule(ctx, NewRecordFromMap(ValMap{}), fn)
+		assert.ErrorIs(t, err, ErrInvalidJobSpec)
+
+		_, err = scheduler.Schedule(ctx, NewRecordFromMap(ValMap{
+			JOB_SPEC__EVERY_PROPNAME: Duration(time.Minute),
+			JOB_SPEC__CRON_PROPNAME:  String("* * * * *"),
+		}), fn)
+		assert.ErrorIs(t, err, ErrInvalidJobSpec)
+
+		_, err = scheduler.Schedule(ctx, NewRecordFromMap(ValMap{JOB_SPEC__CRON_PROPNAME: String("* *")}), fn)
+		assert.ErrorIs(t, err, ErrInvalidCronExpression)
+
+		_, err = scheduler.Schedule(ctx, NewRecordFromMap(ValMap{JOB_SPEC__EVERY_PROPNAME: Duration(time.Minute)}), Int(1))
+		assert.ErrorIs(t, err, ErrNotSchedulableValue)
+	})
+}
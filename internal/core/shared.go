@@ -11,7 +11,8 @@ const SYNC_CHAN_SIZE = 100
 var (
 	_ = []PotentiallySharable{
 		(*Object)(nil), (*InoxFunction)(nil), (*GoFunction)(nil), (*Mapping)(nil),
-		(*RingBuffer)(nil), (*ValueHistory)(nil),
+		(*RingBuffer)(nil), (*ValueHistory)(nil), (*Channel)(nil), (*Supervisor)(nil),
+		(*Scheduler)(nil), (*ScheduledJob)(nil),
 	}
 
 	ErrValueNotSharableNorClonable = errors.New("value is not sharable nor clonable")
@@ -184,6 +184,27 @@ func CheckManifestObject(args ManifestStaticCheckArguments) {
 					onError(n, text.FmtForbiddenNodeInLimitsSection(n))
 				}
 
+				return ast.ContinueTraversal, nil
+			}, nil)
+		case inoxconsts.MANIFEST_RETRY_POLICY_SECTION_NAME:
+			record, ok := p.Value.(*ast.RecordLiteral)
+
+			if !ok {
+				onError(p, text.RETRY_POLICY_SECTION_SHOULD_BE_A_RECORD)
+				continue
+			}
+
+			ast.Walk(record, func(node, parent, scopeNode ast.Node, ancestorChain []ast.Node, after bool) (ast.TraversalAction, error) {
+				if node == record {
+					return ast.ContinueTraversal, nil
+				}
+
+				switch n := node.(type) {
+				case *ast.ObjectProperty, ast.SimpleValueLiteral:
+				default:
+					onError(n, text.FmtForbiddenNodeInRetryPolicySection(n))
+				}
+
 				return ast.ContinueTraversal, nil
 			}, nil)
 		case inoxconsts.MANIFEST_ENV_SECTION_NAME:
@@ -0,0 +1,693 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/inoxlang/inox/internal/commonfmt"
+	"github.com/inoxlang/inox/internal/core/symbolic"
+	"github.com/inoxlang/inox/internal/sourcecode"
+	utils "github.com/inoxlang/inox/internal/utils/common"
+	"github.com/rs/zerolog"
+)
+
+const (
+	DEFAULT_SUPERVISOR_MAX_RESTARTS   = 3
+	DEFAULT_SUPERVISOR_RESTART_PERIOD = 5 * time.Second
+	SUPERVISOR_CHILD_SHUTDOWN_TIMEOUT = 5 * time.Second
+	MAX_SUPERVISOR_CRASH_REPORTS      = 100
+
+	SUPERVISOR_CONFIG__STRATEGY_PROPNAME     = "strategy"
+	SUPERVISOR_CONFIG__MAX_RESTARTS_PROPNAME = "max-restarts"
+	SUPERVISOR_CONFIG__PERIOD_PROPNAME       = "period"
+
+	SUPERVISED_CHILD_OPTIONS__NAME_PROPNAME    = "name"
+	SUPERVISED_CHILD_OPTIONS__RESTART_PROPNAME = "restart"
+)
+
+var (
+	SUPERVISOR_PROPNAMES   = []string{"add", "wait", "shutdown", "crashes"}
+	CRASH_REPORT_PROPNAMES = []string{"child", "error", "stack", "time"}
+
+	ErrRestartIntensityExceeded = errors.New("restart intensity of supervisor exceeded")
+	ErrSupervisorStopped        = errors.New("supervisor is stopped")
+	ErrAlreadySupervisedChild   = errors.New("child is already supervised")
+	ErrInvalidSupervisorConfig  = errors.New("invalid supervisor configuration")
+)
+
+func init() {
+	RegisterSymbolicGoFunction(NewSupervisorFromRecord, func(ctx *symbolic.Context, desc *symbolic.OptionalParam[*symbolic.Record]) *symbolic.Supervisor {
+		if desc.Value != nil && !symbolic.SUPERVISOR_CONFIG_RECORD.Test(*desc.Value, symbolic.RecTestCallState{}) {
+			ctx.AddSymbolicGoFunctionErrorf("the configuration of the supervisor should match %s", symbolic.Stringify(symbolic.SUPERVISOR_CONFIG_RECORD))
+		}
+		return symbolic.ANY_SUPERVISOR
+	})
+}
+
+type SupervisionStrategy int
+
+const (
+	OneForOne SupervisionStrategy = iota //only the exited child is restarted
+	OneForAll                            //all the children are restarted when a child exits
+)
+
+func (s SupervisionStrategy) String() string {
+	switch s {
+	case OneForOne:
+		return "one-for-one"
+	case OneForAll:
+		return "one-for-all"
+	}
+	return "unknown"
+}
+
+// ChildRestartPolicy determines if a supervised child is restarted when it exits.
+type ChildRestartPolicy int
+
+const (
+	TransientChild ChildRestartPolicy = iota //restarted only if it crashes
+	PermanentChild                           //always restarted
+	TemporaryChild                           //never restarted
+)
+
+func (p ChildRestartPolicy) String() string {
+	switch p {
+	case TransientChild:
+		return "transient"
+	case PermanentChild:
+		return "permanent"
+	case TemporaryChild:
+		return "temporary"
+	}
+	return "unknown"
+}
+
+type SupervisorConfig struct {
+	Strategy SupervisionStrategy
+
+	//Restart intensity: if more than MaxRestarts restarts happen during Period the supervisor stops its children
+	//and stops with ErrRestartIntensityExceeded.
+	MaxRestarts *int          //defaults to DEFAULT_SUPERVISOR_MAX_RESTARTS if nil, zero means that children are never restarted
+	Period      time.Duration //defaults to DEFAULT_SUPERVISOR_RESTART_PERIOD if zero
+
+	//(optional) function called with the supervisor locked each time a child crashes, it should not call
+	//the methods of the supervisor.
+	OnCrash func(report *CrashReport)
+}
+
+// A Supervisor owns a set of lthreads and supervisors (children) and restarts them according to a strategy when they exit.
+// If too many restarts happen during a period of time (restart intensity) the supervisor stops its children and stops
+// itself: a stopped supervisor is considered as crashed by its parent supervisor, which restarts it. Supervisors can
+// therefore be organized in trees. The children are stopped in the reverse order of their addition when the supervisor
+// is stopped, this is also the case when the context of the supervisor is gracefully teared down.
+// A Supervisor is always shared.
+type Supervisor struct {
+	ctx    *Context
+	config SupervisorConfig
+	logger zerolog.Logger
+
+	lock            sync.Mutex
+	children        []*supervisedChild
+	addedChildCount int
+	restarts        []time.Time //times of the restarts in the current period
+	crashes         []*CrashReport
+	stopped         bool
+	err             error         //error that caused the supervisor to stop
+	done            chan struct{} //closed when the supervisor is stopped
+	supervised      bool          //true if the supervisor is the child of another supervisor
+}
+
+type supervisedChild struct {
+	name       string
+	restart    ChildRestartPolicy
+	lthread    *LThread    //nil if the child is a supervisor
+	supervisor *Supervisor //nil if the child is a lthread
+
+	//generation is incremented each time the child is started or stopped by the supervisor, it allows
+	//the monitoring goroutines to ignore the exits caused by the supervisor.
+	generation int
+}
+
+// NewSupervisor creates a supervisor with no children, the supervisor is stopped when ctx is gracefully teared down.
+func NewSupervisor(ctx *Context, config SupervisorConfig) (*Supervisor, error) {
+	if (config.MaxRestarts != nil && *config.MaxRestarts < 0) || config.Period < 0 {
+		return nil, fmt.Errorf("%w: the restart intensity should be positive", ErrInvalidSupervisorConfig)
+	}
+	if config.MaxRestarts == nil {
+		config.MaxRestarts = utils.New(DEFAULT_SUPERVISOR_MAX_RESTARTS)
+	}
+	if config.Period == 0 {
+		config.Period = DEFAULT_SUPERVISOR_RESTART_PERIOD
+	}
+
+	supervisor := &Supervisor{
+		ctx:    ctx,
+		config: config,
+		logger: ctx.Logger(),
+		done:   make(chan struct{}),
+	}
+
+	ctx.OnGracefulTearDown(func(ctx *Context) error {
+		supervisor.lock.Lock()
+		defer supervisor.lock.Unlock()
+
+		if !supervisor.stopped {
+			supervisor.stopNoLock(nil)
+		}
+		return nil
+	})
+
+	return supervisor, nil
+}
+
+// NewSupervisorFromRecord is the Inox constructor for supervisors, the optional configuration is a record such as:
+//
+//	#{strategy: "one-for-all", max-restarts: 5, period: 10s}
+//
+// All properties are optional, the strategy is either "one-for-one" (default) or "one-for-all".
+func NewSupervisorFromRecord(ctx *Context, desc *OptionalParam[*Record]) (*Supervisor, error) {
+	config := SupervisorConfig{}
+
+	if desc != nil {
+		err := desc.Value.ForEachEntry(func(k string, v Value) error {
+			switch k {
+			case SUPERVISOR_CONFIG__STRATEGY_PROPNAME:
+				str, ok := v.(StringLike)
+				if !ok {
+					return commonfmt.FmtPropOfArgXShouldBeY(k, "configuration", "a string")
+				}
+				switch str.GetOrBuildString() {
+				case OneForOne.String():
+					config.Strategy = OneForOne
+				case OneForAll.String():
+					config.Strategy = OneForAll
+				default:
+					return commonfmt.FmtPropOfArgXShouldBeY(k, "configuration", `"one-for-one" or "one-for-all"`)
+				}
+			case SUPERVISOR_CONFIG__MAX_RESTARTS_PROPNAME:
+				maxRestarts, ok := v.(Int)
+				if !ok {
+					return commonfmt.FmtPropOfArgXShouldBeY(k, "configuration", "an integer")
+				}
+				config.MaxRestarts = utils.New(int(maxRestarts))
+			case SUPERVISOR_CONFIG__PERIOD_PROPNAME:
+				period, ok := v.(Duration)
+				if !ok {
+					return commonfmt.FmtPropOfArgXShouldBeY(k, "configuration", "a duration")
+				}
+				config.Period = time.Duration(period)
+			default:
+				return commonfmt.FmtUnexpectedPropInArgX(k, "configuration")
+			}
+			return nil
+		})
+
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidSupervisorConfig, err)
+		}
+	}
+
+	return NewSupervisor(ctx, config)
+}
+
+// AddLThread adds a lthread to the supervised children, if name is empty a name is generated. Only the lthreads created
+// by SpawnLThread can be restarted.
+func (s *Supervisor) AddLThread(name string, lthread *LThread, restart ChildRestartPolicy) error {
+	if restart != TemporaryChild && lthread.spawnArgs == nil {
+		return ErrLThreadNotRespawnable
+	}
+	return s.addChild(&supervisedChild{name: name, restart: restart, lthread: lthread})
+}
+
+// AddSupervisor adds a supervisor to the supervised children, if name is empty a name is generated.
+func (s *Supervisor) AddSupervisor(name string, supervisor *Supervisor, restart ChildRestartPolicy) error {
+	if supervisor == s {
+		return errors.New("a supervisor cannot supervise itself")
+	}
+
+	supervisor.lock.Lock()
+	alreadySupervised := supervisor.supervised
+	supervisor.supervised = true
+	supervisor.lock.Unlock()
+
+	if alreadySupervised {
+		return ErrAlreadySupervisedChild
+	}
+
+	return s.addChild(&supervisedChild{name: name, restart: restart, supervisor: supervisor})
+}
+
+func (s *Supervisor) addChild(child *supervisedChild) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.stopped {
+		return ErrSupervisorStopped
+	}
+
+	for _, existing := range s.children {
+		if (child.lthread != nil && existing.lthread == child.lthread) || (child.supervisor != nil && existing.supervisor == child.supervisor) {
+			return ErrAlreadySupervisedChild
+		}
+	}
+
+	s.addedChildCount++
+	if child.name == "" {
+		child.name = fmt.Sprintf("child-%d", s.addedChildCount)
+	}
+
+	s.children = append(s.children, child)
+	s.monitorNoLock(child)
+	return nil
+}
+
+// Add is the Inox method adding a lthread or a supervisor to the children, the optional record can have a
+// .name and a .restart ("transient" (default), "permanent" or "temporary") properties.
+func (s *Supervisor) Add(ctx *Context, child Value, options *OptionalParam[*Record]) error {
+	name := ""
+	restart := TransientChild
+
+	if options != nil {
+		err := options.Value.ForEachEntry(func(k string, v Value) error {
+			switch k {
+			case SUPERVISED_CHILD_OPTIONS__NAME_PROPNAME:
+				str, ok := v.(StringLike)
+				if !ok {
+					return commonfmt.FmtPropOfArgXShouldBeY(k, "options", "a string")
+				}
+				name = str.GetOrBuildString()
+			case SUPERVISED_CHILD_OPTIONS__RESTART_PROPNAME:
+				str, ok := v.(StringLike)
+				if !ok {
+					return commonfmt.FmtPropOfArgXShouldBeY(k, "options", "a string")
+				}
+				switch str.GetOrBuildString() {
+				case TransientChild.String():
+					restart = TransientChild
+				case PermanentChild.String():
+					restart = PermanentChild
+				case TemporaryChild.String():
+					restart = TemporaryChild
+				default:
+					return commonfmt.FmtPropOfArgXShouldBeY(k, "options", `"transient", "permanent" or "temporary"`)
+				}
+			default:
+				return commonfmt.FmtUnexpectedPropInArgX(k, "options")
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	switch c := child.(type) {
+	case *LThread:
+		return s.AddLThread(name, c, restart)
+	case *Supervisor:
+		return s.AddSupervisor(name, c, restart)
+	default:
+		return fmt.Errorf("a supervised child should be a lthread or a supervisor, not a(n) %T", child)
+	}
+}
+
+// monitorNoLock starts a goroutine that waits for the current generation of the child to exit.
+func (s *Supervisor) monitorNoLock(child *supervisedChild) {
+	generation := child.generation
+	childDone := child.done()
+	supervisorDone := s.done
+
+	go func() {
+		select {
+		case <-childDone:
+			s.handleChildExit(child, generation)
+		case <-supervisorDone:
+		}
+	}()
+}
+
+func (s *Supervisor) handleChildExit(child *supervisedChild, generation int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.stopped || child.generation != generation {
+		//the child has been stopped by the supervisor.
+		return
+	}
+
+	err := child.err()
+	crashed := err != nil
+
+	if crashed {
+		s.reportCrashNoLock(child, err)
+	}
+
+	restart := child.restart == PermanentChild || (crashed && child.restart == TransientChild)
+	if !restart {
+		s.children = slices.DeleteFunc(s.children, func(c *supervisedChild) bool {
+			return c == child
+		})
+		return
+	}
+
+	now := time.Now()
+	s.restarts = slices.DeleteFunc(s.restarts, func(t time.Time) bool {
+		return now.Sub(t) > s.config.Period
+	})
+
+	if len(s.restarts) >= *s.config.MaxRestarts {
+		s.stopNoLock(fmt.Errorf("%w: more than %d restarts in %s", ErrRestartIntensityExceeded, *s.config.MaxRestarts, s.config.Period))
+		return
+	}
+	s.restarts = append(s.restarts, now)
+
+	var restartErr error
+
+	switch s.config.Strategy {
+	case OneForAll:
+		restartErr = s.restartAllChildrenNoLock()
+	default:
+		restartErr = s.startChildNoLock(child)
+	}
+
+	if restartErr != nil {
+		s.stopNoLock(fmt.Errorf("failed to restart child %q: %w", child.name, restartErr))
+	}
+}
+
+func (s *Supervisor) reportCrashNoLock(child *supervisedChild, err error) {
+	report := &CrashReport{
+		ChildName: child.name,
+		Error:     NewError(err, Nil),
+		Time:      time.Now(),
+	}
+
+	var locatedErr LocatedEvalError
+	if errors.As(err, &locatedErr) {
+		report.Stack = locatedErr.LocationStack()
+	}
+
+	if len(s.crashes) >= MAX_SUPERVISOR_CRASH_REPORTS {
+		s.crashes = slices.Delete(s.crashes, 0, 1)
+	}
+	s.crashes = append(s.crashes, report)
+
+	s.logger.Err(err).Str("child", child.name).Msg("a supervised child crashed")
+
+	if s.config.OnCrash != nil {
+		s.config.OnCrash(report)
+	}
+}
+
+// restartAllChildrenNoLock stops the children in the reverse order of their addition and starts them again,
+// the temporary children are not restarted. The lock is released while the children are stopping.
+func (s *Supervisor) restartAllChildrenNoLock() error {
+	stoppedChildren := s.stopChildrenNoLock()
+
+	if s.stopped {
+		//the supervisor has been stopped while its children were stopping.
+		return nil
+	}
+
+	s.children = slices.DeleteFunc(s.children, func(c *supervisedChild) bool {
+		return c.restart == TemporaryChild
+	})
+
+	for _, child := range s.children {
+		if !slices.Contains(stoppedChildren, child) {
+			//child added while the other children were stopping.
+			continue
+		}
+		if err := s.startChildNoLock(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Supervisor) startChildNoLock(child *supervisedChild) error {
+	child.generation++
+
+	if child.lthread != nil {
+		lthread, err := child.lthread.Respawn()
+		if err != nil {
+			return err
+		}
+		child.lthread = lthread
+	} else if err := child.supervisor.restart(); err != nil {
+		return err
+	}
+
+	s.monitorNoLock(child)
+	return nil
+}
+
+// stopChildrenNoLock gracefully stops the children in the reverse order of their addition and returns them, it waits
+// at most SUPERVISOR_CHILD_SHUTDOWN_TIMEOUT for each lthread to finish. The lock is released while the children are
+// stopping so the caller should check the state of the supervisor again after the call.
+func (s *Supervisor) stopChildrenNoLock() []*supervisedChild {
+	children := slices.Clone(s.children)
+	lthreads := make([]*LThread, len(children))
+
+	for i, child := range children {
+		child.generation++
+		lthreads[i] = child.lthread
+	}
+
+	s.lock.Unlock()
+	defer s.lock.Lock()
+
+	for i := len(children) - 1; i >= 0; i-- {
+		child := children[i]
+		lthread := lthreads[i]
+
+		if lthread == nil {
+			child.supervisor.Shutdown(nil)
+			continue
+		}
+
+		lthread.Context().CancelGracefully()
+
+		select {
+		case <-lthread.Finished():
+		case <-time.After(SUPERVISOR_CHILD_SHUTDOWN_TIMEOUT):
+			s.logger.Warn().Str("child", child.name).Msg("a supervised lthread did not finish in time after being stopped")
+		}
+	}
+
+	return children
+}
+
+// stopNoLock stops the children and the supervisor, the lock is released while the children are stopping.
+func (s *Supervisor) stopNoLock(err error) {
+	s.stopped = true
+	s.err = err
+	done := s.done
+
+	s.stopChildrenNoLock()
+
+	close(done)
+}
+
+// restart restarts a stopped supervisor: the children that are not temporary are started again.
+func (s *Supervisor) restart() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if !s.stopped {
+		s.stopNoLock(nil)
+	}
+
+	s.stopped = false
+	s.err = nil
+	s.restarts = nil
+	s.done = make(chan struct{})
+
+	s.children = slices.DeleteFunc(s.children, func(c *supervisedChild) bool {
+		return c.restart == TemporaryChild
+	})
+
+	for _, child := range s.children {
+		if err := s.startChildNoLock(child); err != nil {
+			s.stopNoLock(err)
+			return err
+		}
+	}
+	return nil
+}
+
+// Shutdown gracefully stops the children in the reverse order of their addition and stops the supervisor,
+// it returns when all the children are stopped.
+func (s *Supervisor) Shutdown(*Context) {
+	s.lock.Lock()
+	done := s.done
+
+	if !s.stopped {
+		s.stopNoLock(nil)
+	}
+	s.lock.Unlock()
+
+	<-done
+}
+
+// Wait waits for the supervisor to stop, the error that caused the supervisor to stop is returned.
+func (s *Supervisor) Wait(ctx *Context) error {
+	done := s.Done()
+
+	err := ctx.DoIO(func() error {
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+
+	if err != nil {
+		return err
+	}
+	return s.Err()
+}
+
+// Done returns a channel that is closed when the supervisor stops.
+func (s *Supervisor) Done() <-chan struct{} {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.done
+}
+
+// Err returns the error that caused the supervisor to stop, nil is returned if the supervisor is running
+// or has been shut down.
+func (s *Supervisor) Err() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.err
+}
+
+// Crashes returns the reports of the most recent crashes, from the oldest to the most recent.
+func (s *Supervisor) Crashes() []*CrashReport {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return slices.Clone(s.crashes)
+}
+
+// ChildLThread returns the current lthread of the child named name.
+func (s *Supervisor) ChildLThread(name string) (*LThread, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, child := range s.children {
+		if child.name == name && child.lthread != nil {
+			return child.lthread, true
+		}
+	}
+	return nil, false
+}
+
+func (s *Supervisor) GetGoMethod(name string) (*GoFunction, bool) {
+	switch name {
+	case "add":
+		return WrapGoMethod(s.Add), true
+	case "wait":
+		return WrapGoMethod(s.Wait), true
+	case "shutdown":
+		return WrapGoMethod(s.Shutdown), true
+	}
+	return nil, false
+}
+
+func (s *Supervisor) Prop(ctx *Context, name string) Value {
+	switch name {
+	case "crashes":
+		crashes := s.Crashes()
+		reports := make([]Value, len(crashes))
+		for i, report := range crashes {
+			reports[i] = report
+		}
+		return NewArrayFrom(reports...)
+	}
+	method, ok := s.GetGoMethod(name)
+	if !ok {
+		panic(FormatErrPropertyDoesNotExist(name, s))
+	}
+	return method
+}
+
+func (*Supervisor) SetProp(ctx *Context, name string, value Value) error {
+	return ErrCannotSetProp
+}
+
+func (*Supervisor) PropertyNames(ctx *Context) []string {
+	return SUPERVISOR_PROPNAMES
+}
+
+func (s *Supervisor) IsSharable(originState *GlobalState) (bool, string) {
+	return true, ""
+}
+
+func (s *Supervisor) Share(originState *GlobalState) {
+	//ok
+}
+
+func (s *Supervisor) IsShared() bool {
+	return true
+}
+
+func (s *Supervisor) SmartLock(state *GlobalState) {
+	//no-op
+}
+
+func (s *Supervisor) SmartUnlock(state *GlobalState) {
+	//no-op
+}
+
+func (c *supervisedChild) done() <-chan struct{} {
+	if c.lthread != nil {
+		return c.lthread.Finished()
+	}
+	return c.supervisor.Done()
+}
+
+func (c *supervisedChild) err() error {
+	if c.lthread != nil {
+		return c.lthread.Err()
+	}
+	return c.supervisor.Err()
+}
+
+// A CrashReport describes the crash of a supervised child, CrashReport implements Value and is immutable.
+type CrashReport struct {
+	ChildName string
+	Error     Error
+	Stack     sourcecode.PositionStack //empty if the location of the error is unknown
+	Time      time.Time
+}
+
+func (r *CrashReport) GetGoMethod(name string) (*GoFunction, bool) {
+	return nil, false
+}
+
+func (r *CrashReport) Prop(ctx *Context, name string) Value {
+	switch name {
+	case "child":
+		return String(r.ChildName)
+	case "error":
+		return r.Error
+	case "stack":
+		return String(r.Stack.String())
+	case "time":
+		return DateTime(r.Time)
+	}
+	panic(FormatErrPropertyDoesNotExist(name, r))
+}
+
+func (*CrashReport) SetProp(ctx *Context, name string, value Value) error {
+	return ErrCannotSetProp
+}
+
+func (*CrashReport) PropertyNames(ctx *Context) []string {
+	return CRASH_REPORT_PROPNAMES
+}
@@ -0,0 +1,269 @@
+package core
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/inoxlang/inox/internal/core/inoxmod"
+	"github.com/inoxlang/inox/internal/core/limitbase"
+	"github.com/inoxlang/inox/internal/core/permbase"
+	"github.com/inoxlang/inox/internal/parse"
+	"github.com/inoxlang/inox/internal/sourcecode"
+	utils "github.com/inoxlang/inox/internal/utils/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSupervisor(t *testing.T) {
+
+	perms := []Permission{
+		GlobalVarPermission{Kind_: permbase.Read, Name: "*"},
+		GlobalVarPermission{Kind_: permbase.Use, Name: "*"},
+		GlobalVarPermission{Kind_: permbase.Create, Name: "*"},
+		LThreadPermission{permbase.Create},
+	}
+
+	limits := []Limit{
+		limitbase.MustMakeNotAutoDepletingCountLimit(limitbase.THREADS_SIMULTANEOUS_INSTANCES_LIMIT_NAME, 100_000),
+	}
+
+	//spawnCounted spawns a lthread executing code, the lthread has access to a run() function returning the number
+	//of times it has been called, a fail() function returning an error, a block() function waiting for the context
+	//of the lthread to be done and a sleep() function ignoring the cancellation of the context.
+	spawnCounted := func(t *testing.T, state *GlobalState, code string, runs *atomic.Int64) *LThread {
+		chunk := utils.Must(parse.ParseChunkSource(sourcecode.InMemorySource{
+			NameString: "lthread-test",
+			CodeString: code,
+		}))
+
+		lthread, err := SpawnLThread(LthreadSpawnArgs{
+			SpawnerState: state,
+			Globals: GlobalVariablesFromMap(map[string]Value{
+				"run": WrapGoFunction(func(ctx *Context) Int {
+					return Int(runs.Add(1))
+				}),
+				"fail": WrapGoFunction(func(ctx *Context) error {
+					return errors.New("failure")
+				}),
+				"block": WrapGoFunction(func(ctx *Context) {
+					<-ctx.Done()
+				}),
+				"sleep": WrapGoFunction(func(ctx *Context) {
+					time.Sleep(300 * time.Millisecond)
+				}),
+			}, nil),
+			Module: WrapLowerModule(&inoxmod.Module{
+				MainChunk:    chunk,
+				TopLevelNode: chunk.Node,
+				Kind:         UserLThreadModule,
+			}),
+		})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		return lthread
+	}
+
+	t.Run("one-for-one: a crashed child should be restarted", func(t *testing.T) {
+		state := NewGlobalState(NewContext(ContextConfig{Permissions: perms, Limits: limits}))
+		defer state.Ctx.CancelGracefully()
+
+		supervisor, err := NewSupervisor(state.Ctx, SupervisorConfig{MaxRestarts: utils.New(5), Period: time.Minute})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		runs := &atomic.Int64{}
+		lthread := spawnCounted(t, state, "if (run() < 3) { fail!() }; block()", runs)
+		assert.NoError(t, supervisor.AddLThread("worker", lthread, TransientChild))
+
+		assert.Eventually(t, func() bool {
+			return runs.Load() == 3
+		}, time.Second, time.Millisecond)
+
+		time.Sleep(10 * time.Millisecond)
+		assert.EqualValues(t, 3, runs.Load())
+
+		crashes := supervisor.Crashes()
+		if assert.Len(t, crashes, 2) {
+			assert.Equal(t, "worker", crashes[0].ChildName)
+			assert.ErrorContains(t, crashes[0].Error, "failure")
+		}
+		assert.NoError(t, supervisor.Err())
+	})
+
+	t.Run("a transient child exiting normally should not be restarted", func(t *testing.T) {
+		state := NewGlobalState(NewContext(ContextConfig{Permissions: perms, Limits: limits}))
+		defer state.Ctx.CancelGracefully()
+
+		supervisor, _ := NewSupervisor(state.Ctx, SupervisorConfig{})
+
+		runs := &atomic.Int64{}
+		lthread := spawnCounted(t, state, "run()", runs)
+		assert.NoError(t, supervisor.AddLThread("", lthread, TransientChild))
+
+		time.Sleep(50 * time.Millisecond)
+		assert.EqualValues(t, 1, runs.Load())
+	})
+
+	t.Run("a permanent child exiting normally should be restarted", func(t *testing.T) {
+		state := NewGlobalState(NewContext(ContextConfig{Permissions: perms, Limits: limits}))
+		defer state.Ctx.CancelGracefully()
+
+		supervisor, _ := NewSupervisor(state.Ctx, SupervisorConfig{MaxRestarts: utils.New(2), Period: time.Minute})
+
+		runs := &atomic.Int64{}
+		lthread := spawnCounted(t, state, "if (run() < 3) { return 0 }; block()", runs)
+		assert.NoError(t, supervisor.AddLThread("", lthread, PermanentChild))
+
+		assert.Eventually(t, func() bool {
+			return runs.Load() == 3
+		}, time.Second, time.Millisecond)
+		assert.Empty(t, supervisor.Crashes())
+	})
+
+	t.Run("the supervisor should stop if the restart intensity is exceeded", func(t *testing.T) {
+		state := NewGlobalState(NewContext(ContextConfig{Permissions: perms, Limits: limits}))
+		defer state.Ctx.CancelGracefully()
+
+		supervisor, _ := NewSupervisor(state.Ctx, SupervisorConfig{MaxRestarts: utils.New(2), Period: time.Minute})
+
+		runs := &atomic.Int64{}
+		blockingRuns := &atomic.Int64{}
+		assert.NoError(t, supervisor.AddLThread("blocking", spawnCounted(t, state, "run(); block()", blockingRuns), TransientChild))
+		assert.NoError(t, supervisor.AddLThread("crashing", spawnCounted(t, state, "run(); fail!()", runs), TransientChild))
+
+		err := supervisor.Wait(state.Ctx)
+		assert.ErrorIs(t, err, ErrRestartIntensityExceeded)
+		assert.EqualValues(t, 3, runs.Load())
+		assert.Len(t, supervisor.Crashes(), 3)
+
+		//the other children should have been stopped.
+		lthread, _ := supervisor.ChildLThread("blocking")
+		assert.True(t, lthread.IsDone())
+	})
+
+	t.Run("children should never be restarted if the maximum number of restarts is zero", func(t *testing.T) {
+		state := NewGlobalState(NewContext(ContextConfig{Permissions: perms, Limits: limits}))
+		defer state.Ctx.CancelGracefully()
+
+		supervisor, _ := NewSupervisor(state.Ctx, SupervisorConfig{MaxRestarts: utils.New(0)})
+
+		runs := &atomic.Int64{}
+		assert.NoError(t, supervisor.AddLThread("", spawnCounted(t, state, "run(); fail!()", runs), PermanentChild))
+
+		err := supervisor.Wait(state.Ctx)
+		assert.ErrorIs(t, err, ErrRestartIntensityExceeded)
+		assert.EqualValues(t, 1, runs.Load())
+	})
+
+	t.Run("the supervisor should not be locked while its children are stopping", func(t *testing.T) {
+		state := NewGlobalState(NewContext(ContextConfig{Permissions: perms, Limits: limits}))
+		defer state.Ctx.CancelGracefully()
+
+		supervisor, _ := NewSupervisor(state.Ctx, SupervisorConfig{})
+
+		runs := &atomic.Int64{}
+		lthread := spawnCounted(t, state, "run(); sleep()", runs)
+		assert.NoError(t, supervisor.AddLThread("", lthread, TemporaryChild))
+
+		assert.Eventually(t, func() bool {
+			return runs.Load() == 1
+		}, time.Second, time.Millisecond)
+
+		go supervisor.Shutdown(state.Ctx)
+
+		start := time.Now()
+		assert.Eventually(t, func() bool {
+			return supervisor.Err() == nil && !lthread.IsDone()
+		}, time.Second, time.Millisecond)
+		assert.Less(t, time.Since(start), 100*time.Millisecond)
+
+		<-supervisor.Done()
+		assert.True(t, lthread.IsDone())
+	})
+
+	t.Run("one-for-all: all the children should be restarted", func(t *testing.T) {
+		state := NewGlobalState(NewContext(ContextConfig{Permissions: perms, Limits: limits}))
+		defer state.Ctx.CancelGracefully()
+
+		supervisor, _ := NewSupervisor(state.Ctx, SupervisorConfig{Strategy: OneForAll, MaxRestarts: utils.New(5), Period: time.Minute})
+
+		blockingRuns := &atomic.Int64{}
+		crashingRuns := &atomic.Int64{}
+		assert.NoError(t, supervisor.AddLThread("blocking", spawnCounted(t, state, "run(); block()", blockingRuns), TransientChild))
+		assert.NoError(t, supervisor.AddLThread("crashing", spawnCounted(t, state, "if (run() < 2) { fail!() }; block()", crashingRuns), TransientChild))
+
+		assert.Eventually(t, func() bool {
+			return blockingRuns.Load() == 2 && crashingRuns.Load() == 2
+		}, time.Second, time.Millisecond)
+		assert.Len(t, supervisor.Crashes(), 1)
+	})
+
+	t.Run("nested supervisor", func(t *testing.T) {
+		state := NewGlobalState(NewContext(ContextConfig{Permissions: perms, Limits: limits}))
+		defer state.Ctx.CancelGracefully()
+
+		parent, _ := NewSupervisor(state.Ctx, SupervisorConfig{MaxRestarts: utils.New(5), Period: time.Minute})
+		child, _ := NewSupervisor(state.Ctx, SupervisorConfig{MaxRestarts: utils.New(1), Period: time.Minute})
+
+		runs := &atomic.Int64{}
+		assert.NoError(t, child.AddLThread("", spawnCounted(t, state, "if (run() < 3) { fail!() }; block()", runs), TransientChild))
+		assert.NoError(t, parent.AddSupervisor("child", child, TransientChild))
+		assert.ErrorIs(t, parent.AddSupervisor("child", child, TransientChild), ErrAlreadySupervisedChild)
+
+		//the child supervisor stops after the second crash and is restarted by its parent.
+		assert.Eventually(t, func() bool {
+			return runs.Load() == 3
+		}, time.Second, time.Millisecond)
+
+		assert.Len(t, parent.Crashes(), 1)
+		assert.NoError(t, child.Err())
+	})
+
+	t.Run("graceful teardown of the context should stop the children", func(t *testing.T) {
+		state := NewGlobalState(NewContext(ContextConfig{Permissions: perms, Limits: limits}))
+		defer state.Ctx.CancelGracefully()
+
+		supervisor, _ := NewSupervisor(state.Ctx, SupervisorConfig{})
+
+		runs := &atomic.Int64{}
+		lthread := spawnCounted(t, state, "run(); block()", runs)
+		assert.NoError(t, supervisor.AddLThread("", lthread, PermanentChild))
+
+		assert.Eventually(t, func() bool {
+			return runs.Load() == 1
+		}, time.Second, time.Millisecond)
+
+		state.Ctx.CancelGracefully()
+
+		assert.True(t, lthread.IsDone())
+		assert.NoError(t, supervisor.Err())
+		assert.ErrorIs(t, supervisor.AddLThread("", lthread, PermanentChild), ErrSupervisorStopped)
+		assert.EqualValues(t, 1, runs.Load())
+	})
+
+	t.Run("constructor from record", func(t *testing.T) {
+		state := NewGlobalState(NewContext(ContextConfig{Permissions: perms, Limits: limits}))
+		defer state.Ctx.CancelGracefully()
+
+		supervisor, err := NewSupervisorFromRecord(state.Ctx, &OptionalParam[*Record]{
+			Value: NewRecordFromMap(ValMap{
+				SUPERVISOR_CONFIG__STRATEGY_PROPNAME:     String("one-for-all"),
+				SUPERVISOR_CONFIG__MAX_RESTARTS_PROPNAME: Int(10),
+			}),
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, OneForAll, supervisor.config.Strategy)
+		assert.Equal(t, 10, *supervisor.config.MaxRestarts)
+		assert.Equal(t, DEFAULT_SUPERVISOR_RESTART_PERIOD, supervisor.config.Period)
+
+		_, err = NewSupervisorFromRecord(state.Ctx, &OptionalParam[*Record]{
+			Value: NewRecordFromMap(ValMap{SUPERVISOR_CONFIG__STRATEGY_PROPNAME: String("rest-for-one")}),
+		})
+		assert.ErrorIs(t, err, ErrInvalidSupervisorConfig)
+	})
+}
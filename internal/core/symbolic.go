@@ -1374,6 +1374,14 @@ func (g *LThreadGroup) ToSymbolicValue(ctx *Context, encountered map[uintptr]sym
 	return &symbolic.LThreadGroup{}, nil
 }
 
+func (c *Capability) ToSymbolicValue(ctx *Context, encountered map[uintptr]symbolic.Value) (symbolic.Value, error) {
+	return symbolic.ANY_CAPABILITY, nil
+}
+
+func (p *RetryPolicy) ToSymbolicValue(ctx *Context, encountered map[uintptr]symbolic.Value) (symbolic.Value, error) {
+	return symbolic.ANY_RETRY_POLICY, nil
+}
+
 func (i FileInfo) ToSymbolicValue(ctx *Context, encountered map[uintptr]symbolic.Value) (symbolic.Value, error) {
 	return symbolic.ANY_FILEINFO, nil
 }
@@ -1587,10 +1595,53 @@ func (s *ConfluenceStream) ToSymbolicValue(ctx *Context, encountered map[uintptr
 	return symbolic.NewWritableStream(symbolic.ANY), nil
 }
 
+func (s *CSVRowStream) ToSymbolicValue(ctx *Context, encountered map[uintptr]symbolic.Value) (symbolic.Value, error) {
+	if s.reader.config.Pattern == nil {
+		return symbolic.NewReadableStream(symbolic.ANY_REC), nil
+	}
+	pattern, err := s.reader.config.Pattern.ToSymbolicValue(ctx, encountered)
+	if err != nil {
+		return nil, err
+	}
+	return symbolic.NewReadableStream(pattern.(symbolic.Pattern).SymbolicValue()), nil
+}
+
 func (r *RingBuffer) ToSymbolicValue(ctx *Context, encountered map[uintptr]symbolic.Value) (symbolic.Value, error) {
 	return symbolic.ANY_RING_BUFFER, nil
 }
 
+func (c *Channel) ToSymbolicValue(ctx *Context, encountered map[uintptr]symbolic.Value) (symbolic.Value, error) {
+	element, err := c.elementPattern.ToSymbolicValue(ctx, encountered)
+	if err != nil {
+		return nil, err
+	}
+	return symbolic.NewChannel(element.(symbolic.Pattern).SymbolicValue()), nil
+}
+
+func (s *channelStream) ToSymbolicValue(ctx *Context, encountered map[uintptr]symbolic.Value) (symbolic.Value, error) {
+	element, err := s.channel.elementPattern.ToSymbolicValue(ctx, encountered)
+	if err != nil {
+		return nil, err
+	}
+	return symbolic.NewReadableStream(element.(symbolic.Pattern).SymbolicValue()), nil
+}
+
+func (s *Supervisor) ToSymbolicValue(ctx *Context, encountered map[uintptr]symbolic.Value) (symbolic.Value, error) {
+	return symbolic.ANY_SUPERVISOR, nil
+}
+
+func (r *CrashReport) ToSymbolicValue(ctx *Context, encountered map[uintptr]symbolic.Value) (symbolic.Value, error) {
+	return symbolic.ANY_CRASH_REPORT, nil
+}
+
+func (s *Scheduler) ToSymbolicValue(ctx *Context, encountered map[uintptr]symbolic.Value) (symbolic.Value, error) {
+	return symbolic.ANY_SCHEDULER, nil
+}
+
+func (j *ScheduledJob) ToSymbolicValue(ctx *Context, encountered map[uintptr]symbolic.Value) (symbolic.Value, error) {
+	return symbolic.ANY_SCHEDULED_JOB, nil
+}
+
 func (c *DataChunk) ToSymbolicValue(ctx *Context, encountered map[uintptr]symbolic.Value) (symbolic.Value, error) {
 	data, err := c.data.ToSymbolicValue(ctx, encountered)
 	if err != nil {
@@ -0,0 +1,83 @@
+package symbolic
+
+import (
+	pprint "github.com/inoxlang/inox/internal/prettyprint"
+)
+
+var (
+	CAPABILITY_PROPNAMES = []string{"revoke", "is-valid", "remaining-uses", "expiration"}
+
+	ANY_CAPABILITY = &Capability{}
+
+	_ = PotentiallySharable((*Capability)(nil))
+)
+
+// A Capability represents a symbolic Capability.
+type Capability struct {
+	UnassignablePropsMixin
+	_ int
+}
+
+func (c *Capability) Test(v Value, state RecTestCallState) bool {
+	state.StartCall()
+	defer state.FinishCall()
+
+	switch v.(type) {
+	case *Capability:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *Capability) WidestOfType() Value {
+	return ANY_CAPABILITY
+}
+
+func (c *Capability) IsSharable() (bool, string) {
+	return true, ""
+}
+
+func (c *Capability) Share(originState *State) PotentiallySharable {
+	return c
+}
+
+func (c *Capability) IsShared() bool {
+	return true
+}
+
+func (c *Capability) GetGoMethod(name string) (*GoFunction, bool) {
+	switch name {
+	case "revoke":
+		return WrapGoMethod(c.Revoke), true
+	}
+	return nil, false
+}
+
+func (c *Capability) Prop(name string) Value {
+	switch name {
+	case "is-valid":
+		return ANY_BOOL
+	case "remaining-uses":
+		return ANY_INT
+	case "expiration":
+		return NewMultivalue(ANY_DATETIME, Nil)
+	}
+	method, ok := c.GetGoMethod(name)
+	if !ok {
+		panic(FormatErrPropertyDoesNotExist(name, c))
+	}
+	return method
+}
+
+func (*Capability) PropertyNames() []string {
+	return CAPABILITY_PROPNAMES
+}
+
+func (c *Capability) Revoke(ctx *Context) *Error {
+	return nil
+}
+
+func (c *Capability) PrettyPrint(w pprint.PrettyPrintWriter, config *pprint.PrettyPrintConfig) {
+	w.WriteName("capability")
+}
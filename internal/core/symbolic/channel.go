@@ -0,0 +1,132 @@
+package symbolic
+
+import (
+	pprint "github.com/inoxlang/inox/internal/prettyprint"
+)
+
+var (
+	CHANNEL_PROPNAMES = []string{"send", "receive", "close", "capacity"}
+
+	ANY_CHANNEL = &Channel{}
+
+	_ = []StreamSource{(*Channel)(nil)}
+)
+
+// A Channel represents a symbolic Channel.
+type Channel struct {
+	UnassignablePropsMixin
+	element Value //if nil matches any
+}
+
+func NewChannel(element Value) *Channel {
+	return &Channel{element: element}
+}
+
+// Element returns the value matching the elements of the channel.
+func (c *Channel) Element() Value {
+	if c.element == nil {
+		return ANY
+	}
+	return c.element
+}
+
+func (c *Channel) Test(v Value, state RecTestCallState) bool {
+	state.StartCall()
+	defer state.FinishCall()
+
+	otherChannel, ok := v.(*Channel)
+	if !ok {
+		return false
+	}
+	if c.element == nil {
+		return true
+	}
+	return otherChannel.element != nil && c.element.Test(otherChannel.element, state)
+}
+
+func (c *Channel) PrettyPrint(w pprint.PrettyPrintWriter, config *pprint.PrettyPrintConfig) {
+	w.WriteName("channel")
+	if c.element != nil {
+		w.WriteString("(")
+		c.element.PrettyPrint(w.ZeroIndent(), config)
+		w.WriteString(")")
+	}
+}
+
+func (c *Channel) WidestOfType() Value {
+	return ANY_CHANNEL
+}
+
+func (c *Channel) IsSharable() (bool, string) {
+	return true, ""
+}
+
+func (c *Channel) Share(originState *State) PotentiallySharable {
+	return c
+}
+
+func (c *Channel) IsShared() bool {
+	return true
+}
+
+func (c *Channel) GetGoMethod(name string) (*GoFunction, bool) {
+	switch name {
+	case "send":
+		return WrapGoMethod(c.Send), true
+	case "receive":
+		return WrapGoMethod(c.Receive), true
+	case "close":
+		return WrapGoMethod(c.Close), true
+	}
+	return nil, false
+}
+
+func (c *Channel) Prop(name string) Value {
+	switch name {
+	case "capacity":
+		return ANY_INT
+	}
+	method, ok := c.GetGoMethod(name)
+	if !ok {
+		panic(FormatErrPropertyDoesNotExist(name, c))
+	}
+	return method
+}
+
+func (*Channel) PropertyNames() []string {
+	return CHANNEL_PROPNAMES
+}
+
+func (c *Channel) Send(ctx *Context, v Value) *Error {
+	if !c.Element().Test(v, RecTestCallState{}) {
+		ctx.AddSymbolicGoFunctionErrorf("the sent value should match the element of the channel (%s)", Stringify(c.Element()))
+	} else if ok, expl := IsSharableOrClonable(v); !ok {
+		ctx.AddSymbolicGoFunctionErrorf("the sent value is not sharable nor clonable: %s", expl)
+	}
+	return nil
+}
+
+func (c *Channel) Receive(ctx *Context) (Value, *Error) {
+	return c.Element(), nil
+}
+
+func (c *Channel) Close(ctx *Context) *Error {
+	return nil
+}
+
+func (c *Channel) StreamElement() Value {
+	return c.Element()
+}
+
+func (c *Channel) ChunkedStreamElement() Value {
+	return ANY
+}
+
+// JoinChannelElements returns the value matching the elements of all the channels.
+func JoinChannelElements(channels ...*Channel) Value {
+	elements := make([]Value, len(channels))
+	for i, channel := range channels {
+		elements[i] = channel.Element()
+	}
+	return joinValues(elements)
+}
@@ -694,6 +694,14 @@ func fmtGroupPropertyNotLThreadGroup(v Value) string {
 	return fmt.Sprintf("value of .group should be a lthread group, not a(n) %s", Stringify(v))
 }
 
+func fmtCapabilitiesPropertyNotCapabilities(v Value) string {
+	return fmt.Sprintf("value of .capabilities should be a capability or an array of capabilities, not a(n) %s", Stringify(v))
+}
+
+func fmtCPUBudgetPropertyNotDuration(v Value) string {
+	return fmt.Sprintf("value of .cpu-budget should be a duration, not a(n) %s", Stringify(v))
+}
+
 func fmtValueOfVarShouldBeAModuleNode(name string) string {
 	return fmt.Sprintf("%s should be a module node", name)
 }
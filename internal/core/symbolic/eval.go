@@ -4005,6 +4005,18 @@ func evalImportStatement(n *ast.ImportStatement, state *State) (_ Value, finalEr
 
 	hasImportedModuleParameters := len(expectedArgumentsObject.PropertyNames()) > 0
 
+	//The capabilities section is evaluated separately because objects cannot contain capabilities.
+	if capabilitiesNode, ok := objLit.PropValue(inoxconsts.IMPORT_CONFIG__CAPABILITIES_PROPNAME); ok {
+		capabilities, err := symbolicEval(capabilitiesNode, state)
+		if err != nil {
+			return nil, err
+		}
+		if !ANY_CAPABILITY.Test(capabilities, RecTestCallState{}) && !NewArrayOf(ANY_CAPABILITY).Test(capabilities, RecTestCallState{}) {
+			state.addError(MakeSymbolicEvalError(capabilitiesNode, state, fmtCapabilitiesPropertyNotCapabilities(capabilities)))
+		}
+		objLit = objLit.WithoutNamedProp(inoxconsts.IMPORT_CONFIG__CAPABILITIES_PROPNAME)
+	}
+
 	importConfig, err := _symbolicEval(objLit, state, evalOptions{
 		expectedValue: NewInexactObject(
 			map[string]Serializable{
@@ -4113,6 +4125,14 @@ func evalSpawnExpression(node *ast.SpawnExpression, state *State) (_ Value, fina
 				state.addError(MakeSymbolicEvalError(node.Meta, state, fmtGroupPropertyNotLThreadGroup(v)))
 			}
 		case LTHREAD_META_ALLOW_SECTION:
+		case LTHREAD_META_CAPABILITIES_SECTION:
+			if !ANY_CAPABILITY.Test(v, RecTestCallState{}) && !NewArrayOf(ANY_CAPABILITY).Test(v, RecTestCallState{}) {
+				state.addError(MakeSymbolicEvalError(node.Meta, state, fmtCapabilitiesPropertyNotCapabilities(v)))
+			}
+		case LTHREAD_META_CPU_BUDGET_SECTION:
+			if !ANY_DURATION.Test(v, RecTestCallState{}) {
+				state.addError(MakeSymbolicEvalError(node.Meta, state, fmtCPUBudgetPropertyNotDuration(v)))
+			}
 		default:
 			state.addWarning(makeSymbolicEvalWarning(node.Meta, state, fmtUnknownSectionInLThreadMetadata(k)))
 		}
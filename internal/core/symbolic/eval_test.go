@@ -13512,6 +13512,20 @@ func TestSymbolicEval(t *testing.T) {
 			assert.IsType(t, ANY_LTHREAD, res)
 		})
 
+		t.Run("provided CPU budget is not a duration", func(t *testing.T) {
+			n, state := MakeTestStateAndChunk(`
+				return go {cpu-budget: 1, globals: .{}} do { }
+			`)
+
+			res, err := symbolicEval(n, state)
+			obj := ast.FindNode(n, (*ast.ObjectLiteral)(nil), nil)
+			assert.NoError(t, err)
+			assert.Equal(t, []EvaluationError{
+				MakeSymbolicEvalError(obj, state, fmtCPUBudgetPropertyNotDuration(NewInt(1))),
+			}, state.errors())
+			assert.IsType(t, ANY_LTHREAD, res)
+		})
+
 		t.Run("error in embedded module", func(t *testing.T) {
 			n, state := MakeTestStateAndChunk(`
 				return go {globals: .{}} do { return (int + "a") }
@@ -15165,6 +15179,64 @@ func TestSymbolicEval(t *testing.T) {
 			assert.NotEmpty(t, state.errors())
 			assert.Equal(t, ANY_INT, res)
 		})
+
+		t.Run("capabilities section", func(t *testing.T) {
+			n, state := MakeTestStateAndChunk(`
+				manifest {}
+				import lib ./lib.ix {
+					capabilities: cap
+				}
+				return lib
+			`)
+			importStmt := ast.FindNode(n, (*ast.ImportStatement)(nil), nil)
+			state.Module.directlyImportedModules = map[*ast.ImportStatement]*Module{
+				importStmt: {
+					mainChunk: utils.Must(parse.ParseChunkSource(sourcecode.File{
+						NameString:  "/lib.ix",
+						Resource:    "/lib.ix",
+						ResourceDir: "/",
+						CodeString:  "manifest {}",
+					})),
+				},
+			}
+			state.setGlobal("cap", ANY_CAPABILITY, GlobalConst)
+
+			res, err := symbolicEval(n, state)
+
+			assert.NoError(t, err)
+			assert.Empty(t, state.errors())
+			assert.Equal(t, Nil, res)
+		})
+
+		t.Run("capabilities section that does not contain capabilities", func(t *testing.T) {
+			n, state := MakeTestStateAndChunk(`
+				manifest {}
+				import lib ./lib.ix {
+					capabilities: 1
+				}
+				return lib
+			`)
+			importStmt := ast.FindNode(n, (*ast.ImportStatement)(nil), nil)
+			state.Module.directlyImportedModules = map[*ast.ImportStatement]*Module{
+				importStmt: {
+					mainChunk: utils.Must(parse.ParseChunkSource(sourcecode.File{
+						NameString:  "/lib.ix",
+						Resource:    "/lib.ix",
+						ResourceDir: "/",
+						CodeString:  "manifest {}",
+					})),
+				},
+			}
+
+			res, err := symbolicEval(n, state)
+			intLit := ast.FindNode(importStmt, (*ast.IntLiteral)(nil), nil)
+
+			assert.NoError(t, err)
+			assert.Equal(t, []EvaluationError{
+				MakeSymbolicEvalError(intLit, state, fmtCapabilitiesPropertyNotCapabilities(NewInt(1))),
+			}, state.errors())
+			assert.Equal(t, Nil, res)
+		})
 	})
 
 	t.Run("inclusion import statement ", func(t *testing.T) {
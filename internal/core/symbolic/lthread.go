@@ -8,13 +8,22 @@ const (
 	LTHREAD_META_GROUP_SECTION   = "group"
 	LTHREAD_META_ALLOW_SECTION   = "allow"
 	LTHREAD_META_GLOBALS_SECTION = "globals"
+
+	//capabilities delegated to the lthread: a capability or an array of capabilities.
+	LTHREAD_META_CAPABILITIES_SECTION = "capabilities"
+
+	//pseudo CPU time budget of the lthread: a duration.
+	LTHREAD_META_CPU_BUDGET_SECTION = "cpu-budget"
 )
 
 var (
-	ROUTINE_PROPNAMES       = []string{"wait_result", "cancel", "steps"}
+	ROUTINE_PROPNAMES       = []string{"wait_result", "cancel", "steps", "cpu_time", "cpu_budget"}
 	ROUTINE_GROUP_PROPNAMES = []string{"wait_results", "cancel_all"}
 	EXECUTED_STEP_PROPNAMES = []string{"result", "end_time"}
-	LTHREAD_SECTION_NAMES   = []string{LTHREAD_META_ALLOW_SECTION, LTHREAD_META_GLOBALS_SECTION, LTHREAD_META_GROUP_SECTION}
+	LTHREAD_SECTION_NAMES   = []string{
+		LTHREAD_META_ALLOW_SECTION, LTHREAD_META_GLOBALS_SECTION, LTHREAD_META_GROUP_SECTION, LTHREAD_META_CAPABILITIES_SECTION,
+		LTHREAD_META_CPU_BUDGET_SECTION,
+	}
 
 	ANY_LTHREAD       = &LThread{}
 	ANY_LTHREAD_GROUP = &LThreadGroup{}
@@ -57,6 +66,10 @@ func (t *LThread) Prop(name string) Value {
 	switch name {
 	case "steps":
 		return NewArrayOf(&ExecutedStep{})
+	case "cpu_time":
+		return ANY_DURATION
+	case "cpu_budget":
+		return NewMultivalue(ANY_DURATION, Nil)
 	}
 	method, ok := t.GetGoMethod(name)
 	if !ok {
@@ -416,6 +416,14 @@ func (g *LThreadGroup) IsMutable() bool {
 	return true
 }
 
+func (p *RetryPolicy) IsMutable() bool {
+	return false
+}
+
+func (c *Capability) IsMutable() bool {
+	return true
+}
+
 func (fn *InoxFunction) IsMutable() bool {
 	return true
 }
@@ -525,6 +533,26 @@ func (r *RingBuffer) IsMutable() bool {
 	return true
 }
 
+func (c *Channel) IsMutable() bool {
+	return true
+}
+
+func (s *Supervisor) IsMutable() bool {
+	return true
+}
+
+func (r *CrashReport) IsMutable() bool {
+	return false
+}
+
+func (s *Scheduler) IsMutable() bool {
+	return true
+}
+
+func (j *ScheduledJob) IsMutable() bool {
+	return true
+}
+
 func (c *DataChunk) IsMutable() bool {
 	return true
 }
@@ -0,0 +1,49 @@
+package symbolic
+
+import (
+	pprint "github.com/inoxlang/inox/internal/prettyprint"
+)
+
+var (
+	ANY_RETRY_POLICY = &RetryPolicy{}
+
+	// RETRY_POLICY_RECORD is the type of the records describing retry policies, all entries are optional.
+	RETRY_POLICY_RECORD = NewExactRecord(map[string]Serializable{
+		"max-attempts":    ANY_INT,
+		"initial-backoff": ANY_DURATION,
+		"max-backoff":     ANY_DURATION,
+		"multiplier":      ANY_FLOAT,
+		"jitter":          ANY_FLOAT,
+		"attempt-timeout": ANY_DURATION,
+		"deadline":        ANY_DURATION,
+	}, map[string]struct{}{
+		"max-attempts":    {},
+		"initial-backoff": {},
+		"max-backoff":     {},
+		"multiplier":      {},
+		"jitter":          {},
+		"attempt-timeout": {},
+		"deadline":        {},
+	})
+)
+
+// A RetryPolicy represents a symbolic RetryPolicy.
+type RetryPolicy struct {
+	_ int
+}
+
+func (p *RetryPolicy) Test(v Value, state RecTestCallState) bool {
+	state.StartCall()
+	defer state.FinishCall()
+
+	_, ok := v.(*RetryPolicy)
+	return ok
+}
+
+func (p *RetryPolicy) PrettyPrint(w pprint.PrettyPrintWriter, config *pprint.PrettyPrintConfig) {
+	w.WriteName("retry-policy")
+}
+
+func (p *RetryPolicy) WidestOfType() Value {
+	return ANY_RETRY_POLICY
+}
@@ -0,0 +1,182 @@
+package symbolic
+
+import (
+	pprint "github.com/inoxlang/inox/internal/prettyprint"
+)
+
+var (
+	SCHEDULER_PROPNAMES     = []string{"schedule", "jobs", "stop"}
+	SCHEDULED_JOB_PROPNAMES = []string{"name", "next-run", "run-count", "skipped-count", "running", "last-error", "cancel"}
+
+	ANY_SCHEDULER     = &Scheduler{}
+	ANY_SCHEDULED_JOB = &ScheduledJob{}
+
+	// JOB_SPEC_RECORD is the type of the records describing scheduled jobs, the trigger (cron, every or at) is checked
+	// at runtime.
+	JOB_SPEC_RECORD = NewExactRecord(map[string]Serializable{
+		"name":    ANY_STRING,
+		"cron":    ANY_STRING,
+		"every":   ANY_DURATION,
+		"at":      ANY_DATETIME,
+		"overlap": ANY_STRING,
+		"jitter":  ANY_DURATION,
+	}, map[string]struct{}{
+		"name":    {},
+		"cron":    {},
+		"every":   {},
+		"at":      {},
+		"overlap": {},
+		"jitter":  {},
+	})
+)
+
+// A Scheduler represents a symbolic Scheduler.
+type Scheduler struct {
+	UnassignablePropsMixin
+	_ int
+}
+
+func (s *Scheduler) Test(v Value, state RecTestCallState) bool {
+	state.StartCall()
+	defer state.FinishCall()
+
+	_, ok := v.(*Scheduler)
+	return ok
+}
+
+func (s *Scheduler) PrettyPrint(w pprint.PrettyPrintWriter, config *pprint.PrettyPrintConfig) {
+	w.WriteName("scheduler")
+}
+
+func (s *Scheduler) WidestOfType() Value {
+	return ANY_SCHEDULER
+}
+
+func (s *Scheduler) IsSharable() (bool, string) {
+	return true, ""
+}
+
+func (s *Scheduler) Share(originState *State) PotentiallySharable {
+	return s
+}
+
+func (s *Scheduler) IsShared() bool {
+	return true
+}
+
+func (s *Scheduler) GetGoMethod(name string) (*GoFunction, bool) {
+	switch name {
+	case "schedule":
+		return WrapGoMethod(s.Schedule), true
+	case "stop":
+		return WrapGoMethod(s.Stop), true
+	}
+	return nil, false
+}
+
+func (s *Scheduler) Prop(name string) Value {
+	switch name {
+	case "jobs":
+		return NewArrayOf(ANY_SCHEDULED_JOB)
+	}
+	method, ok := s.GetGoMethod(name)
+	if !ok {
+		panic(FormatErrPropertyDoesNotExist(name, s))
+	}
+	return method
+}
+
+func (*Scheduler) PropertyNames() []string {
+	return SCHEDULER_PROPNAMES
+}
+
+func (s *Scheduler) Schedule(ctx *Context, desc *Record, target Value) (*ScheduledJob, *Error) {
+	if !JOB_SPEC_RECORD.Test(desc, RecTestCallState{}) {
+		ctx.AddSymbolicGoFunctionErrorf("the specification of the job should match %s", Stringify(JOB_SPEC_RECORD))
+	}
+
+	switch t := target.(type) {
+	case *InoxFunction:
+		if len(t.Parameters()) != 0 {
+			ctx.AddSymbolicGoFunctionError("a scheduled function should have no parameters")
+		}
+	case *LThread:
+	default:
+		ctx.AddSymbolicGoFunctionErrorf("only functions and lthreads can be scheduled, not %s", Stringify(target))
+	}
+	return ANY_SCHEDULED_JOB, nil
+}
+
+func (s *Scheduler) Stop(ctx *Context) {
+
+}
+
+// A ScheduledJob represents a symbolic ScheduledJob.
+type ScheduledJob struct {
+	UnassignablePropsMixin
+	_ int
+}
+
+func (j *ScheduledJob) Test(v Value, state RecTestCallState) bool {
+	state.StartCall()
+	defer state.FinishCall()
+
+	_, ok := v.(*ScheduledJob)
+	return ok
+}
+
+func (j *ScheduledJob) PrettyPrint(w pprint.PrettyPrintWriter, config *pprint.PrettyPrintConfig) {
+	w.WriteName("scheduled-job")
+}
+
+func (j *ScheduledJob) WidestOfType() Value {
+	return ANY_SCHEDULED_JOB
+}
+
+func (j *ScheduledJob) IsSharable() (bool, string) {
+	return true, ""
+}
+
+func (j *ScheduledJob) Share(originState *State) PotentiallySharable {
+	return j
+}
+
+func (j *ScheduledJob) IsShared() bool {
+	return true
+}
+
+func (j *ScheduledJob) GetGoMethod(name string) (*GoFunction, bool) {
+	switch name {
+	case "cancel":
+		return WrapGoMethod(j.Cancel), true
+	}
+	return nil, false
+}
+
+func (j *ScheduledJob) Prop(name string) Value {
+	switch name {
+	case "name":
+		return ANY_STRING
+	case "next-run":
+		return NewMultivalue(ANY_DATETIME, Nil)
+	case "run-count", "skipped-count":
+		return ANY_INT
+	case "running":
+		return ANY_BOOL
+	case "last-error":
+		return NewMultivalue(ANY_ERR, Nil)
+	}
+	method, ok := j.GetGoMethod(name)
+	if !ok {
+		panic(FormatErrPropertyDoesNotExist(name, j))
+	}
+	return method
+}
+
+func (*ScheduledJob) PropertyNames() []string {
+	return SCHEDULED_JOB_PROPNAMES
+}
+
+func (j *ScheduledJob) Cancel(ctx *Context) {
+
+}
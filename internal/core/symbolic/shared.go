@@ -10,7 +10,8 @@ import (
 var (
 	_ = []PotentiallySharable{
 		(*Object)(nil), (*InoxFunction)(nil), (*GoFunction)(nil), (*RingBuffer)(nil),
-		(*Mapping)(nil), (*ValueHistory)(nil),
+		(*Mapping)(nil), (*ValueHistory)(nil), (*Channel)(nil), (*Supervisor)(nil),
+		(*Scheduler)(nil), (*ScheduledJob)(nil),
 	}
 
 	ErrMissingNodeValue = errors.New("missing node value")
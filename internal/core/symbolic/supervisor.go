@@ -0,0 +1,154 @@
+package symbolic
+
+import (
+	pprint "github.com/inoxlang/inox/internal/prettyprint"
+)
+
+var (
+	SUPERVISOR_PROPNAMES   = []string{"add", "wait", "shutdown", "crashes"}
+	CRASH_REPORT_PROPNAMES = []string{"child", "error", "stack", "time"}
+
+	ANY_SUPERVISOR   = &Supervisor{}
+	ANY_CRASH_REPORT = &CrashReport{}
+
+	// SUPERVISOR_CONFIG_RECORD is the type of the records configuring supervisors, all entries are optional.
+	SUPERVISOR_CONFIG_RECORD = NewExactRecord(map[string]Serializable{
+		"strategy":     ANY_STRING,
+		"max-restarts": ANY_INT,
+		"period":       ANY_DURATION,
+	}, map[string]struct{}{
+		"strategy":     {},
+		"max-restarts": {},
+		"period":       {},
+	})
+
+	// SUPERVISED_CHILD_OPTIONS_RECORD is the type of the records passed to the add method of supervisors, all entries are optional.
+	SUPERVISED_CHILD_OPTIONS_RECORD = NewExactRecord(map[string]Serializable{
+		"name":    ANY_STRING,
+		"restart": ANY_STRING,
+	}, map[string]struct{}{
+		"name":    {},
+		"restart": {},
+	})
+)
+
+// A Supervisor represents a symbolic Supervisor.
+type Supervisor struct {
+	UnassignablePropsMixin
+	_ int
+}
+
+func (s *Supervisor) Test(v Value, state RecTestCallState) bool {
+	state.StartCall()
+	defer state.FinishCall()
+
+	_, ok := v.(*Supervisor)
+	return ok
+}
+
+func (s *Supervisor) PrettyPrint(w pprint.PrettyPrintWriter, config *pprint.PrettyPrintConfig) {
+	w.WriteName("supervisor")
+}
+
+func (s *Supervisor) WidestOfType() Value {
+	return ANY_SUPERVISOR
+}
+
+func (s *Supervisor) IsSharable() (bool, string) {
+	return true, ""
+}
+
+func (s *Supervisor) Share(originState *State) PotentiallySharable {
+	return s
+}
+
+func (s *Supervisor) IsShared() bool {
+	return true
+}
+
+func (s *Supervisor) GetGoMethod(name string) (*GoFunction, bool) {
+	switch name {
+	case "add":
+		return WrapGoMethod(s.Add), true
+	case "wait":
+		return WrapGoMethod(s.Wait), true
+	case "shutdown":
+		return WrapGoMethod(s.Shutdown), true
+	}
+	return nil, false
+}
+
+func (s *Supervisor) Prop(name string) Value {
+	switch name {
+	case "crashes":
+		return NewArrayOf(ANY_CRASH_REPORT)
+	}
+	method, ok := s.GetGoMethod(name)
+	if !ok {
+		panic(FormatErrPropertyDoesNotExist(name, s))
+	}
+	return method
+}
+
+func (*Supervisor) PropertyNames() []string {
+	return SUPERVISOR_PROPNAMES
+}
+
+func (s *Supervisor) Add(ctx *Context, child Value, options *OptionalParam[*Record]) *Error {
+	switch child.(type) {
+	case *LThread, *Supervisor:
+	default:
+		ctx.AddSymbolicGoFunctionErrorf("a supervised child should be a lthread or a supervisor, not %s", Stringify(child))
+	}
+
+	if options != nil && options.Value != nil && !SUPERVISED_CHILD_OPTIONS_RECORD.Test(*options.Value, RecTestCallState{}) {
+		ctx.AddSymbolicGoFunctionErrorf("the options should match %s", Stringify(SUPERVISED_CHILD_OPTIONS_RECORD))
+	}
+	return nil
+}
+
+func (s *Supervisor) Wait(ctx *Context) *Error {
+	return nil
+}
+
+func (s *Supervisor) Shutdown(ctx *Context) {
+
+}
+
+// A CrashReport represents a symbolic CrashReport.
+type CrashReport struct {
+	UnassignablePropsMixin
+	_ int
+}
+
+func (r *CrashReport) Test(v Value, state RecTestCallState) bool {
+	state.StartCall()
+	defer state.FinishCall()
+
+	_, ok := v.(*CrashReport)
+	return ok
+}
+
+func (r *CrashReport) PrettyPrint(w pprint.PrettyPrintWriter, config *pprint.PrettyPrintConfig) {
+	w.WriteName("crash-report")
+}
+
+func (r *CrashReport) WidestOfType() Value {
+	return ANY_CRASH_REPORT
+}
+
+func (r *CrashReport) Prop(name string) Value {
+	switch name {
+	case "child", "stack":
+		return ANY_STRING
+	case "error":
+		return ANY_ERR
+	case "time":
+		return ANY_DATETIME
+	}
+	panic(FormatErrPropertyDoesNotExist(name, r))
+}
+
+func (*CrashReport) PropertyNames() []string {
+	return CRASH_REPORT_PROPNAMES
+}
@@ -5,7 +5,9 @@ import (
 )
 
 var (
-	TRANSACTION_PROPNAMES = []string{"start", "commit", "rollback"}
+	TRANSACTION_PROPNAMES = []string{
+		"start", "commit", "rollback", "start_nested", "savepoint", "rollback_to_savepoint", "release_savepoint",
+	}
 )
 
 // A Transaction represents a symbolic Transaction.
@@ -38,6 +40,22 @@ func (tx *Transaction) Rollback(ctx *Context) *Error {
 	return nil
 }
 
+func (tx *Transaction) StartNested(ctx *Context) (*Transaction, *Error) {
+	return &Transaction{}, nil
+}
+
+func (tx *Transaction) Savepoint(ctx *Context, name StringLike) *Error {
+	return nil
+}
+
+func (tx *Transaction) RollbackToSavepoint(ctx *Context, name StringLike) *Error {
+	return nil
+}
+
+func (tx *Transaction) ReleaseSavepoint(ctx *Context, name StringLike) *Error {
+	return nil
+}
+
 func (tx *Transaction) Prop(name string) Value {
 	method, ok := tx.GetGoMethod(name)
 	if !ok {
@@ -58,6 +76,14 @@ func (tx *Transaction) GetGoMethod(name string) (*GoFunction, bool) {
 		return WrapGoMethod(tx.Commit), true
 	case "rollback":
 		return WrapGoMethod(tx.Rollback), true
+	case "start_nested":
+		return WrapGoMethod(tx.StartNested), true
+	case "savepoint":
+		return WrapGoMethod(tx.Savepoint), true
+	case "rollback_to_savepoint":
+		return WrapGoMethod(tx.RollbackToSavepoint), true
+	case "release_savepoint":
+		return WrapGoMethod(tx.ReleaseSavepoint), true
 	}
 	return nil, false
 }
@@ -46,6 +46,8 @@ const (
 
 	LIMITS_SECTION_SHOULD_BE_AN_OBJECT = "the '" + inoxconsts.MANIFEST_LIMITS_SECTION_NAME + "' section of the manifest should be an object"
 
+	RETRY_POLICY_SECTION_SHOULD_BE_A_RECORD = "the '" + inoxconsts.MANIFEST_RETRY_POLICY_SECTION_NAME + "' section of the manifest should be a record"
+
 	//Env section
 
 	ENV_SECTION_SHOULD_BE_AN_OBJECT_PATTERN                = "the '" + inoxconsts.MANIFEST_ENV_SECTION_NAME + "' section of the manifest should be an object pattern literal"
@@ -191,6 +193,12 @@ func FmtForbiddenNodeInLimitsSection(n ast.Node) string {
 		inoxconsts.MANIFEST_LIMITS_SECTION_NAME, n)
 }
 
+func FmtForbiddenNodeInRetryPolicySection(n ast.Node) string {
+	return fmt.Sprintf(
+		"invalid %s section: invalid node %T, only simple literals are allowed",
+		inoxconsts.MANIFEST_RETRY_POLICY_SECTION_NAME, n)
+}
+
 func FmtForbiddenNodeInEnvSection(n ast.Node) string {
 	return fmt.Sprintf(
 		"invalid %s section: invalid node %T, only variables, simple literals & named patterns are allowed",
@@ -0,0 +1,180 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/inoxlang/inox/internal/mimeconsts"
+	"github.com/pelletier/go-toml/v2"
+)
+
+var (
+	ErrUnsupportedTomlValue = errors.New("unsupported TOML value")
+	ErrNoTomlRepresentation = errors.New("value has no TOML representation")
+	ErrTomlDocumentNotTable = errors.New("a TOML document should be a table (object or record)")
+)
+
+func init() {
+	RegisterParser(mimeconsts.TOML_CTYPE, &tomlParser{})
+}
+
+// ParseTOML parses a TOML document, the result is an object or a record if immutable is true.
+func ParseTOML(ctx *Context, s string, immutable bool) (Serializable, error) {
+	var document map[string]any
+	if err := toml.Unmarshal([]byte(s), &document); err != nil {
+		return nil, err
+	}
+	return ConvertTomlValToInoxVal(ctx, document, immutable)
+}
+
+// ConvertTomlValToInoxVal converts a value returned by the TOML decoder into an Inox value.
+// Records and tuples are returned instead of objects and lists when immutable is true.
+// Local datetimes and dates are converted to UTC datetimes and dates, local times are converted to strings.
+func ConvertTomlValToInoxVal(ctx *Context, v any, immutable bool) (Serializable, error) {
+	switch val := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(val))
+		values := make([]Serializable, 0, len(val))
+
+		for key, value := range val {
+			converted, err := ConvertTomlValToInoxVal(ctx, value, immutable)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, key)
+			values = append(values, converted)
+		}
+
+		if immutable {
+			return NewRecordFromKeyValLists(keys, values), nil
+		}
+		return objFromLists(keys, values), nil
+	case []any:
+		elements := make([]Serializable, len(val))
+		for i, e := range val {
+			converted, err := ConvertTomlValToInoxVal(ctx, e, immutable)
+			if err != nil {
+				return nil, err
+			}
+			elements[i] = converted
+		}
+
+		if immutable {
+			return NewTuple(elements), nil
+		}
+		return NewWrappedValueListFrom(elements), nil
+	case string:
+		return String(val), nil
+	case int64:
+		return Int(val), nil
+	case float64:
+		return Float(val), nil
+	case bool:
+		return Bool(val), nil
+	case time.Time:
+		return DateTime(val), nil
+	case toml.LocalDateTime:
+		return DateTime(val.AsTime(time.UTC)), nil
+	case toml.LocalDate:
+		return Date(val.AsTime(time.UTC)), nil
+	case toml.LocalTime:
+		return String(val.String()), nil
+	default:
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedTomlValue, v)
+	}
+}
+
+func isRecordOrObject(v Value) bool {
+	switch v.(type) {
+	case *Record, *Object:
+		return true
+	}
+	return false
+}
+
+// WriteTOML writes the TOML representation of a record or an object, keys are written in lexicographic order.
+// Quantities are represented by strings (e.g. "10kB").
+func WriteTOML(ctx *Context, w io.Writer, v Serializable) error {
+	if !isRecordOrObject(v) {
+		return ErrTomlDocumentNotTable
+	}
+
+	document, err := convertInoxValToTomlVal(ctx, v)
+	if err != nil {
+		return err
+	}
+
+	encoder := toml.NewEncoder(w)
+	return encoder.Encode(document)
+}
+
+func convertInoxValToTomlVal(ctx *Context, v Value) (any, error) {
+	switch val := v.(type) {
+	case *Record, *Object:
+		props := val.(IProps)
+		table := map[string]any{}
+
+		for _, name := range props.PropertyNames(ctx) {
+			converted, err := convertInoxValToTomlVal(ctx, props.Prop(ctx, name))
+			if err != nil {
+				return nil, fmt.Errorf("property .%s: %w", name, err)
+			}
+			table[name] = converted
+		}
+		return table, nil
+	case *Tuple, *List:
+		indexable := val.(Indexable)
+		array := make([]any, indexable.Len())
+
+		for i := range array {
+			converted, err := convertInoxValToTomlVal(ctx, indexable.At(ctx, i))
+			if err != nil {
+				return nil, fmt.Errorf("element at index %d: %w", i, err)
+			}
+			array[i] = converted
+		}
+		return array, nil
+	case Int:
+		return int64(val), nil
+	case Float:
+		return float64(val), nil
+	case Bool:
+		return bool(val), nil
+	case StringLike:
+		return val.GetOrBuildString(), nil
+	case DateTime:
+		return time.Time(val), nil
+	case Date:
+		return toml.LocalDate{Year: time.Time(val).Year(), Month: int(time.Time(val).Month()), Day: time.Time(val).Day()}, nil
+	case NilT:
+		return nil, fmt.Errorf("%w: TOML has no null value", ErrNoTomlRepresentation)
+	default:
+		text, err := formatValueAsText(v)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %T", ErrNoTomlRepresentation, v)
+		}
+		return text, nil
+	}
+}
+
+type tomlParser struct {
+}
+
+func (p *tomlParser) Validate(ctx *Context, s string) bool {
+	if len(s) > DEFAULT_MAX_TESTED_STRING_BYTE_LENGTH {
+		panic(ErrTestedStringTooLarge)
+	}
+
+	var document map[string]any
+	return toml.Unmarshal([]byte(s), &document) == nil
+}
+
+func (p *tomlParser) Parse(ctx *Context, s string) (Serializable, error) {
+	if len(s) > DEFAULT_MAX_TESTED_STRING_BYTE_LENGTH {
+		return nil, ErrTestedStringTooLarge
+	}
+
+	return ParseTOML(ctx, s, false)
+}
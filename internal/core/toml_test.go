@@ -0,0 +1,135 @@
+package core
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/inoxlang/inox/internal/mimeconsts"
+	"github.com/stretchr/testify/assert"
+)
+
+const TEST_TOML_DOCUMENT = `
+name = "app"
+port = 8080
+ratio = 0.5
+debug = true
+start = 2024-01-02T10:00:00Z
+day = 2024-01-02
+
+[database]
+hosts = ["a", "b"]
+`
+
+func TestParseTOML(t *testing.T) {
+	ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+	defer ctx.CancelGracefully()
+
+	t.Run("immutable", func(t *testing.T) {
+		result, err := ParseTOML(ctx, TEST_TOML_DOCUMENT, true)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		record, ok := result.(*Record)
+		if !assert.True(t, ok) {
+			return
+		}
+
+		assert.Equal(t, String("app"), record.Prop(ctx, "name"))
+		assert.Equal(t, Int(8080), record.Prop(ctx, "port"))
+		assert.Equal(t, Float(0.5), record.Prop(ctx, "ratio"))
+		assert.Equal(t, True, record.Prop(ctx, "debug"))
+		assert.Equal(t, DateTime(time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)), record.Prop(ctx, "start"))
+		assert.Equal(t, Date(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)), record.Prop(ctx, "day"))
+
+		database := record.Prop(ctx, "database").(*Record)
+		assert.Equal(t, NewTupleVariadic(String("a"), String("b")), database.Prop(ctx, "hosts"))
+	})
+
+	t.Run("mutable", func(t *testing.T) {
+		result, err := ParseTOML(ctx, TEST_TOML_DOCUMENT, false)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		obj, ok := result.(*Object)
+		if !assert.True(t, ok) {
+			return
+		}
+
+		database := obj.Prop(ctx, "database").(*Object)
+		assert.IsType(t, (*List)(nil), database.Prop(ctx, "hosts"))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := ParseTOML(ctx, "a = ", true)
+		assert.Error(t, err)
+	})
+
+	t.Run("registered parser", func(t *testing.T) {
+		parser, ok := GetParser(mimeconsts.TOML_CTYPE)
+		if !assert.True(t, ok) {
+			return
+		}
+
+		assert.True(t, parser.Validate(ctx, TEST_TOML_DOCUMENT))
+		assert.False(t, parser.Validate(ctx, "[a"))
+
+		result, err := parser.Parse(ctx, "a = 1")
+		if assert.NoError(t, err) {
+			assert.Equal(t, Int(1), result.(*Object).Prop(ctx, "a"))
+		}
+	})
+}
+
+func TestWriteTOML(t *testing.T) {
+	ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+	defer ctx.CancelGracefully()
+
+	t.Run("keys should be sorted", func(t *testing.T) {
+		document := NewRecordFromMap(ValMap{
+			"b":    Int(1),
+			"a":    String("x"),
+			"size": ByteCount(10_000),
+			"section": NewRecordFromMap(ValMap{
+				"z": True,
+				"y": NewTupleVariadic(Int(1), Int(2)),
+			}),
+		})
+
+		var buf bytes.Buffer
+		if !assert.NoError(t, WriteTOML(ctx, &buf, document)) {
+			return
+		}
+
+		assert.Equal(t, "a = 'x'\nb = 1\nsize = '10kB'\n\n[section]\ny = [1, 2]\nz = true\n", buf.String())
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		original, err := ParseTOML(ctx, TEST_TOML_DOCUMENT, true)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		var buf bytes.Buffer
+		if !assert.NoError(t, WriteTOML(ctx, &buf, original)) {
+			return
+		}
+
+		result, err := ParseTOML(ctx, buf.String(), true)
+		if assert.NoError(t, err) {
+			assert.True(t, original.Equal(ctx, result, map[uintptr]uintptr{}, 0))
+		}
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		err := WriteTOML(ctx, &bytes.Buffer{}, NewRecordFromMap(ValMap{"a": Nil}))
+		assert.ErrorIs(t, err, ErrNoTomlRepresentation)
+	})
+
+	t.Run("not a table", func(t *testing.T) {
+		err := WriteTOML(ctx, &bytes.Buffer{}, Int(1))
+		assert.ErrorIs(t, err, ErrTomlDocumentNotTable)
+	})
+}
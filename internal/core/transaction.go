@@ -28,6 +28,9 @@ var (
 	ErrAlreadySetTransactionEndCallback        = errors.New("transaction end callback is already set")
 	ErrRunningTransactionExpected              = errors.New("running transaction expected")
 	ErrEffectsNotAllowedInReadonlyTransaction  = errors.New("effects are not allowed in a readonly transaction")
+	ErrActiveNestedTransaction                 = errors.New("transaction has an active nested transaction")
+	ErrSavepointAlreadyExists                  = errors.New("savepoint already exists")
+	ErrUnknownSavepoint                        = errors.New("unknown savepoint")
 
 	// closedchan is a reusable closed channel.
 	closedchan = make(chan struct{})
@@ -41,6 +44,10 @@ func init() {
 // A Transaction can be started, commited and rolled back. Effects (reversible or not) such as FS changes are added to it.
 // Actual database transactions or data containers can also register a callback with the OnEnd method, in order to execute logic
 // when the transaction commits or rolls back.
+//
+// Nested transactions are started with StartNested: when a nested transaction commits its effects and end callbacks are
+// transferred to the parent transaction, when it rolls back its effects are reversed and its end callbacks are called.
+// Named savepoints (see Savepoint) allow a partial rollback of a transaction.
 type Transaction struct {
 	ulid           ULID
 	ctx            *Context
@@ -54,6 +61,24 @@ type Transaction struct {
 	finishing      atomic.Bool
 	timeout        Duration
 	isReadonly     bool
+	isDryRun       bool                //see StartNewDryRunTransaction
+	journal        *TransactionJournal //if not nil the effects are recorded in the journal before being applied
+
+	parent      *Transaction //nil if the transaction is not nested
+	activeChild *Transaction
+	savepoints  []txSavepoint
+}
+
+type txSavepoint struct {
+	name         string
+	effectCount  int
+	callbackKeys map[any]struct{} //keys of the end callbacks registered before the savepoint
+}
+
+// nestedTxCallbackKey is the key of an end callback transferred from a nested transaction to its parent.
+type nestedTxCallbackKey struct {
+	tx  ULID
+	key any
 }
 
 type TransactionEndCallbackFn func(tx *Transaction, success bool)
@@ -68,6 +93,7 @@ func newTransaction(ctx *Context, readonly bool, options ...Option) *Transaction
 		values:         make(map[any]any),
 		endCallbackFns: make(map[any]TransactionEndCallbackFn),
 		timeout:        DEFAULT_TRANSACTION_TIMEOUT,
+		journal:        ctx.TransactionJournal(),
 	}
 
 	for _, opt := range options {
@@ -131,6 +157,19 @@ func (tx *Transaction) ID() ULID {
 	return tx.ulid
 }
 
+// Parent returns the parent of a nested transaction, nil is returned if the transaction is not nested.
+func (tx *Transaction) Parent() *Transaction {
+	return tx.parent
+}
+
+func (tx *Transaction) root() *Transaction {
+	root := tx
+	for root.parent != nil {
+		root = root.parent
+	}
+	return root
+}
+
 // Start attaches tx to the passed context and creates a goroutine that will roll it back on timeout or context cancellation.
 // The passed context must be the same context that created the transaction.
 // ErrFinishedTransaction will be returned if Start is called on a finished transaction.
@@ -158,13 +197,18 @@ func (tx *Transaction) Start(ctx *Context) error {
 		panic(ErrCtxAlreadyHasTransaction)
 	}
 
+	var timeout <-chan time.Time
+	if !tx.isDryRun {
+		timeout = time.After(time.Duration(tx.timeout))
+	}
+
 	// spawn a goroutine that rollbacks the transaction when the associated context is done or
 	// if the timeout duration has ellapsed.
 	go func() {
 		select {
 		case <-ctx.Done():
 			tx.Rollback(ctx)
-		case <-time.After(time.Duration(tx.timeout)):
+		case <-timeout:
 			if !tx.IsFinished() {
 				ctx.LoggerPrint(tx.ulid.String(), " transaction timed out")
 				tx.Rollback(ctx)
@@ -177,6 +221,60 @@ func (tx *Transaction) Start(ctx *Context) error {
 	return nil
 }
 
+// StartNested creates a nested transaction and starts it immediately, the nested transaction becomes the current transaction
+// of the context until it is finished. The nested transaction of a readonly transaction is readonly. The timeout of the
+// root transaction applies to the nested transactions. A transaction can only have one active nested transaction at a time.
+func (tx *Transaction) StartNested(ctx *Context) (*Transaction, error) {
+	if tx.IsFinished() {
+		return nil, ErrFinishedTransaction
+	}
+
+	if tx.IsFinishing() {
+		return nil, ErrFinishingTransaction
+	}
+
+	if ctx != tx.ctx {
+		return nil, ErrTransactionShouldBeStartedBySameContext
+	}
+
+	tx.lock.Lock()
+	defer tx.lock.Unlock()
+
+	if tx.startTime == (time.Time{}) {
+		return nil, ErrRunningTransactionExpected
+	}
+
+	if tx.activeChild != nil {
+		return nil, ErrActiveNestedTransaction
+	}
+
+	child := newTransaction(ctx, tx.isReadonly)
+	child.parent = tx
+	child.journal = tx.journal
+	child.isDryRun = tx.isDryRun
+	child.startTime = time.Now()
+
+	tx.activeChild = child
+	ctx.setTx(child)
+	return child, nil
+}
+
+// detachFromParent is called by a nested transaction when it finishes.
+func (tx *Transaction) detachFromParent() {
+	tx.parent.lock.Lock()
+	defer tx.parent.lock.Unlock()
+
+	if tx.parent.activeChild == tx {
+		tx.parent.activeChild = nil
+	}
+}
+
+func (tx *Transaction) getActiveChild() *Transaction {
+	tx.lock.RLock()
+	defer tx.lock.RUnlock()
+	return tx.activeChild
+}
+
 // OnEnd associates with k the callback function fn that will be called on the end of the transacion (success or failure),
 // IMPORTANT NOTE: fn may be called in a goroutine different from the one that registered it.
 // If a function is already associated with k the error ErrAlreadySetTransactionEndCallback is returned
@@ -217,9 +315,19 @@ func (tx *Transaction) AddEffect(ctx *Context, effect Effect) error {
 	tx.lock.Lock()
 	defer tx.lock.Unlock()
 
+	if tx.isDryRun {
+		//all effects are recorded, they are never applied.
+		tx.effects = append(tx.effects, effect)
+		return nil
+	}
+
 	if effect.Reversability(ctx) == Irreversible {
 		return ErrCannotAddIrreversibleEffect
 	}
+
+	if _, ok := effect.(JournaledEffect); !ok && tx.journal != nil {
+		return fmt.Errorf("%w: %T", ErrEffectNotJournalable, effect)
+	}
 	tx.effects = append(tx.effects, effect)
 
 	return nil
@@ -232,19 +340,37 @@ func (tx *Transaction) CurrentEffects() ([]Effect, error) {
 	return slices.Clone(tx.effects), nil
 }
 
+// Commit applies the effects and calls the end callbacks. If the transaction is nested its effects and end callbacks
+// are transferred to the parent transaction instead.
 func (tx *Transaction) Commit(ctx *Context) error {
 
 	if tx.IsFinished() {
 		return ErrFinishedTransaction
 	}
 
+	if tx.isDryRun && tx.parent == nil {
+		return ErrCannotCommitDryRunTransaction
+	}
+
+	//The finishing state is checked before calling getActiveChild because the lock is held during the finishing phase.
+	if tx.IsFinishing() {
+		return ErrFinishingTransaction
+	}
+
+	if tx.getActiveChild() != nil {
+		return ErrActiveNestedTransaction
+	}
+
 	if !tx.finishing.CompareAndSwap(false, true) {
 		return ErrFinishingTransaction
 	}
 
 	tx.lock.Lock()
 	defer func() {
-		tx.ctx.setTx(nil)
+		tx.ctx.setTx(tx.parent)
+		if tx.parent != nil {
+			tx.detachFromParent()
+		}
 
 		d, _ := tx.finished.Load().(chan struct{})
 		if d == nil {
@@ -263,12 +389,60 @@ func (tx *Transaction) Commit(ctx *Context) error {
 
 	tx.endTime = time.Now()
 
-	for _, effect := range tx.effects {
+	if tx.parent != nil {
+		tx.mergeIntoParent()
+		return nil
+	}
+
+	journaled := tx.journal != nil && len(tx.effects) > 0
+
+	if journaled {
+		if err := tx.journal.recordTransaction(tx.ulid, tx.effects); err != nil {
+			for _, fn := range tx.endCallbackFns {
+				fn(tx, false)
+			}
+			return fmt.Errorf("failed to record the effects in the transaction journal: %w", err)
+		}
+	}
+
+	for i, effect := range tx.effects {
 		if err := effect.Apply(ctx); err != nil {
+			err = fmt.Errorf("error when applying effet %#v: %w", effect, err)
+
+			if journaled {
+				//The effects applied before the failing one are reversed. If they cannot be reversed the transaction
+				//is kept in the journal so that (*TransactionJournal).Recover rolls it forward or reverses it later.
+				if reversalErr := tx.reverseJournaledEffects(ctx, i); reversalErr != nil {
+					err = fmt.Errorf("%w (the transaction is kept in the journal: %w)", err, reversalErr)
+				} else if removalErr := tx.journal.removeTransaction(tx.ulid); removalErr != nil {
+					err = fmt.Errorf("%w (failed to remove the transaction from the journal: %w)", err, removalErr)
+				}
+			}
+
 			for _, fn := range tx.endCallbackFns {
 				fn(tx, true)
 			}
-			return fmt.Errorf("error when applying effet %#v: %w", effect, err)
+			return err
+		}
+
+		if journaled {
+			if err := tx.journal.markEffectApplied(tx.ulid, i); err != nil {
+				//The transaction is kept in the journal, it will be recovered by (*TransactionJournal).Recover.
+				for _, fn := range tx.endCallbackFns {
+					fn(tx, false)
+				}
+				return fmt.Errorf("failed to mark an effect as applied in the transaction journal: %w", err)
+			}
+		}
+	}
+
+	var journalRemovalErr error
+
+	if journaled {
+		//All the effects are applied and marked as such, so a transaction that is not removed
+		//is only removed (not reversed) during the recovery.
+		if err := tx.journal.removeTransaction(tx.ulid); err != nil {
+			journalRemovalErr = fmt.Errorf("failed to remove the transaction from the journal: %w", err)
 		}
 	}
 
@@ -289,9 +463,52 @@ func (tx *Transaction) Commit(ctx *Context) error {
 
 	tx.endCallbackFns = nil
 
-	return utils.CombineErrorsWithPrefixMessage("callback errors", callbackErrors...)
+	callbackErr := utils.CombineErrorsWithPrefixMessage("callback errors", callbackErrors...)
+	if journalRemovalErr != nil {
+		return utils.CombineErrors(journalRemovalErr, callbackErr)
+	}
+	return callbackErr
+}
+
+// reverseJournaledEffects reverses the first appliedCount effects of a journaled transaction in the reverse order
+// of application. No effect is reversed if one of them is not Reversible. The journal is updated after each reversal.
+func (tx *Transaction) reverseJournaledEffects(ctx *Context, appliedCount int) error {
+	for _, effect := range tx.effects[:appliedCount] {
+		if effect.Reversability(ctx) != Reversible {
+			return fmt.Errorf("%w: %T", ErrIrreversible, effect)
+		}
+	}
+
+	for i := appliedCount - 1; i >= 0; i-- {
+		if err := tx.effects[i].Reverse(ctx); err != nil {
+			return fmt.Errorf("error when reversing effect %d: %w", i, err)
+		}
+		if err := tx.journal.unmarkEffectApplied(tx.ulid, i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeIntoParent transfers the effects and the end callbacks of a nested transaction to its parent.
+func (tx *Transaction) mergeIntoParent() {
+	parent := tx.parent
+	parent.lock.Lock()
+	defer parent.lock.Unlock()
+
+	parent.effects = append(parent.effects, tx.effects...)
+
+	for k, fn := range tx.endCallbackFns {
+		fn := fn
+		parent.endCallbackFns[nestedTxCallbackKey{tx: tx.ulid, key: k}] = func(_ *Transaction, success bool) {
+			fn(tx, success)
+		}
+	}
+	tx.endCallbackFns = nil
 }
 
+// Rollback calls the end callbacks and reverses the effects in the reverse order of their addition, the active nested
+// transaction (if any) is rolled back first.
 func (tx *Transaction) Rollback(ctx *Context) error {
 	//$ctx may be done.
 
@@ -299,13 +516,24 @@ func (tx *Transaction) Rollback(ctx *Context) error {
 		return ErrFinishedTransaction
 	}
 
+	if tx.IsFinishing() {
+		return ErrFinishingTransaction
+	}
+
+	if child := tx.getActiveChild(); child != nil {
+		child.Rollback(ctx)
+	}
+
 	if !tx.finishing.CompareAndSwap(false, true) {
 		return ErrFinishingTransaction
 	}
 
 	tx.lock.Lock()
 	defer func() {
-		tx.ctx.setTx(nil)
+		tx.ctx.setTx(tx.parent)
+		if tx.parent != nil {
+			tx.detachFromParent()
+		}
 
 		d, _ := tx.finished.Load().(chan struct{})
 		if d == nil {
@@ -340,8 +568,13 @@ func (tx *Transaction) Rollback(ctx *Context) error {
 
 	tx.endCallbackFns = nil
 
-	for _, effect := range tx.effects {
-		if err := effect.Reverse(ctx); err != nil {
+	if tx.isDryRun {
+		//the effects have not been applied.
+		return utils.CombineErrorsWithPrefixMessage("callback errors", callbackErrors...)
+	}
+
+	for i := len(tx.effects) - 1; i >= 0; i-- {
+		if err := tx.effects[i].Reverse(ctx); err != nil {
 			return err
 		}
 	}
@@ -349,6 +582,134 @@ func (tx *Transaction) Rollback(ctx *Context) error {
 	return utils.CombineErrorsWithPrefixMessage("callback errors", callbackErrors...)
 }
 
+// Savepoint creates a named savepoint, RollbackToSavepoint can later be called to reverse the effects added after the savepoint.
+func (tx *Transaction) Savepoint(ctx *Context, name StringLike) error {
+	if tx.IsFinished() {
+		return ErrFinishedTransaction
+	}
+
+	if tx.IsFinishing() {
+		return ErrFinishingTransaction
+	}
+
+	tx.lock.Lock()
+	defer tx.lock.Unlock()
+
+	if tx.activeChild != nil {
+		return ErrActiveNestedTransaction
+	}
+
+	nameString := name.GetOrBuildString()
+	if tx.savepointIndex(nameString) >= 0 {
+		return fmt.Errorf("%w: %s", ErrSavepointAlreadyExists, nameString)
+	}
+
+	callbackKeys := make(map[any]struct{}, len(tx.endCallbackFns))
+	for k := range tx.endCallbackFns {
+		callbackKeys[k] = struct{}{}
+	}
+
+	tx.savepoints = append(tx.savepoints, txSavepoint{
+		name:         nameString,
+		effectCount:  len(tx.effects),
+		callbackKeys: callbackKeys,
+	})
+	return nil
+}
+
+// RollbackToSavepoint reverses (in reverse order) the effects added after the savepoint and calls with success=false
+// the end callbacks registered after it. The savepoints created after the savepoint are removed, the savepoint is kept.
+func (tx *Transaction) RollbackToSavepoint(ctx *Context, name StringLike) error {
+	if tx.IsFinished() {
+		return ErrFinishedTransaction
+	}
+
+	if tx.IsFinishing() {
+		return ErrFinishingTransaction
+	}
+
+	tx.lock.Lock()
+	defer tx.lock.Unlock()
+
+	if tx.activeChild != nil {
+		return ErrActiveNestedTransaction
+	}
+
+	nameString := name.GetOrBuildString()
+	index := tx.savepointIndex(nameString)
+	if index < 0 {
+		return fmt.Errorf("%w: %s", ErrUnknownSavepoint, nameString)
+	}
+
+	savepoint := tx.savepoints[index]
+	tx.savepoints = tx.savepoints[:index+1]
+
+	var callbackErrors []error
+	for k, fn := range tx.endCallbackFns {
+		if _, ok := savepoint.callbackKeys[k]; ok {
+			continue
+		}
+		delete(tx.endCallbackFns, k)
+		if err := callTransactionEndCallback(tx, fn, false); err != nil {
+			callbackErrors = append(callbackErrors, err)
+		}
+	}
+
+	for i := len(tx.effects) - 1; i >= savepoint.effectCount && !tx.isDryRun; i-- {
+		if err := tx.effects[i].Reverse(ctx); err != nil {
+			tx.effects = tx.effects[:i+1]
+			return err
+		}
+	}
+	tx.effects = tx.effects[:savepoint.effectCount]
+
+	return utils.CombineErrorsWithPrefixMessage("callback errors", callbackErrors...)
+}
+
+// ReleaseSavepoint removes a savepoint and the savepoints created after it, the effects are kept.
+func (tx *Transaction) ReleaseSavepoint(ctx *Context, name StringLike) error {
+	if tx.IsFinished() {
+		return ErrFinishedTransaction
+	}
+
+	if tx.IsFinishing() {
+		return ErrFinishingTransaction
+	}
+
+	tx.lock.Lock()
+	defer tx.lock.Unlock()
+
+	if tx.activeChild != nil {
+		return ErrActiveNestedTransaction
+	}
+
+	nameString := name.GetOrBuildString()
+	index := tx.savepointIndex(nameString)
+	if index < 0 {
+		return fmt.Errorf("%w: %s", ErrUnknownSavepoint, nameString)
+	}
+
+	tx.savepoints = tx.savepoints[:index]
+	return nil
+}
+
+func (tx *Transaction) savepointIndex(name string) int {
+	return slices.IndexFunc(tx.savepoints, func(s txSavepoint) bool {
+		return s.name == name
+	})
+}
+
+func callTransactionEndCallback(tx *Transaction, fn TransactionEndCallbackFn, success bool) (err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			defer utils.Recover()
+			err = fmt.Errorf("%w: %s", utils.ConvertPanicValueToError(e), string(debug.Stack()))
+		}
+	}()
+	fn(tx, success)
+	return nil
+}
+
 func (tx *Transaction) Prop(ctx *Context, name string) Value {
 	method, ok := tx.GetGoMethod(name)
 	if !ok {
@@ -373,6 +734,14 @@ func (tx *Transaction) GetGoMethod(name string) (*GoFunction, bool) {
 		return WrapGoMethod(tx.Commit), true
 	case "rollback":
 		return WrapGoMethod(tx.Rollback), true
+	case "start_nested":
+		return WrapGoMethod(tx.StartNested), true
+	case "savepoint":
+		return WrapGoMethod(tx.Savepoint), true
+	case "rollback_to_savepoint":
+		return WrapGoMethod(tx.RollbackToSavepoint), true
+	case "release_savepoint":
+		return WrapGoMethod(tx.ReleaseSavepoint), true
 	}
 	return nil, false
 }
@@ -0,0 +1,217 @@
+package core
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	ErrTransactionIsolationViolation = errors.New("transaction isolation violation: the value is owned by another transaction")
+
+	//state used to lock the values whose snapshot is restored while the lock is not held by the transaction's state.
+	getTxIsolationRestorationState = sync.OnceValue(func() *GlobalState {
+		return NewGlobalState(NewContext(ContextConfig{}))
+	})
+)
+
+// A TransactionIsolator isolates the read-write transaction mutating a shared value from the other lthreads:
+//   - the first read-write transaction mutating the value becomes its owner, the other lthreads (transactions or not) wait for
+//     the owner to finish before accessing the value, so they never observe uncommitted mutations.
+//   - before the first mutation by a transaction (root or nested) a snapshot of the value is taken, the snapshot is restored
+//     if the transaction rolls back (or if the transaction is rolled back to a savepoint created before the first mutation).
+//   - the notifications of mutations (watchers, mutation callbacks) are deferred until the root transaction commits.
+//
+// Because the owner is the root transaction, a nested transaction does not wait for its ancestors. Waiting lthreads wait
+// at most until the owner's timeout. The zero value is ready to use.
+type TransactionIsolator struct {
+	lock             sync.Mutex
+	owner            atomic.Pointer[Transaction] //root transaction, the value is not owned if the owner is finished.
+	snapshots        []txIsolationSnapshot
+	pendingMutations []Mutation
+}
+
+type txIsolationSnapshot struct {
+	tx                   *Transaction
+	data                 any
+	pendingMutationCount int
+}
+
+// txIsolatorCallbackKey is the key of the transaction end callbacks registered by a TransactionIsolator.
+type txIsolatorCallbackKey struct {
+	isolator *TransactionIsolator
+}
+
+// WaitForOtherTxsToTerminate waits until the value is not owned by a transaction other than the current transaction
+// of ctx (or one of its ancestors). The current transaction is returned, nil is returned if there is no current transaction
+// or if the value is not owned. An error is returned if ctx is done.
+func (isolator *TransactionIsolator) WaitForOtherTxsToTerminate(ctx *Context) (currentTx *Transaction, _ error) {
+	if isolator.owner.Load() == nil { //fast path
+		return nil, nil
+	}
+
+	currentTx = getCurrentTxNoCheck(ctx)
+
+	for {
+		isolator.lock.Lock()
+		owner := isolator.ownerNoLock()
+		isolator.lock.Unlock()
+
+		if owner == nil || (currentTx != nil && currentTx.root() == owner) {
+			return currentTx, nil
+		}
+
+		err := ctx.DoIO(func() error {
+			select {
+			case <-owner.Finished():
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// IsOwnedByOtherTx returns true if the value is owned by a transaction that is not tx's root transaction.
+func (isolator *TransactionIsolator) IsOwnedByOtherTx(tx *Transaction) bool {
+	if isolator.owner.Load() == nil { //fast path
+		return false
+	}
+
+	isolator.lock.Lock()
+	defer isolator.lock.Unlock()
+
+	owner := isolator.ownerNoLock()
+	return owner != nil && (tx == nil || tx.root() != owner)
+}
+
+// ownerNoLock returns the owner of the value, the ownership is released if the owner is finished.
+func (isolator *TransactionIsolator) ownerNoLock() *Transaction {
+	owner := isolator.owner.Load()
+	if owner != nil && owner.IsFinished() {
+		isolator.owner.Store(nil)
+		isolator.snapshots = nil
+		isolator.pendingMutations = nil
+		return nil
+	}
+	return owner
+}
+
+// PrepareMutation should be called, with the value locked, by the read-write transaction tx before mutating the value.
+// If tx has not mutated the value yet, takeSnapshot is called and restore will be called with the snapshot if tx rolls back.
+// notify is called for each deferred mutation (see DeferMutationNotification) when the root transaction commits.
+func (isolator *TransactionIsolator) PrepareMutation(
+	tx *Transaction,
+	takeSnapshot func() any,
+	restore func(ctx *Context, snapshot any),
+	notify func(ctx *Context, m Mutation),
+) error {
+	if tx.IsReadonly() {
+		return ErrEffectsNotAllowedInReadonlyTransaction
+	}
+
+	isolator.lock.Lock()
+	defer isolator.lock.Unlock()
+
+	owner := isolator.ownerNoLock()
+	if owner == nil {
+		isolator.owner.Store(tx.root())
+	} else if owner != tx.root() { //should not happen if WaitForOtherTxsToTerminate has been called.
+		return ErrTransactionIsolationViolation
+	}
+
+	hasSnapshot := slices.ContainsFunc(isolator.snapshots, func(s txIsolationSnapshot) bool {
+		return s.tx == tx
+	})
+
+	if hasSnapshot {
+		return nil
+	}
+
+	isolator.snapshots = append(isolator.snapshots, txIsolationSnapshot{
+		tx:                   tx,
+		data:                 takeSnapshot(),
+		pendingMutationCount: len(isolator.pendingMutations),
+	})
+
+	//If tx is nested the callback is transferred to the parent when tx commits, so the callback
+	//is only called with success=true when the root transaction commits.
+	err := tx.OnEnd(txIsolatorCallbackKey{isolator}, func(tx *Transaction, success bool) {
+		if success {
+			isolator.notifyPendingMutations(tx.ctx, notify)
+		} else {
+			isolator.restoreSnapshot(tx, restore)
+		}
+	})
+
+	if errors.Is(err, ErrAlreadySetTransactionEndCallback) {
+		//The previous snapshot of tx has been dropped but its callback is still registered,
+		//the callback will restore the new snapshot.
+		return nil
+	}
+	return err
+}
+
+// DeferMutationNotification defers the notification of a mutation until the owner commits, false is returned if the
+// value is not owned by a transaction: in this case the caller should immediately notify the mutation.
+func (isolator *TransactionIsolator) DeferMutationNotification(m Mutation) bool {
+	isolator.lock.Lock()
+	defer isolator.lock.Unlock()
+
+	if isolator.ownerNoLock() == nil {
+		return false
+	}
+	isolator.pendingMutations = append(isolator.pendingMutations, m)
+	return true
+}
+
+func (isolator *TransactionIsolator) notifyPendingMutations(ctx *Context, notify func(ctx *Context, m Mutation)) {
+	isolator.lock.Lock()
+	mutations := isolator.pendingMutations
+	isolator.pendingMutations = nil
+	isolator.lock.Unlock()
+
+	for _, m := range mutations {
+		notify(ctx, m)
+	}
+}
+
+// restoreSnapshot restores the snapshot taken by tx, the more recent snapshots are dropped.
+func (isolator *TransactionIsolator) restoreSnapshot(tx *Transaction, restore func(ctx *Context, snapshot any)) {
+	isolator.lock.Lock()
+
+	index := slices.IndexFunc(isolator.snapshots, func(s txIsolationSnapshot) bool {
+		return s.tx == tx
+	})
+
+	if index < 0 { //already dropped because an older snapshot has been restored.
+		isolator.lock.Unlock()
+		return
+	}
+
+	snapshot := isolator.snapshots[index]
+	isolator.snapshots = isolator.snapshots[:index]
+	isolator.pendingMutations = isolator.pendingMutations[:snapshot.pendingMutationCount]
+	isolator.lock.Unlock()
+
+	restore(tx.ctx, snapshot.data)
+}
+
+// getCurrentTxNoCheck is equivalent to ctx.GetTx() but it does not panic if ctx is done.
+func getCurrentTxNoCheck(ctx *Context) *Transaction {
+	for c := ctx; c != nil; c = c.parentCtx {
+		c.currentTxLock.Lock()
+		tx := c.currentTx
+		c.currentTxLock.Unlock()
+
+		if tx != nil {
+			return tx
+		}
+	}
+	return nil
+}
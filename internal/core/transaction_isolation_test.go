@@ -0,0 +1,229 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransactionIsolation(t *testing.T) {
+
+	readInGoroutine := func(ctx *Context, obj *Object) chan Value {
+		result := make(chan Value, 1)
+		go func() {
+			result <- obj.Prop(ctx, "a")
+		}()
+		return result
+	}
+
+	t.Run("mutations should not be visible by other lthreads before the commit", func(t *testing.T) {
+		ctx1 := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx1.CancelGracefully()
+		ctx2 := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx2.CancelGracefully()
+
+		obj := NewObjectFromMap(ValMap{"a": Int(1)}, ctx1)
+		obj.Share(ctx1.MustGetClosestState())
+
+		tx := StartNewTransaction(ctx1)
+		if !assert.NoError(t, obj.SetProp(ctx1, "a", Int(2))) {
+			return
+		}
+		assert.Equal(t, Int(2), obj.Prop(ctx1, "a"))
+
+		result := readInGoroutine(ctx2, obj)
+
+		select {
+		case v := <-result:
+			assert.FailNow(t, "the read should wait for the transaction to finish", "read value: %v", v)
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		assert.NoError(t, tx.Commit(ctx1))
+
+		select {
+		case v := <-result:
+			assert.Equal(t, Int(2), v)
+		case <-time.After(time.Second):
+			assert.FailNow(t, "timeout")
+		}
+	})
+
+	t.Run("mutations should be reverted on rollback", func(t *testing.T) {
+		ctx1 := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx1.CancelGracefully()
+		ctx2 := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx2.CancelGracefully()
+
+		obj := NewObjectFromMap(ValMap{"a": Int(1)}, ctx1)
+		obj.Share(ctx1.MustGetClosestState())
+
+		tx := StartNewTransaction(ctx1)
+		obj.SetProp(ctx1, "a", Int(2))
+		obj.SetProp(ctx1, "b", Int(3))
+
+		result := readInGoroutine(ctx2, obj)
+
+		assert.NoError(t, tx.Rollback(ctx1))
+
+		select {
+		case v := <-result:
+			assert.Equal(t, Int(1), v)
+		case <-time.After(time.Second):
+			assert.FailNow(t, "timeout")
+		}
+		assert.Equal(t, []string{"a"}, obj.PropertyNames(ctx2))
+	})
+
+	t.Run("mutations should be reverted when the transaction times out while the object is being read", func(t *testing.T) {
+		ctx1 := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx1.CancelGracefully()
+
+		obj := NewObjectFromMap(ValMap{"a": Int(1)}, ctx1)
+		obj.Share(ctx1.MustGetClosestState())
+
+		tx := StartNewTransaction(ctx1, Option{Name: TX_TIMEOUT_OPTION_NAME, Value: Duration(20 * time.Millisecond)})
+		obj.SetProp(ctx1, "a", Int(2))
+
+		//the snapshot is restored by the goroutine handling the timeout.
+		finished := tx.Finished()
+		timeout := time.After(time.Second)
+
+	loop:
+		for {
+			select {
+			case <-finished:
+				break loop
+			case <-timeout:
+				assert.FailNow(t, "timeout")
+			default:
+				obj.Prop(ctx1, "a")
+				//give the goroutine handling the timeout a chance to acquire the lock of the object.
+				time.Sleep(time.Millisecond)
+			}
+		}
+
+		assert.Equal(t, Int(1), obj.Prop(ctx1, "a"))
+	})
+
+	t.Run("mutations should be reverted on rollback inside a synchronized block", func(t *testing.T) {
+		ctx1 := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx1.CancelGracefully()
+
+		state := ctx1.MustGetClosestState()
+		obj := NewObjectFromMap(ValMap{"a": Int(1)}, ctx1)
+		obj.Share(state)
+
+		tx := StartNewTransaction(ctx1)
+
+		//lock the object as a synchronized block does.
+		obj.SmartLock(state)
+		state.lockedValues = append(state.lockedValues, obj)
+
+		obj.SetProp(ctx1, "a", Int(2))
+
+		start := time.Now()
+		assert.NoError(t, tx.Rollback(ctx1))
+		assert.Less(t, time.Since(start), SMART_LOCK_HOLD_TIMEOUT)
+
+		//the lthread of the transaction should not have been cancelled by a lock takeover.
+		assert.False(t, ctx1.IsDone())
+		assert.Equal(t, Int(1), obj.Prop(ctx1, "a"))
+
+		state.lockedValues = nil
+		obj.SmartUnlock(state)
+	})
+
+	t.Run("mutation callbacks should be called after the commit", func(t *testing.T) {
+		ctx1 := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx1.CancelGracefully()
+
+		obj := NewObjectFromMap(ValMap{"a": Int(1)}, ctx1)
+		obj.Share(ctx1.MustGetClosestState())
+
+		var mutations []Mutation
+		_, err := obj.OnMutation(ctx1, func(ctx *Context, mutation Mutation) (registerAgain bool) {
+			mutations = append(mutations, mutation)
+			return true
+		}, MutationWatchingConfiguration{Depth: ShallowWatching})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		tx := StartNewTransaction(ctx1)
+		obj.SetProp(ctx1, "a", Int(2))
+		assert.Empty(t, mutations)
+
+		assert.NoError(t, tx.Commit(ctx1))
+		assert.Equal(t, []Mutation{NewUpdatePropMutation(ctx1, "a", Int(2), ShallowWatching, "/a")}, mutations)
+
+		//no notification after a rollback.
+		mutations = nil
+		tx = StartNewTransaction(ctx1)
+		obj.SetProp(ctx1, "a", Int(3))
+		assert.NoError(t, tx.Rollback(ctx1))
+		assert.Empty(t, mutations)
+	})
+
+	t.Run("mutations of a nested transaction should be reverted on rollback", func(t *testing.T) {
+		ctx1 := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx1.CancelGracefully()
+
+		obj := NewObjectFromMap(ValMap{"a": Int(1)}, ctx1)
+		obj.Share(ctx1.MustGetClosestState())
+
+		tx := StartNewTransaction(ctx1)
+		defer tx.Rollback(ctx1)
+
+		obj.SetProp(ctx1, "a", Int(2))
+
+		child, _ := tx.StartNested(ctx1)
+		obj.SetProp(ctx1, "a", Int(3))
+		assert.NoError(t, child.Rollback(ctx1))
+
+		assert.Equal(t, Int(2), obj.Prop(ctx1, "a"))
+
+		//mutations of a committed nested transaction should be reverted if the parent rolls back.
+		child, _ = tx.StartNested(ctx1)
+		obj.SetProp(ctx1, "a", Int(4))
+		assert.NoError(t, child.Commit(ctx1))
+		assert.Equal(t, Int(4), obj.Prop(ctx1, "a"))
+
+		assert.NoError(t, tx.Rollback(ctx1))
+		assert.Equal(t, Int(1), obj.Prop(ctx1, "a"))
+	})
+
+	t.Run("mutations should be reverted when rolling back to a savepoint", func(t *testing.T) {
+		ctx1 := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx1.CancelGracefully()
+
+		obj := NewObjectFromMap(ValMap{"a": Int(1)}, ctx1)
+		obj.Share(ctx1.MustGetClosestState())
+
+		tx := StartNewTransaction(ctx1)
+		defer tx.Rollback(ctx1)
+
+		tx.Savepoint(ctx1, String("s"))
+		obj.SetProp(ctx1, "a", Int(2))
+		assert.NoError(t, tx.RollbackToSavepoint(ctx1, String("s")))
+		assert.Equal(t, Int(1), obj.Prop(ctx1, "a"))
+
+		obj.SetProp(ctx1, "a", Int(3))
+		assert.NoError(t, tx.Commit(ctx1))
+		assert.Equal(t, Int(3), obj.Prop(ctx1, "a"))
+	})
+
+	t.Run("a readonly transaction should not be allowed to mutate a shared object", func(t *testing.T) {
+		ctx1 := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx1.CancelGracefully()
+
+		obj := NewObjectFromMap(ValMap{"a": Int(1)}, ctx1)
+		obj.Share(ctx1.MustGetClosestState())
+
+		tx := StartNewReadonlyTransaction(ctx1)
+		defer tx.Rollback(ctx1)
+
+		assert.ErrorIs(t, obj.SetProp(ctx1, "a", Int(2)), ErrEffectsNotAllowedInReadonlyTransaction)
+	})
+}
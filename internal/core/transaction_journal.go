@@ -0,0 +1,365 @@
+package core
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+const (
+	TX_JOURNAL_FILE_MODE    = 0600
+	TX_JOURNAL_OPEN_TIMEOUT = time.Second
+)
+
+var (
+	ErrEffectNotJournalable        = errors.New("effect cannot be recorded in the transaction journal")
+	ErrUnknownJournaledEffectKind  = errors.New("unknown kind of journaled effect")
+	ErrTransactionAlreadyJournaled = errors.New("transaction is already recorded in the journal")
+
+	journaledEffectDecoders     = map[string]JournaledEffectDecoder{}
+	journaledEffectDecodersLock sync.Mutex
+
+	txJournalTransactionsBucket = []byte("transactions")
+	txJournalEffectsBucket      = []byte("effects")
+	txJournalAppliedBucket      = []byte("applied")
+)
+
+// A JournaledEffect is an Effect that can be recorded in a TransactionJournal.
+type JournaledEffect interface {
+	Effect
+
+	// JournalKind returns the kind of the effect, the kind should be registered with RegisterJournaledEffectKind.
+	JournalKind() string
+
+	// MarshalJournalRecord returns the data required to recreate the effect.
+	MarshalJournalRecord() ([]byte, error)
+}
+
+// A JournaledEffectDecoder recreates an effect from the data returned by MarshalJournalRecord, applied
+// is true if the effect was applied before the recording transaction was interrupted.
+type JournaledEffectDecoder func(ctx *Context, data []byte, applied bool) (JournaledEffect, error)
+
+// RegisterJournaledEffectKind registers the decoder of a kind of journaled effect, it panics if the kind is already registered.
+func RegisterJournaledEffectKind(kind string, decoder JournaledEffectDecoder) {
+	journaledEffectDecodersLock.Lock()
+	defer journaledEffectDecodersLock.Unlock()
+
+	if _, ok := journaledEffectDecoders[kind]; ok {
+		panic(fmt.Errorf("journaled effect kind %q is already registered", kind))
+	}
+	journaledEffectDecoders[kind] = decoder
+}
+
+func getJournaledEffectDecoder(kind string) (JournaledEffectDecoder, bool) {
+	journaledEffectDecodersLock.Lock()
+	defer journaledEffectDecodersLock.Unlock()
+
+	decoder, ok := journaledEffectDecoders[kind]
+	return decoder, ok
+}
+
+// A TransactionJournal is a durable write-ahead journal (bbolt database) in which the effects of transactions are recorded
+// before being applied. The effects are marked as applied one by one during the commit, and the transaction is removed
+// from the journal once all its effects are applied. After a crash the transactions still present in the journal are
+// recovered by calling Recover.
+//
+// The journal of a context is set with ContextConfig.TransactionJournal, when a journal is set only effects
+// implementing JournaledEffect (e.g. WriteFileEffect) can be added to the transactions.
+type TransactionJournal struct {
+	db *bbolt.DB
+}
+
+// OpenTransactionJournal opens the journal stored at path, the file is created if it does not exist.
+func OpenTransactionJournal(path string) (*TransactionJournal, error) {
+	db, err := bbolt.Open(path, TX_JOURNAL_FILE_MODE, &bbolt.Options{Timeout: TX_JOURNAL_OPEN_TIMEOUT})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open transaction journal: %w", err)
+	}
+
+	err = db.Update(func(btx *bbolt.Tx) error {
+		_, err := btx.CreateBucketIfNotExists(txJournalTransactionsBucket)
+		return err
+	})
+
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize transaction journal: %w", err)
+	}
+
+	return &TransactionJournal{db: db}, nil
+}
+
+func (j *TransactionJournal) Close() error {
+	return j.db.Close()
+}
+
+type journaledEffectRecord struct {
+	Kind string `json:"kind"`
+	Data []byte `json:"data"`
+}
+
+// recordTransaction durably records the effects of a transaction, it should be called before applying the effects.
+func (j *TransactionJournal) recordTransaction(txID ULID, effects []Effect) error {
+	records := make([][]byte, len(effects))
+
+	for i, effect := range effects {
+		journaled, ok := effect.(JournaledEffect)
+		if !ok {
+			return fmt.Errorf("%w: %T", ErrEffectNotJournalable, effect)
+		}
+		data, err := journaled.MarshalJournalRecord()
+		if err != nil {
+			return fmt.Errorf("failed to marshal effect %T: %w", effect, err)
+		}
+		records[i], err = json.Marshal(journaledEffectRecord{Kind: journaled.JournalKind(), Data: data})
+		if err != nil {
+			return err
+		}
+	}
+
+	return j.db.Update(func(btx *bbolt.Tx) error {
+		txBucket, err := btx.Bucket(txJournalTransactionsBucket).CreateBucket([]byte(txID.String()))
+		if err != nil {
+			if errors.Is(err, bbolt.ErrBucketExists) {
+				return ErrTransactionAlreadyJournaled
+			}
+			return err
+		}
+
+		effectsBucket, err := txBucket.CreateBucket(txJournalEffectsBucket)
+		if err != nil {
+			return err
+		}
+
+		if _, err := txBucket.CreateBucket(txJournalAppliedBucket); err != nil {
+			return err
+		}
+
+		for i, record := range records {
+			if err := effectsBucket.Put(encodeJournaledEffectIndex(i), record); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (j *TransactionJournal) markEffectApplied(txID ULID, index int) error {
+	return j.db.Update(func(btx *bbolt.Tx) error {
+		txBucket := btx.Bucket(txJournalTransactionsBucket).Bucket([]byte(txID.String()))
+		if txBucket == nil {
+			return fmt.Errorf("transaction %s is not present in the journal", txID)
+		}
+		return txBucket.Bucket(txJournalAppliedBucket).Put(encodeJournaledEffectIndex(index), []byte{1})
+	})
+}
+
+func (j *TransactionJournal) unmarkEffectApplied(txID ULID, index int) error {
+	return j.db.Update(func(btx *bbolt.Tx) error {
+		txBucket := btx.Bucket(txJournalTransactionsBucket).Bucket([]byte(txID.String()))
+		if txBucket == nil {
+			return fmt.Errorf("transaction %s is not present in the journal", txID)
+		}
+		return txBucket.Bucket(txJournalAppliedBucket).Delete(encodeJournaledEffectIndex(index))
+	})
+}
+
+func (j *TransactionJournal) removeTransaction(txID ULID) error {
+	return j.db.Update(func(btx *bbolt.Tx) error {
+		err := btx.Bucket(txJournalTransactionsBucket).DeleteBucket([]byte(txID.String()))
+		if errors.Is(err, bbolt.ErrBucketNotFound) {
+			return nil
+		}
+		return err
+	})
+}
+
+// IncompleteTransactions returns the IDs of the transactions whose commit was interrupted.
+func (j *TransactionJournal) IncompleteTransactions() (ids []ULID, _ error) {
+	err := j.db.View(func(btx *bbolt.Tx) error {
+		return btx.Bucket(txJournalTransactionsBucket).ForEach(func(k, _ []byte) error {
+			id, err := ParseULID(string(k))
+			if err != nil {
+				return fmt.Errorf("invalid transaction ID in journal: %w", err)
+			}
+			ids = append(ids, id)
+			return nil
+		})
+	})
+	return ids, err
+}
+
+type journaledTransaction struct {
+	effects []JournaledEffect
+	applied []bool
+}
+
+func (j *TransactionJournal) readTransaction(ctx *Context, txID ULID) (*journaledTransaction, error) {
+	var (
+		records [][]byte
+		applied []bool
+	)
+
+	err := j.db.View(func(btx *bbolt.Tx) error {
+		txBucket := btx.Bucket(txJournalTransactionsBucket).Bucket([]byte(txID.String()))
+		if txBucket == nil {
+			return fmt.Errorf("transaction %s is not present in the journal", txID)
+		}
+		appliedBucket := txBucket.Bucket(txJournalAppliedBucket)
+
+		//keys are big-endian encoded so the effects are iterated in the order of application.
+		return txBucket.Bucket(txJournalEffectsBucket).ForEach(func(k, v []byte) error {
+			records = append(records, slices.Clone(v))
+			applied = append(applied, appliedBucket.Get(k) != nil)
+			return nil
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	tx := &journaledTransaction{applied: applied}
+
+	for i, recordBytes := range records {
+		var record journaledEffectRecord
+		if err := json.Unmarshal(recordBytes, &record); err != nil {
+			return nil, fmt.Errorf("invalid record of effect %d: %w", i, err)
+		}
+
+		decoder, ok := getJournaledEffectDecoder(record.Kind)
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownJournaledEffectKind, record.Kind)
+		}
+
+		effect, err := decoder(ctx, record.Data, applied[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode effect %d: %w", i, err)
+		}
+		tx.effects = append(tx.effects, effect)
+	}
+
+	return tx, nil
+}
+
+type TransactionRecoveryOutcome int
+
+const (
+	TxRolledForward TransactionRecoveryOutcome = iota + 1
+	TxReversed
+)
+
+func (o TransactionRecoveryOutcome) String() string {
+	switch o {
+	case TxRolledForward:
+		return "rolled forward"
+	case TxReversed:
+		return "reversed"
+	}
+	return "unknown"
+}
+
+type RecoveredTransaction struct {
+	ID      ULID
+	Outcome TransactionRecoveryOutcome //zero if Err is not nil
+	Err     error
+}
+
+// Recover recovers the transactions whose commit was interrupted. Transactions whose effects are all applied are only
+// removed. If all the applied effects of a transaction are Reversible they are reversed (in the reverse order of
+// application), otherwise the remaining effects are applied.
+// Successfully recovered transactions are removed from the journal, the others are kept and their recovery error
+// is reported in the returned slice. The returned error is only non-nil if the journal cannot be read or updated.
+func (j *TransactionJournal) Recover(ctx *Context) ([]RecoveredTransaction, error) {
+	ids, err := j.IncompleteTransactions()
+	if err != nil {
+		return nil, err
+	}
+
+	var recovered []RecoveredTransaction
+
+	for _, id := range ids {
+		outcome, err := j.recoverTransaction(ctx, id)
+		recovered = append(recovered, RecoveredTransaction{ID: id, Outcome: outcome, Err: err})
+
+		if err != nil {
+			continue
+		}
+
+		if err := j.removeTransaction(id); err != nil {
+			return recovered, err
+		}
+	}
+
+	return recovered, nil
+}
+
+func (j *TransactionJournal) recoverTransaction(ctx *Context, id ULID) (TransactionRecoveryOutcome, error) {
+	tx, err := j.readTransaction(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+
+	//The commit was interrupted after the application of all the effects.
+	if !slices.Contains(tx.applied, false) {
+		return TxRolledForward, nil
+	}
+
+	reverse := true
+	for i, effect := range tx.effects {
+		if tx.applied[i] && effect.Reversability(ctx) != Reversible {
+			reverse = false
+			break
+		}
+	}
+
+	if reverse {
+		for i := len(tx.effects) - 1; i >= 0; i-- {
+			if !tx.applied[i] {
+				continue
+			}
+			if err := tx.effects[i].Reverse(ctx); err != nil {
+				return 0, fmt.Errorf("error when reversing effect %d: %w", i, err)
+			}
+			//If the recovery is interrupted the reversed effects are not reversed again by the next recovery.
+			if err := j.unmarkEffectApplied(id, i); err != nil {
+				return 0, err
+			}
+		}
+		return TxReversed, nil
+	}
+
+	for i, effect := range tx.effects {
+		if tx.applied[i] {
+			continue
+		}
+		if err := effect.Apply(ctx); err != nil {
+			return 0, fmt.Errorf("error when applying effect %d: %w", i, err)
+		}
+		if err := j.markEffectApplied(id, i); err != nil {
+			return 0, err
+		}
+	}
+	return TxRolledForward, nil
+}
+
+func encodeJournaledEffectIndex(index int) []byte {
+	return binary.BigEndian.AppendUint32(nil, uint32(index))
+}
+
+// TransactionJournal returns the transaction journal of the context, if the context has no journal the journal
+// of the closest ancestor having one is returned. nil is returned if no journal is found.
+func (ctx *Context) TransactionJournal() *TransactionJournal {
+	for c := ctx; c != nil; c = c.parentCtx {
+		if c.transactionJournal != nil {
+			return c.transactionJournal
+		}
+	}
+	return nil
+}
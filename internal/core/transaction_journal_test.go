@@ -0,0 +1,399 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/inoxlang/inox/internal/core/permbase"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	TEST_JOURNALED_EFFECT_KIND                  = "test-set-entry"
+	TEST_FAILING_REVERSAL_JOURNALED_EFFECT_KIND = "test-failing-reversal"
+)
+
+var (
+	testJournaledEffectStore     = map[string]string{}
+	testJournaledEffectStoreLock sync.Mutex
+)
+
+func init() {
+	RegisterJournaledEffectKind(TEST_JOURNALED_EFFECT_KIND, func(ctx *Context, data []byte, applied bool) (JournaledEffect, error) {
+		effect := &testSetEntryEffect{applied: applied}
+		return effect, json.Unmarshal(data, effect)
+	})
+
+	RegisterJournaledEffectKind(TEST_FAILING_REVERSAL_JOURNALED_EFFECT_KIND, func(ctx *Context, data []byte, applied bool) (JournaledEffect, error) {
+		effect := &failingReversalJournaledEffect{}
+		effect.applied = applied
+		return effect, json.Unmarshal(data, effect)
+	})
+}
+
+// testSetEntryEffect sets an entry of testJournaledEffectStore.
+type testSetEntryEffect struct {
+	Key            string        `json:"key"`
+	Value          string        `json:"value"`
+	Reversability_ Reversability `json:"reversability"`
+
+	applied bool
+}
+
+func (e *testSetEntryEffect) Resources() []ResourceName {
+	return []ResourceName{Path("/" + e.Key)}
+}
+
+func (e *testSetEntryEffect) PermissionKind() PermissionKind {
+	return permbase.Update
+}
+
+func (e *testSetEntryEffect) Reversability(*Context) Reversability {
+	return e.Reversability_
+}
+
+func (e *testSetEntryEffect) IsApplied() bool {
+	return e.applied
+}
+
+func (e *testSetEntryEffect) IsApplying() bool {
+	return false
+}
+
+func (e *testSetEntryEffect) Apply(*Context) error {
+	if e.applied {
+		return ErrEffectAlreadyApplied
+	}
+	testJournaledEffectStoreLock.Lock()
+	defer testJournaledEffectStoreLock.Unlock()
+
+	testJournaledEffectStore[e.Key] = e.Value
+	e.applied = true
+	return nil
+}
+
+func (e *testSetEntryEffect) Reverse(*Context) error {
+	testJournaledEffectStoreLock.Lock()
+	defer testJournaledEffectStoreLock.Unlock()
+
+	delete(testJournaledEffectStore, e.Key)
+	e.applied = false
+	return nil
+}
+
+func (e *testSetEntryEffect) JournalKind() string {
+	return TEST_JOURNALED_EFFECT_KIND
+}
+
+func (e *testSetEntryEffect) MarshalJournalRecord() ([]byte, error) {
+	return json.Marshal(e)
+}
+
+func getTestJournaledEffectStoreEntry(key string) (string, bool) {
+	testJournaledEffectStoreLock.Lock()
+	defer testJournaledEffectStoreLock.Unlock()
+
+	v, ok := testJournaledEffectStore[key]
+	return v, ok
+}
+
+func TestTransactionJournal(t *testing.T) {
+
+	openJournal := func(t *testing.T, path string) *TransactionJournal {
+		journal, err := OpenTransactionJournal(path)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		return journal
+	}
+
+	t.Run("committed transactions should be removed from the journal", func(t *testing.T) {
+		journal := openJournal(t, filepath.Join(t.TempDir(), "journal.db"))
+		defer journal.Close()
+
+		ctx := NewContextWithEmptyState(ContextConfig{TransactionJournal: journal}, nil)
+		defer ctx.CancelGracefully()
+
+		tx := StartNewTransaction(ctx)
+		effect := &testSetEntryEffect{Key: "commit", Value: "1", Reversability_: Reversible}
+
+		if !assert.NoError(t, tx.AddEffect(ctx, effect)) {
+			return
+		}
+		if !assert.NoError(t, tx.Commit(ctx)) {
+			return
+		}
+
+		value, _ := getTestJournaledEffectStoreEntry("commit")
+		assert.Equal(t, "1", value)
+
+		ids, err := journal.IncompleteTransactions()
+		assert.NoError(t, err)
+		assert.Empty(t, ids)
+	})
+
+	t.Run("effects that cannot be journaled should not be added", func(t *testing.T) {
+		journal := openJournal(t, filepath.Join(t.TempDir(), "journal.db"))
+		defer journal.Close()
+
+		ctx := NewContextWithEmptyState(ContextConfig{TransactionJournal: journal}, nil)
+		defer ctx.CancelGracefully()
+
+		childCtx := NewContextWithEmptyState(ContextConfig{ParentContext: ctx}, nil)
+		defer childCtx.CancelGracefully()
+
+		tx := StartNewTransaction(childCtx)
+		defer tx.Rollback(childCtx)
+
+		err := tx.AddEffect(childCtx, unjournaledEffect{&testSetEntryEffect{Reversability_: Reversible}})
+		assert.ErrorIs(t, err, ErrEffectNotJournalable)
+	})
+
+	t.Run("applied effects should be reversed if the application of an effect fails", func(t *testing.T) {
+		journal := openJournal(t, filepath.Join(t.TempDir(), "journal.db"))
+		defer journal.Close()
+
+		ctx := NewContextWithEmptyState(ContextConfig{TransactionJournal: journal}, nil)
+		defer ctx.CancelGracefully()
+
+		tx := StartNewTransaction(ctx)
+
+		if !assert.NoError(t, tx.AddEffect(ctx, &testSetEntryEffect{Key: "failed-commit-1", Value: "1", Reversability_: Reversible})) {
+			return
+		}
+		if !assert.NoError(t, tx.AddEffect(ctx, &failingJournaledEffect{})) {
+			return
+		}
+
+		assert.Error(t, tx.Commit(ctx))
+
+		_, ok := getTestJournaledEffectStoreEntry("failed-commit-1")
+		assert.False(t, ok)
+
+		ids, err := journal.IncompleteTransactions()
+		assert.NoError(t, err)
+		assert.Empty(t, ids)
+	})
+
+	t.Run("transaction should be kept in the journal if the applied effects cannot be reversed", func(t *testing.T) {
+		journal := openJournal(t, filepath.Join(t.TempDir(), "journal.db"))
+		defer journal.Close()
+
+		ctx := NewContextWithEmptyState(ContextConfig{TransactionJournal: journal}, nil)
+		defer ctx.CancelGracefully()
+
+		tx := StartNewTransaction(ctx)
+
+		if !assert.NoError(t, tx.AddEffect(ctx, &testSetEntryEffect{Key: "kept-1", Value: "1", Reversability_: SomewhatReversible})) {
+			return
+		}
+		if !assert.NoError(t, tx.AddEffect(ctx, &failingJournaledEffect{})) {
+			return
+		}
+
+		callbackSuccess := true
+		tx.OnEnd(ctx, func(tx *Transaction, success bool) {
+			callbackSuccess = success
+		})
+
+		assert.Error(t, tx.Commit(ctx))
+		assert.True(t, callbackSuccess)
+
+		value, _ := getTestJournaledEffectStoreEntry("kept-1")
+		assert.Equal(t, "1", value)
+
+		ids, err := journal.IncompleteTransactions()
+		assert.NoError(t, err)
+		assert.Equal(t, []ULID{tx.ulid}, ids)
+	})
+
+	t.Run("interrupted transaction whose effects are all applied should only be removed", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "journal.db")
+		journal := openJournal(t, path)
+		defer journal.Close()
+
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		effects := []Effect{&testSetEntryEffect{Key: "applied-1", Value: "1", Reversability_: Reversible}}
+
+		txID := NewULID()
+		if !assert.NoError(t, journal.recordTransaction(txID, effects)) {
+			return
+		}
+		effects[0].Apply(ctx)
+		if !assert.NoError(t, journal.markEffectApplied(txID, 0)) {
+			return
+		}
+
+		recovered, err := journal.Recover(ctx)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.Equal(t, []RecoveredTransaction{{ID: txID, Outcome: TxRolledForward}}, recovered)
+
+		value, _ := getTestJournaledEffectStoreEntry("applied-1")
+		assert.Equal(t, "1", value)
+	})
+
+	t.Run("interrupted transaction with reversible effects should be reversed", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "journal.db")
+		journal := openJournal(t, path)
+
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		effects := []Effect{
+			&testSetEntryEffect{Key: "reversed-1", Value: "1", Reversability_: Reversible},
+			&testSetEntryEffect{Key: "reversed-2", Value: "2", Reversability_: Reversible},
+		}
+
+		//simulate a crash after the application of the first effect.
+		txID := NewULID()
+		if !assert.NoError(t, journal.recordTransaction(txID, effects)) {
+			return
+		}
+		effects[0].Apply(ctx)
+		if !assert.NoError(t, journal.markEffectApplied(txID, 0)) {
+			return
+		}
+		journal.Close()
+
+		journal = openJournal(t, path)
+		defer journal.Close()
+
+		recovered, err := journal.Recover(ctx)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.Equal(t, []RecoveredTransaction{{ID: txID, Outcome: TxReversed}}, recovered)
+
+		_, ok := getTestJournaledEffectStoreEntry("reversed-1")
+		assert.False(t, ok)
+		_, ok = getTestJournaledEffectStoreEntry("reversed-2")
+		assert.False(t, ok)
+
+		ids, err := journal.IncompleteTransactions()
+		assert.NoError(t, err)
+		assert.Empty(t, ids)
+	})
+
+	t.Run("effects reversed by an interrupted recovery should not be reversed again", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "journal.db")
+		journal := openJournal(t, path)
+		defer journal.Close()
+
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		effects := []Effect{
+			&failingReversalJournaledEffect{testSetEntryEffect{Key: "interrupted-1", Value: "1"}},
+			&testSetEntryEffect{Key: "interrupted-2", Value: "2", Reversability_: Reversible},
+			&testSetEntryEffect{Key: "interrupted-3", Value: "3", Reversability_: Reversible},
+		}
+
+		txID := NewULID()
+		if !assert.NoError(t, journal.recordTransaction(txID, effects)) {
+			return
+		}
+		for i := 0; i < 2; i++ {
+			effects[i].Apply(ctx)
+			if !assert.NoError(t, journal.markEffectApplied(txID, i)) {
+				return
+			}
+		}
+
+		//the reversal of the first effect fails.
+		recovered, err := journal.Recover(ctx)
+		if !assert.NoError(t, err) {
+			return
+		}
+		if assert.Len(t, recovered, 1) {
+			assert.Error(t, recovered[0].Err)
+		}
+
+		tx, err := journal.readTransaction(ctx, txID)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, []bool{true, false, false}, tx.applied)
+	})
+
+	t.Run("interrupted transaction with a somewhat reversible applied effect should be rolled forward", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "journal.db")
+		journal := openJournal(t, path)
+
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		effects := []Effect{
+			&testSetEntryEffect{Key: "forward-1", Value: "1", Reversability_: SomewhatReversible},
+			&testSetEntryEffect{Key: "forward-2", Value: "2", Reversability_: Reversible},
+		}
+
+		txID := NewULID()
+		if !assert.NoError(t, journal.recordTransaction(txID, effects)) {
+			return
+		}
+		effects[0].Apply(ctx)
+		if !assert.NoError(t, journal.markEffectApplied(txID, 0)) {
+			return
+		}
+		journal.Close()
+
+		journal = openJournal(t, path)
+		defer journal.Close()
+
+		recovered, err := journal.Recover(ctx)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.Equal(t, []RecoveredTransaction{{ID: txID, Outcome: TxRolledForward}}, recovered)
+
+		value, _ := getTestJournaledEffectStoreEntry("forward-1")
+		assert.Equal(t, "1", value)
+		value, _ = getTestJournaledEffectStoreEntry("forward-2")
+		assert.Equal(t, "2", value)
+	})
+}
+
+// unjournaledEffect only exposes the methods of Effect.
+type unjournaledEffect struct {
+	Effect
+}
+
+// failingJournaledEffect is a reversible effect whose application always fails.
+type failingJournaledEffect struct {
+	testSetEntryEffect
+}
+
+func (e *failingJournaledEffect) Reversability(*Context) Reversability {
+	return Reversible
+}
+
+func (e *failingJournaledEffect) Apply(*Context) error {
+	return errors.New("failure")
+}
+
+// failingReversalJournaledEffect is a reversible effect whose reversal always fails.
+type failingReversalJournaledEffect struct {
+	testSetEntryEffect
+}
+
+func (e *failingReversalJournaledEffect) Reversability(*Context) Reversability {
+	return Reversible
+}
+
+func (e *failingReversalJournaledEffect) Reverse(*Context) error {
+	return errors.New("failure")
+}
+
+func (e *failingReversalJournaledEffect) JournalKind() string {
+	return TEST_FAILING_REVERSAL_JOURNALED_EFFECT_KIND
+}
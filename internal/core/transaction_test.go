@@ -379,3 +379,180 @@ func TestTransaction(t *testing.T) {
 		}
 	})
 }
+
+func TestNestedTransaction(t *testing.T) {
+
+	t.Run("on commit the effects and end callbacks should be transferred to the parent", func(t *testing.T) {
+		ctx := NewContext(ContextConfig{})
+		defer ctx.CancelGracefully()
+
+		tx := StartNewTransaction(ctx)
+		child, err := tx.StartNested(ctx)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Same(t, child, ctx.GetTx())
+		assert.Same(t, tx, child.Parent())
+
+		effect := &testSetEntryEffect{Key: "nested-commit", Value: "1", Reversability_: Reversible}
+		assert.NoError(t, child.AddEffect(ctx, effect))
+
+		var callbackTx *Transaction
+		callbackSuccess := false
+		child.OnEnd(1, func(tx *Transaction, success bool) {
+			callbackTx = tx
+			callbackSuccess = success
+		})
+
+		assert.ErrorIs(t, tx.Commit(ctx), ErrActiveNestedTransaction)
+
+		if !assert.NoError(t, child.Commit(ctx)) {
+			return
+		}
+		assert.Same(t, tx, ctx.GetTx())
+		assert.False(t, effect.IsApplied())
+		assert.Nil(t, callbackTx)
+
+		if !assert.NoError(t, tx.Commit(ctx)) {
+			return
+		}
+		assert.True(t, effect.IsApplied())
+		assert.Same(t, child, callbackTx)
+		assert.True(t, callbackSuccess)
+		assert.Nil(t, ctx.GetTx())
+	})
+
+	t.Run("on rollback the effects should be reversed and end callbacks called", func(t *testing.T) {
+		ctx := NewContext(ContextConfig{})
+		defer ctx.CancelGracefully()
+
+		tx := StartNewTransaction(ctx)
+		child, _ := tx.StartNested(ctx)
+
+		effect := &testSetEntryEffect{Key: "nested-rollback", Value: "1", Reversability_: Reversible}
+		child.AddEffect(ctx, effect)
+
+		callbackCalled := false
+		child.OnEnd(1, func(tx *Transaction, success bool) {
+			callbackCalled = true
+			assert.False(t, success)
+		})
+
+		assert.NoError(t, child.Rollback(ctx))
+		assert.True(t, callbackCalled)
+		assert.Same(t, tx, ctx.GetTx())
+
+		assert.NoError(t, tx.Commit(ctx))
+		effects, _ := tx.CurrentEffects()
+		assert.Empty(t, effects)
+		assert.False(t, effect.IsApplied())
+	})
+
+	t.Run("rolling back the parent should roll back the active nested transaction", func(t *testing.T) {
+		ctx := NewContext(ContextConfig{})
+		defer ctx.CancelGracefully()
+
+		tx := StartNewTransaction(ctx)
+		child, _ := tx.StartNested(ctx)
+
+		assert.NoError(t, tx.Rollback(ctx))
+		assert.True(t, child.IsFinished())
+		assert.True(t, tx.IsFinished())
+		assert.Nil(t, ctx.GetTx())
+	})
+
+	t.Run("the nested transaction of a readonly transaction should be readonly", func(t *testing.T) {
+		ctx := NewContext(ContextConfig{})
+		defer ctx.CancelGracefully()
+
+		tx := StartNewReadonlyTransaction(ctx)
+		defer tx.Rollback(ctx)
+
+		child, _ := tx.StartNested(ctx)
+		assert.True(t, child.IsReadonly())
+		assert.ErrorIs(t, child.AddEffect(ctx, &testSetEntryEffect{Reversability_: Reversible}), ErrEffectsNotAllowedInReadonlyTransaction)
+
+		_, err := child.StartNested(ctx)
+		assert.NoError(t, err)
+		_, err = tx.StartNested(ctx)
+		assert.ErrorIs(t, err, ErrActiveNestedTransaction)
+	})
+}
+
+func TestTransactionSavepoints(t *testing.T) {
+
+	t.Run("rolling back to a savepoint should reverse the effects added after it", func(t *testing.T) {
+		ctx := NewContext(ContextConfig{})
+		defer ctx.CancelGracefully()
+
+		tx := StartNewTransaction(ctx)
+		defer tx.Rollback(ctx)
+
+		effect1 := &testSetEntryEffect{Key: "savepoint-1", Reversability_: Reversible}
+		effect2 := &testSetEntryEffect{Key: "savepoint-2", Reversability_: Reversible}
+		effect3 := &testSetEntryEffect{Key: "savepoint-3", Reversability_: Reversible}
+
+		tx.AddEffect(ctx, effect1)
+		assert.NoError(t, tx.Savepoint(ctx, String("a")))
+		assert.ErrorIs(t, tx.Savepoint(ctx, String("a")), ErrSavepointAlreadyExists)
+
+		tx.AddEffect(ctx, effect2)
+		assert.NoError(t, tx.Savepoint(ctx, String("b")))
+		tx.AddEffect(ctx, effect3)
+
+		callbackCalled := false
+		tx.OnEnd(1, func(tx *Transaction, success bool) {
+			callbackCalled = true
+			assert.False(t, success)
+		})
+
+		assert.NoError(t, tx.RollbackToSavepoint(ctx, String("a")))
+		assert.True(t, callbackCalled)
+
+		effects, _ := tx.CurrentEffects()
+		assert.Equal(t, []Effect{effect1}, effects)
+
+		//savepoints created after 'a' are removed.
+		assert.ErrorIs(t, tx.RollbackToSavepoint(ctx, String("b")), ErrUnknownSavepoint)
+
+		//'a' is kept.
+		tx.AddEffect(ctx, effect2)
+		assert.NoError(t, tx.RollbackToSavepoint(ctx, String("a")))
+		effects, _ = tx.CurrentEffects()
+		assert.Equal(t, []Effect{effect1}, effects)
+	})
+
+	t.Run("releasing a savepoint should keep the effects", func(t *testing.T) {
+		ctx := NewContext(ContextConfig{})
+		defer ctx.CancelGracefully()
+
+		tx := StartNewTransaction(ctx)
+		defer tx.Rollback(ctx)
+
+		effect := &testSetEntryEffect{Key: "release", Reversability_: Reversible}
+
+		assert.NoError(t, tx.Savepoint(ctx, String("a")))
+		tx.AddEffect(ctx, effect)
+		assert.NoError(t, tx.ReleaseSavepoint(ctx, String("a")))
+		assert.ErrorIs(t, tx.RollbackToSavepoint(ctx, String("a")), ErrUnknownSavepoint)
+
+		effects, _ := tx.CurrentEffects()
+		assert.Equal(t, []Effect{effect}, effects)
+	})
+
+	t.Run("savepoints should not be released while a nested transaction is active", func(t *testing.T) {
+		ctx := NewContext(ContextConfig{})
+		defer ctx.CancelGracefully()
+
+		tx := StartNewTransaction(ctx)
+		defer tx.Rollback(ctx)
+
+		assert.NoError(t, tx.Savepoint(ctx, String("a")))
+
+		child, _ := tx.StartNested(ctx)
+		assert.ErrorIs(t, tx.ReleaseSavepoint(ctx, String("a")), ErrActiveNestedTransaction)
+
+		assert.NoError(t, child.Commit(ctx))
+		assert.NoError(t, tx.ReleaseSavepoint(ctx, String("a")))
+	})
+}
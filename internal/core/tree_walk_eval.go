@@ -977,6 +977,8 @@ func TreeWalkEval(node ast.Node, state *TreeWalkState) (result Value, err error)
 	case *ast.SynchronizedBlockStatement:
 		var lockedValues []PotentiallySharable
 		defer func() {
+			state.Global.lockAcquisitionLocation = ""
+
 			for _, val := range utils.ReversedSlice(lockedValues) {
 				val.SmartUnlock(state.Global)
 			}
@@ -1017,7 +1019,12 @@ func TreeWalkEval(node ast.Node, state *TreeWalkState) (result Value, err error)
 			}
 
 			potentiallySharable.Share(state.Global)
+
+			if IsDeadlockDetectionEnabled() {
+				_, state.Global.lockAcquisitionLocation = state.formatLocation(n)
+			}
 			potentiallySharable.SmartLock(state.Global)
+			state.Global.lockAcquisitionLocation = ""
 
 			// update list of locked values
 			state.Global.lockedValues = append(state.Global.lockedValues, potentiallySharable)
@@ -1082,12 +1089,24 @@ func TreeWalkEval(node ast.Node, state *TreeWalkState) (result Value, err error)
 			return nil, err
 		}
 
-		configObj, err := TreeWalkEval(n.Configuration.(*ast.ObjectLiteral), state)
+		//The capabilities section is evaluated separately because objects cannot contain capabilities.
+		configLit := n.Configuration.(*ast.ObjectLiteral)
+		var capabilities Value
+
+		if capabilitiesNode, ok := configLit.PropValue(inoxconsts.IMPORT_CONFIG__CAPABILITIES_PROPNAME); ok {
+			capabilities, err = TreeWalkEval(capabilitiesNode, state)
+			if err != nil {
+				return nil, err
+			}
+			configLit = configLit.WithoutNamedProp(inoxconsts.IMPORT_CONFIG__CAPABILITIES_PROPNAME)
+		}
+
+		configObj, err := TreeWalkEval(configLit, state)
 		if err != nil {
 			return nil, err
 		}
 
-		config, err := buildImportConfig(configObj.(*Object), src.(ResourceName), state.Global)
+		config, err := buildImportConfig(configObj.(*Object), capabilities, src.(ResourceName), state.Global)
 		if err != nil {
 			return nil, err
 		}
@@ -1101,9 +1120,7 @@ func TreeWalkEval(node ast.Node, state *TreeWalkState) (result Value, err error)
 		return Nil, nil
 	case *ast.SpawnExpression:
 		var (
-			group       *LThreadGroup
-			permListing *Object
-			globalsDesc Value
+			spawnMeta lthreadMeta
 
 			explicitlyPassedGlobals = map[string]Value{}
 		)
@@ -1142,7 +1159,7 @@ func TreeWalkEval(node ast.Node, state *TreeWalkState) (result Value, err error)
 				return nil, errors.New("meta should be an object")
 			}
 
-			group, globalsDesc, permListing, err = readLThreadMeta(meta, explicitlyPassedGlobals, state.Global.Ctx)
+			spawnMeta, err = readLThreadMeta(meta, explicitlyPassedGlobals, state.Global.Ctx)
 			if err != nil {
 				return nil, err
 			}
@@ -1161,7 +1178,7 @@ func TreeWalkEval(node ast.Node, state *TreeWalkState) (result Value, err error)
 			return nil
 		})
 
-		switch g := globalsDesc.(type) {
+		switch g := spawnMeta.globalsDesc.(type) {
 		case *Namespace:
 			for k, v := range g.entries {
 				actualGlobals[k] = v
@@ -1207,8 +1224,8 @@ func TreeWalkEval(node ast.Node, state *TreeWalkState) (result Value, err error)
 
 		var grantedPerms []Permission
 
-		if permListing != nil {
-			grantedPerms, err = getPermissionsFromListing(state.Global.Ctx, permListing, nil, nil, true)
+		if spawnMeta.permListing != nil {
+			grantedPerms, err = getPermissionsFromListing(state.Global.Ctx, spawnMeta.permListing, nil, nil, true)
 			if err != nil {
 				return nil, err
 			}
@@ -1222,6 +1239,8 @@ func TreeWalkEval(node ast.Node, state *TreeWalkState) (result Value, err error)
 			ctx = NewContext(ContextConfig{
 				Permissions:          grantedPerms,
 				ForbiddenPermissions: state.Global.Ctx.forbiddenPermissions,
+				Capabilities:         spawnMeta.capabilities,
+				CPUTimeBudget:        spawnMeta.cpuTimeBudget,
 				ParentContext:        state.Global.Ctx,
 			})
 		} else {
@@ -1232,6 +1251,8 @@ func TreeWalkEval(node ast.Node, state *TreeWalkState) (result Value, err error)
 				ParentContext:        state.Global.Ctx,
 				Permissions:          remainingPerms,
 				ForbiddenPermissions: removedPerms,
+				Capabilities:         spawnMeta.capabilities,
+				CPUTimeBudget:        spawnMeta.cpuTimeBudget,
 			})
 		}
 
@@ -1259,8 +1280,8 @@ func TreeWalkEval(node ast.Node, state *TreeWalkState) (result Value, err error)
 			return nil, err
 		}
 
-		if group != nil {
-			group.Add(lthread)
+		if spawnMeta.group != nil {
+			spawnMeta.group.Add(lthread)
 		}
 
 		return lthread, nil
@@ -50,6 +50,12 @@ type VM struct {
 	runFn              bool
 	fnArgCount         int
 	disabledArgSharing []bool
+
+	//the following fields are only set if the VM resumes the execution of an lthread from a checkpoint.
+
+	resumedFromCheckpoint bool
+	resumeSP              int
+	resumeIP              int
 }
 
 // frame represents a call frame.
@@ -174,6 +180,12 @@ func (v *VM) Run() (result Value, err error) {
 	v.framesIndex = 1
 	v.ip = -1
 
+	if v.resumedFromCheckpoint {
+		v.resumedFromCheckpoint = false
+		v.sp = v.resumeSP
+		v.ip = v.resumeIP
+	}
+
 	if v.runFn {
 		v.sp = 1 + v.fnArgCount + 1 + 1
 		if !v.fnCall(v.fnArgCount, false, false, -1) {
@@ -278,6 +290,7 @@ func (v *VM) run() {
 		// 	// val.SynchronizedBlockUnlock(v.global)
 		// }
 
+		v.global.lockAcquisitionLocation = ""
 		for _, locked := range v.global.lockedValues {
 			locked.SmartUnlock(v.global)
 		}
@@ -2329,8 +2342,9 @@ func (v *VM) handleOtherOpcodes(op byte) (_continue bool) {
 		globalNameIndex := int(v.curInsts[v.ip]) | int(v.curInsts[v.ip-1])<<8
 		globalName := v.constants[globalNameIndex].(String)
 
-		source := v.stack[v.sp-2]
-		configObject := v.stack[v.sp-1].(*Object)
+		source := v.stack[v.sp-3]
+		configObject := v.stack[v.sp-2].(*Object)
+		capabilities := v.stack[v.sp-1] //Nil if the configuration has no capabilities section
 
 		varPerm := GlobalVarPermission{permbase.Create, string(globalName)}
 		if err := v.global.Ctx.CheckHasPermission(varPerm); err != nil {
@@ -2338,7 +2352,7 @@ func (v *VM) handleOtherOpcodes(op byte) (_continue bool) {
 			return
 		}
 
-		config, err := buildImportConfig(configObject, source.(ResourceName), v.global)
+		config, err := buildImportConfig(configObject, capabilities, source.(ResourceName), v.global)
 		if err != nil {
 			v.err = err
 			return
@@ -2350,7 +2364,7 @@ func (v *VM) handleOtherOpcodes(op byte) (_continue bool) {
 			return
 		}
 
-		v.sp -= 2
+		v.sp -= 3
 		v.global.Globals.Set(string(globalName), result)
 	case OpSpawnLThread:
 		v.ip += 7
@@ -2367,11 +2381,7 @@ func (v *VM) handleOtherOpcodes(op byte) (_continue bool) {
 		meta := v.stack[v.sp-2]
 		singleExprCallee := v.stack[v.sp-1]
 
-		var (
-			group       *LThreadGroup
-			globalsDesc Value
-			permListing *Object
-		)
+		var spawnMeta lthreadMeta
 
 		if meta != nil && meta != Nil {
 			metaMap := meta.(*ModuleArgs).ValueMap()
@@ -2382,7 +2392,7 @@ func (v *VM) handleOtherOpcodes(op byte) (_continue bool) {
 				explicitlyPassedGlobals = globalsSection.ValueMap()
 			}
 
-			group, globalsDesc, permListing, v.err = readLThreadMeta(metaMap, explicitlyPassedGlobals, v.global.Ctx)
+			spawnMeta, v.err = readLThreadMeta(metaMap, explicitlyPassedGlobals, v.global.Ctx)
 			if v.err != nil {
 				return
 			}
@@ -2404,7 +2414,7 @@ func (v *VM) handleOtherOpcodes(op byte) (_continue bool) {
 
 		// pass global variables
 
-		switch g := globalsDesc.(type) {
+		switch g := spawnMeta.globalsDesc.(type) {
 		case *ModuleArgs:
 			for k, v := range g.values {
 				actualGlobals[k] = v
@@ -2430,8 +2440,8 @@ func (v *VM) handleOtherOpcodes(op byte) (_continue bool) {
 		}
 
 		//create context
-		if permListing != nil {
-			perms, err := getPermissionsFromListing(v.global.Ctx, permListing, nil, nil, true)
+		if spawnMeta.permListing != nil {
+			perms, err := getPermissionsFromListing(v.global.Ctx, spawnMeta.permListing, nil, nil, true)
 			if err != nil {
 				v.err = fmt.Errorf("spawn expression: %w", err)
 				return
@@ -2446,6 +2456,8 @@ func (v *VM) handleOtherOpcodes(op byte) (_continue bool) {
 			ctx = NewContext(ContextConfig{
 				Permissions:          perms,
 				ForbiddenPermissions: v.global.Ctx.forbiddenPermissions,
+				Capabilities:         spawnMeta.capabilities,
+				CPUTimeBudget:        spawnMeta.cpuTimeBudget,
 				ParentContext:        v.global.Ctx,
 			})
 		} else {
@@ -2456,6 +2468,8 @@ func (v *VM) handleOtherOpcodes(op byte) (_continue bool) {
 				ParentContext:        v.global.Ctx,
 				Permissions:          remainingPerms,
 				ForbiddenPermissions: removedPerms,
+				Capabilities:         spawnMeta.capabilities,
+				CPUTimeBudget:        spawnMeta.cpuTimeBudget,
 			})
 		}
 
@@ -2473,8 +2487,8 @@ func (v *VM) handleOtherOpcodes(op byte) (_continue bool) {
 			return
 		}
 
-		if group != nil {
-			group.Add(lthread)
+		if spawnMeta.group != nil {
+			spawnMeta.group.Add(lthread)
 		}
 
 		v.sp -= 1
@@ -2609,7 +2623,7 @@ func (v *VM) handleOtherOpcodes(op byte) (_continue bool) {
 
 		value := v.stack[v.sp-1]
 		v.stack[v.sp-1] = Option{Name: string(name), Value: value}
-	case OpYield:
+	case OpCoyield:
 		v.ip++
 		var retVal Value
 		isValOnStack := int(v.curInsts[v.ip]) == 1
@@ -2645,7 +2659,13 @@ func (v *VM) handleOtherOpcodes(op byte) (_continue bool) {
 			}
 
 			potentiallySharable.Share(v.global)
+
+			if IsDeadlockDetectionEnabled() {
+				//v.ip-1 is the position of the instruction.
+				v.global.lockAcquisitionLocation = v.curFrame.fn.GetSourcePositionRange(v.ip - 1).String()
+			}
 			potentiallySharable.SmartLock(v.global)
+			v.global.lockAcquisitionLocation = ""
 
 			// update list of locked values
 			v.global.lockedValues = append(v.global.lockedValues, potentiallySharable)
@@ -15,6 +15,45 @@ func TestVMStackOverflow(t *testing.T) {
 	`, nil, ErrStackOverflow)
 }
 
+func TestVMForExpressionWithBlockBody(t *testing.T) {
+	testCases := []struct {
+		input  string
+		result Value
+	}{
+		{`return (for e in [1] { yield e })`, NewWrappedValueList(Int(1))},
+		{`return (for e in [1, 2, 3] { if (e != 2) { yield e } })`, NewWrappedValueList(Int(1), Int(3))},
+		{`return (for e in [1, 2] { yield })`, NewWrappedValueList(Nil, Nil)},
+		{`return (for e in [1, 2] { yield e; yield 0 })`, NewWrappedValueList(Int(1), Int(2))},
+		{`return (for e in [1, 2, 3] { if (e == 2) { break }; yield e })`, NewWrappedValueList(Int(1))},
+		{`return (for e in [1, 2] {})`, NewWrappedValueList()},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.input, func(t *testing.T) {
+			bytecode, _, err := traceCompile(t, testCase.input, nil)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			ctx := NewContext(ContextConfig{})
+			defer ctx.CancelGracefully()
+
+			vm, err := NewVM(VMConfig{
+				Bytecode: bytecode,
+				State:    NewGlobalState(ctx),
+			})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			result, err := vm.Run()
+			if assert.NoError(t, err) {
+				assert.Equal(t, testCase.result, result)
+			}
+		})
+	}
+}
+
 func expectError(t *testing.T, input string, globals map[Identifier]Value, target error) {
 	actual, _, e := traceCompile(t, input, nil)
 
@@ -0,0 +1,549 @@
+package core
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// this file contains the implementation of the binary representation of serializable values,
+// see parse_binary_representation.go for the decoding.
+//
+// Layout of a binary representation:
+//
+//	magic (3 bytes) | version (1 byte) | value
+//
+// Each value starts with a one-byte tag followed by a payload whose layout depends on the tag.
+// Integers are encoded as zig-zag varints, lengths & counts as unsigned varints and floats
+// as 8-byte little-endian IEEE 754 numbers.
+
+const (
+	BINARY_REPR_MAGIC             = "IXB"
+	BINARY_REPR_VERSION           = 1
+	BINARY_REPR_HEADER_LENGTH     = len(BINARY_REPR_MAGIC) + 1
+	MAX_BINARY_REPR_WRITING_DEPTH = 20
+)
+
+var (
+	ErrNoBinaryRepresentation               = errors.New("value has no binary representation")
+	ErrMaximumBinaryReprWritingDepthReached = errors.New("maximum binary representation writing depth reached")
+)
+
+type binaryReprTag byte
+
+const (
+	//simple values
+
+	binNil binaryReprTag = iota + 1
+	binFalse
+	binTrue
+	binInt
+	binFloat
+	binByte
+	binRune
+
+	//string-like values
+
+	binString
+	binPath
+	binPathPattern
+	binURL
+	binURLPattern
+	binHost
+	binHostPattern
+	binScheme
+	binIdentifier
+	binPropertyName
+	binEmailAddress
+	binMimetype
+
+	//quantities & time
+
+	binByteCount
+	binLineCount
+	binRuneCount
+	binByteRate
+	binFrequency
+	binDuration
+	binYear
+	binDate
+	binDateTime
+
+	//other atomic values
+
+	binFileMode
+	binULID
+	binUUIDv4
+	binIntRange
+	binFloatRange
+	binRuneRange
+
+	//containers
+
+	binByteSlice
+	binRuneSlice
+	binObject
+	binRecord
+	binList
+	binTuple
+	binKeyList
+	binDictionary
+
+	//patterns
+
+	binTypePattern
+	binExactValuePattern
+	binExactStringPattern
+	binRegexPattern
+	binIntRangePattern
+	binFloatRangePattern
+	binObjectPattern
+	binRecordPattern
+	binListPattern
+	binTuplePattern
+	binUnionPattern
+	binOptionalPattern
+)
+
+type BinarySerializationConfig struct {
+	*ReprConfig
+	Pattern Pattern //nillable, if set the value should match the pattern
+}
+
+// GetBinaryRepresentation returns the binary representation of v, see WriteBinaryRepresentation.
+func GetBinaryRepresentation(ctx *Context, v Serializable, config BinarySerializationConfig) ([]byte, error) {
+	if config.Pattern != nil && !config.Pattern.Test(ctx, v) {
+		return nil, ErrPatternDoesNotMatchValueToSerialize
+	}
+
+	w := &binaryReprWriter{
+		ctx:    ctx,
+		config: config.ReprConfig,
+		buf:    make([]byte, 0, 64),
+	}
+	w.buf = append(w.buf, BINARY_REPR_MAGIC...)
+	w.buf = append(w.buf, BINARY_REPR_VERSION)
+
+	if err := w.writeValue(v, 0); err != nil {
+		return nil, err
+	}
+	return w.buf, nil
+}
+
+// WriteBinaryRepresentation writes a compact and versioned binary representation of v to w, the representation
+// can be decoded by ParseBinaryRepresentation. Properties that are not visible according to config.ReprConfig
+// are not written. Atomic values, quantities, resource names, data structures (objects, records, lists, tuples,
+// dictionaries, ...) and the most common patterns have a binary representation.
+func WriteBinaryRepresentation(ctx *Context, w io.Writer, v Serializable, config BinarySerializationConfig) error {
+	repr, err := GetBinaryRepresentation(ctx, v, config)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(repr)
+	return err
+}
+
+type binaryReprWriter struct {
+	ctx    *Context
+	config *ReprConfig
+	buf    []byte
+}
+
+func (w *binaryReprWriter) writeTag(tag binaryReprTag) {
+	w.buf = append(w.buf, byte(tag))
+}
+
+func (w *binaryReprWriter) writeBool(b bool) {
+	if b {
+		w.buf = append(w.buf, 1)
+	} else {
+		w.buf = append(w.buf, 0)
+	}
+}
+
+func (w *binaryReprWriter) writeInt(i int64) {
+	w.buf = binary.AppendVarint(w.buf, i)
+}
+
+func (w *binaryReprWriter) writeUint(i uint64) {
+	w.buf = binary.AppendUvarint(w.buf, i)
+}
+
+func (w *binaryReprWriter) writeFloat(f float64) {
+	w.buf = binary.LittleEndian.AppendUint64(w.buf, math.Float64bits(f))
+}
+
+func (w *binaryReprWriter) writeString(s string) {
+	w.writeUint(uint64(len(s)))
+	w.buf = append(w.buf, s...)
+}
+
+func (w *binaryReprWriter) writeBytes(b []byte) {
+	w.writeUint(uint64(len(b)))
+	w.buf = append(w.buf, b...)
+}
+
+func (w *binaryReprWriter) writeTime(tag binaryReprTag, t time.Time) error {
+	timeRepr, err := t.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	w.writeTag(tag)
+	w.writeBytes(timeRepr)
+	return nil
+}
+
+func (w *binaryReprWriter) writeValue(v Serializable, depth int) error {
+	if depth > MAX_BINARY_REPR_WRITING_DEPTH {
+		return ErrMaximumBinaryReprWritingDepthReached
+	}
+
+	switch val := v.(type) {
+	case NilT:
+		w.writeTag(binNil)
+	case Bool:
+		if val {
+			w.writeTag(binTrue)
+		} else {
+			w.writeTag(binFalse)
+		}
+	case Int:
+		w.writeTag(binInt)
+		w.writeInt(int64(val))
+	case Float:
+		w.writeTag(binFloat)
+		w.writeFloat(float64(val))
+	case Byte:
+		w.writeTag(binByte)
+		w.buf = append(w.buf, byte(val))
+	case Rune:
+		w.writeTag(binRune)
+		w.writeInt(int64(val))
+
+	//string-like values
+	case String:
+		w.writeTag(binString)
+		w.writeString(string(val))
+	case Path:
+		w.writeTag(binPath)
+		w.writeString(string(val))
+	case PathPattern:
+		w.writeTag(binPathPattern)
+		w.writeString(string(val))
+	case URL:
+		w.writeTag(binURL)
+		w.writeString(string(val))
+	case URLPattern:
+		w.writeTag(binURLPattern)
+		w.writeString(string(val))
+	case Host:
+		w.writeTag(binHost)
+		w.writeString(string(val))
+	case HostPattern:
+		w.writeTag(binHostPattern)
+		w.writeString(string(val))
+	case Scheme:
+		w.writeTag(binScheme)
+		w.writeString(string(val))
+	case Identifier:
+		w.writeTag(binIdentifier)
+		w.writeString(string(val))
+	case PropertyName:
+		w.writeTag(binPropertyName)
+		w.writeString(string(val))
+	case EmailAddress:
+		w.writeTag(binEmailAddress)
+		w.writeString(string(val))
+	case Mimetype:
+		w.writeTag(binMimetype)
+		w.writeString(string(val))
+
+	//quantities & time
+	case ByteCount:
+		w.writeTag(binByteCount)
+		w.writeInt(int64(val))
+	case LineCount:
+		w.writeTag(binLineCount)
+		w.writeInt(int64(val))
+	case RuneCount:
+		w.writeTag(binRuneCount)
+		w.writeInt(int64(val))
+	case ByteRate:
+		w.writeTag(binByteRate)
+		w.writeInt(int64(val))
+	case Frequency:
+		w.writeTag(binFrequency)
+		w.writeFloat(float64(val))
+	case Duration:
+		w.writeTag(binDuration)
+		w.writeInt(int64(val))
+	case Year:
+		return w.writeTime(binYear, time.Time(val))
+	case Date:
+		return w.writeTime(binDate, time.Time(val))
+	case DateTime:
+		return w.writeTime(binDateTime, time.Time(val))
+
+	//other atomic values
+	case FileMode:
+		w.writeTag(binFileMode)
+		w.writeUint(uint64(val))
+	case ULID:
+		w.writeTag(binULID)
+		w.buf = append(w.buf, val[:]...)
+	case UUIDv4:
+		w.writeTag(binUUIDv4)
+		w.buf = append(w.buf, val[:]...)
+	case IntRange:
+		w.writeTag(binIntRange)
+		w.writeIntRange(val)
+	case FloatRange:
+		w.writeTag(binFloatRange)
+		w.writeFloatRange(val)
+	case RuneRange:
+		w.writeTag(binRuneRange)
+		w.writeInt(int64(val.Start))
+		w.writeInt(int64(val.End))
+
+	//containers
+	case *ByteSlice:
+		w.writeTag(binByteSlice)
+		w.writeBool(val.Mutable())
+		w.writeString(string(val.contentType))
+		w.writeBytes(val.UnderlyingBytes())
+	case *RuneSlice:
+		w.writeTag(binRuneSlice)
+		runes := val.ElementsDoNotModify()
+		w.writeUint(uint64(len(runes)))
+		for _, r := range runes {
+			w.writeInt(int64(r))
+		}
+	case *Object:
+		return w.writeObject(val, depth)
+	case *Record:
+		w.writeTag(binRecord)
+		return w.writeProperties(val.keys, val.values, nil, depth)
+	case *List:
+		w.writeTag(binList)
+		return w.writeElements(val.GetOrBuildElements(w.ctx), depth)
+	case *Tuple:
+		w.writeTag(binTuple)
+		return w.writeElements(val.elements, depth)
+	case KeyList:
+		w.writeTag(binKeyList)
+		w.writeUint(uint64(len(val)))
+		for _, key := range val {
+			w.writeString(key)
+		}
+	case *Dictionary:
+		return w.writeDictionary(val, depth)
+
+	//patterns
+	case Pattern:
+		return w.writePattern(val, depth)
+	default:
+		return fmt.Errorf("%w: %T", ErrNoBinaryRepresentation, v)
+	}
+
+	return nil
+}
+
+func (w *binaryReprWriter) writeIntRange(r IntRange) {
+	w.writeBool(r.unknownStart)
+	w.writeInt(r.start)
+	w.writeInt(r.end)
+}
+
+func (w *binaryReprWriter) writeFloatRange(r FloatRange) {
+	w.writeBool(r.unknownStart)
+	w.writeBool(r.inclusiveEnd)
+	w.writeFloat(r.start)
+	w.writeFloat(r.end)
+}
+
+func (w *binaryReprWriter) writeObject(obj *Object, depth int) error {
+	closestState := w.ctx.MustGetClosestState()
+	obj._lock(closestState)
+	keys := obj.keys
+	values := obj.values
+	var visibility *ValueVisibility
+	if obj.hasAdditionalFields() {
+		visibility, _ = GetVisibility(obj.visibilityId)
+	}
+	obj._unlock(closestState)
+
+	w.writeTag(binObject)
+	return w.writeProperties(keys, values, visibility, depth)
+}
+
+func (w *binaryReprWriter) writeProperties(keys []string, values []Serializable, visibility *ValueVisibility, depth int) error {
+	visibleCount := 0
+	for i, key := range keys {
+		if w.config.IsPropertyVisible(key, values[i], visibility, w.ctx) {
+			visibleCount++
+		}
+	}
+
+	w.writeUint(uint64(visibleCount))
+
+	for i, key := range keys {
+		value := values[i]
+		if !w.config.IsPropertyVisible(key, value, visibility, w.ctx) {
+			continue
+		}
+
+		w.writeString(key)
+		if err := w.writeValue(value, depth+1); err != nil {
+			return fmt.Errorf("failed to write property .%s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+func (w *binaryReprWriter) writeElements(elements []Serializable, depth int) error {
+	w.writeUint(uint64(len(elements)))
+
+	for i, e := range elements {
+		if err := w.writeValue(e, depth+1); err != nil {
+			return fmt.Errorf("failed to write element at index %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (w *binaryReprWriter) writeDictionary(dict *Dictionary, depth int) error {
+	w.writeTag(binDictionary)
+	w.writeUint(uint64(len(dict.entries)))
+
+	return dict.ForEachEntry(w.ctx, func(keyRepr string, key, v Serializable) error {
+		if err := w.writeValue(key, depth+1); err != nil {
+			return fmt.Errorf("failed to write dictionary key %s: %w", keyRepr, err)
+		}
+		if err := w.writeValue(v, depth+1); err != nil {
+			return fmt.Errorf("failed to write value of dictionary entry %s: %w", keyRepr, err)
+		}
+		return nil
+	})
+}
+
+func (w *binaryReprWriter) writePattern(pattern Pattern, depth int) error {
+	switch patt := pattern.(type) {
+	case *TypePattern:
+		if patt.Name == "" || DEFAULT_NAMED_PATTERNS[patt.Name] != patt {
+			return fmt.Errorf("%w: only default type patterns have a binary representation", ErrNoBinaryRepresentation)
+		}
+		w.writeTag(binTypePattern)
+		w.writeString(patt.Name)
+	case *ExactValuePattern:
+		w.writeTag(binExactValuePattern)
+		return w.writeValue(patt.value, depth+1)
+	case *ExactStringPattern:
+		w.writeTag(binExactStringPattern)
+		w.writeString(string(patt.value))
+	case *RegexPattern:
+		w.writeTag(binRegexPattern)
+		w.writeString(patt.regexp.String())
+	case *IntRangePattern:
+		w.writeTag(binIntRangePattern)
+		w.writeIntRange(patt.intRange)
+		w.writeInt(int64(patt.multipleOf))
+		w.writeBool(patt.multipleOfFloat != nil)
+		if patt.multipleOfFloat != nil {
+			w.writeFloat(float64(*patt.multipleOfFloat))
+		}
+	case *FloatRangePattern:
+		w.writeTag(binFloatRangePattern)
+		w.writeFloatRange(patt.floatRange)
+		w.writeFloat(float64(patt.multipleOf))
+	case *ObjectPattern:
+		if len(patt.complexPropertyPatterns) > 0 || len(patt.dependentKeys) > 0 {
+			return fmt.Errorf("%w: object patterns with constraints or dependencies", ErrNoBinaryRepresentation)
+		}
+
+		w.writeTag(binObjectPattern)
+		w.writeBool(patt.inexact)
+		w.writeUint(uint64(len(patt.entries)))
+
+		for _, entry := range patt.entries {
+			w.writeString(entry.Name)
+			w.writeBool(entry.IsOptional)
+			if err := w.writeValue(entry.Pattern, depth+1); err != nil {
+				return fmt.Errorf("failed to write pattern of entry %s: %w", entry.Name, err)
+			}
+		}
+	case *RecordPattern:
+		w.writeTag(binRecordPattern)
+		w.writeBool(patt.inexact)
+		w.writeUint(uint64(len(patt.entries)))
+
+		for _, entry := range patt.entries {
+			w.writeString(entry.Name)
+			w.writeBool(entry.IsOptional)
+			if err := w.writeValue(entry.Pattern, depth+1); err != nil {
+				return fmt.Errorf("failed to write pattern of entry %s: %w", entry.Name, err)
+			}
+		}
+	case *ListPattern:
+		w.writeTag(binListPattern)
+		if err := w.writeSequencePattern(patt.elementPatterns, patt.generalElementPattern, depth); err != nil {
+			return err
+		}
+
+		if patt.generalElementPattern != nil {
+			w.writeBool(patt.minElemCountPlusOne > 0)
+			if patt.minElemCountPlusOne > 0 {
+				w.writeUint(uint64(patt.MinElementCount()))
+				w.writeUint(uint64(patt.MaxElementCount()))
+			}
+
+			w.writeBool(patt.containedElement != nil)
+			if patt.containedElement != nil {
+				if err := w.writeValue(patt.containedElement, depth+1); err != nil {
+					return fmt.Errorf("failed to write pattern of the contained element: %w", err)
+				}
+			}
+		}
+	case *TuplePattern:
+		w.writeTag(binTuplePattern)
+		return w.writeSequencePattern(patt.elementPatterns, patt.generalElementPattern, depth)
+	case *UnionPattern:
+		w.writeTag(binUnionPattern)
+		w.writeBool(patt.disjoint)
+		w.writeUint(uint64(len(patt.cases)))
+
+		for i, unionCase := range patt.cases {
+			if err := w.writeValue(unionCase, depth+1); err != nil {
+				return fmt.Errorf("failed to write union case at index %d: %w", i, err)
+			}
+		}
+	case *OptionalPattern:
+		w.writeTag(binOptionalPattern)
+		return w.writeValue(patt.pattern, depth+1)
+	default:
+		return fmt.Errorf("%w: %T", ErrNoBinaryRepresentation, pattern)
+	}
+	return nil
+}
+
+func (w *binaryReprWriter) writeSequencePattern(elementPatterns []Pattern, generalElementPattern Pattern, depth int) error {
+	hasGeneralElementPattern := generalElementPattern != nil
+	w.writeBool(hasGeneralElementPattern)
+
+	if hasGeneralElementPattern {
+		if err := w.writeValue(generalElementPattern, depth+1); err != nil {
+			return fmt.Errorf("failed to write pattern of the general element: %w", err)
+		}
+		return nil
+	}
+
+	w.writeUint(uint64(len(elementPatterns)))
+	for i, elementPattern := range elementPatterns {
+		if err := w.writeValue(elementPattern, depth+1); err != nil {
+			return fmt.Errorf("failed to write pattern of the element at index %d: %w", i, err)
+		}
+	}
+	return nil
+}
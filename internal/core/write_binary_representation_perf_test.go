@@ -0,0 +1,81 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/inoxlang/inox/internal/jsoniter"
+	utils "github.com/inoxlang/inox/internal/utils/common"
+)
+
+func makeBinaryReprBenchmarkValue() Serializable {
+	elements := make([]Serializable, 100)
+	for i := range elements {
+		elements[i] = NewRecordFromMap(ValMap{
+			"id":   Int(i),
+			"name": String("user"),
+			"path": Path("/home/user/"),
+			"size": Float(1.5),
+			"tags": NewTupleVariadic(String("a"), String("b")),
+		})
+	}
+	return NewTuple(elements)
+}
+
+func BenchmarkWriteBinaryRepresentation(b *testing.B) {
+
+	b.Run("binary", func(b *testing.B) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		val := makeBinaryReprBenchmarkValue()
+
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			utils.Must(GetBinaryRepresentation(ctx, val, BinarySerializationConfig{}))
+		}
+	})
+
+	b.Run("JSON", func(b *testing.B) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		val := makeBinaryReprBenchmarkValue()
+
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 0)
+			utils.PanicIfErr(val.WriteJSONRepresentation(ctx, stream, JSONSerializationConfig{}, 0))
+		}
+	})
+}
+
+func BenchmarkParseBinaryRepresentation(b *testing.B) {
+
+	b.Run("binary", func(b *testing.B) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		repr := utils.Must(GetBinaryRepresentation(ctx, makeBinaryReprBenchmarkValue(), BinarySerializationConfig{}))
+
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			utils.Must(ParseBinaryRepresentation(ctx, repr, nil))
+		}
+	})
+
+	b.Run("JSON", func(b *testing.B) {
+		ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+		defer ctx.CancelGracefully()
+
+		repr := GetJSONRepresentation(makeBinaryReprBenchmarkValue(), ctx, nil)
+
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			utils.Must(ParseJSONRepresentation(ctx, repr, nil))
+		}
+	})
+}
@@ -0,0 +1,69 @@
+package core
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteBinaryRepresentation(t *testing.T) {
+	ctx := NewContextWithEmptyState(ContextConfig{}, nil)
+	defer ctx.CancelGracefully()
+
+	t.Run("header", func(t *testing.T) {
+		var buf bytes.Buffer
+		if !assert.NoError(t, WriteBinaryRepresentation(ctx, &buf, Int(1), BinarySerializationConfig{})) {
+			return
+		}
+		assert.Equal(t, []byte{'I', 'X', 'B', BINARY_REPR_VERSION, byte(binInt), 2}, buf.Bytes())
+	})
+
+	t.Run("small integers should take a single byte", func(t *testing.T) {
+		repr, err := GetBinaryRepresentation(ctx, NewWrappedValueList(Int(1), Int(-1), Int(63)), BinarySerializationConfig{})
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Len(t, repr, BINARY_REPR_HEADER_LENGTH+2+3*2)
+	})
+
+	t.Run("sensitive properties", func(t *testing.T) {
+		obj := objFrom(ValMap{
+			"a":        Int(1),
+			"password": String("mypassword"),
+		})
+
+		repr, err := GetBinaryRepresentation(ctx, obj, BinarySerializationConfig{
+			ReprConfig: &ReprConfig{AllVisible: false},
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		result, err := ParseBinaryRepresentation(ctx, repr, nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, []string{"a"}, result.(*Object).PropertyNames(ctx))
+	})
+
+	t.Run("value not matching the pattern", func(t *testing.T) {
+		_, err := GetBinaryRepresentation(ctx, Int(1), BinarySerializationConfig{Pattern: STR_PATTERN})
+		assert.ErrorIs(t, err, ErrPatternDoesNotMatchValueToSerialize)
+	})
+
+	t.Run("value without binary representation", func(t *testing.T) {
+		_, err := GetBinaryRepresentation(ctx, NewWrappedValueList(Port{Number: 80, Scheme: "http"}), BinarySerializationConfig{})
+		assert.ErrorIs(t, err, ErrNoBinaryRepresentation)
+	})
+
+	t.Run("maximum depth", func(t *testing.T) {
+		var value Serializable = Int(1)
+		for i := 0; i <= MAX_BINARY_REPR_WRITING_DEPTH; i++ {
+			value = NewTupleVariadic(value)
+		}
+
+		_, err := GetBinaryRepresentation(ctx, value, BinarySerializationConfig{})
+		assert.ErrorIs(t, err, ErrMaximumBinaryReprWritingDepthReached)
+	})
+}
@@ -12,6 +12,7 @@ func NewDefaultContext(config core.DefaultContextConfig) (*core.Context, error)
 
 		Limits:              config.Limits,
 		HostDefinitions:     config.HostDefinitions,
+		RetryPolicy:         config.RetryPolicy,
 		ParentContext:       config.ParentContext,
 		ParentStdLibContext: config.ParentStdLibContext,
 	}
@@ -10,6 +10,23 @@ var (
 		// events
 		globalnames.EVENT_SRC_FN: core.ValOf(core.NewEventSource),
 
+		//capabilities
+		globalnames.CAPABILITY_FN: core.WrapGoFunction(core.NewCapabilityFromListing),
+
+		//retry
+		globalnames.RETRY_POLICY_FN: core.WrapGoFunction(core.NewRetryPolicyFromRecord),
+		globalnames.RETRY_FN:        core.WrapGoFunction(core.Retry),
+
+		//channels
+		globalnames.CHANNEL_FN:        core.WrapGoFunction(core.NewChannel),
+		globalnames.SELECT_CHANNEL_FN: core.WrapGoFunction(core.SelectChannel),
+
+		//supervision
+		globalnames.SUPERVISOR_FN: core.WrapGoFunction(core.NewSupervisorFromRecord),
+
+		//scheduling
+		globalnames.SCHEDULER_FN: core.WrapGoFunction(core.NewInoxScheduler),
+
 		//watch
 		globalnames.VALUE_HISTORY_FN: core.WrapGoFunction(core.NewValueHistory),
 
@@ -25,6 +42,11 @@ var (
 		//functional
 		globalnames.MAP_ITERABLE_FN: core.WrapGoFunction(core.MapIterable),
 
+		//parallel iteration
+		globalnames.PARALLEL_MAP_FN:      core.WrapGoFunction(core.ParallelMap),
+		globalnames.PARALLEL_FILTER_FN:   core.WrapGoFunction(core.ParallelFilter),
+		globalnames.PARALLEL_FOR_EACH_FN: core.WrapGoFunction(core.ParallelForEach),
+
 		//other
 		globalnames.FILEMODE_FN: core.WrapGoFunction(core.FileModeFrom),
 	}
@@ -21,6 +21,13 @@ const (
 
 	LS_FN = "ls"
 
+	// capabilities
+	CAPABILITY_FN = "Capability"
+
+	// retry
+	RETRY_POLICY_FN = "RetryPolicy"
+	RETRY_FN        = "retry"
+
 	// transaction
 	GET_CURRENT_TX_FN = "get_current_tx"
 	START_TX_FN       = "start_tx"
@@ -114,12 +121,27 @@ const (
 	FIND_FN            = "find"
 	FIND_FIRST_FN      = "find_first"
 
+	// parallel iteration
+	PARALLEL_MAP_FN      = "parallel_map"
+	PARALLEL_FILTER_FN   = "parallel_filter"
+	PARALLEL_FOR_EACH_FN = "parallel_for_each"
+
 	// concurrency & execution
 	LTHREADGROUP_FN = "LThreadGroup"
 	RUN_FN          = "run"
 	EXEC_FN         = "ex" //command execution
 	CANCEL_EXEC_FN  = "cancel_exec"
 
+	// channels
+	CHANNEL_FN        = "Channel"
+	SELECT_CHANNEL_FN = "select_channel"
+
+	// supervision
+	SUPERVISOR_FN = "Supervisor"
+
+	// scheduling
+	SCHEDULER_FN = "Scheduler"
+
 	// integer
 	IS_EVEN_FN = "is_even"
 	IS_ODD_FN  = "is_odd"
@@ -23,13 +23,15 @@ const (
 
 	//Module import
 
-	IMPORT_CONFIG__ALLOW_PROPNAME      = "allow"
-	IMPORT_CONFIG__ARGUMENTS_PROPNAME  = "arguments"
-	IMPORT_CONFIG__VALIDATION_PROPNAME = "validation"
+	IMPORT_CONFIG__ALLOW_PROPNAME        = "allow"
+	IMPORT_CONFIG__ARGUMENTS_PROPNAME    = "arguments"
+	IMPORT_CONFIG__VALIDATION_PROPNAME   = "validation"
+	IMPORT_CONFIG__CAPABILITIES_PROPNAME = "capabilities"
 )
 
 var (
 	IMPORT_CONFIG_SECTION_NAMES = []string{
 		IMPORT_CONFIG__ALLOW_PROPNAME, IMPORT_CONFIG__ARGUMENTS_PROPNAME, IMPORT_CONFIG__VALIDATION_PROPNAME,
+		IMPORT_CONFIG__CAPABILITIES_PROPNAME,
 	}
 )
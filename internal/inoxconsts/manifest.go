@@ -11,10 +11,19 @@ const (
 	MANIFEST_LIMITS_SECTION_NAME           = "limits"
 	MANIFEST_HOST_DEFINITIONS_SECTION_NAME = "host-definitions"
 	MANIFEST_PREINIT_FILES_SECTION_NAME    = "preinit-files"
+	MANIFEST_RETRY_POLICY_SECTION_NAME     = "retry-policy"
 
 	//preinit-files section
 	MANIFEST_PREINIT_FILE__PATTERN_PROP_NAME = "pattern"
 	MANIFEST_PREINIT_FILE__PATH_PROP_NAME    = "path"
+	MANIFEST_PREINIT_FILE__OPENAPI_PROP_NAME = "openapi"
+	MANIFEST_PREINIT_FILE__FORMAT_PROP_NAME  = "format"
+
+	//formats of preinit files
+	PREINIT_FILE_JSON_FORMAT = "json"
+	PREINIT_FILE_YAML_FORMAT = "yaml"
+	PREINIT_FILE_TOML_FORMAT = "toml"
+	PREINIT_FILE_INI_FORMAT  = "ini"
 
 	//parameters
 	MANIFEST_PARAM__PATTERN_PROPNAME                  = "pattern"
@@ -30,5 +39,10 @@ var (
 		MANIFEST_KIND_SECTION_NAME, MANIFEST_ENV_SECTION_NAME, MANIFEST_PARAMS_SECTION_NAME,
 		MANIFEST_PERMS_SECTION_NAME, MANIFEST_LIMITS_SECTION_NAME,
 		MANIFEST_HOST_DEFINITIONS_SECTION_NAME, MANIFEST_PREINIT_FILES_SECTION_NAME,
+		MANIFEST_RETRY_POLICY_SECTION_NAME,
+	}
+
+	PREINIT_FILE_FORMATS = []string{
+		PREINIT_FILE_JSON_FORMAT, PREINIT_FILE_YAML_FORMAT, PREINIT_FILE_TOML_FORMAT, PREINIT_FILE_INI_FORMAT,
 	}
 )
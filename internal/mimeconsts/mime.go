@@ -16,12 +16,17 @@ const (
 	APP_YAML_CTYPE = "application/yaml"
 	//TEXT_YAML_CTYPE        = "text/yaml"
 
+	TOML_CTYPE = "application/toml"
+	INI_CTYPE  = "text/x-ini" //not registered
+
 	HTML_CTYPE             = "text/html"
 	CSS_CTYPE              = "text/css"
 	JS_CTYPE               = "text/javascript"  //https://developer.mozilla.org/en-US/docs/Web/HTTP/Basics_of_HTTP/MIME_types#textjavascript
 	HYPERSCRIPT_CTYPE      = "text/hyperscript" //https://developer.mozilla.org/en-US/docs/Web/HTTP/Basics_of_HTTP/MIME_types#textjavascript
 	INOX_CTYPE             = "text/inox"
 	PLAIN_TEXT_CTYPE       = "text/plain"
+	CSV_CTYPE              = "text/csv"
+	TSV_CTYPE              = "text/tab-separated-values"
 	EVENT_STREAM_CTYPE     = "text/event-stream"
 	APP_OCTET_STREAM_CTYPE = "application/octet-stream"
 	MULTIPART_FORM_DATA    = "multipart/form-data"
@@ -44,6 +49,10 @@ func init() {
 	mime.AddExtensionType(".txt", PLAIN_TEXT_CTYPE)
 	mime.AddExtensionType(".yaml", APP_YAML_CTYPE)
 	mime.AddExtensionType(".yml", APP_YAML_CTYPE)
+	mime.AddExtensionType(".csv", CSV_CTYPE)
+	mime.AddExtensionType(".tsv", TSV_CTYPE)
+	mime.AddExtensionType(".toml", TOML_CTYPE)
+	mime.AddExtensionType(".ini", INI_CTYPE)
 }
 
 // IsMimeTypeForExtension returns true if ext corresponds to mimetype.